@@ -23,9 +23,11 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/odeke-em/command"
 	"github.com/odeke-em/drive/config"
@@ -84,9 +86,16 @@ func main() {
 	runtime.GOMAXPROCS(int(maxProcs))
 
 	bindCommandWithAliases(drive.AboutKey, drive.DescAbout, &aboutCmd{}, []string{})
+	bindCommandWithAliases(drive.CacheKey, drive.DescCache, &cacheCmd{}, []string{})
+	bindCommandWithAliases(drive.LeaseKey, drive.DescLease, &leaseCmd{}, []string{})
+	bindCommandWithAliases(drive.SharedDriveKey, drive.DescSharedDrive, &sharedDriveCmd{}, []string{})
+	bindCommandWithAliases(drive.CompletePathKey, drive.DescCompletePath, &completePathCmd{}, []string{})
 	bindCommandWithAliases(drive.CopyKey, drive.DescCopy, &copyCmd{}, []string{})
 	bindCommandWithAliases(drive.DiffKey, drive.DescDiff, &diffCmd{}, []string{})
+	bindCommandWithAliases(drive.DoctorKey, drive.DescDoctor, &doctorCmd{}, []string{})
 	bindCommandWithAliases(drive.EmptyTrashKey, drive.DescEmptyTrash, &emptyTrashCmd{}, []string{})
+	bindCommandWithAliases(drive.ExpandArchiveKey, drive.DescExpandArchive, &expandArchiveCmd{}, []string{})
+	bindCommandWithAliases(drive.GrepKey, drive.DescGrep, &grepCmd{}, []string{})
 	bindCommandWithAliases(drive.FeaturesKey, drive.DescFeatures, &featuresCmd{}, []string{})
 	bindCommandWithAliases(drive.InitKey, drive.DescInit, &initCmd{}, []string{})
 	bindCommandWithAliases(drive.DeInitKey, drive.DescDeInit, &deInitCmd{}, []string{})
@@ -94,6 +103,9 @@ func main() {
 
 	bindCommandWithAliases(drive.ListKey, drive.DescList, &listCmd{}, []string{})
 	bindCommandWithAliases(drive.MoveKey, drive.DescMove, &moveCmd{}, []string{})
+	bindCommandWithAliases(drive.MkdirKey, drive.DescMkdir, &mkdirCmd{}, []string{})
+	bindCommandWithAliases(drive.DedupeKey, drive.DescDedupe, &dedupeCmd{}, []string{})
+	bindCommandWithAliases(drive.ResolveKey, drive.DescResolve, &resolveCmd{}, []string{})
 	bindCommandWithAliases(drive.PullKey, drive.DescPull, &pullCmd{}, []string{})
 	bindCommandWithAliases(drive.PushKey, drive.DescPush, &pushCmd{}, []string{})
 	bindCommandWithAliases(drive.PubKey, drive.DescPublish, &publishCmd{}, []string{})
@@ -101,6 +113,8 @@ func main() {
 	bindCommandWithAliases(drive.QuotaKey, drive.DescQuota, &quotaCmd{}, []string{})
 	bindCommandWithAliases(drive.ShareKey, drive.DescShare, &shareCmd{}, []string{})
 	bindCommandWithAliases(drive.StatKey, drive.DescStat, &statCmd{}, []string{})
+	bindCommandWithAliases(drive.StatsKey, drive.DescStats, &statsCmd{}, []string{})
+	bindCommandWithAliases(drive.SyncKey, drive.DescSync, &syncCmd{}, []string{})
 	bindCommandWithAliases(drive.Md5sumKey, drive.DescMd5sum, &md5SumCmd{}, []string{})
 	bindCommandWithAliases(drive.UnshareKey, drive.DescUnshare, &unshareCmd{}, []string{})
 	bindCommandWithAliases(drive.TouchKey, drive.DescTouch, &touchCmd{}, []string{})
@@ -112,12 +126,18 @@ func main() {
 	bindCommandWithAliases(drive.NewKey, drive.DescNew, &newCmd{}, []string{})
 	bindCommandWithAliases(drive.IndexKey, drive.DescIndex, &indexCmd{}, []string{})
 	bindCommandWithAliases(drive.UrlKey, drive.DescUrl, &urlCmd{}, []string{})
+	bindCommandWithAliases(drive.ShareLinkKey, drive.DescLink, &linkCmd{}, []string{})
+	bindCommandWithAliases(drive.ExistsKey, drive.DescExists, &existsCmd{}, []string{})
+	bindCommandWithAliases(drive.RevisionsKey, drive.DescRevisions, &revisionsCmd{}, []string{})
+	bindCommandWithAliases(drive.CatKey, drive.DescCat, &catCmd{}, []string{})
 	bindCommandWithAliases(drive.OpenKey, drive.DescOpen, &openCmd{}, []string{})
 	bindCommandWithAliases(drive.EditDescriptionKey, drive.DescEdit, &editDescriptionCmd{}, []string{})
 	bindCommandWithAliases(drive.QRLinkKey, drive.DescQR, &qrLinkCmd{}, []string{})
 	bindCommandWithAliases(drive.DuKey, drive.DescDu, &duCmd{}, []string{})
 	bindCommandWithAliases(drive.StarKey, drive.DescStar, &starCmd{}, []string{})
 	bindCommandWithAliases(drive.UnStarKey, drive.DescUnStar, &unstarCmd{}, []string{})
+	bindCommandWithAliases(drive.UndoKey, drive.DescUndo, &undoCmd{}, []string{})
+	bindCommandWithAliases(drive.WatchKey, drive.DescWatch, &watchCmd{}, []string{})
 	bindCommandWithAliases(drive.ClashesKey, drive.DescFixClashes, &clashesCmd{}, []string{})
 	bindCommandWithAliases(drive.IdKey, drive.DescId, &idCmd{}, []string{})
 	bindCommandWithAliases(drive.ReportIssueKey, drive.DescReportIssue, &issueCmd{}, []string{})
@@ -166,15 +186,31 @@ func (cmd *versionCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 
 type initCmd struct {
 	ServiceAccountJSONFile *string `json:"-"`
+	IndexBackend           *string `json:"-"`
+	ApplicationName        *string `json:"-"`
+	QuotaUser              *string `json:"-"`
 }
 
 func (cmd *initCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.ServiceAccountJSONFile = fs.String(drive.ServiceAccountJSONFileKey, "", "points the Google Service Account JSON file")
+	cmd.IndexBackend = fs.String(drive.IndexBackendKey, "", drive.DescIndexBackend)
+	cmd.ApplicationName = fs.String(drive.ApplicationNameKey, "", drive.DescApplicationName)
+	cmd.QuotaUser = fs.String(drive.QuotaUserKey, "", drive.DescQuotaUser)
 	return fs
 }
 
 func (cmd *initCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
-	comm := drive.New(initContext(args), nil)
+	ctx := initContext(args)
+	if *cmd.IndexBackend != "" {
+		exitWithError(ctx.SetIndexBackend(*cmd.IndexBackend))
+	}
+	if _, ok := definedFlags[drive.ApplicationNameKey]; ok {
+		ctx.ApplicationName = strings.TrimSpace(*cmd.ApplicationName)
+	}
+	if _, ok := definedFlags[drive.QuotaUserKey]; ok {
+		ctx.QuotaUser = strings.TrimSpace(*cmd.QuotaUser)
+	}
+	comm := drive.New(ctx, nil)
 	gcsJSONFile := *cmd.ServiceAccountJSONFile
 	if gcsJSONFile == "" {
 		exitWithError(comm.Init())
@@ -216,6 +252,318 @@ func (cmd *quotaCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	}).About(drive.AboutQuota))
 }
 
+type doctorCmd struct{}
+
+func (cmd *doctorCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	return fs
+}
+
+func (cmd *doctorCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	context, path := discoverContext(args)
+
+	exitWithError(drive.New(context, &drive.Options{
+		Path: path,
+	}).Doctor())
+}
+
+type cacheCmd struct {
+	NoPrompt *bool `json:"no-prompt"`
+	Verbose  *bool `json:"verbose"`
+}
+
+func (cmd *cacheCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before clearing or pruning the cache")
+	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, drive.DescVerbose)
+	return fs
+}
+
+func (cmd *cacheCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	// The arguments are a subcommand and not paths, so discover the context from the cwd.
+	context, path := discoverContext(nil)
+
+	exitWithError(drive.New(context, &drive.Options{
+		Path:     path,
+		Sources:  args,
+		NoPrompt: *cmd.NoPrompt,
+		Verbose:  *cmd.Verbose,
+	}).Cache())
+}
+
+type leaseCmd struct {
+	Holder *string `json:"holder"`
+	TTL    *string `json:"ttl"`
+	Force  *bool   `json:"force"`
+	Quiet  *bool   `json:"quiet"`
+}
+
+func (cmd *leaseCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	hostname, _ := os.Hostname()
+	cmd.Holder = fs.String(drive.CLIOptionLeaseHolder, hostname, drive.DescLeaseHolder)
+	cmd.TTL = fs.String(drive.CLIOptionLeaseTTL, drive.DefaultLeaseTTL, drive.DescLeaseTTL)
+	cmd.Force = fs.Bool(drive.ForceKey, false, "releases the lease even if held by another holder")
+	cmd.Quiet = quietFlag(fs)
+	return fs
+}
+
+func (cmd *leaseCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	// The first argument is a subcommand, so discover the context from the cwd.
+	context, path := discoverContext(nil)
+
+	sources := args
+	if len(args) >= 2 {
+		relPaths, err := relativePaths(context.AbsPathOf(""), args[1:]...)
+		exitWithError(err)
+		sources = append([]string{args[0]}, uniqOrderedStr(relPaths)...)
+	}
+
+	meta := map[string][]string{
+		drive.CLIOptionLeaseHolder: []string{strings.TrimSpace(*cmd.Holder)},
+		drive.CLIOptionLeaseTTL:    []string{*cmd.TTL},
+	}
+
+	exitWithError(drive.New(context, &drive.Options{
+		Path:       path,
+		Sources:    sources,
+		Force:      *cmd.Force,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Meta:       &meta,
+	}).Lease())
+}
+
+type sharedDriveCmd struct {
+	Template *string `json:"template"`
+	Perms    *string `json:"perms"`
+	Notify   *bool   `json:"notify"`
+	Hidden   *bool   `json:"hidden"`
+	Verbose  *bool   `json:"verbose"`
+	Quiet    *bool   `json:"quiet"`
+}
+
+func (cmd *sharedDriveCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Template = fs.String(drive.CLIOptionTemplate, "", drive.DescSharedDriveTemplate)
+	cmd.Perms = fs.String(drive.CLIOptionPermsManifest, "", drive.DescPermsManifest)
+	cmd.Notify = fs.Bool(drive.CLIOptionNotify, false, "toggle whether to notify the grantees of the manifest")
+	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "upload hidden paths of the template too")
+	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, drive.DescVerbose)
+	cmd.Quiet = quietFlag(fs)
+	return fs
+}
+
+func (cmd *sharedDriveCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	if len(args) != 1 {
+		exitWithError(fmt.Errorf("%s: expecting exactly one name for the Shared Drive", drive.SharedDriveKey))
+	}
+
+	// The argument is a name rather than a path, so discover the context from the cwd.
+	context, path := discoverContext(nil)
+
+	var err error
+	template := *cmd.Template
+	if template != "" {
+		template, err = filepath.Abs(template)
+		exitWithError(err)
+	}
+
+	mask := drive.NoopOnShare
+	if *cmd.Notify {
+		mask |= drive.Notify
+	}
+
+	exitWithError(drive.New(context, &drive.Options{
+		Path:       path,
+		Hidden:     *cmd.Hidden,
+		Verbose:    *cmd.Verbose,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		TypeMask:   mask,
+	}).CreateSharedDrive(args[0], template, *cmd.Perms))
+}
+
+type completePathCmd struct {
+	MaxAge  *string `json:"max-age"`
+	Offline *bool   `json:"offline"`
+	Hidden  *bool   `json:"hidden"`
+}
+
+func (cmd *completePathCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.MaxAge = fs.String(drive.CLIOptionCompletionMaxAge, drive.DefaultCompletionMaxAge, drive.DescCompletionMaxAge)
+	cmd.Offline = fs.Bool(drive.CLIOptionCompletionOffline, false, drive.DescCompletionOffline)
+	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "complete hidden paths too")
+	return fs
+}
+
+func (cmd *completePathCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	prefix := ""
+	if len(args) >= 1 {
+		prefix = args[0]
+	}
+
+	// The prefix is a remote path, so discover the context from the cwd.
+	cwd, err := os.Getwd()
+	exitWithError(err)
+	context, path := discoverContext([]string{cwd})
+
+	exitWithError(drive.New(context, &drive.Options{
+		Path:       path,
+		Hidden:     *cmd.Hidden,
+		QuietLevel: quietLevel,
+	}).CompletePath(prefix, *cmd.MaxAge, *cmd.Offline))
+}
+
+type watchCmd struct {
+	On       *string `json:"on"`
+	Webhook  *string `json:"webhook"`
+	Rules    *string `json:"rules"`
+	Interval *string `json:"interval"`
+	Verbose  *bool   `json:"verbose"`
+
+	Digest        *string `json:"digest"`
+	DigestWebhook *string `json:"digest-webhook"`
+	DigestEmail   *string `json:"digest-email"`
+	SMTP          *string `json:"smtp"`
+}
+
+func (cmd *watchCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.On = fs.String(drive.WatchOnKey, drive.DefaultWatchEvents, drive.DescWatchOn)
+	cmd.Webhook = fs.String(drive.WatchWebhookKey, "", drive.DescWatchWebhook)
+	cmd.Rules = fs.String(drive.WatchRulesKey, "", drive.DescWatchRules)
+	cmd.Interval = fs.String(drive.WatchIntervalKey, drive.DefaultWatchInterval, drive.DescWatchInterval)
+	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, drive.DescVerbose)
+	cmd.Digest = fs.String(drive.WatchDigestKey, "", drive.DescWatchDigest)
+	cmd.DigestWebhook = fs.String(drive.WatchDigestWebhookKey, "", drive.DescWatchDigestWebhook)
+	cmd.DigestEmail = fs.String(drive.WatchDigestEmailKey, "", drive.DescWatchDigestEmail)
+	cmd.SMTP = fs.String(drive.WatchSMTPKey, drive.DefaultWatchSMTP, drive.DescWatchSMTP)
+	return fs
+}
+
+func (wcmd *watchCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	// The arguments are rules and not local paths, so discover the context from the cwd.
+	context, path := discoverContext(nil)
+
+	cmd := new(watchCmd)
+	df := defaultsFiller{
+		command: drive.WatchKey,
+		from:    *wcmd, to: cmd,
+		rcSourcePath: context.AbsPathOf(path),
+		definedFlags: definedFlags,
+	}
+
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	meta := map[string][]string{
+		drive.WatchOnKey:       []string{*cmd.On},
+		drive.WatchWebhookKey:  []string{*cmd.Webhook},
+		drive.WatchRulesKey:    []string{*cmd.Rules},
+		drive.WatchIntervalKey: []string{*cmd.Interval},
+
+		drive.WatchDigestKey:        []string{*cmd.Digest},
+		drive.WatchDigestWebhookKey: []string{*cmd.DigestWebhook},
+		drive.WatchDigestEmailKey:   drive.NonEmptyTrimmedStrings(strings.Split(*cmd.DigestEmail, ",")...),
+		drive.WatchSMTPKey:          []string{*cmd.SMTP},
+	}
+
+	exitWithError(drive.New(context, &drive.Options{
+		Path:    path,
+		Sources: args,
+		Meta:    &meta,
+		Verbose: *cmd.Verbose,
+	}).Watch())
+}
+
+type statsCmd struct {
+	Last *string `json:"last"`
+}
+
+func (cmd *statsCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Last = fs.String(drive.StatsLastKey, drive.DefaultStatsLast, drive.DescStatsLast)
+	return fs
+}
+
+func (scmd *statsCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	context, path := discoverContext(args)
+	absEntryPath := context.AbsPathOf(path)
+
+	cmd := new(statsCmd)
+	df := defaultsFiller{
+		command: drive.StatsKey,
+		from:    *scmd, to: cmd,
+		rcSourcePath: absEntryPath,
+		definedFlags: definedFlags,
+	}
+
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	meta := map[string][]string{
+		drive.StatsLastKey: []string{*cmd.Last},
+	}
+
+	exitWithError(drive.New(context, &drive.Options{
+		Path: path,
+		Meta: &meta,
+	}).Stats())
+}
+
+type syncCmd struct {
+	Hidden     *bool `json:"hidden"`
+	NoPrompt   *bool `json:"no-prompt"`
+	Quiet      *bool `json:"quiet"`
+	Verbose    *bool `json:"verbose"`
+	Depth      *int  `json:"depth"`
+	FixClashes *bool `json:"fix-clashes"`
+	Permanent  *bool `json:"permanent"`
+	DryRun     *bool `json:"dry-run"`
+}
+
+func (cmd *syncCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "allows syncing of hidden paths")
+	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before applying the sync")
+	cmd.Quiet = quietFlag(fs)
+	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, drive.DescVerbose)
+	cmd.Depth = fs.Int(drive.DepthKey, drive.DefaultMaxTraversalDepth, "max traversal depth")
+	cmd.FixClashes = fs.Bool(drive.CLIOptionFixClashesKey, false, drive.DescFixClashes)
+	cmd.Permanent = fs.Bool(drive.CLIOptionPermanent, false, drive.DescPermanent)
+	cmd.DryRun = fs.Bool(drive.CLIOptionDryRun, false, drive.DescDryRunChanges)
+	return fs
+}
+
+func (scmd *syncCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgs(args)
+
+	cmd := new(syncCmd)
+	df := defaultsFiller{
+		command: drive.SyncKey,
+		from:    *scmd, to: cmd,
+		rcSourcePath: context.AbsPathOf(path),
+		definedFlags: definedFlags,
+	}
+
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	exitWithError(drive.New(context, &drive.Options{
+		Path:       path,
+		Sources:    sources,
+		Hidden:     *cmd.Hidden,
+		NoPrompt:   *cmd.NoPrompt,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Verbose:    *cmd.Verbose,
+		Depth:      *cmd.Depth,
+		FixClashes: *cmd.FixClashes,
+		Permanent:  *cmd.Permanent,
+		DryRun:     *cmd.DryRun,
+		Recursive:  true,
+
+		ExponentialBackoffRetryCount: drive.MaxFailedRetryCount,
+	}).Sync())
+}
+
 type openCmd struct {
 	ById        *bool `json:"by-id"`
 	FileBrowser *bool `json:"file-browser"`
@@ -319,6 +667,147 @@ func (cmd *urlCmd) Run(args []string, definedArgs map[string]*flag.Flag) {
 	exitWithError(drive.New(context, &opts).Url(*cmd.ById))
 }
 
+type linkCmd struct {
+	ById    *bool   `json:"by-id"`
+	Role    *string `json:"role"`
+	Anyone  *bool   `json:"anyone"`
+	Domain  *string `json:"domain"`
+	Verbose *bool   `json:"verbose"`
+}
+
+func (cmd *linkCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.ById = fs.Bool(drive.CLIOptionId, false, "share by id instead of path")
+	cmd.Role = fs.String(drive.RoleKey, "reader", "role granted by the link: reader, commenter or writer")
+	cmd.Anyone = fs.Bool(drive.CLIOptionAnyone, false, "anyone with the link gets access")
+	cmd.Domain = fs.String(drive.CLIOptionDomain, "", "only users of this domain with the link get access")
+	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, drive.DescVerbose)
+	return fs
+}
+
+func (cmd *linkCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgsByToggle(args, *cmd.ById)
+
+	var accountTypes []string
+	if *cmd.Anyone {
+		accountTypes = append(accountTypes, "anyone")
+	}
+	if *cmd.Domain != "" {
+		accountTypes = append(accountTypes, "domain")
+	}
+
+	meta := map[string][]string{
+		drive.RoleKey:         []string{*cmd.Role},
+		drive.AccountTypeKey:  accountTypes,
+		drive.CLIOptionDomain: []string{*cmd.Domain},
+	}
+
+	opts := drive.Options{
+		Path:    path,
+		Sources: sources,
+		Meta:    &meta,
+		Verbose: *cmd.Verbose,
+	}
+
+	exitWithError(drive.New(context, &opts).Link(*cmd.ById))
+}
+
+type catCmd struct {
+	ById   *bool   `json:"by-id"`
+	Range  *string `json:"range"`
+	Export *string `json:"export"`
+}
+
+func (cmd *catCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.ById = fs.Bool(drive.CLIOptionId, false, "print by id instead of path")
+	cmd.Range = fs.String(drive.CLIOptionRange, "", drive.DescRange)
+	cmd.Export = fs.String(drive.ExportsKey, "", drive.DescCatExport)
+	return fs
+}
+
+func (cmd *catCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgsByToggle(args, *cmd.ById)
+
+	meta := map[string][]string{
+		drive.CLIOptionRange: []string{*cmd.Range},
+	}
+
+	exports := drive.NonEmptyTrimmedStrings(strings.Split(*cmd.Export, ",")...)
+
+	opts := drive.Options{
+		Path:    path,
+		Sources: sources,
+		Meta:    &meta,
+		Piped:   true,
+		Exports: uniqOrderedStr(exports),
+	}
+
+	exitWithError(drive.New(context, &opts).PullPiped(*cmd.ById))
+}
+
+type existsCmd struct {
+	ById *bool `json:"by-id"`
+}
+
+func (cmd *existsCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.ById = fs.Bool(drive.CLIOptionId, false, "check ids instead of paths")
+	return fs
+}
+
+func (cmd *existsCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgsByToggle(args, *cmd.ById)
+
+	opts := drive.Options{
+		Path:    path,
+		Sources: sources,
+	}
+
+	exitWithError(drive.New(context, &opts).Exists(*cmd.ById))
+}
+
+type revisionsCmd struct {
+	ById     *bool   `json:"by-id"`
+	Download *string `json:"download"`
+	Pin      *string `json:"pin"`
+	Unpin    *string `json:"unpin"`
+}
+
+func (cmd *revisionsCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.ById = fs.Bool(drive.CLIOptionId, false, "look up ids instead of paths")
+	cmd.Download = fs.String(drive.CLIOptionDownload, "", drive.DescDownloadRevision)
+	cmd.Pin = fs.String(drive.CLIOptionPin, "", drive.DescPinRevision)
+	cmd.Unpin = fs.String(drive.CLIOptionUnpin, "", drive.DescUnpinRevision)
+	return fs
+}
+
+func (cmd *revisionsCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgsByToggle(args, *cmd.ById)
+
+	given := 0
+	for _, revId := range []string{*cmd.Download, *cmd.Pin, *cmd.Unpin} {
+		if revId != "" {
+			given += 1
+		}
+	}
+	if given > 1 {
+		exitWithError(fmt.Errorf("revisions: only one of -%s, -%s and -%s can be set", drive.CLIOptionDownload, drive.CLIOptionPin, drive.CLIOptionUnpin))
+	}
+
+	meta := map[string][]string{
+		drive.CLIOptionDownload: []string{*cmd.Download},
+		drive.CLIOptionPin:      []string{*cmd.Pin},
+		drive.CLIOptionUnpin:    []string{*cmd.Unpin},
+	}
+
+	opts := drive.Options{
+		Path:    path,
+		Sources: sources,
+		Meta:    &meta,
+		Piped:   *cmd.Download != "",
+	}
+
+	exitWithError(drive.New(context, &opts).Revisions(*cmd.ById))
+}
+
 type listCmd struct {
 	ById         *bool   `json:"by-id"`
 	Hidden       *bool   `json:"hidden"`
@@ -330,6 +819,8 @@ type listCmd struct {
 	LongFmt      *bool   `json:"long"`
 	NoPrompt     *bool   `json:"no-prompt"`
 	Shared       *bool   `json:"shared"`
+	Starred      *bool   `json:"starred"`
+	Orphans      *bool   `json:"orphans"`
 	InTrash      *bool   `json:"trashed"`
 	Version      *bool   `json:"version"`
 	Matches      *bool   `json:"matches"`
@@ -337,11 +828,48 @@ type listCmd struct {
 	Quiet        *bool   `json:"quiet"`
 	SkipMimeKey  *string `json:"skip-mime"`
 	MatchMimeKey *string `json:"match-mime"`
+	Type         *string `json:"type"`
 	ExactTitle   *string `json:"exact-title"`
 	MatchOwner   *string `json:"match-owner"`
 	ExactOwner   *string `json:"exact-owner"`
 	NotOwner     *string `json:"not-owner"`
 	Sort         *string `json:"sort"`
+	Fields       *string `json:"fields"`
+	JSON         *bool   `json:"json"`
+	NDJSON       *bool   `json:"ndjson"`
+	Shortcuts    *bool   `json:"resolve-shortcuts"`
+	Color        *bool   `json:"color"`
+	Palette      *string `json:"color-palette"`
+	Pager        *bool   `json:"pager"`
+	DepthFirst   *bool   `json:"depth-first"`
+	DirsFirst    *bool   `json:"dirs-first"`
+	FilesFirst   *bool   `json:"files-first"`
+	ReportEmpty  *bool   `json:"report-empty"`
+	OwnerEmails  *bool   `json:"owner-emails"`
+	Capabilities *bool   `json:"capabilities"`
+	Perms        *bool   `json:"perms"`
+	Labels       *bool   `json:"with-labels"`
+	Label        *string `json:"label"`
+	Media        *bool   `json:"media"`
+	SharedFrom   *string `json:"from"`
+	SharedAfter  *string `json:"shared-after"`
+	SharedBefore *string `json:"shared-before"`
+	CSV          *bool   `json:"csv"`
+	CSVColumns   *string `json:"columns"`
+	MaxResults   *int64  `json:"max-results"`
+	MaxItems     *int64  `json:"max-items"`
+	MaxPages     *int64  `json:"max-pages"`
+	Resume       *string `json:"resume-token"`
+	Tree         *bool   `json:"tree"`
+	Regex        *bool   `json:"regex"`
+	Format       *string `json:"format"`
+	QPS          *int    `json:"qps"`
+	NewerThan    *string `json:"newer-than"`
+	OlderThan    *string `json:"older-than"`
+	MinSize      *string `json:"min-size"`
+	MaxSize      *string `json:"max-size"`
+	LowMemory    *bool   `json:"low-memory"`
+	AllDrives    *bool   `json:"all-drives"`
 }
 
 func (cmd *listCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -350,8 +878,21 @@ func (cmd *listCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Files = fs.Bool(drive.CLIOptionFiles, false, "list only files")
 	cmd.Directories = fs.Bool(drive.CLIOptionDirectories, false, "list all directories")
 	cmd.LongFmt = fs.Bool(drive.CLIOptionLongFmt, false, "long listing of contents")
-	cmd.PageSize = fs.Int64(drive.PageSizeKey, 100, "number of results per pagination")
+	cmd.PageSize = fs.Int64(drive.PageSizeKey, 100, drive.DescPageSize)
+	cmd.LowMemory = fs.Bool(drive.CLIOptionLowMemory, false, drive.DescLowMemory)
+	cmd.AllDrives = fs.Bool(drive.CLIOptionAllDrives, false, drive.DescAllDrives)
+	cmd.MaxResults = fs.Int64(drive.CLIOptionMaxResults, 0, drive.DescMaxResults)
+	cmd.MaxItems = fs.Int64(drive.CLIOptionMaxItems, 0, drive.DescMaxItems)
+	cmd.MaxPages = fs.Int64(drive.CLIOptionMaxPages, 0, drive.DescMaxPages)
+	cmd.Resume = fs.String(drive.CLIOptionResumeToken, "", drive.DescResumeToken)
+	cmd.QPS = fs.Int(drive.CLIOptionQPS, 0, drive.DescQPS)
+	cmd.NewerThan = fs.String(drive.CLIOptionNewerThan, "", drive.DescNewerThan)
+	cmd.OlderThan = fs.String(drive.CLIOptionOlderThan, "", drive.DescOlderThan)
+	cmd.MinSize = fs.String(drive.CLIOptionMinSize, "", drive.DescMinSize)
+	cmd.MaxSize = fs.String(drive.CLIOptionMaxSize, "", drive.DescMaxSize)
 	cmd.Shared = fs.Bool("shared", false, "show files that are shared with me")
+	cmd.Starred = fs.Bool(drive.CLIOptionStarred, false, drive.DescListStarred)
+	cmd.Orphans = fs.Bool(drive.CLIOptionOrphans, false, drive.DescListOrphans)
 	cmd.InTrash = fs.Bool(drive.CLIOptionTrashed, false, "list content in the trash")
 	cmd.Version = fs.Bool("version", false, "show the number of times that the file has been modified on \n\t\tthe server even with changes not visible to the user")
 	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before pagination")
@@ -359,20 +900,46 @@ func (cmd *listCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Recursive = fs.Bool(drive.RecursiveKey, false, "recursively list subdirectories")
 	cmd.Sort = fs.String(drive.SortKey, "", drive.DescSort)
 	cmd.Matches = fs.Bool(drive.MatchesKey, false, "list by prefix")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.SkipMimeKey = fs.String(drive.CLIOptionSkipMime, "", drive.DescSkipMime)
 	cmd.MatchMimeKey = fs.String(drive.CLIOptionMatchMime, "", drive.DescMatchMime)
+	cmd.Type = fs.String(drive.TypeKey, "", drive.DescMimeCategory)
 	cmd.ExactTitle = fs.String(drive.CLIOptionExactTitle, "", drive.DescExactTitle)
 	cmd.MatchOwner = fs.String(drive.CLIOptionMatchOwner, "", drive.DescMatchOwner)
 	cmd.ExactOwner = fs.String(drive.CLIOptionExactOwner, "", drive.DescExactOwner)
 	cmd.NotOwner = fs.String(drive.CLIOptionNotOwner, "", drive.DescNotOwner)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "list by id instead of path")
+	cmd.Fields = fs.String(drive.FieldsKey, "", drive.DescFields)
+	cmd.JSON = fs.Bool(drive.CLIOptionJSON, false, drive.DescJSON)
+	cmd.NDJSON = fs.Bool(drive.CLIOptionNDJSON, false, drive.DescNDJSON)
+	cmd.Shortcuts = fs.Bool(drive.CLIOptionResolveShortcuts, false, drive.DescResolveShortcuts)
+	cmd.Color = fs.Bool(drive.CLIOptionColor, false, drive.DescColor)
+	cmd.Palette = fs.String(drive.CLIOptionColorPalette, "", drive.DescColorPalette)
+	cmd.Pager = fs.Bool(drive.CLIOptionPager, false, drive.DescPager)
+	cmd.DepthFirst = fs.Bool(drive.CLIOptionDepthFirst, false, drive.DescDepthFirst)
+	cmd.DirsFirst = fs.Bool(drive.CLIOptionDirsFirst, false, drive.DescDirsFirst)
+	cmd.FilesFirst = fs.Bool(drive.CLIOptionFilesFirst, false, drive.DescFilesFirst)
+	cmd.ReportEmpty = fs.Bool(drive.CLIOptionReportEmpty, false, drive.DescReportEmpty)
+	cmd.OwnerEmails = fs.Bool(drive.CLIOptionOwnerEmails, false, drive.DescOwnerEmails)
+	cmd.Capabilities = fs.Bool(drive.CLIOptionCapabilities, false, drive.DescCapabilities)
+	cmd.Perms = fs.Bool(drive.CLIOptionPerms, false, drive.DescPerms)
+	cmd.Labels = fs.Bool(drive.CLIOptionWithLabels, false, drive.DescWithLabels)
+	cmd.Label = fs.String(drive.LabelKey, "", drive.DescLabel)
+	cmd.Media = fs.Bool(drive.CLIOptionMedia, false, drive.DescMedia)
+	cmd.CSV = fs.Bool(drive.CLIOptionCSV, false, drive.DescCSV)
+	cmd.CSVColumns = fs.String(drive.CLIOptionCSVColumns, "", drive.DescCSVColumns)
+	cmd.Tree = fs.Bool(drive.CLIOptionTree, false, drive.DescTree)
+	cmd.Regex = fs.Bool(drive.CLIOptionRegex, false, drive.DescRegex)
+	cmd.Format = fs.String(drive.CLIOptionFormat, "", drive.DescFormat)
+	cmd.SharedFrom = fs.String(drive.SharedFromKey, "", drive.DescSharedFrom)
+	cmd.SharedAfter = fs.String(drive.SharedAfterKey, "", drive.DescSharedAfter)
+	cmd.SharedBefore = fs.String(drive.SharedBeforeKey, "", drive.DescSharedBefore)
 
 	return fs
 }
 
 func (lCmd *listCmd) _run(args []string, definedFlags map[string]*flag.Flag, diskUsageSubset bool) error {
-	sources, context, path := preprocessArgsByToggle(args, (*lCmd.ById || *lCmd.Matches))
+	sources, context, path := preprocessArgsWithIds(args, (*lCmd.ById || *lCmd.Matches))
 	cmd := listCmd{}
 	df := defaultsFiller{
 		command: drive.ListKey,
@@ -404,6 +971,79 @@ func (lCmd *listCmd) _run(args []string, definedFlags map[string]*flag.Flag, dis
 	if *cmd.InTrash {
 		typeMask |= drive.InTrash
 	}
+	if *cmd.NDJSON {
+		*cmd.JSON = true
+		typeMask |= drive.NDJSONOutput
+	}
+	if *cmd.Shortcuts {
+		typeMask |= drive.ResolveShortcuts
+	}
+	if *cmd.Color {
+		typeMask |= drive.ColorOutput
+	}
+	if *cmd.Labels {
+		typeMask |= drive.WithLabels
+	}
+	if *cmd.JSON {
+		typeMask |= drive.JSONOutput
+	}
+	if *cmd.DepthFirst {
+		typeMask |= drive.DepthFirst
+	}
+	if *cmd.DirsFirst && *cmd.FilesFirst {
+		exitWithError(fmt.Errorf("list: only one of -%s and -%s can be set", drive.CLIOptionDirsFirst, drive.CLIOptionFilesFirst))
+	}
+	if *cmd.DirsFirst {
+		typeMask |= drive.DirsFirst
+	}
+	if *cmd.FilesFirst {
+		typeMask |= drive.FilesFirst
+	}
+	if *cmd.ReportEmpty {
+		typeMask |= drive.ReportEmpty
+	}
+	if *cmd.OwnerEmails {
+		typeMask |= drive.OwnerEmails
+	}
+	if *cmd.Capabilities {
+		typeMask |= drive.Capabilities
+	}
+	if *cmd.Perms {
+		typeMask |= drive.PermissionsColumn
+	}
+	if *cmd.Media {
+		typeMask |= drive.MediaMetadata
+	}
+	if *cmd.CSV && *cmd.JSON {
+		exitWithError(fmt.Errorf("list: only one of -%s and -%s can be set", drive.CLIOptionCSV, drive.CLIOptionJSON))
+	}
+	if *cmd.CSV {
+		typeMask |= drive.CSVOutput
+	}
+	if *cmd.Tree && (*cmd.CSV || *cmd.JSON) {
+		exitWithError(fmt.Errorf("list: -%s cannot be combined with -%s or -%s", drive.CLIOptionTree, drive.CLIOptionCSV, drive.CLIOptionJSON))
+	}
+	if *cmd.Tree {
+		typeMask |= drive.TreeView
+	}
+	if *cmd.Starred && (*cmd.Shared || *cmd.Matches || *cmd.Tree) {
+		exitWithError(fmt.Errorf("list: -%s cannot be combined with -shared, -%s or -%s", drive.CLIOptionStarred, drive.MatchesKey, drive.CLIOptionTree))
+	}
+	if *cmd.Resume != "" && (*cmd.Shared || *cmd.Starred || *cmd.Orphans || *cmd.Matches) {
+		exitWithError(fmt.Errorf("list: -%s cannot be combined with -shared, -%s, -%s or -%s", drive.CLIOptionResumeToken, drive.CLIOptionStarred, drive.CLIOptionOrphans, drive.MatchesKey))
+	}
+	if *cmd.Orphans && (*cmd.Shared || *cmd.Starred || *cmd.Matches || *cmd.Tree || *cmd.InTrash) {
+		exitWithError(fmt.Errorf("list: -%s cannot be combined with -shared, -%s, -%s, -%s or -%s", drive.CLIOptionOrphans, drive.CLIOptionStarred, drive.MatchesKey, drive.CLIOptionTree, drive.CLIOptionTrashed))
+	}
+	if *cmd.Regex && !*cmd.Matches {
+		exitWithError(fmt.Errorf("list: -%s only applies with -%s", drive.CLIOptionRegex, drive.MatchesKey))
+	}
+	if *cmd.Regex {
+		typeMask |= drive.RegexTitles
+	}
+	if *cmd.Format != "" && (*cmd.CSV || *cmd.JSON || *cmd.Tree) {
+		exitWithError(fmt.Errorf("list: -%s cannot be combined with -%s, -%s or -%s", drive.CLIOptionFormat, drive.CLIOptionCSV, drive.CLIOptionJSON, drive.CLIOptionTree))
+	}
 
 	if diskUsageSubset {
 		typeMask |= drive.DiskUsageOnly
@@ -422,29 +1062,61 @@ func (lCmd *listCmd) _run(args []string, definedFlags map[string]*flag.Flag, dis
 		drive.SortKey:         drive.NonEmptyTrimmedStrings(*cmd.Sort),
 		drive.SkipMimeKeyKey:  drive.NonEmptyTrimmedStrings(strings.Split(*cmd.SkipMimeKey, ",")...),
 		drive.MatchMimeKeyKey: drive.NonEmptyTrimmedStrings(strings.Split(*cmd.MatchMimeKey, ",")...),
+		drive.MimeCategoryKey: drive.NonEmptyTrimmedStrings(strings.Split(*cmd.Type, ",")...),
+		drive.LabelKey:        drive.NonEmptyTrimmedStrings(strings.Split(*cmd.Label, ",")...),
 		drive.ExactTitleKey:   drive.NonEmptyTrimmedStrings(strings.Split(*cmd.ExactTitle, ",")...),
 		drive.MatchOwnerKey:   drive.NonEmptyTrimmedStrings(strings.Split(*cmd.MatchOwner, ",")...),
 		drive.ExactOwnerKey:   drive.NonEmptyTrimmedStrings(strings.Split(*cmd.ExactOwner, ",")...),
 		drive.NotOwnerKey:     drive.NonEmptyTrimmedStrings(strings.Split(*cmd.NotOwner, ",")...),
+		drive.FieldsKey:       drive.SplitFieldsSelector(*cmd.Fields),
+		drive.SharedFromKey:   drive.NonEmptyTrimmedStrings(strings.Split(*cmd.SharedFrom, ",")...),
+		drive.SharedAfterKey:  []string{*cmd.SharedAfter},
+		drive.SharedBeforeKey: []string{*cmd.SharedBefore},
+	}
+	if *cmd.CSV {
+		meta[drive.CLIOptionCSVColumns] = drive.NonEmptyTrimmedStrings(strings.Split(*cmd.CSVColumns, ",")...)
+	}
+	if *cmd.Format != "" {
+		meta[drive.CLIOptionFormat] = []string{*cmd.Format}
+	}
+	if *cmd.Palette != "" {
+		meta[drive.CLIOptionColorPalette] = []string{*cmd.Palette}
+	}
+	if *cmd.Resume != "" {
+		meta[drive.CLIOptionResumeToken] = []string{*cmd.Resume}
 	}
 
 	opts := &drive.Options{
-		Path:      path,
-		Sources:   sources,
-		Depth:     depth,
-		Hidden:    *cmd.Hidden,
-		InTrash:   *cmd.InTrash,
-		PageSize:  *cmd.PageSize,
-		NoPrompt:  *cmd.NoPrompt,
-		Recursive: *cmd.Recursive,
-		TypeMask:  typeMask,
-		Quiet:     *cmd.Quiet,
-		Meta:      &meta,
-		Match:     *cmd.Matches,
+		Path:       path,
+		Sources:    sources,
+		Depth:      depth,
+		Hidden:     *cmd.Hidden,
+		InTrash:    *cmd.InTrash,
+		PageSize:   *cmd.PageSize,
+		NoPrompt:   *cmd.NoPrompt,
+		Recursive:  *cmd.Recursive,
+		TypeMask:   typeMask,
+		LowMemory:  *cmd.LowMemory,
+		AllDrives:  *cmd.AllDrives,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Meta:       &meta,
+		Match:      *cmd.Matches,
+		Pager:      *cmd.Pager,
+
+		MaxResults: lowerLimit(*cmd.MaxResults, *cmd.MaxItems),
+		MaxPages:   *cmd.MaxPages,
+		QPS:        *cmd.QPS,
 	}
+	opts.ModifiedAfter, opts.ModifiedBefore = modifiedRange(*cmd.NewerThan, *cmd.OlderThan)
+	opts.MinSize, opts.MaxSize = sizeRange(*cmd.MinSize, *cmd.MaxSize)
 
 	if *cmd.Shared {
 		return drive.New(context, opts).ListShared()
+	} else if *cmd.Starred {
+		return drive.New(context, opts).ListStarred()
+	} else if *cmd.Orphans {
+		return drive.New(context, opts).ListOrphans()
 	} else if *cmd.Matches {
 		return drive.New(context, opts).ListMatches()
 	} else {
@@ -478,7 +1150,7 @@ func (cmd *md5SumCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Depth = fs.Int(drive.DepthKey, 1, "max traversal depth")
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "discover hidden paths")
 	cmd.Recursive = fs.Bool(drive.RecursiveKey, false, "recursively discover folders")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "stat by id instead of path")
 	return fs
 }
@@ -505,13 +1177,14 @@ func (mcmd *md5SumCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	}
 
 	opts := drive.Options{
-		Path:      path,
-		Sources:   sources,
-		Depth:     depth,
-		Hidden:    *cmd.Hidden,
-		Recursive: *cmd.Recursive,
-		Quiet:     *cmd.Quiet,
-		Md5sum:    true,
+		Path:       path,
+		Sources:    sources,
+		Depth:      depth,
+		Hidden:     *cmd.Hidden,
+		Recursive:  *cmd.Recursive,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Md5sum:     true,
 	}
 
 	if *cmd.ById {
@@ -528,15 +1201,19 @@ type statCmd struct {
 	Recursive *bool `json:"recursive"`
 	Quiet     *bool `json:"quiet"`
 	Md5sum    *bool `json:"md5sum"`
+	JSON      *bool `json:"json"`
+	Media     *bool `json:"media"`
 }
 
 func (cmd *statCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Depth = fs.Int(drive.DepthKey, 1, "max traversal depth")
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "discover hidden paths")
 	cmd.Recursive = fs.Bool(drive.RecursiveKey, false, "recursively discover folders")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "stat by id instead of path")
 	cmd.Md5sum = fs.Bool(drive.Md5sumKey, false, "produce output compatible with md5sum(1)")
+	cmd.JSON = fs.Bool(drive.CLIOptionJSON, false, drive.DescJSON)
+	cmd.Media = fs.Bool(drive.CLIOptionMedia, false, drive.DescMedia)
 	return fs
 }
 
@@ -561,14 +1238,24 @@ func (scmd *statCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		depth = drive.InfiniteDepth
 	}
 
+	typeMask := 0
+	if *cmd.JSON {
+		typeMask |= drive.JSONOutput
+	}
+	if *cmd.Media {
+		typeMask |= drive.MediaMetadata
+	}
+
 	opts := drive.Options{
-		Depth:     depth,
-		Path:      path,
-		Sources:   sources,
-		Hidden:    *cmd.Hidden,
-		Recursive: *cmd.Recursive,
-		Quiet:     *cmd.Quiet,
-		Md5sum:    *cmd.Md5sum,
+		Depth:      depth,
+		Path:       path,
+		Sources:    sources,
+		Hidden:     *cmd.Hidden,
+		Recursive:  *cmd.Recursive,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Md5sum:     *cmd.Md5sum,
+		TypeMask:   typeMask,
 	}
 
 	if *cmd.ById {
@@ -604,7 +1291,7 @@ func (cmd *indexCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Hidden = fs.Bool(drive.HiddenKey, true, "allows fetching of hidden paths")
 	cmd.Force = fs.Bool(drive.ForceKey, false, "forces a fetch even if no changes present")
 	cmd.IgnoreNameClashes = fs.Bool(drive.CLIOptionIgnoreNameClashes, true, drive.DescIgnoreNameClashes)
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ExcludeOps = fs.String(drive.CLIOptionExcludeOperations, "", drive.DescExcludeOps)
 	cmd.SkipMimeKey = fs.String(drive.CLIOptionSkipMime, "", drive.DescSkipMime)
 	cmd.IgnoreChecksum = fs.Bool(drive.CLIOptionIgnoreChecksum, true, drive.DescIgnoreChecksum)
@@ -617,6 +1304,17 @@ func (cmd *indexCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 }
 
 func (icmd *indexCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	if len(args) >= 1 && args[0] == drive.IndexFsckKey {
+		_, context, path := preprocessArgs(args[1:])
+		exitWithError(drive.New(context, &drive.Options{
+			Path:       path,
+			NoPrompt:   *icmd.NoPrompt,
+			Quiet:      *icmd.Quiet,
+			QuietLevel: quietLevel,
+		}).IndexFsck())
+		return
+	}
+
 	byId := *icmd.ById
 	byMatches := *icmd.Matches
 	sources, context, path := preprocessArgsByToggle(args, byMatches || byId)
@@ -644,6 +1342,7 @@ func (icmd *indexCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		NoClobber:         *cmd.NoClobber,
 		Recursive:         *cmd.Recursive,
 		Quiet:             *cmd.Quiet,
+		QuietLevel:        quietLevel,
 		Force:             *cmd.Force,
 		IgnoreNameClashes: *cmd.IgnoreNameClashes,
 		Match:             *cmd.Matches,
@@ -709,7 +1408,50 @@ type pullCmd struct {
 	ExponentialBackoffRetryCount *int  `json:"retry-count"`
 	ExportsDumpToSameDirectory   *bool `json:"same-exports-dir"`
 
+	RetryMaxWait *string `json:"retry-max-wait"`
+	RetryOn      *string `json:"retry-on"`
+
 	AllowURLLinkedFiles *bool `json:"desktop-links"`
+
+	Shared     *bool   `json:"shared"`
+	ExactOwner *string `json:"exact-owner"`
+
+	PageSize   *int64 `json:"page-size"`
+	MaxResults *int64 `json:"max-results"`
+	QPS        *int   `json:"qps"`
+
+	Range     *string `json:"range"`
+	NewerThan *string `json:"newer-than"`
+	OlderThan *string `json:"older-than"`
+	MinSize   *string `json:"min-size"`
+	MaxSize   *string `json:"max-size"`
+	Include   *string `json:"include"`
+	Conflict  *string `json:"conflict"`
+	Links     *string `json:"links"`
+	Batch     *string `json:"batch"`
+
+	PreservePerms *bool `json:"preserve-perms"`
+	PreserveOwner *bool `json:"preserve-owner"`
+
+	DownloadChunks  *int    `json:"download-chunks"`
+	Transfers       *int    `json:"transfers"`
+	LowMemory       *bool   `json:"low-memory"`
+	BandwidthLimit  *string `json:"bwlimit"`
+	Incremental     *bool   `json:"incremental"`
+	ChangedSince    *string `json:"changed-since"`
+	ExportIfChanged *bool   `json:"export-if-changed"`
+	DryRun          *bool   `json:"dry-run"`
+	JSON            *bool   `json:"json"`
+
+	Extract       *bool   `json:"extract"`
+	ArchiveFormat *string `json:"archive-format"`
+
+	ChunkConcurrency *int `json:"chunk-concurrency"`
+
+	StatsJSON *string `json:"stats-json"`
+
+	Mirror       *bool `json:"mirror"`
+	MaxDeletions *int  `json:"max-deletions"`
 }
 
 func (cmd *pullCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -731,7 +1473,7 @@ func (cmd *pullCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 
 	cmd.Matches = fs.Bool(drive.MatchesKey, false, "search by prefix")
 	cmd.Piped = fs.Bool(drive.CLIOptionPiped, false, drive.DescPiped)
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ExcludeOps = fs.String(drive.CLIOptionExcludeOperations, "", drive.DescExcludeOps)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "pull by id instead of path")
 	cmd.SkipMimeKey = fs.String(drive.CLIOptionSkipMime, "", drive.DescSkipMime)
@@ -741,18 +1483,57 @@ func (cmd *pullCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.FixClashes = fs.Bool(drive.CLIOptionFixClashesKey, false, drive.DescFixClashes)
 	cmd.Starred = fs.Bool(drive.CLIOptionStarred, false, drive.DescStarred)
 	cmd.InTrash = fs.Bool(drive.TrashedKey, false, "pull content in the trash")
+	fs.BoolVar(cmd.InTrash, drive.CLIOptionInTrash, false, drive.DescPullInTrash)
 	cmd.ExponentialBackoffRetryCount = fs.Int(drive.CLIOptionRetryCount, drive.MaxFailedRetryCount, drive.DescExponentialBackoffRetryCount)
+	fs.IntVar(cmd.ExponentialBackoffRetryCount, drive.CLIOptionRetries, drive.MaxFailedRetryCount, drive.DescExponentialBackoffRetryCount)
+	cmd.RetryMaxWait = fs.String(drive.CLIOptionRetryMaxWait, "", drive.DescRetryMaxWait)
+	cmd.RetryOn = fs.String(drive.CLIOptionRetryOn, "", drive.DescRetryOn)
 	cmd.DecryptionPassword = fs.String(drive.CLIDecryptionPassword, "", drive.DescDecryptionPassword)
 
 	cmd.Files = fs.Bool(drive.CLIOptionFiles, false, "pull only files")
 	cmd.Directories = fs.Bool(drive.CLIOptionDirectories, false, "pull only directories")
 	cmd.AllowURLLinkedFiles = fs.Bool(drive.CLIOptionDesktopLinks, true, drive.DescAllowDesktopLinks)
+	cmd.Shared = fs.Bool("shared", false, "pull files shared with me into the destination")
+	cmd.ExactOwner = fs.String(drive.CLIOptionExactOwner, "", "with `-shared`, only pull files owned by these comma separated emails")
+	cmd.PageSize = fs.Int64(drive.PageSizeKey, 0, "with `-matches`, the "+drive.DescPageSize)
+	cmd.MaxResults = fs.Int64(drive.CLIOptionMaxResults, 0, "with `-matches`, "+drive.DescMaxResults)
+	cmd.QPS = fs.Int(drive.CLIOptionQPS, 0, drive.DescQPS)
+	cmd.Range = fs.String(drive.CLIOptionRange, "", "with `-piped`, "+drive.DescRange)
+	cmd.NewerThan = fs.String(drive.CLIOptionNewerThan, "", drive.DescNewerThan)
+	cmd.OlderThan = fs.String(drive.CLIOptionOlderThan, "", drive.DescOlderThan)
+	cmd.MinSize = fs.String(drive.CLIOptionMinSize, "", drive.DescMinSize)
+	cmd.MaxSize = fs.String(drive.CLIOptionMaxSize, "", drive.DescMaxSize)
+	cmd.Include = fs.String(drive.CLIOptionInclude, "", drive.DescInclude)
+	cmd.Conflict = fs.String(drive.CLIOptionConflict, "", drive.DescConflict)
+	cmd.Links = fs.String(drive.CLIOptionLinks, "", drive.DescLinks)
+	cmd.PreservePerms = fs.Bool(drive.CLIOptionPreservePerms, false, drive.DescPreservePerms)
+	cmd.PreserveOwner = fs.Bool(drive.CLIOptionPreserveOwner, false, drive.DescPreserveOwner)
+	cmd.Batch = fs.String(drive.CLIOptionBatch, "", drive.DescBatch)
+	cmd.DownloadChunks = fs.Int(drive.CLIOptionDownloadChunks, 1, drive.DescDownloadChunks)
+	cmd.ChunkConcurrency = fs.Int(drive.CLIOptionChunkConcurrency, 0, drive.DescChunkConcurrency)
+	cmd.Transfers = fs.Int(drive.CLIOptionTransfers, 0, drive.DescTransfers)
+	cmd.LowMemory = fs.Bool(drive.CLIOptionLowMemory, false, drive.DescLowMemory)
+	cmd.BandwidthLimit = fs.String(drive.CLIOptionBandwidthLimit, "", drive.DescBandwidthLimit)
+	cmd.Incremental = fs.Bool(drive.CLIOptionIncremental, false, drive.DescIncremental)
+	cmd.ChangedSince = fs.String(drive.CLIOptionChangedSince, "", drive.DescChangedSince)
+	cmd.ExportIfChanged = fs.Bool(drive.CLIOptionExportIfChanged, false, drive.DescExportIfChanged)
+	cmd.DryRun = fs.Bool(drive.CLIOptionDryRun, false, drive.DescDryRunChanges)
+	cmd.JSON = fs.Bool(drive.CLIOptionJSON, false, drive.DescDryRunJSON)
+	cmd.Extract = fs.Bool(drive.CLIOptionExtract, false, drive.DescExtract)
+	cmd.ArchiveFormat = fs.String(drive.CLIOptionArchiveFormat, "", "with `-extract`, "+drive.DescArchiveFormat)
+	cmd.StatsJSON = fs.String(drive.CLIOptionStatsJSON, "", drive.DescStatsJSON)
+	cmd.Mirror = fs.Bool(drive.CLIOptionMirror, false, drive.DescMirror)
+	cmd.MaxDeletions = fs.Int(drive.CLIOptionMaxDeletions, drive.DefaultMirrorMaxDeletions, drive.DescMaxDeletions)
 
 	return fs
 }
 
 func (pCmd *pullCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
-	sources, context, path := preprocessArgsByToggle(args, (*pCmd.ById || *pCmd.Matches || *pCmd.Starred))
+	sources, context, path := preprocessArgsWithIds(args, (*pCmd.ById || *pCmd.Matches || *pCmd.Starred))
+	if *pCmd.Batch != "" && len(args) < 1 {
+		// Only what the batch lists, not the current directory too.
+		sources = nil
+	}
 	cmd := pullCmd{}
 	df := defaultsFiller{
 		command: drive.PullKey,
@@ -773,6 +1554,27 @@ func (pCmd *pullCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 
 	meta := map[string][]string{
 		drive.SkipMimeKeyKey: drive.NonEmptyTrimmedStrings(strings.Split(*cmd.SkipMimeKey, ",")...),
+		drive.ExactOwnerKey:  drive.NonEmptyTrimmedStrings(strings.Split(*cmd.ExactOwner, ",")...),
+	}
+	if *cmd.Extract {
+		meta[drive.CLIOptionArchiveFormat] = []string{*cmd.ArchiveFormat}
+	}
+	if *cmd.Range != "" {
+		if !*cmd.Piped {
+			exitWithError(fmt.Errorf("pull: -%s only applies to -%s pulls, otherwise the local copy would be partial", drive.CLIOptionRange, drive.CLIOptionPiped))
+		}
+		meta[drive.CLIOptionRange] = []string{*cmd.Range}
+	}
+	if *cmd.Batch != "" {
+		batchPath, err := filepath.Abs(*cmd.Batch)
+		exitWithError(err)
+		meta[drive.CLIOptionBatch] = []string{batchPath}
+	}
+	if *cmd.Incremental && (*cmd.Batch != "" || *cmd.InTrash) {
+		exitWithError(fmt.Errorf("pull: -%s cannot be combined with -%s or -%s", drive.CLIOptionIncremental, drive.CLIOptionBatch, drive.CLIOptionInTrash))
+	}
+	if *cmd.ExportIfChanged && (*cmd.Batch != "" || *cmd.Incremental || *cmd.Matches || *cmd.Shared) {
+		exitWithError(fmt.Errorf("pull: -%s cannot be combined with -%s, -%s, -%s or -shared", drive.CLIOptionExportIfChanged, drive.CLIOptionBatch, drive.CLIOptionIncremental, drive.MatchesKey))
 	}
 
 	// Filter out empty strings.
@@ -804,6 +1606,11 @@ func (pCmd *pullCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 
 	exitIfIllogicalFileAndFolder(typeMask)
 
+	if *cmd.DryRun && (*cmd.Piped || *cmd.ExportIfChanged || *cmd.Extract) {
+		exitWithError(fmt.Errorf("pull: -%s cannot be combined with -%s, -%s or -%s", drive.CLIOptionDryRun, drive.CLIOptionPiped, drive.CLIOptionExportIfChanged, drive.CLIOptionExtract))
+	}
+	typeMask |= dryRunOutput(drive.PullKey, *cmd.DryRun, *cmd.JSON)
+
 	fixMode, ok := translateFixMode(*cmd.FixMode)
 	if !ok {
 		exitWithError(fmt.Errorf("Unknown fix mode: %s", *cmd.FixMode))
@@ -821,11 +1628,13 @@ func (pCmd *pullCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		NoClobber:  *cmd.NoClobber,
 		Recursive:  *cmd.Recursive,
 		Piped:      *cmd.Piped,
-		Quiet:      *cmd.Quiet,
+		Quiet:      *cmd.Quiet || *cmd.JSON,
+		QuietLevel: quietLevel,
 		Meta:       &meta,
 		Verbose:    *cmd.Verbose,
 		Depth:      *cmd.Depth,
 		FixClashes: *cmd.FixClashes,
+		DryRun:     *cmd.DryRun,
 		Starred:    *cmd.Starred,
 		Match:      *cmd.Matches,
 		InTrash:    *cmd.InTrash,
@@ -842,14 +1651,49 @@ func (pCmd *pullCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		AllowURLLinkedFiles:          *cmd.AllowURLLinkedFiles,
 		ExportsDumpToSameDirectory:   *cmd.ExportsDumpToSameDirectory,
 		ExponentialBackoffRetryCount: retryCount,
+
+		PageSize:   *cmd.PageSize,
+		MaxResults: *cmd.MaxResults,
+		QPS:        *cmd.QPS,
+
+		Transfers:      *cmd.Transfers,
+		BandwidthLimit: bandwidthLimit(*cmd.BandwidthLimit),
+		Incremental:    *cmd.Incremental,
+		LowMemory:      *cmd.LowMemory,
+	}
+	// -download-chunks is what -chunk-concurrency used to be called.
+	options.ChunkConcurrency = *cmd.ChunkConcurrency
+	if options.ChunkConcurrency < 1 {
+		options.ChunkConcurrency = *cmd.DownloadChunks
+	}
+	options.ModifiedAfter, options.ModifiedBefore = modifiedRange(*cmd.NewerThan, *cmd.OlderThan)
+	options.MinSize, options.MaxSize = sizeRange(*cmd.MinSize, *cmd.MaxSize)
+	options.Includes = includeFilter(*cmd.Include)
+	options.ConflictStrategy = conflictStrategy(*cmd.Conflict)
+	options.Links = linkMode(*cmd.Links)
+	options.PreservePerms, options.PreserveOwner = *cmd.PreservePerms || *cmd.PreserveOwner, *cmd.PreserveOwner
+	options.RetryMaxWait, options.RetryOn = retryPolicy(*cmd.RetryMaxWait, *cmd.RetryOn)
+	options.StatsJSON = *cmd.StatsJSON
+	options.Mirror, options.MirrorMaxDeletions = *cmd.Mirror, *cmd.MaxDeletions
+	if *cmd.ChangedSince != "" {
+		var err error
+		if options.ChangedSince, err = drive.ParseTimeOrAge(*cmd.ChangedSince); err != nil {
+			exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionChangedSince, err))
+		}
 	}
 
-	if *cmd.Matches || *cmd.Starred {
+	if *cmd.ExportIfChanged {
+		exitWithError(drive.New(context, options).PullChangedExports(*cmd.ById))
+	} else if *cmd.Extract {
+		exitWithError(drive.New(context, options).PullExtract())
+	} else if *cmd.Matches || *cmd.Starred {
 		if *cmd.AllStarred {
 			exitWithError(drive.New(context, options).PullAllStarred())
 		} else {
 			exitWithError(drive.New(context, options).PullMatchLike())
 		}
+	} else if *cmd.Shared {
+		exitWithError(drive.New(context, options).PullShared())
 	} else if *cmd.Piped {
 		exitWithError(drive.New(context, options).PullPiped(*cmd.ById))
 	} else if *cmd.ById {
@@ -886,12 +1730,51 @@ type pushCmd struct {
 	FixClashes                   *bool   `json:"fix-clashes"`
 	Destination                  *string `json:"dest"`
 	ExponentialBackoffRetryCount *int    `json:"retry-count"`
+	RetryMaxWait                 *string `json:"retry-max-wait"`
+	RetryOn                      *string `json:"retry-on"`
 	EncryptionPassword           *string `json:"encryption-password"`
 
 	Files           *bool `json:"files"`
 	Directories     *bool `json:"directories"`
 	UploadChunkSize *int  `json:"upload-chunk-size"`
 	UploadRateLimit *int  `json:"upload-rate-limit"`
+
+	SmallFileSize *string `json:"small-file-size"`
+	SmallFileJobs *int    `json:"small-file-jobs"`
+	Transfers     *int    `json:"transfers"`
+	LowMemory     *bool   `json:"low-memory"`
+	AsArchive     *bool   `json:"as-archive"`
+	ArchiveFormat *string `json:"archive-format"`
+
+	Permanent    *bool   `json:"permanent"`
+	DeletionMode *string `json:"deletion"`
+
+	QPS       *int    `json:"qps"`
+	NewerThan *string `json:"newer-than"`
+	OlderThan *string `json:"older-than"`
+	Include   *string `json:"include"`
+	Conflict  *string `json:"conflict"`
+	Links     *string `json:"links"`
+
+	DuplicateTitles *string `json:"duplicate-titles"`
+
+	PreservePerms *bool `json:"preserve-perms"`
+	PreserveOwner *bool `json:"preserve-owner"`
+	Compress      *bool `json:"compress"`
+
+	StatsJSON *string `json:"stats-json"`
+
+	Mirror       *bool `json:"mirror"`
+	MaxDeletions *int  `json:"max-deletions"`
+
+	Manifest *string `json:"manifest"`
+	Batch    *string `json:"batch"`
+
+	BandwidthLimit *string `json:"bwlimit"`
+	SkipDuplicates *bool   `json:"skip-duplicates"`
+
+	DryRun *bool `json:"dry-run"`
+	JSON   *bool `json:"json"`
 }
 
 func (cmd *pushCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -907,7 +1790,7 @@ func (cmd *pushCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Piped = fs.Bool(drive.CLIOptionPiped, false, drive.DescPiped)
 	cmd.IgnoreChecksum = fs.Bool(drive.CLIOptionIgnoreChecksum, true, drive.DescIgnoreChecksum)
 	cmd.IgnoreConflict = fs.Bool(drive.CLIOptionIgnoreConflict, false, drive.DescIgnoreConflict)
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.CoercedMimeKey = fs.String(drive.CoercedMimeKeyKey, "", "the mimeType you are trying to coerce this file to be")
 	cmd.IgnoreNameClashes = fs.Bool(drive.CLIOptionIgnoreNameClashes, false, drive.DescIgnoreNameClashes)
 	cmd.ExcludeOps = fs.String(drive.CLIOptionExcludeOperations, "", drive.DescExcludeOps)
@@ -917,11 +1800,41 @@ func (cmd *pushCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.FixClashes = fs.Bool(drive.CLIOptionFixClashesKey, false, drive.DescFixClashes)
 	cmd.Destination = fs.String(drive.CLIOptionPushDestination, "", drive.DescPushDestination)
 	cmd.ExponentialBackoffRetryCount = fs.Int(drive.CLIOptionRetryCount, drive.MaxFailedRetryCount, drive.DescExponentialBackoffRetryCount)
+	fs.IntVar(cmd.ExponentialBackoffRetryCount, drive.CLIOptionRetries, drive.MaxFailedRetryCount, drive.DescExponentialBackoffRetryCount)
+	cmd.RetryMaxWait = fs.String(drive.CLIOptionRetryMaxWait, "", drive.DescRetryMaxWait)
+	cmd.RetryOn = fs.String(drive.CLIOptionRetryOn, "", drive.DescRetryOn)
 	cmd.EncryptionPassword = fs.String(drive.CLIEncryptionPassword, "", drive.DescEncryptionPassword)
 	cmd.Files = fs.Bool(drive.CLIOptionFiles, false, "push only files")
 	cmd.Directories = fs.Bool(drive.CLIOptionDirectories, false, "push only directories")
 	cmd.UploadChunkSize = fs.Int(drive.CLIOptionUploadChunkSize, 0, "specifies the size of each data chunk to be uploaded. Only set it if you want a custom chunk size. Otherwise the default value of googleapi.DefaultUploadChunkSize ie 8MiB will be used. However it must be at least googleapi.MinUploadChunkSize ie 256KiB. See https://godoc.org/google.golang.org/api/googleapi#pkg-constants. If `-upload-chunk-size` is not set yet `-upload-rate-limit` is, `-upload-chunk-size` will be the same as `-upload-rate-limit`.")
 	cmd.UploadRateLimit = fs.Int(drive.CLIOptionUploadRateLimit, 0, "Limit the upload bandwidth to n KiB/s, default is unlimited.")
+	cmd.SmallFileSize = fs.String(drive.CLIOptionSmallFileSize, drive.DefaultSmallFileSize, drive.DescSmallFileSize)
+	cmd.SmallFileJobs = fs.Int(drive.CLIOptionSmallFileJobs, 0, drive.DescSmallFileJobs)
+	cmd.Transfers = fs.Int(drive.CLIOptionTransfers, 0, drive.DescTransfers)
+	cmd.LowMemory = fs.Bool(drive.CLIOptionLowMemory, false, drive.DescLowMemory)
+	cmd.QPS = fs.Int(drive.CLIOptionQPS, 0, drive.DescQPS)
+	cmd.NewerThan = fs.String(drive.CLIOptionNewerThan, "", drive.DescNewerThan)
+	cmd.OlderThan = fs.String(drive.CLIOptionOlderThan, "", drive.DescOlderThan)
+	cmd.Include = fs.String(drive.CLIOptionInclude, "", drive.DescInclude)
+	cmd.Conflict = fs.String(drive.CLIOptionConflict, "", drive.DescConflict)
+	cmd.Links = fs.String(drive.CLIOptionLinks, "", drive.DescLinks)
+	cmd.DuplicateTitles = fs.String(drive.CLIOptionDuplicateTitles, "", drive.DescDuplicateTitles)
+	cmd.PreservePerms = fs.Bool(drive.CLIOptionPreservePerms, false, drive.DescPreservePerms)
+	cmd.PreserveOwner = fs.Bool(drive.CLIOptionPreserveOwner, false, drive.DescPreserveOwner)
+	cmd.Compress = fs.Bool(drive.CLIOptionCompress, false, drive.DescCompress)
+	cmd.StatsJSON = fs.String(drive.CLIOptionStatsJSON, "", drive.DescStatsJSON)
+	cmd.Mirror = fs.Bool(drive.CLIOptionMirror, false, drive.DescMirror)
+	cmd.MaxDeletions = fs.Int(drive.CLIOptionMaxDeletions, drive.DefaultMirrorMaxDeletions, drive.DescMaxDeletions)
+	cmd.AsArchive = fs.Bool(drive.CLIOptionAsArchive, false, drive.DescAsArchive)
+	cmd.ArchiveFormat = fs.String(drive.CLIOptionArchiveFormat, drive.DefaultArchiveFormat, drive.DescArchiveFormat)
+	cmd.Permanent = fs.Bool(drive.CLIOptionPermanent, false, drive.DescPermanent)
+	cmd.DeletionMode = fs.String(drive.DeletionModeKey, drive.DeletionTrash, drive.DescDeletionMode)
+	cmd.Manifest = fs.String(drive.CLIOptionManifest, "", drive.DescManifest)
+	cmd.Batch = fs.String(drive.CLIOptionBatch, "", drive.DescBatch)
+	cmd.BandwidthLimit = fs.String(drive.CLIOptionBandwidthLimit, "", drive.DescBandwidthLimit)
+	cmd.SkipDuplicates = fs.Bool(drive.CLIOptionSkipDuplicates, false, drive.DescSkipDuplicates)
+	cmd.DryRun = fs.Bool(drive.CLIOptionDryRun, false, drive.DescDryRunChanges)
+	cmd.JSON = fs.Bool(drive.CLIOptionJSON, false, drive.DescDryRunJSON)
 
 	return fs
 }
@@ -933,6 +1846,13 @@ func (cmd *pushCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	}
 
 	sources, context, path := preprocessArgs(args)
+	if *cmd.Batch != "" && len(args) < 1 {
+		// Only what the batch lists, not the current directory too.
+		sources = nil
+	}
+	if *cmd.DryRun && (*cmd.Piped || *cmd.AsArchive) {
+		exitWithError(fmt.Errorf("push: -%s cannot be combined with -%s or -%s", drive.CLIOptionDryRun, drive.CLIOptionPiped, drive.CLIOptionAsArchive))
+	}
 
 	options, err := cmd.createPushOptions(context.AbsPathOf(path), definedFlags)
 	if err != nil {
@@ -944,6 +1864,8 @@ func (cmd *pushCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 
 	if *cmd.Piped {
 		exitWithError(drive.New(context, options).PushPiped())
+	} else if *cmd.AsArchive {
+		exitWithError(drive.New(context, options).PushArchive())
 	} else {
 		exitWithError(drive.New(context, options).Push())
 	}
@@ -1009,7 +1931,7 @@ func (cmd *touchCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "allows pushing of hidden paths")
 	cmd.Recursive = fs.Bool(drive.RecursiveKey, false, "toggles recursive touching")
 	cmd.Matches = fs.Bool(drive.MatchesKey, false, "search by prefix and touch")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "share by id instead of path")
 	cmd.Depth = fs.Int(drive.DepthKey, drive.DefaultMaxTraversalDepth, "max traversal depth")
 	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, true, drive.DescVerbose)
@@ -1043,14 +1965,15 @@ func (tcmd *touchCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	}
 
 	opts := drive.Options{
-		Path:      path,
-		Sources:   sources,
-		Hidden:    *cmd.Hidden,
-		Recursive: *cmd.Recursive,
-		Depth:     depth,
-		Quiet:     *cmd.Quiet,
-		Match:     *cmd.Matches,
-		Verbose:   *cmd.Verbose,
+		Path:       path,
+		Sources:    sources,
+		Hidden:     *cmd.Hidden,
+		Recursive:  *cmd.Recursive,
+		Depth:      depth,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Match:      *cmd.Matches,
+		Verbose:    *cmd.Verbose,
 	}
 
 	meta := map[string][]string{
@@ -1068,6 +1991,104 @@ func (tcmd *touchCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	}
 }
 
+// modifiedRange parses --newer-than and --older-than, exiting if either is invalid.
+func modifiedRange(newerThan, olderThan string) (after, before time.Time) {
+	var err error
+	if newerThan != "" {
+		if after, err = drive.ParseTimeOrAge(newerThan); err != nil {
+			exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionNewerThan, err))
+		}
+	}
+	if olderThan != "" {
+		if before, err = drive.ParseTimeOrAge(olderThan); err != nil {
+			exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionOlderThan, err))
+		}
+	}
+	if !after.IsZero() && !before.IsZero() && !after.Before(before) {
+		exitWithError(fmt.Errorf("-%s must be earlier than -%s", drive.CLIOptionNewerThan, drive.CLIOptionOlderThan))
+	}
+	return after, before
+}
+
+// quietLevel is set by -quiet, whichever command it is given to.
+var quietLevel drive.QuietLevel
+
+// quietValue is -quiet, which optionally takes how quiet to be e.g
+// -quiet=summary. A bare -quiet still means only logging errors.
+type quietValue struct {
+	quiet *bool
+}
+
+func (qv *quietValue) IsBoolFlag() bool {
+	return true
+}
+
+func (qv *quietValue) String() string {
+	if qv.quiet == nil || !*qv.quiet {
+		return drive.QuietNone.String()
+	}
+	return quietLevel.String()
+}
+
+func (qv *quietValue) Set(s string) error {
+	level, err := drive.ParseQuietLevel(s)
+	if err != nil {
+		return err
+	}
+	*qv.quiet = level != drive.QuietNone
+	quietLevel = level
+	return nil
+}
+
+func quietFlag(fs *flag.FlagSet) *bool {
+	quiet := new(bool)
+	fs.Var(&quietValue{quiet: quiet}, drive.QuietKey, drive.DescQuiet)
+	return quiet
+}
+
+// lowerLimit returns the lower of two limits where 0 means no limit.
+func lowerLimit(a, b int64) int64 {
+	if a < 1 || (b >= 1 && b < a) {
+		return b
+	}
+	return a
+}
+
+// sizeRange parses --min-size and --max-size, exiting if either is invalid.
+func sizeRange(minSize, maxSize string) (min, max int64) {
+	var err error
+	if minSize != "" {
+		if min, err = drive.ParseByteSize(minSize); err != nil {
+			exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionMinSize, err))
+		}
+	}
+	if maxSize != "" {
+		if max, err = drive.ParseByteSize(maxSize); err != nil {
+			exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionMaxSize, err))
+		}
+	}
+	if min >= 1 && max >= 1 && min > max {
+		exitWithError(fmt.Errorf("-%s cannot be greater than -%s", drive.CLIOptionMinSize, drive.CLIOptionMaxSize))
+	}
+	return min, max
+}
+
+func bandwidthLimit(spec string) *drive.BandwidthLimit {
+	limit, err := drive.ParseBandwidthLimit(spec)
+	if err != nil {
+		exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionBandwidthLimit, err))
+	}
+	return limit
+}
+
+func includeFilter(spec string) *drive.IncludeFilter {
+	inc, err := drive.ParseIncludeFilter(spec)
+	if err != nil {
+		exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionInclude, err))
+	}
+	return inc
+}
+
 func exitIfIllogicalFileAndFolder(mask int) {
 	fileAndFolder := drive.NonFolder | drive.Folder
 	if (mask & fileAndFolder) == fileAndFolder {
@@ -1106,8 +2127,20 @@ func (pCmd *pushCmd) createPushOptions(absEntryPath string, definedFlags map[str
 	exitIfIllogicalFileAndFolder(mask)
 
 	meta := map[string][]string{
-		drive.CoercedMimeKeyKey: drive.NonEmptyTrimmedStrings(*cmd.CoercedMimeKey),
-		drive.SkipMimeKeyKey:    drive.NonEmptyTrimmedStrings(strings.Split(*cmd.SkipMimeKey, ",")...),
+		drive.CoercedMimeKeyKey:      drive.NonEmptyTrimmedStrings(*cmd.CoercedMimeKey),
+		drive.SkipMimeKeyKey:         drive.NonEmptyTrimmedStrings(strings.Split(*cmd.SkipMimeKey, ",")...),
+		drive.CLIOptionSmallFileSize: []string{*cmd.SmallFileSize},
+	}
+	if *cmd.AsArchive {
+		meta[drive.CLIOptionArchiveFormat] = []string{*cmd.ArchiveFormat}
+	}
+	if *cmd.Manifest != "" {
+		meta[drive.CLIOptionManifest] = []string{*cmd.Manifest}
+	}
+	if *cmd.Batch != "" {
+		batchPath, err := filepath.Abs(*cmd.Batch)
+		exitWithError(err)
+		meta[drive.CLIOptionBatch] = []string{batchPath}
 	}
 
 	excludes := drive.NonEmptyTrimmedStrings(strings.Split(*cmd.ExcludeOps, ",")...)
@@ -1146,9 +2179,11 @@ func (pCmd *pushCmd) createPushOptions(absEntryPath string, definedFlags map[str
 		NoPrompt:                     *cmd.NoPrompt,
 		Recursive:                    *cmd.Recursive,
 		Piped:                        *cmd.Piped,
-		Quiet:                        *cmd.Quiet,
+		Quiet:                        *cmd.Quiet || *cmd.JSON,
+		QuietLevel:                   quietLevel,
 		Meta:                         &meta,
-		TypeMask:                     mask,
+		TypeMask:                     mask | dryRunOutput(drive.PushKey, *cmd.DryRun, *cmd.JSON),
+		DryRun:                       *cmd.DryRun,
 		ExcludeCrudMask:              excludeCrudMask,
 		IgnoreNameClashes:            *cmd.IgnoreNameClashes,
 		Verbose:                      *cmd.Verbose,
@@ -1160,7 +2195,25 @@ func (pCmd *pushCmd) createPushOptions(absEntryPath string, definedFlags map[str
 		UploadChunkSize:              *cmd.UploadChunkSize,
 		UploadRateLimit:              *cmd.UploadRateLimit,
 		FixClashesMode:               fixMode,
-	}
+		SmallFileJobs:                *cmd.SmallFileJobs,
+		Transfers:                    *cmd.Transfers,
+		LowMemory:                    *cmd.LowMemory,
+		Permanent:                    *cmd.Permanent,
+		DeletionMode:                 *cmd.DeletionMode,
+		QPS:                          *cmd.QPS,
+		BandwidthLimit:               bandwidthLimit(*cmd.BandwidthLimit),
+		SkipDuplicates:               *cmd.SkipDuplicates,
+	}
+	opts.ModifiedAfter, opts.ModifiedBefore = modifiedRange(*cmd.NewerThan, *cmd.OlderThan)
+	opts.Includes = includeFilter(*cmd.Include)
+	opts.ConflictStrategy = conflictStrategy(*cmd.Conflict)
+	opts.Links = linkMode(*cmd.Links)
+	opts.DuplicateTitles = duplicateTitlePolicy(*cmd.DuplicateTitles)
+	opts.PreservePerms, opts.PreserveOwner = *cmd.PreservePerms || *cmd.PreserveOwner, *cmd.PreserveOwner
+	opts.Compress = *cmd.Compress
+	opts.RetryMaxWait, opts.RetryOn = retryPolicy(*cmd.RetryMaxWait, *cmd.RetryOn)
+	opts.StatsJSON = *cmd.StatsJSON
+	opts.Mirror, opts.MirrorMaxDeletions = *cmd.Mirror, *cmd.MaxDeletions
 
 	return opts, nil
 }
@@ -1221,7 +2274,7 @@ type aboutCmd struct {
 func (cmd *aboutCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Features = fs.Bool("features", false, "gives information on features present on this drive")
 	cmd.Filesize = fs.Bool("filesize", false, "prints out information about file sizes e.g the max upload size for a specific file size")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.Quota = fs.Bool("quota", false, "prints out quota information for this drive")
 	return fs
 }
@@ -1258,7 +2311,8 @@ func (acmd *aboutCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	}
 
 	exitWithError(drive.New(context, &drive.Options{
-		Quiet: *cmd.Quiet,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
 	}).About(mask))
 }
 
@@ -1273,6 +2327,7 @@ type diffCmd struct {
 	Unified           *bool `json:"unified"`
 	BaseLocal         *bool `json:"base-local"`
 	SkipContentCheck  *bool `json:"skip-content-check"`
+	Metadata          *bool `json:"metadata"`
 }
 
 func (cmd *diffCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -1280,12 +2335,13 @@ func (cmd *diffCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.IgnoreChecksum = fs.Bool(drive.CLIOptionIgnoreChecksum, true, drive.DescIgnoreChecksum)
 	cmd.IgnoreConflict = fs.Bool(drive.CLIOptionIgnoreConflict, false, drive.DescIgnoreConflict)
 	cmd.IgnoreNameClashes = fs.Bool(drive.CLIOptionIgnoreNameClashes, false, drive.DescIgnoreNameClashes)
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.Depth = fs.Int(drive.DepthKey, drive.DefaultMaxTraversalDepth, "max traversal depth")
 	cmd.Recursive = fs.Bool(drive.RecursiveKey, true, "recursively diff")
 	cmd.Unified = fs.Bool(drive.CLIOptionUnifiedShortKey, true, drive.DescUnifiedDiff)
 	cmd.BaseLocal = fs.Bool(drive.CLIOptionDiffBaseLocal, true, drive.DescDiffBaseLocal)
 	cmd.SkipContentCheck = fs.Bool(drive.SkipContentCheckKey, false, drive.DescSkipContentCheck)
+	cmd.Metadata = fs.Bool(drive.CLIOptionMetadata, false, drive.DescDiffMetadata)
 
 	return fs
 }
@@ -1297,6 +2353,9 @@ func (cmd *diffCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	if *cmd.Unified {
 		mask |= drive.DiffUnified
 	}
+	if *cmd.Metadata {
+		mask |= drive.DiffMetadata
+	}
 
 	var metaPtr *map[string][]string
 	if *cmd.SkipContentCheck {
@@ -1315,6 +2374,7 @@ func (cmd *diffCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		IgnoreNameClashes: *cmd.IgnoreNameClashes,
 		IgnoreConflict:    *cmd.IgnoreConflict,
 		Quiet:             *cmd.Quiet,
+		QuietLevel:        quietLevel,
 		Depth:             *cmd.Depth,
 		BaseLocal:         *cmd.BaseLocal,
 		Meta:              metaPtr,
@@ -1330,7 +2390,7 @@ type unpublishCmd struct {
 
 func (cmd *unpublishCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "allows pulling of hidden paths")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "unpublish by id instead of path")
 	return fs
 }
@@ -1351,61 +2411,86 @@ func (uCmd *unpublishCmd) Run(args []string, definedFlags map[string]*flag.Flag)
 	}
 
 	exitWithError(drive.New(context, &drive.Options{
-		Path:    path,
-		Sources: sources,
-		Quiet:   *cmd.Quiet,
+		Path:       path,
+		Sources:    sources,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
 	}).Unpublish(*cmd.ById))
 }
 
 type emptyTrashCmd struct {
-	NoPrompt *bool `json:"no-prompt"`
-	Quiet    *bool `json:"quiet"`
+	NoPrompt  *bool `json:"no-prompt"`
+	Quiet     *bool `json:"quiet"`
+	Permanent *bool `json:"permanent"`
 }
 
 func (cmd *emptyTrashCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before emptying the trash")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
+	cmd.Permanent = fs.Bool(drive.CLIOptionPermanent, false, "confirms that emptying the trash is unrecoverable")
 	return fs
 }
 
 func (cmd *emptyTrashCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	_, context, _ := preprocessArgs(args)
 	exitWithError(drive.New(context, &drive.Options{
-		NoPrompt: *cmd.NoPrompt,
-		Quiet:    *cmd.Quiet,
+		NoPrompt:   *cmd.NoPrompt,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Permanent:  *cmd.Permanent,
 	}).EmptyTrash())
 }
 
 type deleteCmd struct {
-	Hidden   *bool `json:"hidden"`
-	Matches  *bool `json:"matches"`
-	Quiet    *bool `json:"quiet"`
-	ById     *bool `json:"by-id"`
-	NoPrompt *bool `json:"no-prompt"`
+	Hidden       *bool   `json:"hidden"`
+	Matches      *bool   `json:"matches"`
+	Quiet        *bool   `json:"quiet"`
+	ById         *bool   `json:"by-id"`
+	NoPrompt     *bool   `json:"no-prompt"`
+	Permanent    *bool   `json:"permanent"`
+	DeletionMode *string `json:"deletion"`
 }
 
 func (cmd *deleteCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "allows trashing hidden paths")
 	cmd.Matches = fs.Bool(drive.MatchesKey, false, "search by prefix and delete")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "delete by id instead of path")
 	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "disables the prompt")
+	cmd.Permanent = fs.Bool(drive.CLIOptionPermanent, false, drive.DescPermanent)
+	cmd.DeletionMode = fs.String(drive.DeletionModeKey, drive.DeletionTrash, drive.DescDeletionMode)
 
 	return fs
 }
 
-func (cmd *deleteCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
-	if *cmd.NoPrompt {
-		exitWithError(drive.PermanentDeletionNoPromptError)
+func (dCmd *deleteCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgsByToggle(args, *dCmd.Matches || *dCmd.ById)
+
+	cmd := new(deleteCmd)
+	df := defaultsFiller{
+		command: drive.DeleteKey,
+		from:    *dCmd, to: cmd,
+		rcSourcePath: context.AbsPathOf(path),
+		definedFlags: definedFlags,
 	}
 
-	sources, context, path := preprocessArgsByToggle(args, *cmd.Matches || *cmd.ById)
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	if *cmd.NoPrompt && *cmd.Permanent {
+		exitWithError(drive.PermanentDeletionNoPromptError)
+	}
 
 	opts := drive.Options{
-		Path:    path,
-		Sources: sources,
-		Quiet:   *cmd.Quiet,
-		Match:   *cmd.Matches,
+		Path:         path,
+		Sources:      sources,
+		Quiet:        *cmd.Quiet,
+		QuietLevel:   quietLevel,
+		Match:        *cmd.Matches,
+		NoPrompt:     *cmd.NoPrompt,
+		Permanent:    *cmd.Permanent,
+		DeletionMode: *cmd.DeletionMode,
 	}
 
 	if !*cmd.Matches {
@@ -1426,7 +2511,7 @@ type trashCmd struct {
 func (cmd *trashCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "allows trashing hidden paths")
 	cmd.Matches = fs.Bool(drive.MatchesKey, false, "search by prefix and trash")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "trash by id instead of path")
 	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, drive.DescVerbose)
 
@@ -1437,11 +2522,12 @@ func (cmd *trashCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	sources, context, path := preprocessArgsByToggle(args, *cmd.Matches || *cmd.ById)
 
 	opts := drive.Options{
-		Path:    path,
-		Sources: sources,
-		Quiet:   *cmd.Quiet,
-		Match:   *cmd.Matches,
-		Verbose: *cmd.Verbose,
+		Path:       path,
+		Sources:    sources,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Match:      *cmd.Matches,
+		Verbose:    *cmd.Verbose,
 	}
 
 	if !*cmd.Matches {
@@ -1483,20 +2569,138 @@ func (cmd *newCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	}
 }
 
+type mkdirCmd struct {
+	Parents *bool `json:"p"`
+	PrintId *bool `json:"print-id"`
+	Quiet   *bool `json:"quiet"`
+}
+
+func (cmd *mkdirCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Parents = fs.Bool(drive.CLIOptionParents, false, drive.DescParents)
+	cmd.PrintId = fs.Bool(drive.CLIOptionPrintId, false, drive.DescPrintId)
+	cmd.Quiet = quietFlag(fs)
+	return fs
+}
+
+func (cmd *mkdirCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	if len(args) < 1 {
+		exitWithError(fmt.Errorf("mkdir: expecting at least one path"))
+	}
+	sources, context, path := preprocessArgs(args)
+
+	opts := drive.Options{
+		Path:       path,
+		Sources:    sources,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+	}
+
+	exitWithError(drive.New(context, &opts).Mkdir(*cmd.Parents, *cmd.PrintId))
+}
+
+type dedupeCmd struct {
+	Local  *bool `json:"local"`
+	Record *bool `json:"record"`
+	Hidden *bool `json:"hidden"`
+	Quiet  *bool `json:"quiet"`
+}
+
+func (cmd *dedupeCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Local = fs.Bool(drive.CLIOptionDedupeLocal, false, drive.DescDedupeLocal)
+	cmd.Record = fs.Bool(drive.CLIOptionRecordDuplicates, false, drive.DescRecordDuplicates)
+	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "checks hidden paths too")
+	cmd.Quiet = quietFlag(fs)
+	return fs
+}
+
+func (cmd *dedupeCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	if !*cmd.Local {
+		exitWithError(fmt.Errorf("dedupe: only local trees can be deduplicated for now, use -%s", drive.CLIOptionDedupeLocal))
+	}
+	sources, context, path := preprocessArgs(args)
+
+	opts := drive.Options{
+		Path:       path,
+		Sources:    sources,
+		Hidden:     *cmd.Hidden,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+	}
+
+	exitWithError(drive.New(context, &opts).DedupeLocal(*cmd.Record))
+}
+
+type resolveCmd struct {
+	From     *string `json:"from"`
+	Strategy *string `json:"strategy"`
+	Hidden   *bool   `json:"hidden"`
+	NoPrompt *bool   `json:"no-prompt"`
+	Quiet    *bool   `json:"quiet"`
+}
+
+func (cmd *resolveCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.From = fs.String(drive.CLIOptionResolveFrom, "", drive.DescResolveFrom)
+	cmd.Strategy = fs.String(drive.CLIOptionResolveStrategy, "", drive.DescResolveStrategy)
+	cmd.Hidden = fs.Bool(drive.HiddenKey, true, "allows resolving hidden paths")
+	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before applying the resolutions")
+	cmd.Quiet = quietFlag(fs)
+	return fs
+}
+
+func (cmd *resolveCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	context, path := discoverContext(args)
+
+	reportPath := config.ConflictsSuffixedPath(context.AbsPath)
+	if *cmd.From != "" {
+		absPath, err := filepath.Abs(*cmd.From)
+		exitWithError(err)
+		reportPath = absPath
+	}
+
+	strategy, err := drive.ParseConflictStrategy(*cmd.Strategy)
+	if err != nil {
+		exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionResolveStrategy, err))
+	}
+
+	opts := drive.Options{
+		Path:       path,
+		Hidden:     *cmd.Hidden,
+		NoPrompt:   *cmd.NoPrompt,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Meta:       &map[string][]string{},
+	}
+
+	exitWithError(drive.New(context, &opts).ResolveConflicts(reportPath, strategy))
+}
+
 type copyCmd struct {
 	Quiet     *bool `json:"quiet"`
 	Recursive *bool `json:"recursive"`
 	ById      *bool `json:"by-id"`
+
+	PreservePermissions *bool `json:"preserve-permissions"`
+
+	FromContext *string `json:"from-context"`
+	ToContext   *string `json:"to-context"`
 }
 
 func (cmd *copyCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Recursive = fs.Bool(drive.RecursiveKey, false, "recursive copying")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "copy by id instead of path")
+	cmd.PreservePermissions = fs.Bool(drive.CLIOptionPreservePermissions, false, drive.DescPreservePermissions)
+	cmd.FromContext = fs.String(drive.CLIOptionFromContext, "", drive.DescFromContext)
+	cmd.ToContext = fs.String(drive.CLIOptionToContext, "", drive.DescToContext)
 	return fs
 }
 
 func (cmd *copyCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	if *cmd.FromContext != "" || *cmd.ToContext != "" {
+		cmd.runAcrossContexts(args)
+		return
+	}
+
 	if len(args) < 2 {
 		args = append(args, ".")
 	}
@@ -1519,25 +2723,126 @@ func (cmd *copyCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	sources = append(sources, dest)
 
 	exitWithError(drive.New(context, &drive.Options{
-		Path:      path,
-		Sources:   sources,
-		Recursive: *cmd.Recursive,
-		Quiet:     *cmd.Quiet,
+		Path:       path,
+		Sources:    sources,
+		Recursive:  *cmd.Recursive,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+
+		PreservePermissions: *cmd.PreservePermissions,
 	}).Copy(*cmd.ById))
 }
 
+func (cmd *copyCmd) runAcrossContexts(args []string) {
+	if *cmd.FromContext == "" || *cmd.ToContext == "" || len(args) > 0 {
+		exitWithError(fmt.Errorf("copy: expecting both -%s and -%s and no paths", drive.CLIOptionFromContext, drive.CLIOptionToContext))
+	}
+
+	fromContextPath, fromPath, err := drive.ParseContextSpec(*cmd.FromContext)
+	exitWithError(err)
+	toContextPath, toPath, err := drive.ParseContextSpec(*cmd.ToContext)
+	exitWithError(err)
+
+	fromContext, _ := discoverContext([]string{fromContextPath})
+	toContext, _ := discoverContext([]string{toContextPath})
+
+	exitWithError(drive.New(fromContext, &drive.Options{
+		Sources:    []string{fromPath, toPath},
+		Recursive:  *cmd.Recursive,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+	}).CopyAcrossContexts(toContext))
+}
+
+type expandArchiveCmd struct {
+	Force         *bool   `json:"force"`
+	Quiet         *bool   `json:"quiet"`
+	ArchiveFormat *string `json:"archive-format"`
+}
+
+func (cmd *expandArchiveCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Force = fs.Bool(drive.ForceKey, false, "overwrite files that already exist in the folder")
+	cmd.Quiet = quietFlag(fs)
+	cmd.ArchiveFormat = fs.String(drive.CLIOptionArchiveFormat, "", drive.DescArchiveFormat)
+	return fs
+}
+
+func (cmd *expandArchiveCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	if len(args) != 2 {
+		exitWithError(fmt.Errorf("expand-archive: expected an archive and a folder"))
+	}
+
+	sources, context, path := preprocessArgs(args)
+
+	meta := map[string][]string{
+		drive.CLIOptionArchiveFormat: []string{*cmd.ArchiveFormat},
+	}
+
+	exitWithError(drive.New(context, &drive.Options{
+		Path:       path,
+		Sources:    sources,
+		Force:      *cmd.Force,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Meta:       &meta,
+	}).ExpandArchive())
+}
+
+type grepCmd struct {
+	IgnoreCase       *bool   `json:"ignore-case"`
+	FilesWithMatches *bool   `json:"files-with-matches"`
+	FullText         *string `json:"full-text"`
+	Hidden           *bool   `json:"hidden"`
+	Verbose          *bool   `json:"verbose"`
+}
+
+func (cmd *grepCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.IgnoreCase = fs.Bool(drive.CLIOptionIgnoreCase, false, drive.DescIgnoreCase)
+	cmd.FilesWithMatches = fs.Bool(drive.CLIOptionFilesWithMatches, false, drive.DescFilesWithMatches)
+	cmd.FullText = fs.String(drive.CLIOptionFullText, "", drive.DescFullText)
+	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "search hidden paths")
+	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, drive.DescVerbose)
+	return fs
+}
+
+func (cmd *grepCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	if len(args) < 1 {
+		exitWithError(fmt.Errorf("grep: expected a pattern"))
+	}
+
+	pattern := args[0]
+	if *cmd.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		exitWithError(fmt.Errorf("grep: %v", err))
+	}
+
+	sources, context, path := preprocessArgs(args[1:])
+
+	exitWithError(drive.New(context, &drive.Options{
+		Path:    path,
+		Sources: sources,
+		Hidden:  *cmd.Hidden,
+		Verbose: *cmd.Verbose,
+	}).Grep(re, strings.TrimSpace(*cmd.FullText), *cmd.FilesWithMatches))
+}
+
 type untrashCmd struct {
-	Hidden  *bool `json:"hidden"`
-	Matches *bool `json:"matches"`
-	Quiet   *bool `json:"quiet"`
-	ById    *bool `json:"by-id"`
+	Hidden  *bool   `json:"hidden"`
+	Matches *bool   `json:"matches"`
+	Quiet   *bool   `json:"quiet"`
+	ById    *bool   `json:"by-id"`
+	To      *string `json:"to"`
 }
 
 func (cmd *untrashCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "allows untrashing hidden paths")
 	cmd.Matches = fs.Bool(drive.MatchesKey, false, "search by prefix and untrash")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "untrash by id instead of path")
+	cmd.To = fs.String(drive.CLIOptionUntrashTo, "", drive.DescUntrashTo)
 
 	return fs
 }
@@ -1545,11 +2850,20 @@ func (cmd *untrashCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 func (cmd *untrashCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	sources, context, path := preprocessArgsByToggle(args, *cmd.ById || *cmd.Matches)
 
+	meta := map[string][]string{}
+	if *cmd.To != "" {
+		to, err := relativePaths(context.AbsPathOf(""), *cmd.To)
+		exitWithError(err)
+		meta[drive.CLIOptionUntrashTo] = to
+	}
+
 	opts := drive.Options{
-		Path:    path,
-		Sources: sources,
-		Quiet:   *cmd.Quiet,
-		Match:   *cmd.Matches,
+		Path:       path,
+		Sources:    sources,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Match:      *cmd.Matches,
+		Meta:       &meta,
 	}
 
 	if !*cmd.Matches {
@@ -1559,6 +2873,51 @@ func (cmd *untrashCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	}
 }
 
+type undoCmd struct {
+	Last     *string `json:"last"`
+	NoPrompt *bool   `json:"no-prompt"`
+	Quiet    *bool   `json:"quiet"`
+	Verbose  *bool   `json:"verbose"`
+}
+
+func (cmd *undoCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Last = fs.String(drive.UndoLastKey, fmt.Sprintf("%d", drive.DefaultUndoCount), drive.DescUndoLast)
+	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before undoing")
+	cmd.Quiet = quietFlag(fs)
+	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, true, drive.DescVerbose)
+	return fs
+}
+
+func (ucmd *undoCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	context, path := discoverContext(args)
+	absEntryPath := context.AbsPathOf(path)
+
+	cmd := new(undoCmd)
+	df := defaultsFiller{
+		command: drive.UndoKey,
+		from:    *ucmd, to: cmd,
+		rcSourcePath: absEntryPath,
+		definedFlags: definedFlags,
+	}
+
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	meta := map[string][]string{
+		drive.UndoLastKey: []string{*cmd.Last},
+	}
+
+	exitWithError(drive.New(context, &drive.Options{
+		Path:       path,
+		Meta:       &meta,
+		NoPrompt:   *cmd.NoPrompt,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Verbose:    *cmd.Verbose,
+	}).Undo())
+}
+
 type publishCmd struct {
 	Hidden *bool `json:"hidden"`
 	Quiet  *bool `json:"quiet"`
@@ -1567,7 +2926,7 @@ type publishCmd struct {
 
 func (cmd *publishCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "allows publishing of hidden paths")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "publish by id instead of path")
 	return fs
 }
@@ -1575,9 +2934,10 @@ func (cmd *publishCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 func (cmd *publishCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	sources, context, path := preprocessArgsByToggle(args, *cmd.ById)
 	exitWithError(drive.New(context, &drive.Options{
-		Path:    path,
-		Sources: sources,
-		Quiet:   *cmd.Quiet,
+		Path:       path,
+		Sources:    sources,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
 	}).Publish(*cmd.ById))
 }
 
@@ -1592,6 +2952,7 @@ type shareCmd struct {
 	Quiet       *bool   `json:"quiet"`
 	Verbose     *bool   `json:"verbose"`
 	WithLink    *bool   `json:"with-link"`
+	DryRun      *bool   `json:"dry-run"`
 }
 
 func (cmd *shareCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -1602,9 +2963,10 @@ func (cmd *shareCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Notify = fs.Bool(drive.CLIOptionNotify, true, "toggle whether to notify receipients about share")
 	cmd.WithLink = fs.Bool(drive.CLIOptionWithLink, false, drive.DescWithLink)
 	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "disables the prompt")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "share by id instead of path")
 	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, true, drive.DescVerbose)
+	cmd.DryRun = fs.Bool(drive.CLIOptionDryRun, false, drive.DescDryRun)
 
 	return fs
 }
@@ -1626,15 +2988,19 @@ func (cmd *shareCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	if *cmd.WithLink {
 		mask |= drive.WithLink
 	}
+	if *cmd.DryRun {
+		mask |= drive.DryRun
+	}
 
 	exitWithError(drive.New(context, &drive.Options{
-		Path:     path,
-		Sources:  sources,
-		Meta:     &meta,
-		TypeMask: mask,
-		NoPrompt: *cmd.NoPrompt,
-		Quiet:    *cmd.Quiet,
-		Verbose:  *cmd.Verbose,
+		Path:       path,
+		Sources:    sources,
+		Meta:       &meta,
+		TypeMask:   mask,
+		NoPrompt:   *cmd.NoPrompt,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Verbose:    *cmd.Verbose,
 	}).Share(*cmd.ById))
 }
 
@@ -1646,16 +3012,18 @@ type unshareCmd struct {
 	Emails      *string `json:"emails"`
 	NoPrompt    *bool   `json:"no-prompt"`
 	Verbose     *bool   `json:"verbose"`
+	DryRun      *bool   `json:"dry-run"`
 }
 
 func (cmd *unshareCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "unshare by id instead of path")
 	cmd.Role = fs.String(drive.RoleKey, "", "role to set to receipients of share. Possible values: "+drive.DescRoles)
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, true, drive.DescVerbose)
 	cmd.Emails = fs.String(drive.EmailsKey, "", "emails to share the file to")
 	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "disables the prompt")
 	cmd.AccountType = fs.String(drive.TypeKey, "", "scope of account to revoke access to")
+	cmd.DryRun = fs.Bool(drive.CLIOptionDryRun, false, drive.DescDryRun)
 
 	return fs
 }
@@ -1669,13 +3037,20 @@ func (cmd *unshareCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		drive.AccountTypeKey: uniqOrderedStr(drive.NonEmptyTrimmedStrings(strings.Split(*cmd.AccountType, ",")...)),
 	}
 
+	mask := drive.NoopOnShare
+	if *cmd.DryRun {
+		mask |= drive.DryRun
+	}
+
 	exitWithError(drive.New(context, &drive.Options{
-		Meta:     &meta,
-		Path:     path,
-		Sources:  sources,
-		NoPrompt: *cmd.NoPrompt,
-		Quiet:    *cmd.Quiet,
-		Verbose:  *cmd.Verbose,
+		Meta:       &meta,
+		Path:       path,
+		Sources:    sources,
+		TypeMask:   mask,
+		NoPrompt:   *cmd.NoPrompt,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+		Verbose:    *cmd.Verbose,
 	}).Unshare(*cmd.ById))
 }
 
@@ -1683,12 +3058,15 @@ type moveCmd struct {
 	Quiet      *bool `json:"quiet"`
 	ById       *bool `json:"by-id"`
 	KeepParent *bool `json:"keep-parent"`
+
+	PreservePermissions *bool `json:"preserve-permissions"`
 }
 
 func (cmd *moveCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "move by id instead of path")
 	cmd.KeepParent = fs.Bool(drive.CLIOptionKeepParent, false, drive.DescKeepParent)
+	cmd.PreservePermissions = fs.Bool(drive.CLIOptionPreservePermissions, false, drive.DescPreservePermissions)
 	return fs
 }
 
@@ -1709,9 +3087,12 @@ func (cmd *moveCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	sources = append(sources, destRels[0])
 
 	exitWithError(drive.New(context, &drive.Options{
-		Path:    path,
-		Sources: sources,
-		Quiet:   *cmd.Quiet,
+		Path:       path,
+		Sources:    sources,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
+
+		PreservePermissions: *cmd.PreservePermissions,
 	}).Move(*cmd.ById, *cmd.KeepParent))
 }
 
@@ -1725,7 +3106,7 @@ type renameCmd struct {
 
 func (cmd *renameCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Force = fs.Bool(drive.ForceKey, false, "coerce rename even if remote already exists")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "unshare by id instead of path")
 	cmd.RenameLocal = fs.Bool(drive.CLIOptionRenameLocal, true, "rename local as well")
 	cmd.RenameRemote = fs.Bool(drive.CLIOptionRenameRemote, true, "rename remote as well")
@@ -1759,6 +3140,7 @@ func (cmd *renameCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Sources:    sources,
 		Force:      *cmd.Force,
 		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
 		RenameMode: renameMode,
 	}).Rename(*cmd.ById))
 }
@@ -1772,7 +3154,7 @@ type starCmd struct {
 func (cmd *starCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "open by id instead of path")
 	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "disables the prompt")
-	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Quiet = quietFlag(fs)
 	return fs
 }
 
@@ -1780,9 +3162,10 @@ func (cmd *starCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	sources, context, path := preprocessArgsByToggle(args, *cmd.ById)
 
 	opts := &drive.Options{
-		Path:    path,
-		Sources: sources,
-		Quiet:   *cmd.Quiet,
+		Path:       path,
+		Sources:    sources,
+		Quiet:      *cmd.Quiet,
+		QuietLevel: quietLevel,
 	}
 
 	exitWithError(drive.New(context, opts).Star(*cmd.ById))
@@ -1846,6 +3229,7 @@ type clashesCmd struct {
 	Depth    *int    `json:"depth"`
 	Hidden   *bool   `json:"hidden"`
 	NoPrompt *bool   `json:"no-prompt"`
+	JSON     *bool   `json:"json"`
 }
 
 func (cmd *clashesCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -1856,10 +3240,61 @@ func (cmd *clashesCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Depth = fs.Int(drive.DepthKey, drive.InfiniteDepth, "maximum recursion depth")
 	cmd.Hidden = fs.Bool(drive.HiddenKey, true, "allows operation on hidden paths")
 	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before fixing clashes")
+	cmd.JSON = fs.Bool(drive.CLIOptionJSON, false, drive.DescClashesJSON)
 
 	return fs
 }
 
+// dryRunOutput is the mask for JSON plans, -json only applying to dry runs.
+func dryRunOutput(command string, dryRun, asJSON bool) int {
+	if !asJSON {
+		return 0
+	}
+	if !dryRun {
+		exitWithError(fmt.Errorf("%s: -%s only applies to -%s", command, drive.CLIOptionJSON, drive.CLIOptionDryRun))
+	}
+	return drive.JSONOutput
+}
+
+func conflictStrategy(spec string) drive.ConflictStrategy {
+	strategy, err := drive.ParseConflictStrategy(spec)
+	if err != nil {
+		exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionConflict, err))
+	}
+	return strategy
+}
+
+func linkMode(spec string) drive.LinkMode {
+	mode, err := drive.ParseLinkMode(spec)
+	if err != nil {
+		exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionLinks, err))
+	}
+	return mode
+}
+
+func duplicateTitlePolicy(spec string) drive.DuplicateTitlePolicy {
+	policy, err := drive.ParseDuplicateTitlePolicy(spec)
+	if err != nil {
+		exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionDuplicateTitles, err))
+	}
+	return policy
+}
+
+func retryPolicy(maxWait, retryOn string) (time.Duration, []drive.RetryCondition) {
+	var wait time.Duration
+	if maxWait = strings.TrimSpace(maxWait); maxWait != "" {
+		var err error
+		if wait, err = time.ParseDuration(maxWait); err != nil || wait <= 0 {
+			exitWithError(fmt.Errorf("-%s: %q is not a positive duration e.g 2m", drive.CLIOptionRetryMaxWait, maxWait))
+		}
+	}
+	conditions, err := drive.ParseRetryConditions(retryOn)
+	if err != nil {
+		exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionRetryOn, err))
+	}
+	return wait, conditions
+}
+
 func translateFixMode(strFixMode string) (drive.FixClashesMode, bool) {
 	switch strings.ToLower(strFixMode) {
 	case "rename":
@@ -1890,6 +3325,15 @@ func (ccmd *clashesCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		exitWithError(fmt.Errorf("Unknown fix mode: %s", *cmd.FixMode))
 	}
 
+	if *cmd.JSON && *cmd.Fix {
+		exitWithError(fmt.Errorf("clashes: -%s only applies to listing clashes", drive.CLIOptionJSON))
+	}
+
+	typeMask := 0
+	if *cmd.JSON {
+		typeMask |= drive.JSONOutput
+	}
+
 	opts := &drive.Options{
 		Path:           path,
 		Sources:        sources,
@@ -1897,6 +3341,7 @@ func (ccmd *clashesCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Hidden:         *cmd.Hidden,
 		NoPrompt:       *cmd.NoPrompt,
 		FixClashesMode: fixMode,
+		TypeMask:       typeMask,
 	}
 
 	driveInstance := drive.New(context, opts)
@@ -2093,3 +3538,29 @@ func preprocessArgsByToggle(args []string, skipArgPreprocess bool) (sources []st
 	sources = uniqOrderedStr(args)
 	return sources, context, path
 }
+
+// preprocessArgsWithIds is preprocessArgsByToggle except that ids and links
+// from the web UI mixed in with paths are passed through as they are.
+func preprocessArgsWithIds(args []string, skipArgPreprocess bool) (sources []string, context *config.Context, path string) {
+	if skipArgPreprocess {
+		return preprocessArgsByToggle(args, true)
+	}
+
+	var paths, ids []string
+	for _, arg := range args {
+		if _, ok := drive.IdFromSource(arg); ok {
+			ids = append(ids, arg)
+		} else {
+			paths = append(paths, arg)
+		}
+	}
+	if len(ids) < 1 {
+		return preprocessArgs(args)
+	}
+	if len(paths) < 1 {
+		return preprocessArgsByToggle(ids, true)
+	}
+
+	sources, context, path = preprocessArgs(paths)
+	return uniqOrderedStr(append(sources, ids...)), context, path
+}
@@ -0,0 +1,345 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	CacheKey = "cache"
+
+	cacheHitsKey   = "hits"
+	cacheMissesKey = "misses"
+	cacheLimitKey  = "limit"
+)
+
+// CacheStatus describes the local metadata cache i.e the indices.
+type CacheStatus struct {
+	Entries int64
+	Bytes   int64
+	DbBytes int64
+	Hits    int64
+	Misses  int64
+	// Limit is the budget in bytes for the indices, 0 meaning unlimited.
+	Limit int64
+}
+
+func getCounter(bucket *bolt.Bucket, key string) int64 {
+	if bucket == nil {
+		return 0
+	}
+	n, _ := strconv.ParseInt(string(bucket.Get(byteify(key))), 10, 64)
+	return n
+}
+
+func putCounter(bucket *bolt.Bucket, key string, n int64) error {
+	return bucket.Put(byteify(key), byteify(strconv.FormatInt(n, 10)))
+}
+
+func (c *Context) cacheCounters() (hits, misses, limit int64, err error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer db.Close()
+
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(CacheKey))
+		hits = getCounter(bucket, cacheHitsKey)
+		misses = getCounter(bucket, cacheMissesKey)
+		limit = getCounter(bucket, cacheLimitKey)
+		return nil
+	})
+	return hits, misses, limit, err
+}
+
+func (c *Context) CacheStatus() (*CacheStatus, error) {
+	status := &CacheStatus{}
+	var err error
+	status.Hits, status.Misses, status.Limit, err = c.cacheCounters()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes, total, err := c.indexSizes()
+	if err != nil {
+		return nil, err
+	}
+	status.Entries, status.Bytes = int64(len(sizes)), total
+
+	if info, sErr := os.Stat(DbSuffixedPath(c.AbsPathOf(""))); sErr == nil {
+		status.DbBytes = info.Size()
+	}
+
+	return status, nil
+}
+
+// RecordCacheLookups adds to the running totals of index hits and misses.
+func (c *Context) RecordCacheLookups(hits, misses int64) error {
+	if hits == 0 && misses == 0 {
+		return nil
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(CacheKey))
+		if err != nil {
+			return err
+		}
+		if err := putCounter(bucket, cacheHitsKey, getCounter(bucket, cacheHitsKey)+hits); err != nil {
+			return err
+		}
+		return putCounter(bucket, cacheMissesKey, getCounter(bucket, cacheMissesKey)+misses)
+	})
+}
+
+func (c *Context) SetCacheLimit(limit int64) error {
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(CacheKey))
+		if err != nil {
+			return err
+		}
+		return putCounter(bucket, cacheLimitKey, limit)
+	})
+}
+
+// ClearCache removes all the indices and folder listings and resets the
+// hit and miss counts but retains the limit. It returns the number of
+// indices removed.
+func (c *Context) ClearCache() (cleared int64, err error) {
+	store, err := c.OpenIndexStore()
+	if err != nil {
+		return 0, err
+	}
+	cleared, err = store.Clear()
+	// Closed right away since the bolt store holds the lock on the db.
+	if cErr := store.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return cleared, err
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		return cleared, err
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(byteify(FolderListingsKey)) != nil {
+			if err := tx.DeleteBucket(byteify(FolderListingsKey)); err != nil {
+				return err
+			}
+		}
+
+		bucket, err := tx.CreateBucketIfNotExists(byteify(CacheKey))
+		if err != nil {
+			return err
+		}
+		if err := bucket.Delete(byteify(cacheHitsKey)); err != nil {
+			return err
+		}
+		return bucket.Delete(byteify(cacheMissesKey))
+	})
+
+	return cleared, err
+}
+
+type indexSize struct {
+	key       string
+	size      int64
+	indexTime int64
+}
+
+type byIndexTime []*indexSize
+
+func (bi byIndexTime) Len() int           { return len(bi) }
+func (bi byIndexTime) Less(i, j int) bool { return bi[i].indexTime < bi[j].indexTime }
+func (bi byIndexTime) Swap(i, j int)      { bi[i], bi[j] = bi[j], bi[i] }
+
+// indexSizes returns the size and index time of every index, along with
+// their total size, whichever backend they are kept in.
+func (c *Context) indexSizes() (sizes []*indexSize, total int64, err error) {
+	store, err := c.OpenIndexStore()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer store.Close()
+
+	err = store.ForEach(func(key string, value []byte) error {
+		index := Index{}
+		// Unreadable indices are evicted first.
+		_ = json.Unmarshal(value, &index)
+
+		size := int64(len(key) + len(value))
+		total += size
+		sizes = append(sizes, &indexSize{key: key, size: size, indexTime: index.IndexTime})
+		return nil
+	})
+	if err == ErrNoSuchDbBucket {
+		err = nil
+	}
+	return sizes, total, err
+}
+
+// EnforceCacheLimit evicts the least recently indexed entries until the
+// indices fit within the set limit. It returns the number of evictions.
+func (c *Context) EnforceCacheLimit() (evicted int64, err error) {
+	_, _, limit, err := c.cacheCounters()
+	if err != nil || limit < 1 {
+		return 0, err
+	}
+
+	sizes, total, err := c.indexSizes()
+	if err != nil || total <= limit {
+		return 0, err
+	}
+
+	sort.Sort(byIndexTime(sizes))
+
+	store, err := c.OpenIndexStore()
+	if err != nil {
+		return 0, err
+	}
+	defer store.Close()
+
+	for _, is := range sizes {
+		if total <= limit {
+			break
+		}
+		if err := store.Delete(is.key); err != nil {
+			return evicted, err
+		}
+		total -= is.size
+		evicted += 1
+	}
+
+	return evicted, nil
+}
+
+// IndexEntries returns the indices keyed by their db keys, along
+// with the keys of any whose values could not be read.
+func (c *Context) IndexEntries() (entries map[string]*Index, unreadable []string, err error) {
+	store, err := c.OpenIndexStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer store.Close()
+
+	entries = map[string]*Index{}
+	err = store.ForEach(func(key string, value []byte) error {
+		index := &Index{}
+		if err := json.Unmarshal(value, index); err != nil {
+			unreadable = append(unreadable, key)
+			return nil
+		}
+		entries[key] = index
+		return nil
+	})
+	if err == ErrNoSuchDbBucket {
+		err = nil
+	}
+
+	return entries, unreadable, err
+}
+
+const (
+	FolderListingsKey = "folder-listings"
+)
+
+// FolderListing is what a remote folder held when it was last listed,
+// so that paths within it can be completed without a round trip.
+type FolderListing struct {
+	Path    string          `json:"path"`
+	Entries []*ListingEntry `json:"entries"`
+	Time    time.Time       `json:"time"`
+}
+
+type ListingEntry struct {
+	Name  string `json:"name"`
+	IsDir bool   `json:"dir,omitempty"`
+}
+
+func (c *Context) SaveFolderListing(fl *FolderListing) error {
+	if fl.Time.IsZero() {
+		fl.Time = time.Now()
+	}
+	data, err := json.Marshal(fl)
+	if err != nil {
+		return err
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(FolderListingsKey))
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+		return bucket.Put(byteify(fl.Path), data)
+	})
+}
+
+// FolderListing returns the last listing of the remote folder at p,
+// or nil if it was never listed.
+func (c *Context) FolderListing(p string) (*FolderListing, error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var fl *FolderListing
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(FolderListingsKey))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get(byteify(p))
+		if len(data) < 1 {
+			return nil
+		}
+		fl = &FolderListing{}
+		return json.Unmarshal(data, fl)
+	})
+
+	return fl, err
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func testCacheBackend(t *testing.T, backend string) {
+	dir, err := ioutil.TempDir("", "drive-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, _, c, err := Initialize(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetIndexBackend(backend); err != nil {
+		t.Fatal(err)
+	}
+
+	indices := []*Index{
+		{FileId: "a", Md5Checksum: "x", IndexTime: 3},
+		{FileId: "b", Md5Checksum: "y", IndexTime: 1},
+		{FileId: "c", Md5Checksum: "z", IndexTime: 2},
+	}
+	for _, index := range indices {
+		if err := c.SerializeIndex(index); err != nil {
+			t.Fatalf("%s: serialize %s: %v", backend, index.FileId, err)
+		}
+	}
+
+	entries, unreadable, err := c.IndexEntries()
+	if err != nil || len(entries) != 3 || len(unreadable) != 0 {
+		t.Fatalf("%s: expected 3 readable entries, got %d, unreadable %v, %v", backend, len(entries), unreadable, err)
+	}
+	if entries["b"] == nil || entries["b"].Md5Checksum != "y" {
+		t.Errorf("%s: expected b to be read back, got %+v", backend, entries["b"])
+	}
+
+	status, err := c.CacheStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Entries != 3 || status.Bytes < 1 {
+		t.Errorf("%s: expected 3 entries with a size, got %d of %d bytes", backend, status.Entries, status.Bytes)
+	}
+
+	// Room for two of them, leaving out the least recently indexed.
+	if err := c.SetCacheLimit(status.Bytes * 2 / 3); err != nil {
+		t.Fatal(err)
+	}
+	evicted, err := c.EnforceCacheLimit()
+	if err != nil || evicted != 1 {
+		t.Errorf("%s: expected 1 eviction, got %d %v", backend, evicted, err)
+	}
+	if _, err := c.DeserializeIndex("b"); err != ErrNoSuchDbKey {
+		t.Errorf("%s: expected b, the least recently indexed, to be evicted, got %v", backend, err)
+	}
+	if _, err := c.DeserializeIndex("a"); err != nil {
+		t.Errorf("%s: expected a to be kept, got %v", backend, err)
+	}
+
+	cleared, err := c.ClearCache()
+	if err != nil || cleared != 2 {
+		t.Errorf("%s: expected 2 indices cleared, got %d %v", backend, cleared, err)
+	}
+	if status, err = c.CacheStatus(); err != nil || status.Entries != 0 {
+		t.Errorf("%s: expected no entries once cleared, got %+v %v", backend, status, err)
+	}
+}
+
+func TestCacheBackends(t *testing.T) {
+	for _, backend := range IndexBackends() {
+		testCacheBackend(t, backend)
+	}
+}
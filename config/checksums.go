@@ -0,0 +1,99 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	LocalChecksumsKey = "local-checksums"
+)
+
+// LocalChecksum is the md5 of a local file as of when it had Size and
+// ModTime, for it not to be checksummed again while they stay the same.
+type LocalChecksum struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Md5     string `json:"md5"`
+	// FileId is the id of the remote file last pushed from or pulled
+	// into the local one, if known.
+	FileId string `json:"id,omitempty"`
+}
+
+// LocalChecksums returns the recorded checksums keyed by path.
+func (c *Context) LocalChecksums() (map[string]*LocalChecksum, error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	sums := make(map[string]*LocalChecksum)
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(LocalChecksumsKey))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(key, value []byte) error {
+			sum := &LocalChecksum{}
+			if err := json.Unmarshal(value, sum); err != nil {
+				// Just checksummed again.
+				return nil
+			}
+			sums[sum.Path] = sum
+			return nil
+		})
+	})
+
+	return sums, err
+}
+
+// SaveLocalChecksums records sums in a single transaction.
+func (c *Context) SaveLocalChecksums(sums []*LocalChecksum) error {
+	if len(sums) < 1 {
+		return nil
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(LocalChecksumsKey))
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+		for _, sum := range sums {
+			data, err := json.Marshal(sum)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(byteify(sum.Path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"golang.org/x/oauth2/jwt"
 
@@ -42,8 +43,11 @@ var (
 )
 
 const (
-	IndicesKey = "indices"
-	DriveDb    = "drivedb"
+	IndicesKey    = "indices"
+	DriveDb       = "drivedb"
+	DriveSQLiteDb = "drivedb.sqlite"
+
+	ConflictsReport = "conflicts.json"
 )
 
 const (
@@ -57,6 +61,17 @@ type Context struct {
 	ClientSecret string `json:"client_secret"`
 	RefreshToken string `json:"refresh_token"`
 	AbsPath      string `json:"-"`
+
+	// IndexBackend is the IndexStore that indices are kept in, bolt if empty.
+	IndexBackend string `json:"index_backend,omitempty"`
+
+	// ApplicationName if set prefixes the User-Agent of API requests and
+	// QuotaUser attributes them, so that admins can tell tools apart.
+	ApplicationName string `json:"application_name,omitempty"`
+	QuotaUser       string `json:"quota_user,omitempty"`
+
+	memIndexOnce sync.Once
+	memIndex     *memoryIndexStore
 }
 
 type Index struct {
@@ -67,6 +82,18 @@ type Index struct {
 	ModTime     int64  `json:"mtime"`
 	Version     int64  `json:"version"`
 	IndexTime   int64  `json:"itime"`
+
+	// Metadata if set is what the file's metadata was when it was last synced.
+	Metadata *Metadata `json:"meta,omitempty"`
+}
+
+type Metadata struct {
+	Starred     bool              `json:"starred,omitempty"`
+	Trashed     bool              `json:"trashed,omitempty"`
+	Description string            `json:"desc,omitempty"`
+	Properties  map[string]string `json:"props,omitempty"`
+	// Permissions is a fingerprint of the permissions, empty if they were not known.
+	Permissions string `json:"perms,omitempty"`
 }
 
 type MountPoint struct {
@@ -116,33 +143,13 @@ func (c *Context) Read() error {
 }
 
 func (c *Context) DeserializeIndex(key string) (*Index, error) {
-	if creationErr := c.CreateIndicesBucket(); creationErr != nil {
-		return nil, creationErr
-	}
-
-	db, err := c.OpenDB()
+	store, err := c.OpenIndexStore()
 	if err != nil {
 		return nil, err
 	}
+	defer store.Close()
 
-	defer db.Close()
-
-	var data []byte
-
-	err = db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(byteify(IndicesKey))
-		if bucket == nil {
-			return ErrNoSuchDbBucket
-		}
-
-		retr := bucket.Get(byteify(key))
-		if len(retr) < 1 {
-			return ErrNoSuchDbKey
-		}
-		data = retr
-		return nil
-	})
-
+	data, err := store.Get(key)
 	if err != nil {
 		return nil, err
 	}
@@ -153,12 +160,34 @@ func (c *Context) DeserializeIndex(key string) (*Index, error) {
 }
 
 func (c *Context) ListKeys(dir, bucketName string) (chan string, error) {
+	if bucketName != IndicesKey {
+		return c.listBoltKeys(bucketName)
+	}
+
 	keysChan := make(chan string)
-	if err := c.CreateIndicesBucket(); err != nil {
+	store, err := c.OpenIndexStore()
+	if err != nil {
 		close(keysChan)
 		return keysChan, err
 	}
 
+	go func() {
+		defer func() {
+			store.Close()
+			close(keysChan)
+		}()
+
+		store.ForEachKey(func(key string) error {
+			keysChan <- key
+			return nil
+		})
+	}()
+
+	return keysChan, nil
+}
+
+func (c *Context) listBoltKeys(bucketName string) (chan string, error) {
+	keysChan := make(chan string)
 	db, err := c.OpenDB()
 	if err != nil {
 		close(keysChan)
@@ -191,27 +220,13 @@ func (c *Context) ListKeys(dir, bucketName string) (chan string, error) {
 }
 
 func (c *Context) PopIndicesKey(key string) error {
-	return c.popDbKey(IndicesKey, key)
-}
-
-func (c *Context) popDbKey(bucketName, key string) error {
-	db, err := c.OpenDB()
+	store, err := c.OpenIndexStore()
 	if err != nil {
 		return err
 	}
-	defer db.Close()
-
-	return db.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists(byteify(IndicesKey))
-		if err != nil {
-			return err
-		}
-		if bucket == nil {
-			return ErrNoSuchDbBucket
-		}
+	defer store.Close()
 
-		return bucket.Delete(byteify(key))
-	})
+	return store.Delete(key)
 }
 
 func (c *Context) RemoveIndex(index *Index, p string) error {
@@ -222,41 +237,15 @@ func (c *Context) RemoveIndex(index *Index, p string) error {
 		return ErrEmptyFileIdForIndex
 	}
 
-	db, err := c.OpenDB()
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	return db.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists(byteify(IndicesKey))
-		if err != nil {
-			return err
-		}
-		if bucket == nil {
-			return ErrNoSuchDbBucket
-		}
-		return bucket.Delete(byteify(index.FileId))
-	})
+	return c.PopIndicesKey(index.FileId)
 }
 
 func (c *Context) CreateIndicesBucket() error {
-	db, err := c.OpenDB()
+	store, err := c.OpenIndexStore()
 	if err != nil {
 		return err
 	}
-	defer db.Close()
-
-	return db.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists(byteify(IndicesKey))
-		if err != nil {
-			return err
-		}
-		if bucket == nil {
-			return ErrNoSuchDbBucket
-		}
-		return nil
-	})
+	return store.Close()
 }
 
 func (c *Context) SerializeIndex(index *Index) error {
@@ -265,22 +254,13 @@ func (c *Context) SerializeIndex(index *Index) error {
 		return err
 	}
 
-	db, err := c.OpenDB()
+	store, err := c.OpenIndexStore()
 	if err != nil {
 		return err
 	}
-	defer db.Close()
+	defer store.Close()
 
-	return db.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists(byteify(IndicesKey))
-		if err != nil {
-			return err
-		}
-		if bucket == nil {
-			return ErrNoSuchDbBucket
-		}
-		return bucket.Put(byteify(index.FileId), data)
-	})
+	return store.Put(index.FileId, data)
 }
 
 func (c *Context) Write() error {
@@ -297,6 +277,9 @@ func (c *Context) DeInitialize(prompter func(...interface{}) bool, returnOnAnyEr
 		credentialsPath(rootDir),
 		DbSuffixedPath(rootDir),
 	}
+	if c.IndexBackend == SQLiteIndexBackend {
+		pathsToRemove = append(pathsToRemove, SQLiteSuffixedPath(rootDir))
+	}
 
 	for _, p := range pathsToRemove {
 		if !prompter("remove: ", p, ". This operation is permanent (Y/N) ") {
@@ -376,6 +359,12 @@ func Initialize(absPath string) (pathGD string, firstInit bool, c *Context, err
 		return
 	}
 	c = &Context{AbsPath: absPath}
+	// Re-initializing keeps the indices where they were, and the identity
+	// that API requests are made under.
+	if prev := (&Context{AbsPath: absPath}); prev.Read() == nil {
+		c.IndexBackend = prev.IndexBackend
+		c.ApplicationName, c.QuotaUser = prev.ApplicationName, prev.QuotaUser
+	}
 	err = c.Write()
 	return
 }
@@ -392,6 +381,14 @@ func DbSuffixedPath(dir string) string {
 	return path.Join(gdPath(dir), DriveDb)
 }
 
+func SQLiteSuffixedPath(dir string) string {
+	return path.Join(gdPath(dir), DriveSQLiteDb)
+}
+
+func ConflictsSuffixedPath(dir string) string {
+	return path.Join(gdPath(dir), ConflictsReport)
+}
+
 func LeastNonExistantRoot(contextAbsPath string) string {
 	last := ""
 	p := contextAbsPath
@@ -0,0 +1,149 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	HistoryKey = "history"
+
+	// MaxHistoryEntries is how many of the most recent operations are kept.
+	MaxHistoryEntries = 1000
+)
+
+// HistoryEntry describes a mutating remote operation with
+// enough information to be able to reverse it.
+type HistoryEntry struct {
+	Key    string    `json:"-"`
+	Time   time.Time `json:"time"`
+	Op     string    `json:"op"`
+	FileId string    `json:"id"`
+	Path   string    `json:"path"`
+
+	OldName     string `json:"old_name,omitempty"`
+	OldParentId string `json:"old_parent,omitempty"`
+	NewParentId string `json:"new_parent,omitempty"`
+
+	// OldRevisionId is the head revision of a file before a push modified it.
+	OldRevisionId string `json:"old_rev,omitempty"`
+
+	PermissionId string `json:"perm_id,omitempty"`
+	Role         string `json:"role,omitempty"`
+	AccountType  string `json:"account_type,omitempty"`
+	Value        string `json:"value,omitempty"`
+	WithLink     bool   `json:"with_link,omitempty"`
+
+	Undone bool `json:"undone,omitempty"`
+}
+
+func (c *Context) putHistoryEntry(entry *HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(HistoryKey))
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+		if err := bucket.Put(byteify(entry.Key), data); err != nil {
+			return err
+		}
+		return trimHistory(bucket, MaxHistoryEntries)
+	})
+}
+
+// trimHistory removes the oldest entries beyond the max most recent ones,
+// the keys sorting by time.
+func trimHistory(bucket *bolt.Bucket, max int) error {
+	count := 0
+	cur := bucket.Cursor()
+	for key, _ := cur.First(); key != nil; key, _ = cur.Next() {
+		count += 1
+	}
+
+	for key, _ := cur.First(); key != nil && count > max; key, _ = cur.First() {
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+		count -= 1
+	}
+	return nil
+}
+
+func (c *Context) AppendHistory(entry *HistoryEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	// The fileId suffix keeps keys unique for concurrent operations.
+	entry.Key = fmt.Sprintf("%s|%s|%s", entry.Time.UTC().Format(time.RFC3339Nano), entry.Op, entry.FileId)
+	return c.putHistoryEntry(entry)
+}
+
+func (c *Context) MarkHistoryUndone(entry *HistoryEntry) error {
+	entry.Undone = true
+	return c.putHistoryEntry(entry)
+}
+
+// LastHistory returns at most n of the most recent entries that
+// haven't yet been undone, most recent first.
+func (c *Context) LastHistory(n int) ([]*HistoryEntry, error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var entries []*HistoryEntry
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(HistoryKey))
+		if bucket == nil {
+			return nil
+		}
+
+		cur := bucket.Cursor()
+		for key, value := cur.Last(); key != nil && len(entries) < n; key, value = cur.Prev() {
+			entry := &HistoryEntry{}
+			if err := json.Unmarshal(value, entry); err != nil {
+				return err
+			}
+			if entry.Undone {
+				continue
+			}
+			entry.Key = string(key)
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+
+	return entries, err
+}
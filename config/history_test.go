@@ -0,0 +1,71 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+func TestTrimHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, _, c, err := Initialize(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		entry := &HistoryEntry{Time: start.Add(time.Duration(i) * time.Minute), Op: "push-mod", FileId: fmt.Sprintf("f%d", i), OldRevisionId: "r"}
+		if err := c.AppendHistory(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return trimHistory(tx.Bucket(byteify(HistoryKey)), 3)
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := c.LastHistory(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected the 3 most recent entries to be kept, got %d", len(entries))
+	}
+	for i, want := range []string{"f4", "f3", "f2"} {
+		if entries[i].FileId != want || entries[i].OldRevisionId != "r" {
+			t.Errorf("#%d: expected %s with its old revision, got %+v", i, want, entries[i])
+		}
+	}
+}
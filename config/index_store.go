@@ -0,0 +1,285 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	BoltIndexBackend   = "bolt"
+	SQLiteIndexBackend = "sqlite"
+	MemoryIndexBackend = "memory"
+
+	DefaultIndexBackend = BoltIndexBackend
+)
+
+// IndexStore is where the indices of a context are kept, keyed by file id.
+type IndexStore interface {
+	// Get returns ErrNoSuchDbKey if there is no value for key.
+	Get(key string) ([]byte, error)
+	Put(key string, value []byte) error
+	Delete(key string) error
+	// ForEachKey stops at the first error returned by fn.
+	ForEachKey(fn func(key string) error) error
+	// ForEach is ForEachKey along with the values, that are only valid
+	// until fn returns.
+	ForEach(fn func(key string, value []byte) error) error
+	// Clear removes all the indices, returning how many it removed.
+	Clear() (int64, error)
+	Close() error
+}
+
+type indexStoreOpener func(c *Context) (IndexStore, error)
+
+var indexBackends = map[string]indexStoreOpener{
+	BoltIndexBackend:   openBoltIndexStore,
+	MemoryIndexBackend: openMemoryIndexStore,
+}
+
+func IndexBackends() []string {
+	var names []string
+	for name := range indexBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetIndexBackend selects the backend that the context's indices are kept in,
+// the default being used if name is empty.
+func (c *Context) SetIndexBackend(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == DefaultIndexBackend {
+		c.IndexBackend = ""
+		return nil
+	}
+	if _, ok := indexBackends[name]; !ok {
+		return fmt.Errorf("unknown index backend %q, expecting one of %s", name, strings.Join(IndexBackends(), ", "))
+	}
+	c.IndexBackend = name
+	return nil
+}
+
+func (c *Context) OpenIndexStore() (IndexStore, error) {
+	name := c.IndexBackend
+	if name == "" {
+		name = DefaultIndexBackend
+	}
+	opener, ok := indexBackends[name]
+	if !ok {
+		if name == SQLiteIndexBackend {
+			return nil, fmt.Errorf("index backend %q needs a drive built with cgo", name)
+		}
+		return nil, fmt.Errorf("unknown index backend %q", name)
+	}
+	return opener(c)
+}
+
+type boltIndexStore struct {
+	db *bolt.DB
+}
+
+func openBoltIndexStore(c *Context) (IndexStore, error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(byteify(IndicesKey))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltIndexStore{db: db}, nil
+}
+
+func (bs *boltIndexStore) Get(key string) ([]byte, error) {
+	var data []byte
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(IndicesKey))
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+
+		retr := bucket.Get(byteify(key))
+		if len(retr) < 1 {
+			return ErrNoSuchDbKey
+		}
+		// retr is only valid for the life of the transaction.
+		data = append([]byte(nil), retr...)
+		return nil
+	})
+	return data, err
+}
+
+func (bs *boltIndexStore) Put(key string, value []byte) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(IndicesKey))
+		if err != nil {
+			return err
+		}
+		return bucket.Put(byteify(key), value)
+	})
+}
+
+func (bs *boltIndexStore) Delete(key string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(IndicesKey))
+		if err != nil {
+			return err
+		}
+		return bucket.Delete(byteify(key))
+	})
+}
+
+func (bs *boltIndexStore) ForEachKey(fn func(key string) error) error {
+	return bs.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(IndicesKey))
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+
+		cur := bucket.Cursor()
+		for key, _ := cur.First(); key != nil; key, _ = cur.Next() {
+			if err := fn(string(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *boltIndexStore) ForEach(fn func(key string, value []byte) error) error {
+	return bs.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(IndicesKey))
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+		return bucket.ForEach(func(key, value []byte) error {
+			return fn(string(key), value)
+		})
+	})
+}
+
+func (bs *boltIndexStore) Clear() (cleared int64, err error) {
+	err = bs.db.Update(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(byteify(IndicesKey)); bucket != nil {
+			cleared = int64(bucket.Stats().KeyN)
+			if err := tx.DeleteBucket(byteify(IndicesKey)); err != nil {
+				return err
+			}
+		}
+		_, err := tx.CreateBucketIfNotExists(byteify(IndicesKey))
+		return err
+	})
+	return cleared, err
+}
+
+func (bs *boltIndexStore) Close() error {
+	return bs.db.Close()
+}
+
+// memoryIndexStore keeps indices for as long as its context lives,
+// it is meant for tests rather than for real drive contexts.
+type memoryIndexStore struct {
+	sync.RWMutex
+	indices map[string][]byte
+}
+
+func openMemoryIndexStore(c *Context) (IndexStore, error) {
+	c.memIndexOnce.Do(func() {
+		c.memIndex = &memoryIndexStore{indices: make(map[string][]byte)}
+	})
+	return c.memIndex, nil
+}
+
+func (ms *memoryIndexStore) Get(key string) ([]byte, error) {
+	ms.RLock()
+	defer ms.RUnlock()
+
+	data, ok := ms.indices[key]
+	if !ok {
+		return nil, ErrNoSuchDbKey
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (ms *memoryIndexStore) Put(key string, value []byte) error {
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.indices[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (ms *memoryIndexStore) Delete(key string) error {
+	ms.Lock()
+	defer ms.Unlock()
+
+	delete(ms.indices, key)
+	return nil
+}
+
+func (ms *memoryIndexStore) ForEachKey(fn func(key string) error) error {
+	ms.RLock()
+	var keys []string
+	for key := range ms.indices {
+		keys = append(keys, key)
+	}
+	ms.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *memoryIndexStore) ForEach(fn func(key string, value []byte) error) error {
+	return ms.ForEachKey(func(key string) error {
+		value, err := ms.Get(key)
+		if err == ErrNoSuchDbKey {
+			// Deleted since the keys were listed.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return fn(key, value)
+	})
+}
+
+func (ms *memoryIndexStore) Clear() (int64, error) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	cleared := int64(len(ms.indices))
+	ms.indices = make(map[string][]byte)
+	return cleared, nil
+}
+
+func (ms *memoryIndexStore) Close() error {
+	return nil
+}
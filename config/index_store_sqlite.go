@@ -0,0 +1,117 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+// +build cgo
+
+package config
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	indexBackends[SQLiteIndexBackend] = openSQLiteIndexStore
+}
+
+// sqliteIndexStore trades bolt's single file lock, that only lets one
+// drive process at a time into the context, for SQLite's journaling.
+type sqliteIndexStore struct {
+	db *sql.DB
+}
+
+func openSQLiteIndexStore(c *Context) (IndexStore, error) {
+	db, err := sql.Open("sqlite3", SQLiteSuffixedPath(c.AbsPathOf(""))+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS ` + IndicesKey + ` (id TEXT PRIMARY KEY, data BLOB NOT NULL)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteIndexStore{db: db}, nil
+}
+
+func (ss *sqliteIndexStore) Get(key string) ([]byte, error) {
+	var data []byte
+	err := ss.db.QueryRow(`SELECT data FROM `+IndicesKey+` WHERE id = ?`, key).Scan(&data)
+	if err == sql.ErrNoRows || (err == nil && len(data) < 1) {
+		return nil, ErrNoSuchDbKey
+	}
+	return data, err
+}
+
+func (ss *sqliteIndexStore) Put(key string, value []byte) error {
+	_, err := ss.db.Exec(`INSERT OR REPLACE INTO `+IndicesKey+` (id, data) VALUES (?, ?)`, key, value)
+	return err
+}
+
+func (ss *sqliteIndexStore) Delete(key string) error {
+	_, err := ss.db.Exec(`DELETE FROM `+IndicesKey+` WHERE id = ?`, key)
+	return err
+}
+
+func (ss *sqliteIndexStore) ForEachKey(fn func(key string) error) error {
+	rows, err := ss.db.Query(`SELECT id FROM ` + IndicesKey + ` ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return err
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (ss *sqliteIndexStore) ForEach(fn func(key string, value []byte) error) error {
+	rows, err := ss.db.Query(`SELECT id, data FROM ` + IndicesKey + ` ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			return err
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (ss *sqliteIndexStore) Clear() (int64, error) {
+	res, err := ss.db.Exec(`DELETE FROM ` + IndicesKey)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (ss *sqliteIndexStore) Close() error {
+	return ss.db.Close()
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func testIndexBackend(t *testing.T, backend string) {
+	dir, err := ioutil.TempDir("", "drive-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, _, c, err := Initialize(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetIndexBackend(backend); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.DeserializeIndex("a"); err != ErrNoSuchDbKey {
+		t.Errorf("%s: expected ErrNoSuchDbKey, got %v", backend, err)
+	}
+
+	indices := []*Index{{FileId: "b", Md5Checksum: "x"}, {FileId: "a", Version: 2}}
+	for _, index := range indices {
+		if err := c.SerializeIndex(index); err != nil {
+			t.Fatalf("%s: serialize %s: %v", backend, index.FileId, err)
+		}
+	}
+
+	got, err := c.DeserializeIndex("b")
+	if err != nil || !reflect.DeepEqual(got, indices[0]) {
+		t.Errorf("%s: expected %+v, got %+v %v", backend, indices[0], got, err)
+	}
+
+	keysChan, err := c.ListKeys("", IndicesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for key := range keysChan {
+		keys = append(keys, key)
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("%s: expected keys [a b], got %v", backend, keys)
+	}
+
+	if err := c.RemoveIndex(indices[1], ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.DeserializeIndex("a"); err != ErrNoSuchDbKey {
+		t.Errorf("%s: expected a to be removed, got %v", backend, err)
+	}
+}
+
+func TestIndexBackends(t *testing.T) {
+	for _, backend := range IndexBackends() {
+		testIndexBackend(t, backend)
+	}
+}
+
+func TestSetIndexBackend(t *testing.T) {
+	c := &Context{}
+	if err := c.SetIndexBackend("Memory"); err != nil || c.IndexBackend != MemoryIndexBackend {
+		t.Errorf("expected the memory backend, got %q %v", c.IndexBackend, err)
+	}
+	if err := c.SetIndexBackend(BoltIndexBackend); err != nil || c.IndexBackend != "" {
+		t.Errorf("expected the default backend to be left unset, got %q %v", c.IndexBackend, err)
+	}
+	if err := c.SetIndexBackend("leveldb"); err == nil {
+		t.Errorf("expected an unknown backend to be rejected")
+	}
+}
@@ -0,0 +1,413 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	UploadJournalKey = "upload-journal"
+)
+
+// UploadIntent is journaled before a file is created remotely and cleared
+// once it has been, so that a push interrupted in between can tell
+// whether the file was created after all instead of creating it again.
+type UploadIntent struct {
+	Path     string    `json:"path"`
+	ParentId string    `json:"parent"`
+	Time     time.Time `json:"time"`
+}
+
+func (c *Context) JournalUploadIntent(intent *UploadIntent) error {
+	if intent.Time.IsZero() {
+		intent.Time = time.Now()
+	}
+	data, err := json.Marshal(intent)
+	if err != nil {
+		return err
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(UploadJournalKey))
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+		return bucket.Put(byteify(intent.Path), data)
+	})
+}
+
+// UploadIntent returns the intent journaled for p, or nil if there is none.
+func (c *Context) UploadIntent(p string) (*UploadIntent, error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var intent *UploadIntent
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(UploadJournalKey))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get(byteify(p))
+		if len(data) < 1 {
+			return nil
+		}
+		intent = &UploadIntent{}
+		return json.Unmarshal(data, intent)
+	})
+
+	return intent, err
+}
+
+func (c *Context) ClearUploadIntent(p string) error {
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(UploadJournalKey))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete(byteify(p))
+	})
+}
+
+const (
+	UploadSessionsKey = "upload-sessions"
+)
+
+// UploadSession is the resumable upload session that a local file is being
+// uploaded through, kept so that an interrupted upload can carry on from
+// the last chunk that Drive confirmed instead of starting over.
+type UploadSession struct {
+	Path     string    `json:"path"`
+	URI      string    `json:"uri"`
+	FileId   string    `json:"id,omitempty"`
+	ParentId string    `json:"parent"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mtime"`
+	Time     time.Time `json:"time"`
+}
+
+func (c *Context) JournalUploadSession(session *UploadSession) error {
+	if session.Time.IsZero() {
+		session.Time = time.Now()
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(UploadSessionsKey))
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+		return bucket.Put(byteify(session.Path), data)
+	})
+}
+
+// UploadSession returns the session journaled for p, or nil if there is none.
+func (c *Context) UploadSession(p string) (*UploadSession, error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var session *UploadSession
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(UploadSessionsKey))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get(byteify(p))
+		if len(data) < 1 {
+			return nil
+		}
+		session = &UploadSession{}
+		return json.Unmarshal(data, session)
+	})
+
+	return session, err
+}
+
+func (c *Context) ClearUploadSession(p string) error {
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(UploadSessionsKey))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete(byteify(p))
+	})
+}
+
+const (
+	ChangesCheckpointsKey = "changes-checkpoints"
+)
+
+// ChangesCheckpoint is where the Changes API left off the last time that
+// a path was pulled, so that the next incremental pull only has to look
+// at what changed since.
+type ChangesCheckpoint struct {
+	Path      string    `json:"path"`
+	PageToken string    `json:"page_token"`
+	Time      time.Time `json:"time"`
+}
+
+func (c *Context) SaveChangesCheckpoint(cp *ChangesCheckpoint) error {
+	if cp.Time.IsZero() {
+		cp.Time = time.Now()
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(ChangesCheckpointsKey))
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+		return bucket.Put(byteify(cp.Path), data)
+	})
+}
+
+// ChangesCheckpoint returns the checkpoint saved for p, or nil if there is none.
+func (c *Context) ChangesCheckpoint(p string) (*ChangesCheckpoint, error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var cp *ChangesCheckpoint
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(ChangesCheckpointsKey))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get(byteify(p))
+		if len(data) < 1 {
+			return nil
+		}
+		cp = &ChangesCheckpoint{}
+		return json.Unmarshal(data, cp)
+	})
+
+	return cp, err
+}
+
+const (
+	ExportRevisionsKey = "export-revisions"
+)
+
+// ExportRevision is the revision of a Google Doc that was last exported,
+// so that it is only exported again once it has changed.
+type ExportRevision struct {
+	Id           string    `json:"id"`
+	Revision     string    `json:"revision"`
+	ModifiedTime time.Time `json:"modified_time"`
+	Time         time.Time `json:"time"`
+}
+
+func (c *Context) SaveExportRevision(er *ExportRevision) error {
+	if er.Time.IsZero() {
+		er.Time = time.Now()
+	}
+	data, err := json.Marshal(er)
+	if err != nil {
+		return err
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(ExportRevisionsKey))
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+		return bucket.Put(byteify(er.Id), data)
+	})
+}
+
+// ExportRevision returns the revision last exported of the file with id,
+// or nil if it was never exported.
+func (c *Context) ExportRevision(id string) (*ExportRevision, error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var er *ExportRevision
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(ExportRevisionsKey))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get(byteify(id))
+		if len(data) < 1 {
+			return nil
+		}
+		er = &ExportRevision{}
+		return json.Unmarshal(data, er)
+	})
+
+	return er, err
+}
+
+const (
+	LocalDuplicatesKey = "local-duplicates"
+)
+
+// LocalDuplicate records that the local file at Path has the same content
+// as Canonical, so that a push can point a shortcut at the copy of
+// Canonical instead of uploading it again.
+type LocalDuplicate struct {
+	Path        string `json:"path"`
+	Canonical   string `json:"canonical"`
+	Md5Checksum string `json:"md5"`
+	Size        int64  `json:"size"`
+}
+
+// ReplaceLocalDuplicates drops the duplicates recorded at or below any of
+// paths, then records dups.
+func (c *Context) ReplaceLocalDuplicates(paths []string, dups []*LocalDuplicate) error {
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(LocalDuplicatesKey))
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+
+		var stale [][]byte
+		err = bucket.ForEach(func(k, _ []byte) error {
+			key := string(k)
+			for _, p := range paths {
+				p = strings.TrimSuffix(p, "/")
+				if p == "" || key == p || strings.HasPrefix(key, p+"/") {
+					stale = append(stale, k)
+					break
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		for _, dup := range dups {
+			data, err := json.Marshal(dup)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(byteify(dup.Path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LocalDuplicates returns all the recorded duplicates by their paths.
+func (c *Context) LocalDuplicates() (map[string]*LocalDuplicate, error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	dups := map[string]*LocalDuplicate{}
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(LocalDuplicatesKey))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			dup := &LocalDuplicate{}
+			if err := json.Unmarshal(v, dup); err != nil {
+				return err
+			}
+			dups[dup.Path] = dup
+			return nil
+		})
+	})
+
+	return dups, err
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	StatsKey = "stats"
+)
+
+// RunStat records the outcome of a single push or pull run.
+type RunStat struct {
+	Command   string    `json:"cmd"`
+	StartTime time.Time `json:"start"`
+	Duration  int64     `json:"duration"`
+	Bytes     int64     `json:"bytes"`
+	Files     int64     `json:"files"`
+	Failures  int64     `json:"failures"`
+}
+
+// Keys are RFC3339 timestamps so that bolt's byte-sorted
+// cursor iterates the runs in chronological order.
+func runStatKey(t time.Time) []byte {
+	return byteify(t.UTC().Format(time.RFC3339Nano))
+}
+
+func (c *Context) RecordRunStat(stat *RunStat) error {
+	data, err := json.Marshal(stat)
+	if err != nil {
+		return err
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(StatsKey))
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+		return bucket.Put(runStatKey(stat.StartTime), data)
+	})
+}
+
+// RunStatsSince returns the recorded runs that started at or after since.
+func (c *Context) RunStatsSince(since time.Time) ([]*RunStat, error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var stats []*RunStat
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(StatsKey))
+		if bucket == nil {
+			return nil
+		}
+
+		cur := bucket.Cursor()
+		for key, value := cur.Seek(runStatKey(since)); key != nil; key, value = cur.Next() {
+			stat := &RunStat{}
+			if err := json.Unmarshal(value, stat); err != nil {
+				return err
+			}
+			stats = append(stats, stat)
+		}
+		return nil
+	})
+
+	return stats, err
+}
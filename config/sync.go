@@ -0,0 +1,121 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	SyncStatesKey = "sync-states"
+)
+
+// SyncState is what a file was like on both sides when `drive sync` last
+// left them the same, for the next sync to tell which side changed since.
+type SyncState struct {
+	Path    string    `json:"path"`
+	Id      string    `json:"id"`
+	Md5     string    `json:"md5"`
+	Size    int64     `json:"size"`
+	ModTime int64     `json:"mtime"`
+	Time    time.Time `json:"time"`
+}
+
+// SyncStates returns the recorded states of the files at or under any of
+// prefixes, keyed by path.
+func (c *Context) SyncStates(prefixes ...string) (map[string]*SyncState, error) {
+	db, err := c.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	states := make(map[string]*SyncState)
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byteify(SyncStatesKey))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(key, value []byte) error {
+			if !underAny(string(key), prefixes) {
+				return nil
+			}
+			state := &SyncState{}
+			if err := json.Unmarshal(value, state); err != nil {
+				return err
+			}
+			states[state.Path] = state
+			return nil
+		})
+	})
+
+	return states, err
+}
+
+// UpdateSyncStates records saved and forgets the states of the paths in forgotten.
+func (c *Context) UpdateSyncStates(saved []*SyncState, forgotten []string) error {
+	if len(saved) < 1 && len(forgotten) < 1 {
+		return nil
+	}
+
+	db, err := c.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(byteify(SyncStatesKey))
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrNoSuchDbBucket
+		}
+		for _, p := range forgotten {
+			if err := bucket.Delete(byteify(p)); err != nil {
+				return err
+			}
+		}
+		for _, state := range saved {
+			data, err := json.Marshal(state)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(byteify(state.Path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func underAny(p string, prefixes []string) bool {
+	if len(prefixes) < 1 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if prefix == "" || p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
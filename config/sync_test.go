@@ -0,0 +1,58 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSyncStates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, _, c, err := Initialize(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := []*SyncState{{Path: "/docs/a", Md5: "x", Size: 1}, {Path: "/docs2/b"}, {Path: "/c"}}
+	if err := c.UpdateSyncStates(saved, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UpdateSyncStates(nil, []string{"/c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := c.SyncStates("/docs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 || states["/docs/a"] == nil || states["/docs/a"].Md5 != "x" {
+		t.Errorf("expected only /docs/a, got %v", states)
+	}
+
+	states, err = c.SyncStates("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 || states["/c"] != nil {
+		t.Errorf("expected /docs/a and /docs2/b, got %v", states)
+	}
+}
@@ -0,0 +1,707 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+)
+
+const (
+	ArchiveZip   = "zip"
+	ArchiveTarGz = "tar.gz"
+
+	DefaultArchiveFormat = ArchiveZip
+)
+
+// Private properties set on pushed archives, describing their contents.
+const (
+	ArchiveFormatProperty = "driveArchiveFormat"
+	ArchiveFilesProperty  = "driveArchiveFiles"
+	ArchiveBytesProperty  = "driveArchiveBytes"
+	ArchiveSourceProperty = "driveArchiveSource"
+)
+
+// A property's key and value together cannot exceed this many bytes.
+const maxPropertyBytes = 124
+
+var archiveMimeTypes = map[string]string{
+	ArchiveZip:   "application/zip",
+	ArchiveTarGz: "application/gzip",
+}
+
+type archiveManifest struct {
+	format string
+	source string
+	files  int64
+	bytes  int64
+	// entries are the slash separated paths relative to the archived folder.
+	entries []string
+}
+
+func (am *archiveManifest) properties() map[string]string {
+	props := map[string]string{
+		ArchiveFormatProperty: am.format,
+		ArchiveFilesProperty:  strconv.FormatInt(am.files, 10),
+		ArchiveBytesProperty:  strconv.FormatInt(am.bytes, 10),
+	}
+	if len(ArchiveSourceProperty)+len(am.source) <= maxPropertyBytes {
+		props[ArchiveSourceProperty] = am.source
+	}
+	return props
+}
+
+func archiveFormatByName(name string) (string, bool) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip, true
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz, true
+	}
+	return "", false
+}
+
+func (g *Commands) archiveFormat() (format string, ok bool) {
+	if g.opts.Meta == nil {
+		return "", false
+	}
+	formatL := (*g.opts.Meta)[CLIOptionArchiveFormat]
+	if len(formatL) < 1 || formatL[0] == "" {
+		return "", false
+	}
+	return formatL[0], true
+}
+
+// archiveManifestOf lists the files and folders within fsAbsPath that
+// would otherwise have been pushed, skipping hidden and ignored paths.
+func (g *Commands) archiveManifestOf(relToRootPath, fsAbsPath, format string) (*archiveManifest, error) {
+	manifest := &archiveManifest{format: format, source: relToRootPath}
+
+	err := filepath.Walk(fsAbsPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == fsAbsPath {
+			return nil
+		}
+
+		rel, rErr := filepath.Rel(fsAbsPath, p)
+		if rErr != nil {
+			return rErr
+		}
+		rel = filepath.ToSlash(rel)
+
+		skip := isHidden(info.Name(), g.opts.Hidden) ||
+			anyMatch(g.opts.Ignorer, rel, info.Name()) ||
+			!(info.IsDir() || info.Mode().IsRegular())
+		if skip {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		manifest.entries = append(manifest.entries, rel)
+		if !info.IsDir() {
+			manifest.files += 1
+			manifest.bytes += info.Size()
+		}
+		return nil
+	})
+
+	return manifest, err
+}
+
+func writeArchiveEntry(fsAbsPath, rel string, fn func(info os.FileInfo, r io.Reader) error) error {
+	p := filepath.Join(fsAbsPath, filepath.FromSlash(rel))
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fn(info, nil)
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return fn(info, f)
+}
+
+func writeZip(w io.Writer, fsAbsPath string, entries []string) error {
+	zw := zip.NewWriter(w)
+	for _, rel := range entries {
+		err := writeArchiveEntry(fsAbsPath, rel, func(info os.FileInfo, r io.Reader) error {
+			header, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return err
+			}
+			header.Name = rel
+			if info.IsDir() {
+				header.Name += "/"
+			} else {
+				header.Method = zip.Deflate
+			}
+
+			ew, err := zw.CreateHeader(header)
+			if err != nil || r == nil {
+				return err
+			}
+			_, err = io.Copy(ew, r)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeTarGz(w io.Writer, fsAbsPath string, entries []string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, rel := range entries {
+		err := writeArchiveEntry(fsAbsPath, rel, func(info os.FileInfo, r io.Reader) error {
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = rel
+			if info.IsDir() {
+				header.Name += "/"
+			}
+
+			if err := tw.WriteHeader(header); err != nil || r == nil {
+				return err
+			}
+			_, err = io.Copy(tw, r)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func writeArchive(w io.Writer, manifest *archiveManifest, fsAbsPath string) error {
+	switch manifest.format {
+	case ArchiveZip:
+		return writeZip(w, fsAbsPath, manifest.entries)
+	case ArchiveTarGz:
+		return writeTarGz(w, fsAbsPath, manifest.entries)
+	}
+	return invalidArgumentsErr(fmt.Errorf("unknown archive format %q, expected %s or %s", manifest.format, ArchiveZip, ArchiveTarGz))
+}
+
+// PushArchive pushes each source folder as a single archive, created
+// on the fly, alongside where the folder would otherwise have been pushed.
+func (g *Commands) PushArchive() error {
+	format, _ := g.archiveFormat()
+	if _, known := archiveMimeTypes[format]; !known {
+		return invalidArgumentsErr(fmt.Errorf("--%s: unknown format %q, expected %s or %s", CLIOptionArchiveFormat, format, ArchiveZip, ArchiveTarGz))
+	}
+
+	g.rem.encrypter = g.opts.Encrypter
+
+	rootAbsPath := g.context.AbsPathOf("")
+	destAbsPath := g.context.AbsPathOf(g.opts.Destination)
+	remoteDestRelPath, err := filepath.Rel(rootAbsPath, destAbsPath)
+	if err != nil {
+		return err
+	}
+
+	for _, relToRootPath := range g.opts.Sources {
+		if err := g.pushArchive(relToRootPath, remotePathJoin(remoteDestRelPath, relToRootPath), format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Commands) pushArchive(relToRootPath, relToDestPath, format string) error {
+	fsAbsPath := g.context.AbsPathOf(relToRootPath)
+	info, err := os.Stat(fsAbsPath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return invalidArgumentsErr(fmt.Errorf("%s: only folders can be pushed as archives", relToRootPath))
+	}
+
+	manifest, err := g.archiveManifestOf(relToRootPath, fsAbsPath, format)
+	if err != nil {
+		return err
+	}
+
+	dir, base := remotePathSplit(relToDestPath)
+	archivePath := remotePathJoin(dir, base+"."+format)
+
+	rem, err := g.rem.FindByPath(archivePath)
+	if err != nil && err != ErrPathNotExists {
+		return err
+	}
+	if rem != nil && !g.opts.Force {
+		return overwriteAttemptedErr(fmt.Errorf("%s already exists remotely, use `%s` to override this behaviour.\n", archivePath, ForceKey))
+	}
+
+	parent, err := g.remoteMkdirAll(dir)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return errCannotMkdirAll(dir)
+	}
+
+	src := fauxLocalFile(base + "." + format)
+	src.MimeType = archiveMimeTypes[format]
+	if rem != nil {
+		src.Id = rem.Id
+	}
+
+	if !g.opts.Quiet {
+		g.log.Logf("Archiving %s: %d files, %s\n", relToRootPath, manifest.files, prettyBytes(manifest.bytes))
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeArchive(pw, manifest, fsAbsPath))
+	}()
+
+	args := &upsertOpt{
+		uploadChunkSize: g.opts.UploadChunkSize,
+		uploadRateLimit: g.opts.UploadRateLimit,
+		parentId:        parent.Id,
+		fsAbsPath:       fsAbsPath,
+		src:             src,
+		dest:            rem,
+		mask:            g.opts.TypeMask,
+		nonStatable:     true,
+		retryCount:      g.opts.ExponentialBackoffRetryCount,
+		properties:      manifest.properties(),
+	}
+
+	start := time.Now()
+	uploaded, _, err := g.rem.upsertByComparison(pr, args)
+	// Unblock the archiver in case the upload ended early.
+	pr.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %v", archivePath, err)
+	}
+
+	if uploaded != nil {
+		g.recordRunStat(PushKey, start, uploaded.Size, manifest.files, 0)
+		if rem == nil {
+			g.recordHistory(&config.HistoryEntry{Op: HistoryOpPushAdd, FileId: uploaded.Id, Path: archivePath})
+		}
+		if wErr := g.context.SerializeIndex(uploaded.ToIndex()); wErr != nil {
+			g.log.LogErrf("serializeIndex %s: %v\n", uploaded.Name, wErr)
+		}
+	}
+
+	return nil
+}
+
+type archiveEntry struct {
+	name    string
+	isDir   bool
+	modTime time.Time
+}
+
+// eachArchiveEntry invokes fn with every entry of the archive read from
+// body. Zip archives are spooled to a temporary file since their index is
+// at the end, tar archives are streamed.
+func eachArchiveEntry(format string, body io.Reader, fn func(*archiveEntry, io.Reader) error) error {
+	switch format {
+	case ArchiveTarGz:
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+
+		tr := tar.NewReader(gr)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			isDir := header.Typeflag == tar.TypeDir
+			if !isDir && header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+				continue
+			}
+			if err := fn(&archiveEntry{name: header.Name, isDir: isDir, modTime: header.ModTime}, tr); err != nil {
+				return err
+			}
+		}
+
+	case ArchiveZip:
+		spool, err := ioutil.TempFile("", "drive-expand-archive")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+
+		size, err := io.Copy(spool, body)
+		if err != nil {
+			return err
+		}
+
+		zr, err := zip.NewReader(spool, size)
+		if err != nil {
+			return err
+		}
+		for _, zf := range zr.File {
+			entry := &archiveEntry{name: zf.Name, isDir: zf.FileInfo().IsDir(), modTime: zf.ModTime()}
+			if entry.isDir {
+				if err := fn(entry, nil); err != nil {
+					return err
+				}
+				continue
+			}
+			if !zf.FileInfo().Mode().IsRegular() {
+				continue
+			}
+
+			rc, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			err = fn(entry, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return invalidArgumentsErr(fmt.Errorf("unknown archive format %q, expected %s or %s", format, ArchiveZip, ArchiveTarGz))
+}
+
+// archiveEntryPath returns the slash separated path of an entry relative
+// to the expansion folder, refusing entries that would escape it.
+func archiveEntryPath(name string) (string, bool) {
+	name = strings.Replace(name, "\\", "/", -1)
+	if strings.HasPrefix(name, "/") {
+		return "", false
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", false
+		}
+	}
+
+	cleaned := strings.Trim(path.Clean("/"+name), "/")
+	return cleaned, cleaned != ""
+}
+
+// ExpandArchive downloads the remote archive that is the first source and
+// uploads its contents into the remote folder that is the second source.
+func (g *Commands) ExpandArchive() error {
+	if len(g.opts.Sources) != 2 {
+		return invalidArgumentsErr(fmt.Errorf("expand-archive: expecting <archive> <folder> got: %v", g.opts.Sources))
+	}
+	archivePath, destPath := g.opts.Sources[0], g.opts.Sources[1]
+
+	archive, err := g.rem.FindByPath(archivePath)
+	if err != nil {
+		return remoteLookupErr(fmt.Errorf("%s: %v", archivePath, err))
+	}
+	if archive.IsDir {
+		return invalidArgumentsErr(fmt.Errorf("%s: is a folder not an archive", archivePath))
+	}
+
+	format, ok := g.archiveFormat()
+	if !ok {
+		if format, ok = archiveFormatByName(archive.Name); !ok {
+			return invalidArgumentsErr(fmt.Errorf("%s: cannot tell the archive format from its name, use `%s`", archivePath, CLIOptionArchiveFormat))
+		}
+	}
+
+	if dest, dErr := g.rem.FindByPath(destPath); dErr == nil && dest != nil && !dest.IsDir {
+		return illogicalStateErr(fmt.Errorf("%s: %v", destPath, ErrPathNotDir))
+	}
+
+	body, err := g.rem.Download(archive.Id, "")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var files, skipped int64
+	err = eachArchiveEntry(format, body, func(entry *archiveEntry, r io.Reader) error {
+		rel, ok := archiveEntryPath(entry.name)
+		if !ok {
+			g.log.LogErrf("expand-archive: skipping %q which is outside of the archive\n", entry.name)
+			return nil
+		}
+
+		target := remotePathJoin(destPath, rel)
+		if entry.isDir {
+			_, err := g.remoteMkdirAll(target)
+			return err
+		}
+
+		uploaded, err := g.expandArchiveEntry(target, entry, r)
+		if err != nil {
+			return fmt.Errorf("%s: %v", target, err)
+		}
+		if uploaded {
+			files += 1
+		} else {
+			skipped += 1
+		}
+		return nil
+	})
+
+	if !g.opts.Quiet {
+		g.log.Logf("expand-archive: %s: uploaded %d files into %s", archivePath, files, destPath)
+		if skipped >= 1 {
+			g.log.Logf(", skipped %d that already exist, use `%s` to overwrite them", skipped, ForceKey)
+		}
+		g.log.Logln()
+	}
+
+	return err
+}
+
+func (g *Commands) expandArchiveEntry(target string, entry *archiveEntry, r io.Reader) (uploaded bool, err error) {
+	dir, base := remotePathSplit(target)
+
+	rem, err := g.rem.FindByPath(target)
+	if err != nil && err != ErrPathNotExists {
+		return false, err
+	}
+	if rem != nil && !g.opts.Force {
+		return false, nil
+	}
+
+	parent, err := g.remoteMkdirAll(dir)
+	if err != nil {
+		return false, err
+	}
+	if parent == nil {
+		return false, errCannotMkdirAll(dir)
+	}
+
+	src := fauxLocalFile(base)
+	src.ModTime = entry.modTime
+	if rem != nil {
+		src.Id = rem.Id
+	}
+
+	args := &upsertOpt{
+		uploadChunkSize: g.opts.UploadChunkSize,
+		parentId:        parent.Id,
+		fsAbsPath:       target,
+		src:             src,
+		dest:            rem,
+		mimeKey:         filepath.Ext(base),
+		nonStatable:     true,
+		retryCount:      g.opts.ExponentialBackoffRetryCount,
+	}
+
+	cur, _, err := g.rem.upsertByComparison(r, args)
+	if err != nil {
+		return false, err
+	}
+
+	if cur != nil {
+		if rem == nil {
+			g.recordHistory(&config.HistoryEntry{Op: HistoryOpPushAdd, FileId: cur.Id, Path: target})
+		}
+		if wErr := g.context.SerializeIndex(cur.ToIndex()); wErr != nil {
+			g.log.LogErrf("serializeIndex %s: %v\n", cur.Name, wErr)
+		}
+	}
+	return true, nil
+}
+
+// PullExtract downloads each source archive and unpacks it locally into
+// the folder that the archive was pushed from, i.e its path without the
+// archive extension. Files that exist locally are left as is unless forced.
+func (g *Commands) PullExtract() (err error) {
+	defer g.handleInterrupts(nil)()
+
+	for _, relToRootPath := range g.opts.Sources {
+		if g.interrupted() {
+			break
+		}
+		if exErr := g.pullExtract(relToRootPath); exErr != nil {
+			g.log.LogErrf("pull: %s: %v\n", relToRootPath, exErr)
+			err = combineErrors(err, fmt.Errorf("%s: %v", relToRootPath, exErr))
+		}
+	}
+	return err
+}
+
+func (g *Commands) pullExtract(relToRootPath string) error {
+	archive, err := g.rem.FindByPath(relToRootPath)
+	if err != nil {
+		return remoteLookupErr(err)
+	}
+	if archive.IsDir {
+		return invalidArgumentsErr(fmt.Errorf("is a folder not an archive"))
+	}
+
+	format, ok := g.archiveFormat()
+	if !ok {
+		format, ok = archiveFormatOf(archive)
+	}
+	if !ok {
+		return invalidArgumentsErr(fmt.Errorf("cannot tell the archive format from its name, use `%s`", CLIOptionArchiveFormat))
+	}
+
+	destAbsPath := g.context.AbsPathOf(archiveStem(relToRootPath, format))
+	if info, sErr := os.Stat(destAbsPath); sErr == nil && !info.IsDir() {
+		return illogicalStateErr(fmt.Errorf("%s: %v", destAbsPath, ErrPathNotDir))
+	}
+
+	body, err := g.rem.Download(archive.Id, "")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var files, skipped int64
+	err = eachArchiveEntry(format, body, func(entry *archiveEntry, r io.Reader) error {
+		if g.interrupted() {
+			return interruptedErr(fmt.Errorf("interrupted"))
+		}
+		rel, ok := archiveEntryPath(entry.name)
+		if !ok {
+			g.log.LogErrf("pull: skipping %q which is outside of the archive\n", entry.name)
+			return nil
+		}
+
+		target := filepath.Join(destAbsPath, filepath.FromSlash(rel))
+		if entry.isDir {
+			return os.MkdirAll(target, os.ModeDir|0755)
+		}
+
+		extracted, err := g.extractArchiveEntry(target, entry, r)
+		if err != nil {
+			return fmt.Errorf("%s: %v", target, err)
+		}
+		if extracted {
+			files += 1
+		} else {
+			skipped += 1
+		}
+		return nil
+	})
+
+	if !g.opts.Quiet {
+		g.log.Logf("pull: %s: extracted %d files into %s", relToRootPath, files, destAbsPath)
+		if skipped >= 1 {
+			g.log.Logf(", skipped %d that already exist, use `%s` to overwrite them", skipped, ForceKey)
+		}
+		g.log.Logln()
+	}
+
+	return err
+}
+
+func (g *Commands) extractArchiveEntry(target string, entry *archiveEntry, r io.Reader) (extracted bool, err error) {
+	if _, sErr := os.Lstat(target); sErr == nil && !g.opts.Force {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), os.ModeDir|0755); err != nil {
+		return false, err
+	}
+
+	partialPath := target + PartialDownloadSuffix
+	g.partials.add(partialPath)
+	defer g.partials.remove(partialPath)
+
+	fo, err := os.Create(partialPath)
+	if err != nil {
+		return false, err
+	}
+	_, err = io.Copy(fo, r)
+	if cErr := fo.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(partialPath, target)
+	}
+	if err != nil {
+		os.Remove(partialPath)
+		return false, err
+	}
+
+	if !entry.modTime.IsZero() {
+		if err := os.Chtimes(target, entry.modTime, entry.modTime); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// archiveFormatOf returns the format recorded on an archive pushed with
+// -as-archive, falling back to the one its name suggests.
+func archiveFormatOf(f *File) (string, bool) {
+	if f.raw != nil {
+		for _, prop := range f.raw.Properties {
+			if prop == nil || prop.Key != ArchiveFormatProperty {
+				continue
+			}
+			if _, known := archiveMimeTypes[prop.Value]; known {
+				return prop.Value, true
+			}
+		}
+	}
+	return archiveFormatByName(f.Name)
+}
+
+// archiveStem returns p without the extension of an archive of format,
+// which is the path of the folder that it was pushed from.
+func archiveStem(p, format string) string {
+	lower := strings.ToLower(p)
+	exts := []string{"." + format}
+	if format == ArchiveTarGz {
+		exts = append(exts, ".tgz")
+	}
+	for _, ext := range exts {
+		if strings.HasSuffix(lower, ext) && len(p) > len(ext) {
+			return p[:len(p)-len(ext)]
+		}
+	}
+	return p + ".d"
+}
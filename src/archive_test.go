@@ -0,0 +1,47 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+func TestArchiveStem(t *testing.T) {
+	tests := []struct {
+		path, format, want string
+	}{
+		{"/backups/2016.zip", ArchiveZip, "/backups/2016"},
+		{"/backups/2016.ZIP", ArchiveZip, "/backups/2016"},
+		{"/node-project.tar.gz", ArchiveTarGz, "/node-project"},
+		{"/node-project.tgz", ArchiveTarGz, "/node-project"},
+		{"/backups/2016", ArchiveZip, "/backups/2016.d"},
+	}
+	for _, tt := range tests {
+		if got := archiveStem(tt.path, tt.format); got != tt.want {
+			t.Errorf("archiveStem(%q, %q) = %q, want %q", tt.path, tt.format, got, tt.want)
+		}
+	}
+
+	f := &File{Name: "2016.zip", raw: &drive.File{Properties: []*drive.Property{{Key: ArchiveFormatProperty, Value: ArchiveTarGz}}}}
+	if format, ok := archiveFormatOf(f); !ok || format != ArchiveTarGz {
+		t.Errorf("expected the recorded format to win over the name, got %q %v", format, ok)
+	}
+	f.raw = nil
+	if format, ok := archiveFormatOf(f); !ok || format != ArchiveZip {
+		t.Errorf("expected the format to be inferred from the name, got %q %v", format, ok)
+	}
+}
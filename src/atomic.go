@@ -0,0 +1,84 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"os"
+)
+
+// downloadAtomically downloads dlArg into a partial file alongside
+// dlArg.path, only renamed into place once complete and verified, so
+// that an interrupted or corrupted download leaves the original intact.
+func (g *Commands) downloadAtomically(dlArg *downloadArg) error {
+	destAbsPath := dlArg.path
+	partialPath := destAbsPath + PartialDownloadSuffix
+	dlArg.path = partialPath
+
+	g.partials.add(partialPath)
+	defer g.partials.remove(partialPath)
+
+	if err := g.singleDownload(dlArg); err != nil {
+		// Those with a sidecar are kept for the next pull to resume.
+		if !checkpointPartial(partialPath) {
+			removePartial(partialPath)
+		}
+		return err
+	}
+	if err := g.verifyDownload(dlArg); err != nil {
+		removePartial(partialPath)
+		return err
+	}
+	os.Remove(sidecarPath(partialPath))
+
+	if info, sErr := os.Stat(destAbsPath); sErr == nil {
+		os.Chmod(partialPath, info.Mode())
+	}
+	return os.Rename(partialPath, destAbsPath)
+}
+
+// verifyDownload checks the md5 of a completed download against that
+// of the remote file. Encrypted content is stored remotely as ciphertext
+// so its checksum can't be compared against what was decrypted.
+func (g *Commands) verifyDownload(dlArg *downloadArg) error {
+	if dlArg.md5 == "" || g.rem.decrypter != nil {
+		return nil
+	}
+	checksum, err := fileMd5(dlArg.path)
+	if err != nil {
+		return err
+	}
+	if checksum != dlArg.md5 {
+		return downloadFailedErr(fmt.Errorf("download: %q has md5 %s instead of %s, leaving the local file as it was",
+			dlArg.relToRootPath, checksum, dlArg.md5))
+	}
+	return nil
+}
+
+func fileMd5(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyDownload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "notes.txt"+PartialDownloadSuffix)
+	if err := ioutil.WriteFile(p, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &Commands{rem: &Remote{}}
+	tests := []struct {
+		md5     string
+		wantErr bool
+	}{
+		{"5d41402abc4b2a76b9719d911017c592", false},
+		{"00000000000000000000000000000000", true},
+		{"", false},
+	}
+	for _, tt := range tests {
+		err := g.verifyDownload(&downloadArg{path: p, md5: tt.md5, relToRootPath: "notes.txt"})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("md5 %q: got err %v, wantErr %v", tt.md5, err, tt.wantErr)
+		}
+	}
+}
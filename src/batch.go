@@ -0,0 +1,143 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// batchPair is an entry of a batch manifest: a local path and the remote
+// path that it is pushed to, or a remote path and the local path that it
+// is pulled into.
+type batchPair struct {
+	src  string
+	dest string
+}
+
+// parseBatchManifest reads "source,destination" pairs, one per line, with
+// blank lines and lines starting with # skipped. Paths containing commas
+// can be quoted as in any CSV file.
+func parseBatchManifest(r io.Reader) ([]*batchPair, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+
+	var pairs []*batchPair
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return pairs, nil
+		}
+		if err != nil {
+			return nil, invalidArgumentsErr(err)
+		}
+		pair := &batchPair{src: strings.TrimSpace(record[0]), dest: strings.TrimSpace(record[1])}
+		if pair.src == "" || pair.dest == "" {
+			return nil, invalidArgumentsErr(fmt.Errorf("batch entry #%d: expecting both a source and a destination, got %q", len(pairs)+1, record))
+		}
+		pairs = append(pairs, pair)
+	}
+}
+
+func (g *Commands) batchPairs() ([]*batchPair, error) {
+	if g.opts.Meta == nil {
+		return nil, nil
+	}
+	paths := (*g.opts.Meta)[CLIOptionBatch]
+	if len(paths) < 1 || paths[0] == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pairs, err := parseBatchManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", paths[0], err)
+	}
+	return pairs, nil
+}
+
+// pushBatchResolve resolves the changes for each local path of the batch
+// manifest against its remote path, relative to the drive root.
+func (g *Commands) pushBatchResolve() (cl, clashes []*Change, err error) {
+	pairs, err := g.batchPairs()
+	if err != nil {
+		return cl, clashes, err
+	}
+
+	for _, pair := range pairs {
+		fsAbsPath, absErr := filepath.Abs(pair.src)
+		if absErr != nil {
+			return cl, clashes, absErr
+		}
+		ccl, cclashes, cErr := g.changeListResolve(remotePathJoin("/", pair.dest), fsAbsPath, true)
+		clashes = append(clashes, cclashes...)
+		if cErr != nil && cErr != ErrClashesDetected {
+			return cl, clashes, cErr
+		}
+		cl = append(cl, ccl...)
+	}
+	return cl, clashes, nil
+}
+
+// pullBatchResolve resolves the changes for each remote path of the batch
+// manifest against its local path, which has to be within the context.
+func (g *Commands) pullBatchResolve() (cl, clashes []*Change, err error) {
+	pairs, err := g.batchPairs()
+	if err != nil {
+		return cl, clashes, err
+	}
+
+	root := g.context.AbsPathOf("")
+	for _, pair := range pairs {
+		fsAbsPath, absErr := filepath.Abs(pair.dest)
+		if absErr != nil {
+			return cl, clashes, absErr
+		}
+		relPath, relErr := filepath.Rel(root, fsAbsPath)
+		if relErr != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return cl, clashes, invalidArgumentsErr(fmt.Errorf("%s: can only pull into the drive context %s", pair.dest, root))
+		}
+		relToRoot := remotePathJoin("/", filepath.ToSlash(relPath))
+
+		r, rErr := g.rem.FindByPath(remotePathJoin("/", pair.src))
+		if rErr != nil {
+			err = combineErrors(err, fmt.Errorf("%s: %v", pair.src, rErr))
+			continue
+		}
+
+		ccl, cclashes, cErr := g.byRemoteResolve(relToRoot, fsAbsPath, r, false)
+		clashes = append(clashes, cclashes...)
+		cl = append(cl, ccl...)
+		if cErr != nil && cErr != ErrClashesDetected {
+			err = combineErrors(err, cErr)
+		}
+	}
+
+	if len(clashes) >= 1 && err == nil {
+		err = ErrClashesDetected
+	}
+	return cl, clashes, err
+}
@@ -0,0 +1,42 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBatchManifest(t *testing.T) {
+	manifest := "# local,remote\n\nreports/q1, /archive/2016/q1\n\"a,b.txt\",\"/misc/a,b.txt\"\n"
+	pairs, err := parseBatchManifest(strings.NewReader(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*batchPair{
+		{src: "reports/q1", dest: "/archive/2016/q1"},
+		{src: "a,b.txt", dest: "/misc/a,b.txt"},
+	}
+	if !reflect.DeepEqual(pairs, expected) {
+		t.Errorf("expected %v, got %v", expected, pairs)
+	}
+
+	for _, malformed := range []string{"reports/q1\n", "a,b,c\n", "a,\n"} {
+		if _, err := parseBatchManifest(strings.NewReader(malformed)); err == nil {
+			t.Errorf("%q: expected an error", malformed)
+		}
+	}
+}
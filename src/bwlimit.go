@@ -0,0 +1,214 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// bandwidthWindow limits the rate between two times of the day, in minutes
+// since midnight. Windows ending before they start wrap around midnight.
+type bandwidthWindow struct {
+	from, to int
+	rate     int64
+}
+
+func (bw *bandwidthWindow) contains(minute int) bool {
+	if bw.from <= bw.to {
+		return minute >= bw.from && minute < bw.to
+	}
+	return minute >= bw.from || minute < bw.to
+}
+
+// BandwidthLimit is the rate in bytes per second that transfers are
+// throttled to, optionally varying with the time of the day.
+type BandwidthLimit struct {
+	rate    int64
+	windows []*bandwidthWindow
+}
+
+// ParseBandwidthLimit parses `;` separated rates like 2M, each optionally
+// restricted to a time of the day e.g `08:00-18:00,512k;4M` for 512KiB/s
+// during office hours and 4MiB/s otherwise. Outside of all windows and
+// without a rate for the rest of the day, transfers aren't throttled.
+func ParseBandwidthLimit(spec string) (*BandwidthLimit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	limit := &BandwidthLimit{}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		commaIndex := strings.Index(entry, ",")
+		if commaIndex < 0 {
+			rate, err := parseByteSize(entry)
+			if err != nil {
+				return nil, err
+			}
+			limit.rate = rate
+			continue
+		}
+
+		window, err := parseBandwidthWindow(entry[:commaIndex])
+		if err != nil {
+			return nil, err
+		}
+		if window.rate, err = parseByteSize(entry[commaIndex+1:]); err != nil {
+			return nil, err
+		}
+		limit.windows = append(limit.windows, window)
+	}
+	return limit, nil
+}
+
+func parseBandwidthWindow(s string) (*bandwidthWindow, error) {
+	bounds := strings.Split(strings.TrimSpace(s), "-")
+	if len(bounds) != 2 {
+		return nil, fmt.Errorf("%q is not a time window of the form HH:MM-HH:MM", s)
+	}
+	from, err := parseMinuteOfDay(bounds[0])
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseMinuteOfDay(bounds[1])
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, fmt.Errorf("%q: the window is empty", s)
+	}
+	return &bandwidthWindow{from: from, to: to}, nil
+}
+
+func parseMinuteOfDay(s string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d", &hour, &minute); err != nil {
+		return 0, fmt.Errorf("%q is not a time of the form HH:MM", s)
+	}
+	if hour < 0 || minute < 0 || minute >= 60 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("%q is not a time of the day", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// rateAt is the rate in bytes per second at t, 0 meaning unthrottled.
+// The first window containing t wins.
+func (bl *BandwidthLimit) rateAt(t time.Time) int64 {
+	minute := (t.Hour()*60 + t.Minute()) % minutesPerDay
+	for _, window := range bl.windows {
+		if window.contains(minute) {
+			return window.rate
+		}
+	}
+	return bl.rate
+}
+
+// throttle is a token bucket refilled at the limit's current rate and
+// holding at most a second's worth of tokens. Transfers take tokens for
+// what they have just read, going into debt if need be, and then wait
+// for the bucket to be refilled to even.
+type throttle struct {
+	mu sync.Mutex
+
+	limit  *BandwidthLimit
+	tokens float64
+	last   time.Time
+}
+
+func newThrottle(limit *BandwidthLimit) *throttle {
+	if limit == nil {
+		return nil
+	}
+	return &throttle{limit: limit}
+}
+
+// reserve takes n tokens at now and returns the time until which the
+// transfer must then hold off.
+func (t *throttle) reserve(n int, now time.Time) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rate := float64(t.limit.rateAt(now))
+	if rate <= 0 {
+		t.tokens, t.last = 0, now
+		return now
+	}
+
+	if !t.last.IsZero() {
+		t.tokens += now.Sub(t.last).Seconds() * rate
+	} else {
+		t.tokens = rate
+	}
+	if t.tokens > rate {
+		t.tokens = rate
+	}
+	t.last = now
+
+	t.tokens -= float64(n)
+	if t.tokens >= 0 {
+		return now
+	}
+	return now.Add(time.Duration(-t.tokens / rate * float64(time.Second)))
+}
+
+func (t *throttle) wait(n int) {
+	now := time.Now()
+	if until := t.reserve(n, now); until.After(now) {
+		time.Sleep(until.Sub(now))
+	}
+}
+
+type throttledReader struct {
+	io.Reader
+	t *throttle
+}
+
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	n, err := tr.Reader.Read(p)
+	if n > 0 {
+		tr.t.wait(n)
+	}
+	return n, err
+}
+
+type throttledReadCloser struct {
+	throttledReader
+	io.Closer
+}
+
+func (t *throttle) reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &throttledReader{Reader: r, t: t}
+}
+
+func (t *throttle) readCloser(rc io.ReadCloser) io.ReadCloser {
+	if t == nil || rc == nil {
+		return rc
+	}
+	return &throttledReadCloser{throttledReader{Reader: rc, t: t}, rc}
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBandwidthLimit(t *testing.T) {
+	limit, err := ParseBandwidthLimit("08:00-18:00,512k; 22:00-06:00,8M; 2M")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]int64{
+		"07:59": 2 * 1024 * 1024,
+		"08:00": 512 * 1024,
+		"17:59": 512 * 1024,
+		"18:00": 2 * 1024 * 1024,
+		"23:30": 8 * 1024 * 1024,
+		"05:59": 8 * 1024 * 1024,
+	}
+	for clock, expected := range cases {
+		at, _ := time.Parse("15:04", clock)
+		if got := limit.rateAt(at); got != expected {
+			t.Errorf("%s: expected %d, got %d", clock, expected, got)
+		}
+	}
+
+	if limit, err := ParseBandwidthLimit(""); limit != nil || err != nil {
+		t.Errorf("expected no limit, got %v %v", limit, err)
+	}
+	for _, malformed := range []string{"fast", "08:00,1M", "08:00-08:00,1M", "25:00-26:00,1M", "08:00-18:00,x"} {
+		if _, err := ParseBandwidthLimit(malformed); err == nil {
+			t.Errorf("%q: expected an error", malformed)
+		}
+	}
+}
+
+func TestThrottleReserve(t *testing.T) {
+	limit, _ := ParseBandwidthLimit("1K")
+	th := newThrottle(limit)
+	now := time.Now()
+
+	if until := th.reserve(1024, now); !until.Equal(now) {
+		t.Errorf("expected a full bucket to let a second's worth through, held off until %v", until.Sub(now))
+	}
+	if until := th.reserve(512, now); until.Sub(now) != 500*time.Millisecond {
+		t.Errorf("expected to be held off for 500ms, got %v", until.Sub(now))
+	}
+	if until := th.reserve(0, now.Add(time.Second)); !until.Equal(now.Add(time.Second)) {
+		t.Errorf("expected the debt to have been repaid a second later, held off until %v", until.Sub(now))
+	}
+
+	if th := newThrottle(nil); th != nil {
+		t.Errorf("expected no throttle without a limit")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// byteRange is an inclusive range of bytes like those of HTTP Range headers.
+// An end < 0 means up to the end of the file while a start < 0 means
+// the last -start bytes of the file.
+type byteRange struct {
+	start int64
+	end   int64
+}
+
+// parseByteRange parses ranges of the forms `first-last`,
+// `first-` and `-suffixLength` e.g 0-1048575, 1024- or -65536.
+func parseByteRange(s string) (*byteRange, error) {
+	s = strings.TrimSpace(s)
+	sepIndex := strings.Index(s, "-")
+	if sepIndex < 0 {
+		return nil, fmt.Errorf("%q is not of the form first-last, first- or -suffixLength", s)
+	}
+
+	first, last := s[:sepIndex], s[sepIndex+1:]
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%q: expecting a positive suffix length", s)
+		}
+		return &byteRange{start: -n, end: -1}, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil, fmt.Errorf("%q: expecting a non-negative first byte", s)
+	}
+
+	br := &byteRange{start: start, end: -1}
+	if last == "" {
+		return br, nil
+	}
+
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return nil, fmt.Errorf("%q: expecting a last byte no less than the first", s)
+	}
+	br.end = end
+	return br, nil
+}
+
+func (br *byteRange) header() string {
+	if br.start < 0 {
+		return fmt.Sprintf("bytes=%d", br.start)
+	}
+	if br.end < 0 {
+		return fmt.Sprintf("bytes=%d-", br.start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", br.start, br.end)
+}
+
+// requestedByteRange returns the range passed in by --range if any.
+func (g *Commands) requestedByteRange() (*byteRange, error) {
+	if g.opts.Meta == nil {
+		return nil, nil
+	}
+
+	ranges := (*g.opts.Meta)[CLIOptionRange]
+	if len(ranges) < 1 || ranges[0] == "" {
+		return nil, nil
+	}
+
+	br, err := parseByteRange(ranges[0])
+	if err != nil {
+		return nil, invalidArgumentsErr(fmt.Errorf("--%s: %v", CLIOptionRange, err))
+	}
+	return br, nil
+}
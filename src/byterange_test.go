@@ -0,0 +1,146 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+func TestParseByteRange(t *testing.T) {
+	samples := []struct {
+		value   string
+		header  string
+		wantErr bool
+	}{
+		{value: "0-1048575", header: "bytes=0-1048575"},
+		{value: " 1024- ", header: "bytes=1024-"},
+		{value: "-65536", header: "bytes=-65536"},
+		{value: "7-7", header: "bytes=7-7"},
+		{value: "10-9", wantErr: true},
+		{value: "-0", wantErr: true},
+		{value: "1024", wantErr: true},
+		{value: "a-b", wantErr: true},
+		{value: "-", wantErr: true},
+	}
+
+	for i, sample := range samples {
+		br, err := parseByteRange(sample.value)
+		if sample.wantErr {
+			if err == nil {
+				t.Errorf("#%d: %q expected an error, got %#v", i, sample.value, br)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %q unexpected err %v", i, sample.value, err)
+			continue
+		}
+		if got := br.header(); got != sample.header {
+			t.Errorf("#%d: got %q want %q", i, got, sample.header)
+		}
+	}
+}
+
+func TestRequestedByteRange(t *testing.T) {
+	g := &Commands{opts: &Options{}}
+	if br, err := g.requestedByteRange(); br != nil || err != nil {
+		t.Errorf("expected no range without --%s, got %v %v", CLIOptionRange, br, err)
+	}
+
+	meta := map[string][]string{CLIOptionRange: {"-65536"}}
+	g.opts.Meta = &meta
+	br, err := g.requestedByteRange()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := br.header(), "bytes=-65536"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+
+	meta[CLIOptionRange] = []string{"10-9"}
+	if _, err := g.requestedByteRange(); err == nil {
+		t.Errorf("expected an error for an inverted range")
+	}
+}
+
+func TestDownloadRange(t *testing.T) {
+	content := []byte("0123456789abcdef")
+	tests := []struct {
+		br       *byteRange
+		partial  bool
+		want     string
+		wantRead string
+	}{
+		{br: &byteRange{start: 2, end: 5}, partial: true, want: "bytes=2-5", wantRead: "2345"},
+		{br: &byteRange{start: 12, end: -1}, partial: true, want: "bytes=12-", wantRead: "cdef"},
+		{br: &byteRange{start: -3, end: -1}, partial: true, want: "bytes=-3", wantRead: "def"},
+		// A server ignoring the range must not pass for one honoring it.
+		{br: &byteRange{start: 2, end: 5}, want: "bytes=2-5"},
+	}
+
+	for i, tt := range tests {
+		var gotRange string
+		transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			gotRange = req.Header.Get("Range")
+			if !tt.partial {
+				return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(content))}, nil
+			}
+			var start, end int
+			switch {
+			case tt.br.start < 0:
+				start, end = len(content)+int(tt.br.start), len(content)
+			case tt.br.end < 0:
+				start, end = int(tt.br.start), len(content)
+			default:
+				start, end = int(tt.br.start), int(tt.br.end)+1
+			}
+			header := http.Header{}
+			header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, len(content)))
+			return &http.Response{StatusCode: http.StatusPartialContent, Header: header, Body: ioutil.NopCloser(bytes.NewReader(content[start:end]))}, nil
+		})
+		service, err := drive.New(&http.Client{Transport: transport})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := &Remote{service: service}
+
+		rc, err := r.DownloadRange("f1", tt.br)
+		if gotRange != tt.want {
+			t.Errorf("#%d: sent Range %q want %q", i, gotRange, tt.want)
+		}
+		if !tt.partial {
+			if err == nil {
+				rc.Close()
+				t.Errorf("#%d: expected an error when the whole file is served", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		got, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil || string(got) != tt.wantRead {
+			t.Errorf("#%d: read %q %v want %q", i, got, err, tt.wantRead)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+const (
+	CacheStatusKey = "status"
+	CacheClearKey  = "clear"
+	CacheLimitKey  = "limit"
+	CachePruneKey  = "prune"
+)
+
+func (g *Commands) countCacheLookup(hit bool) {
+	if hit {
+		atomic.AddInt64(&g.cacheHits, 1)
+	} else {
+		atomic.AddInt64(&g.cacheMisses, 1)
+	}
+}
+
+// flushCacheLookups persists the lookups counted during this run
+// and evicts indices that no longer fit within the cache limit.
+func (g *Commands) flushCacheLookups() {
+	hits := atomic.SwapInt64(&g.cacheHits, 0)
+	misses := atomic.SwapInt64(&g.cacheMisses, 0)
+	if err := g.context.RecordCacheLookups(hits, misses); err != nil {
+		g.DebugPrintf("flushCacheLookups: %v", err)
+	}
+
+	evicted, err := g.context.EnforceCacheLimit()
+	if err != nil {
+		g.log.LogErrf("cache: enforcing limit: %v\n", err)
+	} else if evicted >= 1 && g.opts.Verbose {
+		g.log.Logf("cache: evicted %d indices to stay within the limit\n", evicted)
+	}
+}
+
+func (g *Commands) Cache() error {
+	args := g.opts.Sources
+	if len(args) < 1 {
+		return g.cacheStatus()
+	}
+
+	switch subcommand, rest := args[0], args[1:]; subcommand {
+	case CacheStatusKey:
+		return g.cacheStatus()
+	case CacheClearKey:
+		return g.cacheClear()
+	case CacheLimitKey:
+		if len(rest) != 1 {
+			return invalidArgumentsErr(fmt.Errorf("cache: expected `%s <size>` e.g `%s 50M`", CacheLimitKey, CacheLimitKey))
+		}
+		return g.cacheLimit(rest[0])
+	case CachePruneKey:
+		return g.cachePrune()
+	default:
+		return invalidArgumentsErr(fmt.Errorf("cache: unknown subcommand %q, expected one of %s, %s, %s or %s",
+			subcommand, CacheStatusKey, CacheClearKey, CacheLimitKey, CachePruneKey))
+	}
+}
+
+func (g *Commands) cacheStatus() error {
+	status, err := g.context.CacheStatus()
+	if err != nil {
+		return err
+	}
+
+	limit := "unlimited"
+	if status.Limit >= 1 {
+		limit = prettyBytes(status.Limit)
+	}
+
+	hitRate := "n/a"
+	if lookups := status.Hits + status.Misses; lookups >= 1 {
+		hitRate = fmt.Sprintf("%.1f%%", 100*float64(status.Hits)/float64(lookups))
+	}
+
+	g.log.Logf("Indices:  %d entries, %s\n", status.Entries, prettyBytes(status.Bytes))
+	g.log.Logf("Database: %s\n", prettyBytes(status.DbBytes))
+	g.log.Logf("Limit:    %s\n", limit)
+	g.log.Logf("Lookups:  %d hits, %d misses, hit rate %s\n", status.Hits, status.Misses, hitRate)
+
+	return nil
+}
+
+func (g *Commands) cacheClear() error {
+	if g.opts.canPrompt() {
+		status := promptForChanges("This removes all indices which will be rebuilt on the next pull or push. Continue [Y/n] ")
+		if !accepted(status) {
+			return status.Error()
+		}
+	}
+
+	cleared, err := g.context.ClearCache()
+	if err != nil {
+		return err
+	}
+
+	g.log.Logf("cache: cleared %d indices\n", cleared)
+	return nil
+}
+
+func (g *Commands) cacheLimit(size string) error {
+	limit, err := parseByteSize(size)
+	if err != nil {
+		return invalidArgumentsErr(fmt.Errorf("cache %s: %v", CacheLimitKey, err))
+	}
+
+	if err := g.context.SetCacheLimit(limit); err != nil {
+		return err
+	}
+
+	if limit < 1 {
+		g.log.Logln("cache: limit removed")
+		return nil
+	}
+
+	evicted, err := g.context.EnforceCacheLimit()
+	if err != nil {
+		return err
+	}
+
+	g.log.Logf("cache: limit set to %s, evicted %d indices\n", prettyBytes(limit), evicted)
+	return nil
+}
+
+// cachePrune removes the indices of files deleted or trashed remotely.
+func (g *Commands) cachePrune() error {
+	problems, checked, err := g.indexProblems()
+	if err != nil {
+		return err
+	}
+
+	var stale []*indexProblem
+	for _, problem := range problems {
+		if problem.stale {
+			stale = append(stale, problem)
+		}
+	}
+
+	if len(stale) < 1 {
+		g.log.Logf("cache: none of %d indices is stale\n", checked)
+		return nil
+	}
+
+	return g.removeIndexProblems("cache "+CachePruneKey, stale, checked)
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"sort"
+)
+
+// pullChangedSince only compares the files modified remotely since
+// ChangedSince, found by a single search of the drive, with their local
+// copies instead of walking the sources in full. Remote deletions and
+// new empty folders are left for a full pull to reconcile.
+func (g *Commands) pullChangedSince() (cl, clashes []*Change, err error) {
+	pagePair := g.rem.findModifiedSince(g.opts.ChangedSince, g.opts.Hidden)
+
+	changed := map[string]*File{}
+	backPaths := map[string][]string{}
+
+	working := true
+	for working {
+		select {
+		case pErr := <-pagePair.errsChan:
+			if pErr != nil {
+				return cl, clashes, pErr
+			}
+		case f, stillHasContent := <-pagePair.filesChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if f == nil || f.raw == nil || !g.opts.admits(f) {
+				continue
+			}
+			for _, p := range g.changedFilePaths(f.raw, backPaths) {
+				if withinAny(p, g.opts.Sources) {
+					changed[p] = f
+				}
+			}
+		}
+	}
+
+	var paths []string
+	for p := range changed {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		fsPath := g.context.AbsPathOf(p)
+		l, lErr := g.resolveToLocalFile(p, fsPath)
+		if lErr != nil {
+			err = combineErrors(err, lErr)
+			continue
+		}
+
+		ccl, cclashes, cErr := g.doChangeListRecv(p, fsPath, l, changed[p], false)
+		clashes = append(clashes, cclashes...)
+		cl = append(cl, ccl...)
+		if cErr != nil && cErr != ErrClashesDetected {
+			err = combineErrors(err, cErr)
+		}
+	}
+
+	if err == nil && len(clashes) >= 1 {
+		err = ErrClashesDetected
+	}
+	return cl, clashes, err
+}
@@ -21,6 +21,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/odeke-em/drive/config"
@@ -126,6 +127,12 @@ func (g *Commands) resolveToLocalFile(relToRoot string, fsPaths ...string) (loca
 	}
 
 	for _, fsPath := range fsPaths {
+		if g.opts.Links != LinksFollow {
+			if linkInfo, lErr := os.Lstat(fsPath); lErr == nil && symlink(linkInfo.Mode()) {
+				return newLocalLink(fsPath, linkInfo)
+			}
+		}
+
 		localInfo, statErr := os.Stat(fsPath)
 
 		if statErr != nil && !os.IsNotExist(statErr) {
@@ -157,9 +164,16 @@ func (g *Commands) byRemoteResolve(relToRoot, fsPath string, r *File, push bool)
 
 func (g *Commands) changeListResolve(relToRoot, fsPath string, push bool) (cl, clashes []*Change, err error) {
 	pagePair := g.rem.FindByPathM(relToRoot)
+	if !push && g.opts.InTrash {
+		pagePair = g.rem.FindByPathTrashedM(relToRoot)
+	}
 	iterCount := uint64(0)
 	noClashThreshold := uint64(1)
 
+	// Same-named remotes are held back for pick to choose one of.
+	pick := g.duplicatePicker(push)
+	var candidates []*File
+
 	errsChan := pagePair.errsChan
 	remotesChan := pagePair.filesChan
 
@@ -181,6 +195,10 @@ func (g *Commands) changeListResolve(relToRoot, fsPath string, push bool) (cl, c
 					return
 				}
 				iterCount++
+				if pick != nil {
+					candidates = append(candidates, rem)
+					continue
+				}
 			}
 
 			g.DebugPrintf("[changeListResolve] relToRoot: %s remoteFile: %#v isPush: %v\n", relToRoot, rem, push)
@@ -194,6 +212,16 @@ func (g *Commands) changeListResolve(relToRoot, fsPath string, push bool) (cl, c
 		}
 	}
 
+	if len(candidates) >= 1 {
+		rem := candidates[0]
+		if len(candidates) > 1 {
+			if rem, err = pick(relToRoot, candidates); err != nil {
+				return nil, nil, err
+			}
+		}
+		return g.byRemoteResolve(relToRoot, fsPath, rem, push)
+	}
+
 	if iterCount > noClashThreshold && len(clashes) < 1 {
 		clashes = append(clashes, cl...)
 		// err = reComposeError(err, ErrClashesDetected.Error())
@@ -216,6 +244,10 @@ func directionalComplement(local, remote *File, push bool) *File {
 }
 
 func (g *Commands) doChangeListRecv(relToRoot, fsPath string, l, r *File, push bool) (cl, clashes []*Change, err error) {
+	return g.doChangeListRecvDepth(relToRoot, fsPath, l, r, push, g.opts.Depth)
+}
+
+func (g *Commands) doChangeListRecvDepth(relToRoot, fsPath string, l, r *File, push bool, depth int) (cl, clashes []*Change, err error) {
 	if l == nil && r == nil {
 		err = illogicalStateErr(fmt.Errorf("'%s' aka '%s' doesn't exist locally nor remotely",
 			relToRoot, fsPath))
@@ -240,8 +272,9 @@ func (g *Commands) doChangeListRecv(relToRoot, fsPath string, l, r *File, push b
 		local:      l,
 		push:       push,
 		remote:     r,
-		depth:      g.opts.Depth,
-		filter:     makeFileFilter(g.opts.TypeMask),
+		depth:      depth,
+		filter:     g.fileFilter(),
+		policy:     g.policyFor(path.Dir(localBase), policyCommand(push)),
 	}
 
 	return g.resolveChangeListRecv(clr)
@@ -299,6 +332,7 @@ type changeListResolve struct {
 	remote     *File
 	localBase  string
 	remoteBase string
+	policy     *subtreePolicy
 }
 
 type changeSliceArg struct {
@@ -307,6 +341,7 @@ type changeSliceArg struct {
 	depth  int
 	push   bool
 	filter driveFileFilter
+	policy *subtreePolicy
 
 	mu *sync.Mutex
 
@@ -337,10 +372,24 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 		matchChecks = append(matchChecks, r.Name)
 	}
 
-	if anyMatch(g.opts.Ignorer, matchChecks...) {
+	if anyMatch(g.opts.Ignorer, matchChecks...) || clr.policy.ignores(matchChecks...) {
+		return
+	}
+
+	g.checksums.prime(l)
+
+	isDir := (l != nil && l.IsDir) || (r != nil && r.IsDir)
+	if !g.opts.Force && clr.policy.ignoresPath(g.context.AbsPathOf(clr.localBase), isDir) {
 		return
 	}
 
+	policy := g.folderPolicy(clr.policy, nil, l, clr.localBase, policyCommand(clr.push))
+
+	if clr.push && policy.isReadOnly() {
+		g.DebugPrintf("[resolveChangeListRecv] %s is read-only by policy\n", clr.localBase)
+		return cl, clashes, nil
+	}
+
 	explicitlyRequested := g.opts.ExplicitlyExport && hasExportLinks(r) && len(g.opts.Exports) >= 1
 
 	if clr.push {
@@ -365,6 +414,7 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 
 	change.NoClobber = g.opts.NoClobber
 	change.IgnoreChecksum = g.opts.IgnoreChecksum
+	change.policy = policy
 
 	if explicitlyRequested {
 		change.Force = true
@@ -377,9 +427,13 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 		return cl, clashes, nil
 	}
 
-	if change.Op() != OpNone {
+	if change.Op() == OpNone && !isDir {
+		atomic.AddInt64(&g.upToDate, 1)
+	}
+
+	if change.Op() != OpNone || (g.keepUnchanged && l != nil && r != nil) {
 		subject := directionalComplement(l, r, clr.push)
-		if clr.filter == nil || clr.filter(subject) {
+		if (clr.filter == nil || clr.filter(subject)) && g.opts.Includes.admits(clr.localBase, isDir) {
 			cl = append(cl, change)
 		}
 	}
@@ -425,6 +479,7 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 			hidden:  g.opts.Hidden,
 			depth:   originalDepth, // local listing needs to start from original depth
 			ignore:  g.opts.Ignorer,
+			links:   g.opts.Links,
 		}
 
 		var lErr error
@@ -437,8 +492,13 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 
 	var pagePair *paginationPair
 
-	if r != nil {
-		pagePair = g.rem.FindByParentId(r.Id, g.opts.Hidden)
+	var remoteRC *File
+	if r != nil && !clr.push && g.opts.InTrash {
+		pagePair = g.rem.FindByParentIdTrashed(r.Id, g.opts.Hidden)
+	} else if r != nil {
+		// Hidden files are sifted out here rather than by the listing, for
+		// the folder's remote .driverc not to be missed.
+		pagePair = siftDriveRC(g.rem.FindByParentId(r.Id, true), g.opts.Hidden, &remoteRC)
 	} else {
 		// TODO: Figure out if the condition
 		// file == nil && err == nil
@@ -451,11 +511,26 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 		pagePair = &paginationPair{errsChan: errsChan, filesChan: filesChan}
 	}
 
-	dirlist, clashingFiles, err := merge(pagePair, localChildren, g.opts.IgnoreNameClashes)
+	var pick func(string, []*File) (*File, error)
+	if picker := g.duplicatePicker(clr.push); picker != nil {
+		pick = func(name string, candidates []*File) (*File, error) {
+			return picker(sepJoin("/", clr.remoteBase, name), candidates)
+		}
+	}
+
+	dirlist, clashingFiles, err := merge(pagePair, localChildren, g.opts.IgnoreNameClashes, pick)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if remoteRC != nil {
+		policy = g.folderPolicy(clr.policy, remoteRC, l, clr.localBase, policyCommand(clr.push))
+		if clr.push && policy.isReadOnly() {
+			g.DebugPrintf("[resolveChangeListRecv] %s is read-only by its remote policy\n", clr.localBase)
+			return cl, clashes, nil
+		}
+	}
+
 	if !g.opts.IgnoreNameClashes && len(clashingFiles) >= 1 {
 		remoteBase := clr.remoteBase
 		if rootLike(remoteBase) {
@@ -508,6 +583,7 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 			clashesMap:    clashesMap,
 			mu:            mu,
 			filter:        clr.filter,
+			policy:        policy,
 		}
 
 		go g.changeSlice(&cslArgs)
@@ -556,6 +632,7 @@ func (g *Commands) changeSlice(cslArg *changeSliceArg) {
 			local:      l.local,
 			depth:      cslArg.depth,
 			filter:     cslArg.filter,
+			policy:     cslArg.policy,
 		}
 
 		childChanges, childClashes, cErr := g.resolveChangeListRecv(clr)
@@ -576,7 +653,10 @@ func (g *Commands) changeSlice(cslArg *changeSliceArg) {
 	}
 }
 
-func merge(remotePagePair *paginationPair, locals chan *File, ignoreClashes bool) (merged []*dirList, clashes []*File, err error) {
+// merge pairs up remote and local files by name. If pick is set, it chooses
+// which of several same-named remotes is paired with the local file, the
+// others being left out, instead of them being reported as clashes.
+func merge(remotePagePair *paginationPair, locals chan *File, ignoreClashes bool, pick func(string, []*File) (*File, error)) (merged []*dirList, clashes []*File, err error) {
 	localsMap := map[string]*File{}
 	remotesMap := map[string]*File{}
 
@@ -599,6 +679,9 @@ func merge(remotePagePair *paginationPair, locals chan *File, ignoreClashes bool
 		localsMap[l.Name] = l
 	}
 
+	// sameNamed holds the remotes named like a local file when picking.
+	sameNamed := map[string][]*File{}
+
 	working := true
 	for working {
 		select {
@@ -623,6 +706,11 @@ func merge(remotePagePair *paginationPair, locals chan *File, ignoreClashes bool
 			}
 			list := &dirList{remote: r}
 
+			if l, ok := localsMap[r.Name]; ok && pick != nil && l.IsDir == r.IsDir {
+				sameNamed[r.Name] = append(sameNamed[r.Name], r)
+				continue
+			}
+
 			if !ignoreClashes {
 				prev, present := remotesMap[r.Name]
 				if present {
@@ -644,6 +732,17 @@ func merge(remotePagePair *paginationPair, locals chan *File, ignoreClashes bool
 		}
 	}
 
+	for name, candidates := range sameNamed {
+		r := candidates[0]
+		if len(candidates) > 1 {
+			if r, err = pick(name, candidates); err != nil {
+				return merged, clashes, err
+			}
+		}
+		merged = append(merged, &dirList{remote: r, local: localsMap[name]})
+		delete(localsMap, name)
+	}
+
 	// if anything left in locals, add to the dir listing
 	for _, l := range localsMap {
 		merged = append(merged, &dirList{local: l})
@@ -717,7 +816,19 @@ func (g *Commands) resolveConflicts(cl []*Change, push bool) (*[]*Change, *[]*Ch
 
 	nonConflicts, conflicts := sift(cl)
 	resolved, unresolved := resolveConflicts(conflicts, push, g.deserializeIndex)
+	if g.opts.ConflictStrategy != ConflictAbort {
+		settled, skipped := settleConflicts(unresolved, g.opts.ConflictStrategy, push)
+		for _, ch := range skipped {
+			g.log.LogErrf("Conflict: skipping %s\n", ch.Path)
+		}
+		if len(skipped) >= 1 {
+			g.reportConflicts(skipped, push, ConflictOutcomeSkipped)
+		}
+		resolved = append(resolved, settled...)
+		unresolved = nil
+	}
 	if conflictsPersist(unresolved) {
+		g.reportConflicts(unresolved, push, ConflictOutcomeAborted)
 		return &resolved, &unresolved
 	}
 
@@ -0,0 +1,190 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+)
+
+// racyChecksumWindow is how recently a file can have been modified for its
+// checksum not to be recorded, since a change within the same second
+// would leave its size and rounded modTime as they were.
+const racyChecksumWindow = 2 * time.Second
+
+// checksumIndex saves pushes and pulls from checksumming local files again
+// that haven't changed since, going by their size and modTime, checksums
+// being what takes longest to resolve large trees that are mostly unchanged.
+type checksumIndex struct {
+	context *config.Context
+
+	loadOnce sync.Once
+	mu       sync.Mutex
+	known    map[string]*config.LocalChecksum
+	fresh    map[string]*config.LocalChecksum
+}
+
+func newChecksumIndex(context *config.Context) *checksumIndex {
+	if context == nil {
+		return nil
+	}
+	return &checksumIndex{context: context, fresh: make(map[string]*config.LocalChecksum)}
+}
+
+func (ci *checksumIndex) load() {
+	ci.loadOnce.Do(func() {
+		known, err := ci.context.LocalChecksums()
+		if err != nil {
+			known = make(map[string]*config.LocalChecksum)
+		}
+		ci.mu.Lock()
+		ci.known = known
+		ci.mu.Unlock()
+	})
+}
+
+func (ci *checksumIndex) key(absPath string) (string, bool) {
+	rel, err := filepath.Rel(ci.context.AbsPathOf(""), absPath)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		// Outside of the context e.g mounted paths.
+		return "", false
+	}
+	return rel, true
+}
+
+// prime sets the checksum of the local file f if it is recorded for its
+// current size and modTime, otherwise arranging for it to be recorded
+// once computed.
+func (ci *checksumIndex) prime(f *File) {
+	if ci == nil || f == nil || f.IsDir || f.BlobAt == "" || f.Md5Checksum != "" {
+		return
+	}
+	key, ok := ci.key(f.BlobAt)
+	if !ok {
+		return
+	}
+	ci.load()
+
+	ci.mu.Lock()
+	sum := ci.known[key]
+	ci.mu.Unlock()
+	if sum != nil && sum.Size == f.Size && sum.ModTime == f.ModTime.Unix() && sum.Md5 != "" {
+		f.Md5Checksum = sum.Md5
+		return
+	}
+	f.checksums = ci
+}
+
+// remember records md5 as the checksum of the local file at absPath as of
+// when it had size and modTime.
+func (ci *checksumIndex) remember(absPath string, size int64, modTime time.Time, md5, fileId string) {
+	if ci == nil || md5 == "" || time.Since(modTime) < racyChecksumWindow {
+		return
+	}
+	key, ok := ci.key(absPath)
+	if !ok {
+		return
+	}
+	ci.load()
+
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	if fileId == "" {
+		if prev := ci.known[key]; prev != nil {
+			fileId = prev.FileId
+		}
+	}
+	sum := &config.LocalChecksum{Path: key, Size: size, ModTime: modTime.Unix(), Md5: md5, FileId: fileId}
+	ci.known[key] = sum
+	ci.fresh[key] = sum
+}
+
+// remotePaths returns the remote paths that the files recorded were last
+// pushed from or pulled into, keyed by the ids of the remote files.
+func (ci *checksumIndex) remotePaths() map[string][]string {
+	paths := make(map[string][]string)
+	if ci == nil {
+		return paths
+	}
+	ci.load()
+
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	for key, sum := range ci.known {
+		if sum.FileId != "" {
+			paths[sum.FileId] = append(paths[sum.FileId], remotePathJoin(key))
+		}
+	}
+	return paths
+}
+
+// flush saves the checksums recorded since the last flush.
+func (ci *checksumIndex) flush() error {
+	if ci == nil {
+		return nil
+	}
+	ci.mu.Lock()
+	sums := make([]*config.LocalChecksum, 0, len(ci.fresh))
+	for _, sum := range ci.fresh {
+		sums = append(sums, sum)
+	}
+	ci.fresh = make(map[string]*config.LocalChecksum)
+	ci.mu.Unlock()
+
+	return ci.context.SaveLocalChecksums(sums)
+}
+
+// rememberPushed records the checksum of the local file src once pushed as rem.
+func (g *Commands) rememberPushed(src, rem *File) {
+	if src == nil || rem == nil || src.IsDir || src.BlobAt == "" {
+		return
+	}
+	md5 := src.Md5Checksum
+	if md5 == "" && g.rem.encrypter == nil {
+		md5 = rem.Md5Checksum
+	}
+	g.checksums.remember(src.BlobAt, src.Size, src.ModTime, md5, rem.Id)
+}
+
+// rememberPulled records the checksum of what change pulled, which is
+// that of the remote file unless it was decrypted or exported.
+func (g *Commands) rememberPulled(change *Change) {
+	src := change.Src
+	if src == nil || src.IsDir || src.Md5Checksum == "" || g.rem.decrypter != nil {
+		return
+	}
+	absPath := g.context.AbsPathOf(change.Path)
+	info, err := os.Stat(absPath)
+	if err != nil || info.Size() != src.Size {
+		return
+	}
+	local := NewLocalFile(absPath, info)
+	g.checksums.remember(absPath, local.Size, local.ModTime, src.Md5Checksum, src.Id)
+}
+
+func (g *Commands) flushChecksums() {
+	if err := g.checksums.flush(); err != nil {
+		g.DebugPrintf("flushChecksums: %v", err)
+	}
+}
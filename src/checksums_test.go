@@ -0,0 +1,112 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+)
+
+func TestChecksumIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-checksums")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, _, context, err := config.Initialize(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := filepath.Join(dir, "a.txt")
+	if err := ioutil.WriteFile(p, []byte("abc"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(p, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	localFile := func() *File {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewLocalFile(p, info)
+	}
+
+	ci := newChecksumIndex(context)
+	f := localFile()
+	ci.prime(f)
+	if f.Md5Checksum != "" {
+		t.Fatalf("expected no recorded checksum, got %q", f.Md5Checksum)
+	}
+	want := md5Checksum(f)
+	if err := ci.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same size and modTime, so the recorded checksum is trusted.
+	if err := ioutil.WriteFile(p, []byte("xyz"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	f = localFile()
+	newChecksumIndex(context).prime(f)
+	if f.Md5Checksum != want {
+		t.Errorf("expected the recorded checksum %q, got %q", want, f.Md5Checksum)
+	}
+
+	// A different modTime means checksumming again.
+	if err := os.Chtimes(p, modTime.Add(time.Minute), modTime.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	f = localFile()
+	newChecksumIndex(context).prime(f)
+	if f.Md5Checksum != "" {
+		t.Errorf("expected the checksum of a modified file not to be used, got %q", f.Md5Checksum)
+	}
+}
+
+func TestChecksumIndexRemotePaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-checksums")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, _, context, err := config.Initialize(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modTime := time.Now().Add(-time.Hour)
+	ci := newChecksumIndex(context)
+	ci.remember(filepath.Join(dir, "docs", "a.txt"), 3, modTime, "x", "id1")
+	ci.remember(filepath.Join(dir, "b.txt"), 3, modTime, "y", "")
+
+	want := map[string][]string{"id1": {"/docs/a.txt"}}
+	if got := ci.remotePaths(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
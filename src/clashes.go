@@ -15,9 +15,11 @@
 package drive
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 type FixClashesMode uint8
@@ -33,6 +35,13 @@ func (g *Commands) ListClashes(byId bool) error {
 	clashes, err := listClashes(g, g.opts.Sources, byId)
 	spin.stop()
 
+	if jsonOutput(g.opts.TypeMask) {
+		if err != nil && err != ErrClashesDetected {
+			return err
+		}
+		return g.printClashesJSON(clashes)
+	}
+
 	if len(clashes) < 1 {
 		if err == nil {
 			return fmt.Errorf("no clashes exist!")
@@ -49,6 +58,54 @@ func (g *Commands) ListClashes(byId bool) error {
 	return nil
 }
 
+type clashingFile struct {
+	Id           string `json:"id"`
+	Md5Checksum  string `json:"md5"`
+	Size         int64  `json:"size"`
+	ModifiedTime string `json:"modifiedTime"`
+	MimeType     string `json:"mimeType"`
+}
+
+type clashReport struct {
+	Path  string          `json:"path"`
+	Files []*clashingFile `json:"files"`
+}
+
+// clashReports groups clashes by path, in the order they were found.
+func clashReports(clashes []*Change) []*clashReport {
+	reports := []*clashReport{}
+	byPath := map[string]*clashReport{}
+	for _, clash := range clashes {
+		if clash == nil || clash.Src == nil {
+			continue
+		}
+		report, ok := byPath[clash.Path]
+		if !ok {
+			report = &clashReport{Path: clash.Path}
+			byPath[clash.Path] = report
+			reports = append(reports, report)
+		}
+		f := clash.Src
+		report.Files = append(report.Files, &clashingFile{
+			Id:           f.Id,
+			Md5Checksum:  f.Md5Checksum,
+			Size:         f.Size,
+			ModifiedTime: f.ModTime.UTC().Format(time.RFC3339),
+			MimeType:     f.MimeType,
+		})
+	}
+	return reports
+}
+
+func (g *Commands) printClashesJSON(clashes []*Change) error {
+	blob, err := json.MarshalIndent(clashReports(clashes), "", "  ")
+	if err != nil {
+		return err
+	}
+	g.log.Logf("%s\n", blob)
+	return nil
+}
+
 func (g *Commands) FixClashes(byId bool) error {
 	spin := g.playabler()
 	spin.play()
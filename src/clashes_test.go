@@ -0,0 +1,44 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClashReports(t *testing.T) {
+	modTime := time.Date(2016, 5, 1, 10, 0, 0, 0, time.UTC)
+	clashes := []*Change{
+		{Path: "/a", Src: &File{Id: "1", Md5Checksum: "x", Size: 3, ModTime: modTime}},
+		{Path: "/b", Src: &File{Id: "2"}},
+		{Path: "/a", Src: &File{Id: "3"}},
+		{Path: "/c"},
+	}
+
+	reports := clashReports(clashes)
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 paths, got %d", len(reports))
+	}
+	if reports[0].Path != "/a" || len(reports[0].Files) != 2 || reports[0].Files[1].Id != "3" {
+		t.Errorf("expected both clashes of /a grouped in order, got %+v", reports[0])
+	}
+	if got, want := reports[0].Files[0].ModifiedTime, "2016-05-01T10:00:00Z"; got != want {
+		t.Errorf("modifiedTime: got %q want %q", got, want)
+	}
+	if len(clashReports(nil)) != 0 {
+		t.Errorf("expected no reports for no clashes")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const DefaultColorPalette = "dir=01;34,doc=36,shared=35,trashed=90"
+
+// colorKinds are the kinds of files that can be colored, in the
+// order that they take precedence when a file is of several kinds.
+var colorKinds = []string{"trashed", "dir", "doc", "shared"}
+
+// colorPalette maps each kind of file to the SGR codes, as in
+// LS_COLORS e.g "01;34", that its path is printed with.
+type colorPalette map[string]string
+
+func validSGR(codes string) bool {
+	if codes == "" {
+		return false
+	}
+	for _, r := range codes {
+		if (r < '0' || r > '9') && r != ';' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseColorPalette overrides the default palette with the comma
+// separated kind=codes pairs in spec, an empty codes value turning
+// off coloring of that kind.
+func parseColorPalette(spec string) (colorPalette, error) {
+	palette := colorPalette{}
+	for _, s := range []string{DefaultColorPalette, spec} {
+		for _, pair := range NonEmptyTrimmedStrings(strings.Split(s, ",")...) {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return nil, invalidArgumentsErr(fmt.Errorf("--%s: %q is not of the form kind=codes", CLIOptionColorPalette, pair))
+			}
+			kind, codes := strings.ToLower(strings.TrimSpace(kv[0])), strings.TrimSpace(kv[1])
+			known := false
+			for _, k := range colorKinds {
+				known = known || k == kind
+			}
+			if !known {
+				return nil, invalidArgumentsErr(fmt.Errorf("--%s: unknown kind %q, expecting one of %s", CLIOptionColorPalette, kind, strings.Join(colorKinds, ", ")))
+			}
+			if codes == "" {
+				delete(palette, kind)
+				continue
+			}
+			if !validSGR(codes) {
+				return nil, invalidArgumentsErr(fmt.Errorf("--%s: %q are not SGR codes", CLIOptionColorPalette, codes))
+			}
+			palette[kind] = codes
+		}
+	}
+	return palette, nil
+}
+
+func (f *File) colorKind(inTrash bool) string {
+	switch {
+	case inTrash || (f.Labels != nil && f.Labels.Trashed):
+		return "trashed"
+	case f.IsDir:
+		return "dir"
+	case strings.HasPrefix(f.MimeType, "application/vnd.google-apps."):
+		return "doc"
+	case f.Shared:
+		return "shared"
+	}
+	return ""
+}
+
+// paint wraps s in the escapes for f's kind, leaving it as is if
+// that kind has no color or coloring is off.
+func (cp colorPalette) paint(f *File, s string, inTrash bool) string {
+	codes, ok := cp[f.colorKind(inTrash)]
+	if !ok {
+		return s
+	}
+	return fmt.Sprintf("\033[%sm%s\033[0m", codes, s)
+}
+
+// prepareListColors sets up the palette of a --color listing unless
+// the NO_COLOR convention asks for no colors at all.
+func (g *Commands) prepareListColors() error {
+	if g.listPalette != nil || !colorOutput(g.opts.TypeMask) || os.Getenv(NoColorEnvKey) != "" {
+		return nil
+	}
+
+	spec := ""
+	if g.opts.Meta != nil {
+		if specs := (*g.opts.Meta)[CLIOptionColorPalette]; len(specs) >= 1 {
+			spec = specs[0]
+		}
+	}
+
+	palette, err := parseColorPalette(spec)
+	if err != nil {
+		return err
+	}
+	g.listPalette = palette
+	return nil
+}
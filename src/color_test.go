@@ -0,0 +1,48 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseColorPalette(t *testing.T) {
+	palette, err := parseColorPalette("dir=01;33, shared=,TRASHED=2")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	expected := colorPalette{"dir": "01;33", "doc": "36", "trashed": "2"}
+	if !reflect.DeepEqual(palette, expected) {
+		t.Errorf("expected %v, got %v", expected, palette)
+	}
+
+	dir := &File{IsDir: true, Shared: true}
+	if got, want := palette.paint(dir, "a", false), "\033[01;33ma\033[0m"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got, want := palette.paint(dir, "a", true), "\033[2ma\033[0m"; got != want {
+		t.Errorf("expected trashed to take precedence, got %q", got)
+	}
+	if got := palette.paint(&File{Shared: true}, "a", false); got != "a" {
+		t.Errorf("expected shared to be uncolored, got %q", got)
+	}
+
+	for _, malformed := range []string{"dir", "link=31", "dir=red"} {
+		if _, err := parseColorPalette(malformed); err == nil {
+			t.Errorf("%q: expected an error", malformed)
+		}
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"text/template"
+	"time"
 
 	"github.com/cheggaaa/pb"
 	"github.com/mattn/go-isatty"
@@ -67,8 +69,12 @@ type Options struct {
 	NoPrompt bool
 	Path     string
 	// PageSize determines the number of results returned per API call
-	PageSize  int64
-	Recursive bool
+	PageSize int64
+	// MaxPages if set stops listings after that many pages.
+	MaxPages int64
+	// MaxResults if set stops listings and queries after that many results.
+	MaxResults int64
+	Recursive  bool
 	// Sources is a of list all paths that are
 	// within the scope/path of the current gd context
 	Sources []string
@@ -79,8 +85,11 @@ type Options struct {
 	Piped bool
 	// Quiet when set toggles only logging of errors to stderrs as
 	// well as reading from stdin in this case stdout is not logged to
-	Quiet             bool
+	Quiet bool
+	// QuietLevel refines Quiet, a set Quiet meaning at least QuietErrors.
+	QuietLevel        QuietLevel
 	StdoutIsTty       bool
+	StdinIsTty        bool
 	IgnoreNameClashes bool
 	ExcludeCrudMask   CrudValue
 	ExplicitlyExport  bool
@@ -100,6 +109,31 @@ type Options struct {
 	Destination                  string
 	RenameMode                   RenameMode
 	ExponentialBackoffRetryCount int
+	// QPS if set is the most requests made per second.
+	QPS int
+	// ModifiedAfter and ModifiedBefore if set restrict operations
+	// to files last modified within them.
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+	// ChangedSince if set pulls only the files modified remotely since,
+	// found by searching for them rather than by walking the sources.
+	ChangedSince time.Time
+	// MinSize and MaxSize if set restrict operations to files
+	// within those sizes. Drive queries cannot filter by size
+	// so these are only applied to files as they are traversed.
+	MinSize int64
+	MaxSize int64
+	// Includes if set restricts operations to the files that it admits.
+	Includes *IncludeFilter
+	// ConflictStrategy settles the files that changed on both sides
+	// instead of aborting.
+	ConflictStrategy ConflictStrategy
+	// DryRun prints the changes that a push or pull would make instead
+	// of making them.
+	DryRun bool
+	// PreservePermissions if set reapplies the source permissions
+	// onto the destinations of copies and moves.
+	PreservePermissions bool
 
 	Encrypter func(io.Reader) (io.Reader, error)
 	Decrypter func(io.Reader) (io.ReadCloser, error)
@@ -118,6 +152,74 @@ type Options struct {
 
 	// Limit the upload bandwidth to n KiB/s.
 	UploadRateLimit int
+
+	// Pager when set pipes listings through the user's $PAGER.
+	Pager bool
+
+	// SmallFileJobs is the number of small files pushed in parallel,
+	// independently of the parallelism of larger transfers.
+	SmallFileJobs int
+
+	// Incremental pulls only what changed since the last incremental
+	// pull of the same path, according to the Changes API.
+	Incremental bool
+
+	// BandwidthLimit if set throttles uploads and downloads,
+	// each direction separately.
+	BandwidthLimit *BandwidthLimit
+
+	// Transfers if set is the number of files transferred concurrently,
+	// DRIVE_GOMAXPROCS otherwise.
+	Transfers int
+
+	// ChunkConcurrency is the number of ranges of a large file
+	// that are pulled concurrently.
+	ChunkConcurrency int
+
+	// SkipDuplicates pushes shortcuts instead of the local duplicates
+	// recorded by `dedupe -local -record`.
+	SkipDuplicates bool
+
+	// PreservePerms records the mode of pushed files and restores it on pull.
+	PreservePerms bool
+	// PreserveOwner also records and restores the uid and gid.
+	PreserveOwner bool
+
+	// Permanent when set allows unrecoverable deletions.
+	Permanent bool
+	// DeletionMode is what destructive operations do without Permanent,
+	// either DeletionTrash or DeletionPermanent which refuses them.
+	DeletionMode string
+
+	// Links is how local symlinks are pushed and pulled.
+	Links LinkMode
+
+	// LowMemory trades speed for a smaller footprint on constrained
+	// devices, with smaller pages, fewer jobs and sorting spilled to disk.
+	LowMemory bool
+
+	// AllDrives also lists the content of the Shared Drives one is a member of.
+	AllDrives bool
+
+	// DuplicateTitles picks which same-named remote entry a push updates.
+	DuplicateTitles DuplicateTitlePolicy
+
+	// Compress gzips compressible files as they are pushed.
+	Compress bool
+
+	// RetryMaxWait if set is the longest wait between retries of failed
+	// requests, and RetryOn the failures retried, instead of the defaults.
+	RetryMaxWait time.Duration
+	RetryOn      []RetryCondition
+
+	// StatsJSON if set is the file that the summary of a push
+	// or pull is also written to as JSON.
+	StatsJSON string
+
+	// Mirror holds back the deletions of a push or pull until everything
+	// else is transferred, asking before more than MirrorMaxDeletions.
+	Mirror             bool
+	MirrorMaxDeletions int
 }
 
 func (opts *Options) CryptoEnabled() bool {
@@ -125,14 +227,56 @@ func (opts *Options) CryptoEnabled() bool {
 }
 
 type Commands struct {
+	// cacheHits and cacheMisses count index lookups. They are accessed
+	// atomically so are kept first for 64-bit alignment.
+	cacheHits   int64
+	cacheMisses int64
+	// unstarted counts the changes left unstarted once interrupted.
+	unstarted int64
+	// upToDate counts the files resolved as unchanged.
+	upToDate int64
+	// interrupts counts the interrupts received, see handleInterrupts.
+	interrupts int32
+
 	context *config.Context
 	rem     *Remote
 	opts    *Options
 	rcOpts  *Options
 	log     *log.Logger
+	// summary is log, except at QuietSummary where it still logs to stdout.
+	summary *log.Logger
 
 	progress      *pb.ProgressBar
 	mkdirAllCache *expirableCache.OperationCache
+	// transfers shows the progress of each file pushed or pulled.
+	transfers *transferProgress
+	// partials are the files being downloaded.
+	partials partialDownloads
+	// csvOut is shared by everything listed so that the header is written once.
+	csvOut *csvListing
+	// listFormat is the parsed --format template of listings.
+	listFormat *template.Template
+	// listPalette colors the paths of --color listings.
+	listPalette colorPalette
+	// results counts the results taken towards MaxResults.
+	results int64
+	// pages counts the pages turned towards MaxPages.
+	pages int64
+	// conflicts are those reported so far, written out together.
+	conflicts []*ConflictRecord
+	// resumeFrom is where a listing stopped by MaxResults can be resumed from.
+	resumeFrom *resumeToken
+	// pushManifest collects what is pushed when --manifest is set.
+	pushManifest *pushManifest
+	// permCarrier reapplies permissions when --preserve-permissions is set.
+	permCarrier *permissionCarrier
+	// changesCheckpoints are saved once an incremental pull succeeds.
+	changesCheckpoints []*config.ChangesCheckpoint
+	// keepUnchanged keeps files that exist on both sides in change
+	// lists even if unchanged, for their metadata to be compared.
+	keepUnchanged bool
+	// checksums spares unchanged local files from being checksummed again.
+	checksums *checksumIndex
 }
 
 func (opts *Options) canPrompt() bool {
@@ -145,6 +289,12 @@ func (opts *Options) canPrompt() bool {
 	return !opts.NoPrompt
 }
 
+// canPaginate reports whether to ask before fetching each next page.
+// Reading the answer would block forever if stdin isn't a terminal.
+func (opts *Options) canPaginate() bool {
+	return opts.canPrompt() && opts.StdinIsTty
+}
+
 func (c *Commands) DebugPrintf(fmt_ string, args ...interface{}) {
 	if !((Debug() || c.opts.Verbose) && c.opts.canPreview()) {
 		return
@@ -197,16 +347,16 @@ func (opts *Options) rcPath() (string, error) {
 	return rcPathChecker(FsHomeDir)
 }
 
-func New(context *config.Context, opts *Options) *Commands {
-	var rem *Remote
-	var err error
-
+// remoteFor connects to the account that context is mounted from.
+func remoteFor(context *config.Context) (*Remote, error) {
 	if context.GSAJWTConfig != nil {
-		rem, err = NewRemoteContextFromServiceAccount(context.GSAJWTConfig)
-	} else {
-		rem, err = NewRemoteContext(context)
+		return remoteFromServiceAccount(context.GSAJWTConfig, context)
 	}
+	return NewRemoteContext(context)
+}
 
+func New(context *config.Context, opts *Options) *Commands {
+	rem, err := remoteFor(context)
 	if err != nil {
 		panic(fmt.Errorf("failed to initialize remoteContext: %v", err))
 	}
@@ -214,10 +364,23 @@ func New(context *config.Context, opts *Options) *Commands {
 	stdin, stdout, stderr := os.Stdin, os.Stdout, os.Stderr
 
 	var logger *log.Logger = nil
+	var summary *log.Logger = nil
 
 	if opts == nil {
 		logger = log.New(stdin, stdout, stderr)
+		summary = logger
 	} else {
+		if opts.Quiet && opts.QuietLevel == QuietNone {
+			opts.QuietLevel = QuietErrors
+		}
+		opts.Quiet = opts.QuietLevel != QuietNone
+
+		if opts.QuietLevel == QuietSummary {
+			summary = log.New(stdin, stdout, stderr)
+		}
+		if opts.QuietLevel == QuietSilent {
+			stderr = nil
+		}
 		if opts.Quiet {
 			stdout = nil
 		}
@@ -225,12 +388,16 @@ func New(context *config.Context, opts *Options) *Commands {
 		if stdout != nil {
 			opts.StdoutIsTty = isatty.IsTerminal(stdout.Fd())
 		}
+		opts.StdinIsTty = isatty.IsTerminal(stdin.Fd())
 
 		if stdout == nil && opts.Piped {
 			panic("piped requires stdout to be non-nil")
 		}
 
 		logger = log.New(stdin, stdout, stderr)
+		if summary == nil {
+			summary = logger
+		}
 
 		// should always start with /
 		opts.Path = path.Clean(path.Join("/", opts.Path))
@@ -246,10 +413,23 @@ func New(context *config.Context, opts *Options) *Commands {
 			opts.Ignorer = ignorer
 		}
 
+		opts.applyLowMemory()
+
 		if opts.UploadChunkSize == 0 {
 			// UploadRateLimit is in KiB/s
 			opts.UploadChunkSize = opts.UploadRateLimit * 1024
 		}
+
+		rem.permanentDeletion = opts.Permanent
+		rem.pacer.setQPS(opts.QPS)
+		rem.retry.configure(opts.ExponentialBackoffRetryCount, opts.RetryMaxWait, opts.RetryOn)
+		rem.uploadThrottle = newThrottle(opts.BandwidthLimit)
+		rem.downloadThrottle = newThrottle(opts.BandwidthLimit)
+
+		rem.pageSize = opts.PageSize
+		if opts.MaxResults >= 1 && (rem.pageSize < 1 || opts.MaxResults < rem.pageSize) {
+			rem.pageSize = opts.MaxResults
+		}
 	}
 
 	return &Commands{
@@ -257,7 +437,9 @@ func New(context *config.Context, opts *Options) *Commands {
 		rem:           rem,
 		opts:          opts,
 		log:           logger,
+		summary:       summary,
 		mkdirAllCache: expirableCache.New(),
+		checksums:     newChecksumIndex(context),
 	}
 }
 
@@ -277,10 +459,17 @@ func (g *Commands) taskAdd(n int64) {
 	if g.progress != nil {
 		g.progress.Add64(n)
 	}
+	if g.transfers != nil {
+		g.transfers.add(n)
+	}
 }
 
 func (g *Commands) taskFinish() {
 	if g.progress != nil {
 		g.progress.Finish()
 	}
+	if g.transfers != nil {
+		g.transfers.finish()
+		g.transfers, g.rem.transfers = nil, nil
+	}
 }
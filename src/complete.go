@@ -0,0 +1,134 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+)
+
+const DefaultCompletionMaxAge = "10m"
+
+// completionSplit splits prefix into the folder as typed, ending in a
+// slash if not empty, and the start of the name to complete within it.
+func completionSplit(prefix string) (typedDir, base string) {
+	i := strings.LastIndex(prefix, "/")
+	return prefix[:i+1], prefix[i+1:]
+}
+
+// completionCandidates lists the entries of a folder whose names start with
+// base, as typedDir followed by the name and a slash for folders. Hidden
+// entries are only listed if hidden is set or base starts with a dot.
+func completionCandidates(typedDir, base string, entries []*config.ListingEntry, hidden bool) []string {
+	hidden = hidden || strings.HasPrefix(base, ".")
+
+	var candidates []string
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name, base) || isHidden(entry.Name, hidden) {
+			continue
+		}
+		candidate := typedDir + entry.Name
+		if entry.IsDir {
+			candidate += "/"
+		}
+		candidates = append(candidates, candidate)
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// CompletePath prints the remote paths that prefix can be completed to, one
+// per line, from the listing of its folder cached within maxAge. Older
+// listings are refreshed from the remote unless offline is set.
+func (g *Commands) CompletePath(prefix, maxAge string, offline bool) error {
+	age, err := parseAgeDuration(maxAge)
+	if err != nil {
+		return invalidArgumentsErr(fmt.Errorf("%s: -%s: %v", CompletePathKey, CLIOptionCompletionMaxAge, err))
+	}
+
+	typedDir, base := completionSplit(prefix)
+	dirPath := remotePathJoin(g.opts.Path, typedDir)
+	if strings.HasPrefix(typedDir, "/") {
+		dirPath = remotePathJoin(typedDir)
+	}
+
+	// The cache only speeds completion up so it failing isn't fatal.
+	listing, err := g.context.FolderListing(dirPath)
+	if err != nil {
+		g.DebugPrintf("complete-path: %s: %v", dirPath, err)
+	}
+
+	if (listing == nil || time.Since(listing.Time) > age) && !offline {
+		fresh, lErr := g.listFolder(dirPath)
+		if lErr != nil && listing == nil {
+			return lErr
+		}
+		if lErr == nil {
+			listing = fresh
+			if sErr := g.context.SaveFolderListing(listing); sErr != nil {
+				g.DebugPrintf("complete-path: %s: %v", dirPath, sErr)
+			}
+		}
+	}
+	if listing == nil {
+		return nil
+	}
+
+	for _, candidate := range completionCandidates(typedDir, base, listing.Entries, g.opts.Hidden) {
+		g.log.Logln(candidate)
+	}
+	return nil
+}
+
+// listFolder lists the names within the remote folder at dirPath, an empty
+// listing if there is no such folder.
+func (g *Commands) listFolder(dirPath string) (*config.FolderListing, error) {
+	listing := &config.FolderListing{Path: dirPath, Time: time.Now()}
+
+	dir, err := g.rem.FindByPath(dirPath)
+	if err == ErrPathNotExists || (err == nil && (dir == nil || !dir.IsDir)) {
+		return listing, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pagePair := g.rem.FindByParentId(dir.Id, true)
+	errsChan := pagePair.errsChan
+	childrenChan := pagePair.filesChan
+
+	working := true
+	for working {
+		select {
+		case err := <-errsChan:
+			if err != nil {
+				return nil, err
+			}
+		case child, stillHasContent := <-childrenChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if child != nil {
+				listing.Entries = append(listing.Entries, &config.ListingEntry{Name: child.Name, IsDir: child.IsDir})
+			}
+		}
+	}
+	return listing, nil
+}
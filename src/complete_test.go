@@ -0,0 +1,60 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/odeke-em/drive/config"
+)
+
+func TestCompletionCandidates(t *testing.T) {
+	splits := map[string][2]string{
+		"":             {"", ""},
+		"Proj":         {"", "Proj"},
+		"Projects/":    {"Projects/", ""},
+		"/Projects/Ap": {"/Projects/", "Ap"},
+	}
+	for prefix, want := range splits {
+		if typedDir, base := completionSplit(prefix); typedDir != want[0] || base != want[1] {
+			t.Errorf("%q: got (%q, %q) want %q", prefix, typedDir, base, want)
+		}
+	}
+
+	entries := []*config.ListingEntry{
+		{Name: "Proposal.docx"},
+		{Name: "Projects", IsDir: true},
+		{Name: "notes.txt"},
+		{Name: ".Private", IsDir: true},
+	}
+	cases := []struct {
+		typedDir, base string
+		hidden         bool
+		want           []string
+	}{
+		{typedDir: "", base: "Pro", want: []string{"Projects/", "Proposal.docx"}},
+		{typedDir: "docs/", base: "", want: []string{"docs/Projects/", "docs/Proposal.docx", "docs/notes.txt"}},
+		{typedDir: "", base: "", hidden: true, want: []string{".Private/", "Projects/", "Proposal.docx", "notes.txt"}},
+		{typedDir: "/", base: ".P", want: []string{"/.Private/"}},
+		{typedDir: "", base: "x"},
+	}
+	for _, tc := range cases {
+		got := completionCandidates(tc.typedDir, tc.base, entries, tc.hidden)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q %q: got %v want %v", tc.typedDir, tc.base, got, tc.want)
+		}
+	}
+}
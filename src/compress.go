@@ -0,0 +1,97 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+// Private properties set on files pushed gzipped, describing
+// their uncompressed content.
+const (
+	CompressionProperty      = "driveCompression"
+	UncompressedSizeProperty = "driveUncompressedSize"
+	UncompressedMd5Property  = "driveUncompressedMd5"
+)
+
+const (
+	compressionGzip = "gzip"
+	compressionNone = "none"
+)
+
+// incompressibleExts are those of formats that are compressed already.
+var incompressibleExts = map[string]bool{
+	".7z": true, ".apk": true, ".avi": true, ".bz2": true, ".docx": true,
+	".gif": true, ".gz": true, ".heic": true, ".jar": true, ".jpeg": true,
+	".jpg": true, ".m4a": true, ".mkv": true, ".mov": true, ".mp3": true,
+	".mp4": true, ".odp": true, ".ods": true, ".odt": true, ".ogg": true,
+	".pdf": true, ".png": true, ".pptx": true, ".rar": true, ".tgz": true,
+	".webm": true, ".webp": true, ".xlsx": true, ".xz": true, ".zip": true,
+	".zst": true,
+}
+
+func compressible(name string) bool {
+	return !incompressibleExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// compressionProperties describes the local file f as pushed gzipped.
+func compressionProperties(f *File) map[string]string {
+	return map[string]string{
+		CompressionProperty:      compressionGzip,
+		UncompressedSizeProperty: strconv.FormatInt(f.Size, 10),
+		UncompressedMd5Property:  md5Checksum(f),
+	}
+}
+
+// applyCompression makes a remote file pushed gzipped look like its
+// uncompressed content, so that it compares equal to the local copy.
+func (f *File) applyCompression(props []*drive.Property) {
+	values := make(map[string]string)
+	for _, prop := range props {
+		if prop != nil {
+			values[prop.Key] = prop.Value
+		}
+	}
+	if values[CompressionProperty] != compressionGzip {
+		return
+	}
+	size, err := strconv.ParseInt(values[UncompressedSizeProperty], 10, 64)
+	if err != nil {
+		return
+	}
+	f.compressed = true
+	f.Size = size
+	f.Md5Checksum = values[UncompressedMd5Property]
+}
+
+// gzipReader gzips what is read from r on the fly.
+func gzipReader(r io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, r)
+		if cErr := zw.Close(); err == nil {
+			err = cErr
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+func TestCompression(t *testing.T) {
+	for name, want := range map[string]bool{
+		"server.log":  true,
+		"data.csv":    true,
+		"Makefile":    true,
+		"photo.JPG":   false,
+		"dump.tar.gz": false,
+		"report.docx": false,
+	} {
+		if got := compressible(name); got != want {
+			t.Errorf("compressible(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	content := bytes.Repeat([]byte("drive compress "), 1000)
+	zr, err := gzip.NewReader(gzipReader(bytes.NewReader(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTripped, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(roundTripped, content) {
+		t.Errorf("gzip round trip altered the content")
+	}
+
+	f := &File{Size: 42, Md5Checksum: "gzipped"}
+	f.applyCompression([]*drive.Property{{Key: CompressionProperty, Value: compressionNone}})
+	if f.compressed || f.Size != 42 {
+		t.Errorf("expected no longer compressed file to be left as is, got %+v", f)
+	}
+	f.applyCompression([]*drive.Property{
+		{Key: CompressionProperty, Value: compressionGzip},
+		{Key: UncompressedSizeProperty, Value: "15000"},
+		{Key: UncompressedMd5Property, Value: "plain"},
+	})
+	if !f.compressed || f.Size != 15000 || f.Md5Checksum != "plain" {
+		t.Errorf("expected uncompressed size and checksum, got %+v", f)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/odeke-em/drive/config"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// Google APIs only gzip responses if the User-Agent mentions gzip.
+	// See https://developers.google.com/drive/v2/web/performance#gzip
+	GzipUserAgentSuffix = "(gzip)"
+)
+
+// compressionTransport requests gzip encoded API responses. The
+// Accept-Encoding header is left to net/http so that it transparently
+// decompresses the responses for us.
+type compressionTransport struct {
+	base    http.RoundTripper
+	enabled bool
+}
+
+func (t *compressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request it was given.
+	clone := new(http.Request)
+	*clone = *req
+	clone.Header = make(http.Header, len(req.Header))
+	for key, values := range req.Header {
+		clone.Header[key] = append([]string(nil), values...)
+	}
+
+	if t.enabled {
+		userAgent := clone.Header.Get("User-Agent")
+		if !strings.Contains(userAgent, "gzip") {
+			clone.Header.Set("User-Agent", strings.TrimSpace(userAgent+" "+GzipUserAgentSuffix))
+		}
+	} else if clone.Header.Get("Accept-Encoding") == "" {
+		clone.Header.Set("Accept-Encoding", "identity")
+	}
+
+	return t.base.RoundTrip(clone)
+}
+
+// compressionEnabled reports whether gzip responses should be requested.
+// Set DRIVE_NO_GZIP to anything non-empty to turn compression off.
+func compressionEnabled() bool {
+	return os.Getenv(DriveNoGzipEnvKey) == ""
+}
+
+// transportContext returns the context that oauth2 clients use
+// to look up the base client that their requests are sent with.
+func transportContext(p *pacer, rp *retryPolicy, configContext *config.Context) context.Context {
+	client := &http.Client{
+		Transport: &retryTransport{
+			policy: rp,
+			base: &pacingTransport{
+				pacer: p,
+				base: withIdentity(&compressionTransport{
+					base:    http.DefaultTransport,
+					enabled: compressionEnabled(),
+				}, configContext),
+			},
+		},
+	}
+	return context.WithValue(context.Background(), oauth2.HTTPClient, client)
+}
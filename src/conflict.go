@@ -0,0 +1,320 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+)
+
+// ConflictStrategy settles files that changed both locally and remotely
+// since they were last synced, which otherwise abort a push or pull.
+type ConflictStrategy uint8
+
+const (
+	ConflictAbort ConflictStrategy = iota
+	ConflictNewer
+	ConflictLocal
+	ConflictRemote
+	ConflictKeepBoth
+	ConflictSkip
+)
+
+var conflictStrategyNames = map[ConflictStrategy]string{
+	ConflictNewer:    "newer",
+	ConflictLocal:    "local",
+	ConflictRemote:   "remote",
+	ConflictKeepBoth: "keep-both",
+	ConflictSkip:     "skip",
+}
+
+func (cs ConflictStrategy) String() string {
+	return conflictStrategyNames[cs]
+}
+
+// ParseConflictStrategy parses one of newer, local, remote, keep-both or
+// skip, nothing standing for aborting on conflicts.
+func ParseConflictStrategy(s string) (ConflictStrategy, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return ConflictAbort, nil
+	}
+	for cs, name := range conflictStrategyNames {
+		if name == s {
+			return cs, nil
+		}
+	}
+	return ConflictAbort, fmt.Errorf("unknown strategy %q, expecting newer, local, remote, keep-both or skip", s)
+}
+
+// conflictCopyName suffixes name, before its extension, with the side and
+// the modTime of the copy kept alongside the other.
+func conflictCopyName(name, side string, modTime time.Time) string {
+	ext := filepath.Ext(name)
+	stem := name[:len(name)-len(ext)]
+	if stem == "" {
+		stem, ext = name, ""
+	}
+	return fmt.Sprintf("%s (%s conflict %s)%s", stem, side, modTime.UTC().Format("2006-01-02 150405"), ext)
+}
+
+// settleConflicts applies strategy to conflicts, returning the changes to
+// carry out. Those left out keep the destination as it is.
+func settleConflicts(conflicts []*Change, strategy ConflictStrategy, push bool) (settled, skipped []*Change) {
+	for _, ch := range conflicts {
+		keep := false
+		switch strategy {
+		case ConflictLocal:
+			keep = push
+		case ConflictRemote:
+			keep = !push
+		case ConflictNewer:
+			keep = ch.Src.ModTime.After(ch.Dest.ModTime)
+		case ConflictKeepBoth:
+			settled = append(settled, keepBothChange(ch, push))
+			continue
+		}
+
+		if !keep {
+			skipped = append(skipped, ch)
+			continue
+		}
+		ch.IgnoreConflict = true
+		settled = append(settled, ch)
+	}
+	return settled, skipped
+}
+
+// keepBothChange turns ch into the addition of its source next to the
+// destination, under a suffixed name, leaving the destination untouched.
+func keepBothChange(ch *Change, push bool) *Change {
+	side := "remote"
+	if push {
+		side = "local"
+	}
+
+	src := *ch.Src
+	src.Name = conflictCopyName(src.Name, side, src.ModTime)
+	if push {
+		// Uploaded as a new file rather than over the remote one.
+		src.Id = ""
+	}
+
+	return &Change{
+		Src:            &src,
+		Parent:         ch.Parent,
+		Path:           path.Join(path.Dir(ch.Path), src.Name),
+		Force:          ch.Force,
+		NoClobber:      ch.NoClobber,
+		IgnoreChecksum: ch.IgnoreChecksum,
+		g:              ch.g,
+		policy:         ch.policy,
+	}
+}
+
+const (
+	ConflictOutcomeAborted = "aborted"
+	ConflictOutcomeSkipped = "skipped"
+)
+
+// ConflictReport lists the conflicts that aborted a push or pull, or were
+// skipped, so that `drive resolve` can settle them later on.
+type ConflictReport struct {
+	Time      time.Time         `json:"time"`
+	Conflicts []*ConflictRecord `json:"conflicts"`
+}
+
+type ConflictRecord struct {
+	Command   string        `json:"command"`
+	Path      string        `json:"path"`
+	FileId    string        `json:"file_id,omitempty"`
+	Outcome   string        `json:"outcome"`
+	Local     *conflictSide `json:"local"`
+	Remote    *conflictSide `json:"remote"`
+	Suggested string        `json:"suggested_resolution"`
+}
+
+type conflictSide struct {
+	Md5Checksum string    `json:"md5,omitempty"`
+	ModTime     time.Time `json:"mod_time"`
+	Size        int64     `json:"size"`
+}
+
+func newConflictSide(f *File) *conflictSide {
+	return &conflictSide{Md5Checksum: md5Checksum(f), ModTime: f.ModTime.UTC(), Size: f.Size}
+}
+
+// suggestedResolution keeps the side modified last, or both if neither is.
+func suggestedResolution(local, remote *File) ConflictStrategy {
+	switch {
+	case local.ModTime.After(remote.ModTime):
+		return ConflictLocal
+	case remote.ModTime.After(local.ModTime):
+		return ConflictRemote
+	}
+	return ConflictKeepBoth
+}
+
+func newConflictRecord(ch *Change, push bool, outcome string) *ConflictRecord {
+	command, local, remote := PullKey, ch.Dest, ch.Src
+	if push {
+		command, local, remote = PushKey, ch.Src, ch.Dest
+	}
+	return &ConflictRecord{
+		Command:   command,
+		Path:      ch.Path,
+		FileId:    remote.Id,
+		Outcome:   outcome,
+		Local:     newConflictSide(local),
+		Remote:    newConflictSide(remote),
+		Suggested: suggestedResolution(local, remote).String(),
+	}
+}
+
+// reportConflicts adds conflicts to those reported so far by g, writing
+// them all to the report of the context.
+func (g *Commands) reportConflicts(conflicts []*Change, push bool, outcome string) {
+	if g.opts.DryRun {
+		return
+	}
+	for _, ch := range conflicts {
+		g.conflicts = append(g.conflicts, newConflictRecord(ch, push, outcome))
+	}
+
+	p := config.ConflictsSuffixedPath(g.context.AbsPath)
+	if err := writeConflictReport(p, &ConflictReport{Time: time.Now().UTC(), Conflicts: g.conflicts}); err != nil {
+		g.log.LogErrf("conflicts report: %v\n", err)
+		return
+	}
+	g.log.LogErrf("Conflicts recorded in %s, use `drive %s` to settle them\n", p, ResolveKey)
+}
+
+func writeConflictReport(p string, report *ConflictReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, append(data, '\n'), 0600)
+}
+
+func readConflictReport(p string) (*ConflictReport, error) {
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	report := &ConflictReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("%s: %v", p, err)
+	}
+	return report, nil
+}
+
+// resolveGroup is the paths that are pushed or pulled with one strategy.
+type resolveGroup struct {
+	push     bool
+	strategy ConflictStrategy
+	paths    []string
+}
+
+// groupConflicts groups the records of a report by command and strategy,
+// each record using its suggested resolution unless strategy is set.
+func groupConflicts(records []*ConflictRecord, strategy ConflictStrategy) (groups []*resolveGroup, err error) {
+	for _, rec := range records {
+		if rec.Command != PushKey && rec.Command != PullKey {
+			err = combineErrors(err, fmt.Errorf("%s: unknown command %q", rec.Path, rec.Command))
+			continue
+		}
+		push := rec.Command == PushKey
+
+		cs := strategy
+		if cs == ConflictAbort {
+			var pErr error
+			if cs, pErr = ParseConflictStrategy(rec.Suggested); pErr != nil || cs == ConflictAbort {
+				err = combineErrors(err, fmt.Errorf("%s: no strategy to resolve it with", rec.Path))
+				continue
+			}
+		}
+
+		var group *resolveGroup
+		for _, candidate := range groups {
+			if candidate.push == push && candidate.strategy == cs {
+				group = candidate
+				break
+			}
+		}
+		if group == nil {
+			group = &resolveGroup{push: push, strategy: cs}
+			groups = append(groups, group)
+		}
+		group.paths = append(group.paths, rec.Path)
+	}
+	return groups, err
+}
+
+// ResolveConflicts settles the conflicts of the report at reportPath by
+// pushing or pulling their paths again with strategy, or with the
+// resolution suggested for each if strategy is ConflictAbort.
+func (g *Commands) ResolveConflicts(reportPath string, strategy ConflictStrategy) error {
+	report, err := readConflictReport(reportPath)
+	if err != nil {
+		return err
+	}
+	if len(report.Conflicts) < 1 {
+		g.log.Logln("No conflicts to resolve")
+		return nil
+	}
+
+	groups, err := groupConflicts(report.Conflicts, strategy)
+	if err != nil {
+		return invalidArgumentsErr(err)
+	}
+
+	// Conflicts that persist are reported anew.
+	if filepath.Clean(reportPath) == config.ConflictsSuffixedPath(g.context.AbsPath) {
+		if err := os.Remove(reportPath); err != nil {
+			return err
+		}
+	}
+
+	for _, group := range groups {
+		command := PullKey
+		if group.push {
+			command = PushKey
+		}
+		g.log.Logf("%s -%s %s: %s\n", command, CLIOptionConflict, group.strategy, strings.Join(group.paths, ", "))
+
+		g.opts.Sources = group.paths
+		g.opts.ConflictStrategy = group.strategy
+
+		var rErr error
+		if group.push {
+			rErr = g.Push()
+		} else {
+			rErr = g.Pull()
+		}
+		if rErr != nil {
+			err = combineErrors(err, fmt.Errorf("%s: %v", command, rErr))
+		}
+	}
+	return err
+}
@@ -0,0 +1,192 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConflictCopyName(t *testing.T) {
+	modTime := time.Date(2016, 3, 14, 15, 9, 26, 0, time.UTC)
+	cases := []struct {
+		name, side, want string
+	}{
+		{"report.txt", "local", "report (local conflict 2016-03-14 150926).txt"},
+		{"archive.tar.gz", "remote", "archive.tar (remote conflict 2016-03-14 150926).gz"},
+		{"Makefile", "local", "Makefile (local conflict 2016-03-14 150926)"},
+		{".bashrc", "remote", ".bashrc (remote conflict 2016-03-14 150926)"},
+	}
+	for _, tc := range cases {
+		if got := conflictCopyName(tc.name, tc.side, modTime); got != tc.want {
+			t.Errorf("conflictCopyName(%q, %q): expected %q, got %q", tc.name, tc.side, tc.want, got)
+		}
+	}
+}
+
+func TestSettleConflicts(t *testing.T) {
+	older := time.Date(2016, 3, 14, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	conflicts := func() []*Change {
+		return []*Change{
+			{
+				Path:   "/a/notes.txt",
+				Parent: "/a",
+				Src:    &File{Id: "src", Name: "notes.txt", Size: 2, ModTime: newer},
+				Dest:   &File{Id: "dest", Name: "notes.txt", Size: 1, ModTime: older},
+			},
+			{
+				Path:   "/a/todo.md",
+				Parent: "/a",
+				Src:    &File{Id: "src", Name: "todo.md", Size: 2, ModTime: older},
+				Dest:   &File{Id: "dest", Name: "todo.md", Size: 1, ModTime: newer},
+			},
+		}
+	}
+	paths := func(cl []*Change) (ps []string) {
+		for _, ch := range cl {
+			ps = append(ps, ch.Path)
+		}
+		return ps
+	}
+
+	cases := []struct {
+		strategy     ConflictStrategy
+		push         bool
+		settled      []string
+		skippedCount int
+	}{
+		{ConflictNewer, true, []string{"/a/notes.txt"}, 1},
+		{ConflictLocal, true, []string{"/a/notes.txt", "/a/todo.md"}, 0},
+		{ConflictLocal, false, nil, 2},
+		{ConflictRemote, false, []string{"/a/notes.txt", "/a/todo.md"}, 0},
+		{ConflictSkip, true, nil, 2},
+	}
+	for _, ch := range conflicts() {
+		if ch.Op() != OpModConflict {
+			t.Fatalf("%s: expected a conflict, got %v", ch.Path, ch.Op())
+		}
+	}
+	for _, tc := range cases {
+		settled, skipped := settleConflicts(conflicts(), tc.strategy, tc.push)
+		if got := paths(settled); !reflect.DeepEqual(got, tc.settled) {
+			t.Errorf("strategy %d push=%v: expected %v settled, got %v", tc.strategy, tc.push, tc.settled, got)
+		}
+		if len(skipped) != tc.skippedCount {
+			t.Errorf("strategy %d push=%v: expected %d skipped, got %d", tc.strategy, tc.push, tc.skippedCount, len(skipped))
+		}
+		for _, ch := range settled {
+			if ch.Op() != OpMod {
+				t.Errorf("%s: expected a modification, got %v", ch.Path, ch.Op())
+			}
+		}
+	}
+
+	cl := conflicts()
+	settled, _ := settleConflicts(cl[:1], ConflictKeepBoth, true)
+	if len(settled) != 1 {
+		t.Fatalf("expected one addition, got %d", len(settled))
+	}
+	kept := settled[0]
+	if want := "/a/notes (local conflict 2016-03-14 010000).txt"; kept.Path != want {
+		t.Errorf("expected path %q, got %q", want, kept.Path)
+	}
+	if kept.Dest != nil || kept.Src.Id != "" || kept.Op() != OpAdd {
+		t.Errorf("expected the local copy to be added as a new file, got dest=%v id=%q op=%v", kept.Dest, kept.Src.Id, kept.Op())
+	}
+	if cl[0].Src.Name != "notes.txt" || cl[0].Src.Id != "src" {
+		t.Errorf("the conflicting change was modified: %+v", cl[0].Src)
+	}
+
+	settled, _ = settleConflicts(conflicts()[:1], ConflictKeepBoth, false)
+	if kept := settled[0]; kept.Src.Id != "src" || kept.Path != "/a/notes (remote conflict 2016-03-14 010000).txt" {
+		t.Errorf("expected the remote copy to be downloaded alongside, got id=%q path=%q", kept.Src.Id, kept.Path)
+	}
+}
+
+func TestParseConflictStrategy(t *testing.T) {
+	for cs, name := range conflictStrategyNames {
+		got, err := ParseConflictStrategy(" " + strings.ToUpper(name) + " ")
+		if err != nil || got != cs {
+			t.Errorf("%q: expected %v, got %v %v", name, cs, got, err)
+		}
+	}
+	if got, err := ParseConflictStrategy(""); err != nil || got != ConflictAbort {
+		t.Errorf("expected to abort by default, got %v %v", got, err)
+	}
+	if _, err := ParseConflictStrategy("theirs"); err == nil {
+		t.Errorf("expected an unknown strategy to fail")
+	}
+}
+
+func TestConflictReport(t *testing.T) {
+	older := time.Date(2016, 3, 14, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	local := &File{Name: "notes.txt", Md5Checksum: "aa", Size: 2, ModTime: newer}
+	remote := &File{Id: "remote-id", Name: "notes.txt", Md5Checksum: "bb", Size: 1, ModTime: older}
+
+	pushed := newConflictRecord(&Change{Path: "/a/notes.txt", Src: local, Dest: remote}, true, ConflictOutcomeAborted)
+	pulled := newConflictRecord(&Change{Path: "/b/notes.txt", Src: remote, Dest: local}, false, ConflictOutcomeSkipped)
+	for _, rec := range []*ConflictRecord{pushed, pulled} {
+		if rec.FileId != "remote-id" || rec.Local.Md5Checksum != "aa" || rec.Remote.Md5Checksum != "bb" {
+			t.Errorf("%s: sides mixed up: %+v %+v %+v", rec.Path, rec, rec.Local, rec.Remote)
+		}
+		if rec.Suggested != "local" {
+			t.Errorf("%s: expected the newer local side to be suggested, got %q", rec.Path, rec.Suggested)
+		}
+	}
+	if pushed.Command != PushKey || pulled.Command != PullKey {
+		t.Errorf("expected push and pull, got %q and %q", pushed.Command, pulled.Command)
+	}
+
+	dir, err := ioutil.TempDir("", "conflicts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "conflicts.json")
+	want := &ConflictReport{Time: newer, Conflicts: []*ConflictRecord{pushed, pulled}}
+	if err := writeConflictReport(p, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readConflictReport(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v after a round trip, got %+v", want, got)
+	}
+
+	pulled.Suggested = ""
+	if _, err := groupConflicts(want.Conflicts, ConflictAbort); err == nil {
+		t.Errorf("expected an error for a conflict without a suggested resolution")
+	}
+	groups, err := groupConflicts(append(want.Conflicts, &ConflictRecord{Command: PushKey, Path: "/c", Suggested: "remote"}), ConflictKeepBoth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected a group for pushes and one for pulls, got %d", len(groups))
+	}
+	if !groups[0].push || !reflect.DeepEqual(groups[0].paths, []string{"/a/notes.txt", "/c"}) || groups[1].push {
+		t.Errorf("expected the pushes then the pull to be grouped, got %+v %+v", groups[0], groups[1])
+	}
+}
@@ -41,6 +41,12 @@ func (g *Commands) Copy(byId bool) error {
 
 	end := argc - 1
 	sources, dest := g.opts.Sources[:end], g.opts.Sources[end]
+	if !byId {
+		var err error
+		if sources, err = g.expandRemoteGlobs(sources); err != nil {
+			return err
+		}
+	}
 
 	destFile, err := g.rem.FindByPath(dest)
 	if err != nil && err != ErrPathNotExists {
@@ -63,6 +69,8 @@ func (g *Commands) Copy(byId bool) error {
 		srcResolver = g.rem.FindById
 	}
 
+	g.permCarrier = g.newPermissionCarrier()
+
 	done := make(chan bool)
 	waitCount := uint64(0)
 
@@ -88,7 +96,7 @@ func (g *Commands) Copy(byId bool) error {
 		<-done
 	}
 
-	return nil
+	return g.reportCarriedPermissions(g.permCarrier)
 }
 
 func (g *Commands) copy(src *File, destPath string) (*File, error) {
@@ -116,14 +124,21 @@ func (g *Commands) copy(src *File, destPath string) (*File, error) {
 		if destFile != nil && destFile.IsDir {
 			parentId = destFile.Id
 			destBase = src.Name
+			destPath = sepJoin("/", destPath, src.Name)
+		}
+		copied, err := g.rem.copy(destBase, parentId, src)
+		if err == nil {
+			g.carryOver(src, copied, destPath)
 		}
-		return g.rem.copy(destBase, parentId, src)
+		return copied, err
 	}
 
 	destFile, destErr := g.remoteMkdirAll(destPath)
 	if destErr != nil {
 		return nil, destErr
 	}
+	// Carried over before the children so that they can inherit them.
+	g.carryOver(src, destFile, destPath)
 
 	pagePair := g.rem.findChildren(src.Id, false)
 
@@ -0,0 +1,162 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	expirableCache "github.com/odeke-em/cache"
+	"github.com/odeke-em/drive/config"
+)
+
+// ParseContextSpec splits a `context:path` spec e.g `~/work/gd:Reports` into
+// the local path of a drive context and a remote path within it.
+func ParseContextSpec(spec string) (contextPath, remotePath string, err error) {
+	i := strings.Index(spec, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("%q: expecting context:path", spec)
+	}
+	contextPath, remotePath = spec[:i], spec[i+1:]
+	if contextPath == "" {
+		return "", "", fmt.Errorf("%q: no context path", spec)
+	}
+	return contextPath, path.Clean(path.Join("/", remotePath)), nil
+}
+
+// CopyAcrossContexts copies the first source, in this context, to the
+// second, in toContext which may be mounted from another account. Files
+// that the other account can access are copied server-side, the rest are
+// downloaded from this account and uploaded to the other.
+func (g *Commands) CopyAcrossContexts(toContext *config.Context) error {
+	if len(g.opts.Sources) != 2 {
+		return invalidArgumentsErr(fmt.Errorf("expecting src dest got: %v", g.opts.Sources))
+	}
+	srcPath, destPath := g.opts.Sources[0], g.opts.Sources[1]
+
+	toRem, err := remoteFor(toContext)
+	if err != nil {
+		return err
+	}
+	toRem.pacer.setQPS(g.opts.QPS)
+	toRem.retry.configure(g.opts.ExponentialBackoffRetryCount, g.opts.RetryMaxWait, g.opts.RetryOn)
+	toRem.uploadThrottle = newThrottle(g.opts.BandwidthLimit)
+
+	dst := &Commands{
+		context:       toContext,
+		rem:           toRem,
+		opts:          g.opts,
+		log:           g.log,
+		summary:       g.summary,
+		mkdirAllCache: expirableCache.New(),
+	}
+
+	srcFile, err := g.rem.FindByPath(srcPath)
+	if err != nil {
+		return remoteLookupErr(fmt.Errorf("%s: %v", srcPath, err))
+	}
+
+	g.log.Logln("Processing...")
+
+	spin := g.playabler()
+	spin.play()
+	defer spin.stop()
+
+	tally := &crossCopyTally{}
+	err = g.crossCopy(dst, srcFile, destPath, tally)
+	g.summary.Logf("%d copied server-side, %d streamed\n", tally.serverSide, tally.streamed)
+	return err
+}
+
+type crossCopyTally struct {
+	serverSide uint64
+	streamed   uint64
+}
+
+func (g *Commands) crossCopy(dst *Commands, src *File, destPath string, tally *crossCopyTally) error {
+	if !src.IsDir {
+		destDir, destBase := dst.pathSplitter(destPath)
+		destParent, err := dst.remoteMkdirAll(destDir)
+		if err != nil {
+			return err
+		}
+
+		parentId := destParent.Id
+		destFile, err := dst.rem.FindByPath(destPath)
+		if err != nil && err != ErrPathNotExists {
+			return err
+		}
+		if destFile != nil && destFile.IsDir {
+			parentId = destFile.Id
+			destBase = src.Name
+		}
+
+		// The other account sees the file if it was shared with it.
+		if shared, sErr := dst.rem.FindById(src.Id); sErr == nil && shared != nil && shared.Copyable {
+			if _, err := dst.rem.copy(destBase, parentId, shared); err != nil {
+				return err
+			}
+			tally.serverSide += 1
+			return nil
+		}
+
+		if src.BlobAt == "" {
+			return illogicalStateErr(fmt.Errorf("%s can only be copied server-side, share it with the other account first", src.Name))
+		}
+		body, err := g.rem.Download(src.Id, "")
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+
+		if _, err := dst.rem.insertIntoSharedDrive(parentId, destBase, src.MimeType, body); err != nil {
+			return err
+		}
+		tally.streamed += 1
+		return nil
+	}
+
+	if _, err := dst.remoteMkdirAll(destPath); err != nil {
+		return err
+	}
+
+	pagePair := g.rem.findChildren(src.Id, false)
+
+	children := pagePair.filesChan
+	errsChan := pagePair.errsChan
+
+	var err error
+	working := true
+	for working {
+		select {
+		case pErr := <-errsChan:
+			if pErr != nil {
+				return combineErrors(err, pErr)
+			}
+		case child, stillHasContent := <-children:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			chName := sepJoin("/", destPath, child.Name)
+			if chErr := g.crossCopy(dst, child, chName, tally); chErr != nil {
+				g.log.LogErrf("copy: %s: %v\n", chName, chErr)
+				err = combineErrors(err, fmt.Errorf("%s: %v", chName, chErr))
+			}
+		}
+	}
+	return err
+}
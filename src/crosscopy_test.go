@@ -0,0 +1,38 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+)
+
+func TestParseContextSpec(t *testing.T) {
+	specs := map[string][2]string{
+		"~/work/gd:Reports":      {"~/work/gd", "/Reports"},
+		"/mnt/gd:/Archive/2024/": {"/mnt/gd", "/Archive/2024"},
+		"gd:":                    {"gd", "/"},
+	}
+	for spec, want := range specs {
+		contextPath, remotePath, err := ParseContextSpec(spec)
+		if err != nil || contextPath != want[0] || remotePath != want[1] {
+			t.Errorf("%q: got (%q, %q, %v) want %q", spec, contextPath, remotePath, err, want)
+		}
+	}
+	for _, spec := range []string{"~/work/gd", ":Reports"} {
+		if _, _, err := ParseContextSpec(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
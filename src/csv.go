@@ -0,0 +1,135 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/odeke-em/log"
+)
+
+var DefaultCSVColumns = []string{"path", "type", "size", "modTime", "owners", "shared", "id"}
+
+// csvColumns are the columns that are derived from the file.
+// Any other column is looked up among the fields returned by the API.
+var csvColumns = map[string]func(f *File, fmtdPath string) string{
+	"path": func(f *File, fmtdPath string) string { return fmtdPath },
+	"name": func(f *File, _ string) string { return f.Name },
+	"id":   func(f *File, _ string) string { return f.Id },
+	"type": func(f *File, _ string) string {
+		if f.IsDir {
+			return "folder"
+		}
+		return "file"
+	},
+	"size":     func(f *File, _ string) string { return fmt.Sprintf("%d", f.Size) },
+	"modTime":  func(f *File, _ string) string { return f.ModTime.UTC().Format(time.RFC3339) },
+	"mimeType": func(f *File, _ string) string { return f.MimeType },
+	"md5":      func(f *File, _ string) string { return f.Md5Checksum },
+	"version":  func(f *File, _ string) string { return fmt.Sprintf("%d", f.Version) },
+	"shared":   func(f *File, _ string) string { return fmt.Sprintf("%v", f.Shared) },
+	"owners":   func(f *File, _ string) string { return strings.Join(f.ownerDescriptions(), "; ") },
+	"sharedBy": func(f *File, _ string) string { return userDescription(f.SharingUser) },
+	"role": func(f *File, _ string) string {
+		if f.UserPermission == nil {
+			return ""
+		}
+		return f.UserPermission.Role
+	},
+	"url": func(f *File, _ string) string { return f.Url() },
+}
+
+// logWriter adapts a logger to an io.Writer.
+type logWriter struct {
+	logy *log.Logger
+}
+
+func (lw *logWriter) Write(p []byte) (int, error) {
+	lw.logy.Logf("%s", p)
+	return len(p), nil
+}
+
+// csvListing writes listed files as RFC 4180 records, preceded
+// by a header row naming the columns.
+type csvListing struct {
+	columns       []string
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (g *Commands) listingCSV() *csvListing {
+	if g.csvOut != nil {
+		return g.csvOut
+	}
+
+	var columns []string
+	if g.opts.Meta != nil {
+		columns = (*g.opts.Meta)[CLIOptionCSVColumns]
+	}
+	if len(columns) < 1 {
+		columns = DefaultCSVColumns
+	}
+
+	w := csv.NewWriter(&logWriter{logy: g.log})
+	// RFC 4180 records end in CRLF.
+	w.UseCRLF = true
+
+	g.csvOut = &csvListing{columns: columns, w: w}
+	return g.csvOut
+}
+
+func (cl *csvListing) record(f *File, fmtdPath string) []string {
+	var raw map[string]interface{}
+
+	record := make([]string, 0, len(cl.columns))
+	for _, column := range cl.columns {
+		if fn, ok := csvColumns[column]; ok {
+			record = append(record, fn(f, fmtdPath))
+			continue
+		}
+
+		if raw == nil {
+			raw, _ = f.rawFieldValues(nil, true)
+		}
+		switch v := raw[column].(type) {
+		case nil:
+			record = append(record, "")
+		case string:
+			record = append(record, v)
+		default:
+			blob, _ := json.Marshal(v)
+			record = append(record, string(blob))
+		}
+	}
+	return record
+}
+
+func (cl *csvListing) write(logy *log.Logger, f *File, fmtdPath string) {
+	if !cl.headerWritten {
+		cl.w.Write(cl.columns)
+		cl.headerWritten = true
+	}
+
+	cl.w.Write(cl.record(f, fmtdPath))
+	// Flushing every record keeps the output in step with pagination.
+	cl.w.Flush()
+	if err := cl.w.Error(); err != nil {
+		logy.LogErrf("%s: %v\n", fmtdPath, err)
+	}
+}
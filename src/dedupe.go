@@ -0,0 +1,256 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/odeke-em/drive/config"
+)
+
+// duplicateSet is a group of local files with the same content, the first
+// path being the one kept when the others are skipped on push.
+type duplicateSet struct {
+	md5   string
+	size  int64
+	paths []string
+}
+
+// DedupeLocal reports the sets of files with identical content within the
+// local sources. With record set, the duplicates are remembered so that
+// `push -skip-duplicates` uploads a single copy of each set.
+func (g *Commands) DedupeLocal(record bool) error {
+	var files []*File
+	for _, relToRootPath := range g.opts.Sources {
+		found, err := g.localFilesOf(relToRootPath)
+		if err != nil {
+			return err
+		}
+		files = append(files, found...)
+	}
+
+	sets := duplicateSets(files, func(f *File) string {
+		rel, _ := filepath.Rel(g.context.AbsPathOf(""), f.BlobAt)
+		return remotePathJoin("/", filepath.ToSlash(rel))
+	})
+
+	var dups []*config.LocalDuplicate
+	wasted := int64(0)
+	for i, set := range sets {
+		if i >= 1 {
+			g.log.Logln()
+		}
+		g.log.Logf("%s %s x %d\n", set.md5, prettyBytes(set.size), len(set.paths))
+		for j, p := range set.paths {
+			g.log.Logf("  %s\n", p)
+			if j >= 1 {
+				dups = append(dups, &config.LocalDuplicate{Path: p, Canonical: set.paths[0], Md5Checksum: set.md5, Size: set.size})
+				wasted += set.size
+			}
+		}
+	}
+
+	if len(sets) < 1 {
+		g.log.Logln("No duplicates found")
+	} else {
+		g.log.Logf("\n%d duplicate sets, %s in %d redundant copies\n", len(sets), prettyBytes(wasted), len(dups))
+	}
+
+	if !record {
+		return nil
+	}
+	if err := g.context.ReplaceLocalDuplicates(g.opts.Sources, dups); err != nil {
+		return err
+	}
+	if len(dups) >= 1 {
+		g.log.Logf("Recorded, `push -%s` will upload one copy of each and shortcuts for the rest\n", CLIOptionSkipDuplicates)
+	}
+	return nil
+}
+
+// localFilesOf lists the regular files within relToRootPath that would
+// otherwise be pushed, skipping hidden and ignored paths.
+func (g *Commands) localFilesOf(relToRootPath string) (files []*File, err error) {
+	fsAbsPath := g.context.AbsPathOf(relToRootPath)
+	err = filepath.Walk(fsAbsPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		skip := p != fsAbsPath && (isHidden(info.Name(), g.opts.Hidden) || anyMatch(g.opts.Ignorer, info.Name()))
+		if skip && info.IsDir() {
+			return filepath.SkipDir
+		}
+		if skip || !info.Mode().IsRegular() {
+			return nil
+		}
+		files = append(files, NewLocalFile(p, info))
+		return nil
+	})
+	return files, err
+}
+
+// duplicateSets groups files by size first, so that only those that can
+// have duplicates are checksummed. Sets are sorted by the space that their
+// duplicates take, largest first, and paths within them lexically.
+func duplicateSets(files []*File, pathOf func(*File) string) []*duplicateSet {
+	bySize := map[int64][]*File{}
+	for _, f := range files {
+		if f.Size < 1 {
+			continue
+		}
+		bySize[f.Size] = append(bySize[f.Size], f)
+	}
+
+	var sets []*duplicateSet
+	for size, sized := range bySize {
+		if len(sized) < 2 {
+			continue
+		}
+
+		byMd5 := map[string]*duplicateSet{}
+		for _, f := range sized {
+			checksum := md5Checksum(f)
+			if checksum == "" {
+				continue
+			}
+			set, ok := byMd5[checksum]
+			if !ok {
+				set = &duplicateSet{md5: checksum, size: size}
+				byMd5[checksum] = set
+			}
+			set.paths = append(set.paths, pathOf(f))
+		}
+
+		for _, set := range byMd5 {
+			if len(set.paths) >= 2 {
+				sort.Strings(set.paths)
+				sets = append(sets, set)
+			}
+		}
+	}
+
+	sort.Sort(byWastedSpace(sets))
+	return sets
+}
+
+type byWastedSpace []*duplicateSet
+
+func (ds byWastedSpace) wasted(i int) int64 {
+	return ds[i].size * int64(len(ds[i].paths)-1)
+}
+
+func (ds byWastedSpace) Less(i, j int) bool {
+	if wi, wj := ds.wasted(i), ds.wasted(j); wi != wj {
+		return wi > wj
+	}
+	return ds[i].paths[0] < ds[j].paths[0]
+}
+
+func (ds byWastedSpace) Len() int      { return len(ds) }
+func (ds byWastedSpace) Swap(i, j int) { ds[i], ds[j] = ds[j], ds[i] }
+
+// skippedDuplicate is a duplicate left out of a push, to be replaced by a
+// shortcut to the remote copy of its canonical file.
+type skippedDuplicate struct {
+	path      string
+	name      string
+	canonical string
+}
+
+// skipLocalDuplicates takes out of cl the additions of recorded duplicates
+// whose content is still that of their canonical copy, as long as that
+// copy is either already remote or pushed along. They are returned so
+// that shortcuts to the canonical copies are made for them once pushed.
+func (g *Commands) skipLocalDuplicates(cl []*Change) (kept []*Change, skipped []*skippedDuplicate, err error) {
+	dups, err := g.context.LocalDuplicates()
+	if err != nil || len(dups) < 1 {
+		return cl, nil, err
+	}
+
+	rootAbsPath := g.context.AbsPathOf("")
+	relToRootOf := func(f *File) string {
+		rel, _ := filepath.Rel(rootAbsPath, f.BlobAt)
+		return remotePathJoin("/", filepath.ToSlash(rel))
+	}
+
+	pushed := map[string]bool{}
+	for _, c := range cl {
+		if c != nil && c.Src != nil && !c.Src.IsDir && c.Src.BlobAt != "" {
+			pushed[relToRootOf(c.Src)] = true
+		}
+	}
+
+	for _, c := range cl {
+		if c == nil || c.Op() != OpAdd || c.Src == nil || c.Src.IsDir || c.Src.BlobAt == "" {
+			kept = append(kept, c)
+			continue
+		}
+
+		relToRoot := relToRootOf(c.Src)
+		dup, ok := dups[relToRoot]
+		if !ok || dup.Size != c.Src.Size || md5Checksum(c.Src) != dup.Md5Checksum {
+			kept = append(kept, c)
+			continue
+		}
+
+		// The canonical copy is expected at the same place relative to the
+		// destination that the duplicate is pushed to.
+		canonicalPath := strings.TrimSuffix(c.Path, relToRoot) + dup.Canonical
+		if !pushed[dup.Canonical] {
+			target, tErr := g.rem.FindByPath(canonicalPath)
+			if tErr != nil || target == nil || target.Md5Checksum != dup.Md5Checksum {
+				kept = append(kept, c)
+				continue
+			}
+		}
+
+		skipped = append(skipped, &skippedDuplicate{path: c.Path, name: c.Src.Name, canonical: canonicalPath})
+	}
+	return kept, skipped, nil
+}
+
+// pushDuplicateShortcuts creates, for each skipped duplicate or symlink, a
+// shortcut in its place pointing at the remote copy of its canonical file
+// or target.
+func (g *Commands) pushDuplicateShortcuts(skipped []*skippedDuplicate) (err error) {
+	for _, sd := range skipped {
+		target, tErr := g.rem.FindByPath(sd.canonical)
+		if tErr == nil && target == nil {
+			tErr = ErrPathNotExists
+		}
+		if tErr != nil {
+			err = combineErrors(err, fmt.Errorf("%s: shortcut target %s: %v", sd.path, sd.canonical, tErr))
+			continue
+		}
+
+		parent, pErr := g.remoteMkdirAll(g.parentPather(sd.path))
+		if pErr != nil {
+			err = combineErrors(err, fmt.Errorf("%s: %v", sd.path, pErr))
+			continue
+		}
+
+		if _, sErr := g.rem.createShortcut(parent.Id, sd.name, target.Id); sErr != nil {
+			err = combineErrors(err, fmt.Errorf("%s: shortcut: %v", sd.path, sErr))
+			continue
+		}
+		g.log.Logf("%s -> %s\n", sd.path, sd.canonical)
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDuplicateSets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-dedupe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	contents := map[string]string{
+		"a.txt":   "same",
+		"b.txt":   "same",
+		"c/d.txt": "same",
+		"e.txt":   "diff",
+		"f.txt":   "longer content",
+		"g.txt":   "longer content",
+		"h.txt":   "",
+		"i.txt":   "",
+	}
+	var files []*File
+	for name, content := range contents {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, &File{Name: filepath.Base(p), BlobAt: p, Size: int64(len(content))})
+	}
+
+	sets := duplicateSets(files, func(f *File) string {
+		rel, _ := filepath.Rel(dir, f.BlobAt)
+		return filepath.ToSlash(rel)
+	})
+
+	var got [][]string
+	for _, set := range sets {
+		got = append(got, set.paths)
+	}
+	want := [][]string{{"f.txt", "g.txt"}, {"a.txt", "b.txt", "c/d.txt"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -33,6 +33,7 @@ var Ruler = strings.Repeat("*", 4)
 const (
 	DiffNone = 1 << iota
 	DiffUnified
+	DiffMetadata
 )
 
 type diffSt struct {
@@ -47,15 +48,25 @@ type diffSt struct {
 	// baseLocal when set uses local as the base
 	// otherwise remote is used as the base.
 	baseLocal bool
+	// metadata when set also reports how the remote's metadata
+	// has drifted from what it was when last synced.
+	metadata bool
 }
 
 func (d diffSt) unified() bool {
 	return (d.mask & DiffUnified) != 0
 }
 
+func metadataDiffed(mask int) bool {
+	return (mask & DiffMetadata) != 0
+}
+
 func (g *Commands) Diff() (err error) {
 	var cl []*Change
 
+	metadata := metadataDiffed(g.opts.TypeMask)
+	g.keepUnchanged = metadata
+
 	spin := g.playabler()
 	spin.play()
 	defer spin.stop()
@@ -102,6 +113,7 @@ func (g *Commands) Diff() (err error) {
 		mask:         g.opts.TypeMask,
 		printRuler:   len(cl) > 1,
 		baseLocal:    g.opts.BaseLocal,
+		metadata:     metadata,
 	}
 
 	metaPtr := g.opts.Meta
@@ -135,6 +147,16 @@ func (g *Commands) perDiff(dSt diffSt) (err error) {
 		return illogicalStateErr(fmt.Errorf("%s only on remote", change.Path))
 	}
 
+	if dSt.metadata {
+		if err := g.diffMetadata(change.Path, r); err != nil {
+			return err
+		}
+		// Only kept for its metadata.
+		if change.Op() == OpNone {
+			return nil
+		}
+	}
+
 	// Pre-screening phase
 	if r.IsDir && l.IsDir {
 		// Note that if they are both directories, comparing times is spurious
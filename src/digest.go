@@ -0,0 +1,179 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultWatchSMTP = "localhost:25"
+)
+
+// WatchDigest sums up the changes in the watched folders over a period,
+// each file only once with the most notable of its events.
+type WatchDigest struct {
+	Since   time.Time     `json:"since"`
+	Until   time.Time     `json:"until"`
+	Changes []*WatchAlert `json:"changes"`
+}
+
+type watchDigest struct {
+	period   time.Duration
+	webhook  string
+	emails   []string
+	smtpAddr string
+
+	since   time.Time
+	changes []*WatchAlert
+	byId    map[string]*WatchAlert
+}
+
+func (g *Commands) watchDigest() (*watchDigest, error) {
+	if g.opts.Meta == nil {
+		return nil, nil
+	}
+	meta := *g.opts.Meta
+
+	periodL := meta[WatchDigestKey]
+	if len(periodL) < 1 || periodL[0] == "" {
+		return nil, nil
+	}
+	period, err := parseAgeDuration(periodL[0])
+	if err == nil && period < time.Minute {
+		err = fmt.Errorf("%q is less than a minute", periodL[0])
+	}
+	if err != nil {
+		return nil, invalidArgumentsErr(fmt.Errorf("--%s: %v", WatchDigestKey, err))
+	}
+
+	d := &watchDigest{
+		period:   period,
+		emails:   meta[WatchDigestEmailKey],
+		smtpAddr: DefaultWatchSMTP,
+	}
+	if webhook := meta[WatchDigestWebhookKey]; len(webhook) >= 1 {
+		d.webhook = webhook[0]
+	}
+	if smtpAddr := meta[WatchSMTPKey]; len(smtpAddr) >= 1 && smtpAddr[0] != "" {
+		d.smtpAddr = smtpAddr[0]
+	}
+	if d.webhook == "" && len(d.emails) < 1 {
+		return nil, invalidArgumentsErr(fmt.Errorf("--%s: expecting --%s or --%s to send digests to", WatchDigestKey, WatchDigestWebhookKey, WatchDigestEmailKey))
+	}
+	if len(d.emails) >= 1 && os.Getenv(DriveSMTPUserEnvKey) == "" {
+		return nil, invalidArgumentsErr(fmt.Errorf("--%s: $%s must be set to the sender's address", WatchDigestEmailKey, DriveSMTPUserEnvKey))
+	}
+
+	d.reset(time.Now())
+	return d, nil
+}
+
+func (d *watchDigest) reset(now time.Time) {
+	d.since = now.UTC()
+	d.changes = nil
+	d.byId = map[string]*WatchAlert{}
+}
+
+// record adds a change to the digest. A file changed more than once is
+// reported once, by its latest change unless an earlier one was more notable.
+func (d *watchDigest) record(alert *WatchAlert) {
+	if d == nil {
+		return
+	}
+	if alert.Time.IsZero() {
+		alert.Time = time.Now().UTC()
+	}
+
+	prev, ok := d.byId[alert.Id]
+	if !ok {
+		d.byId[alert.Id] = alert
+		d.changes = append(d.changes, alert)
+		return
+	}
+	event := prev.Event
+	if event == WatchModifiedEvent {
+		event = alert.Event
+	}
+	*prev = *alert
+	prev.Event = event
+}
+
+func (d *watchDigest) due(now time.Time) bool {
+	return d != nil && now.Sub(d.since) >= d.period
+}
+
+// flush sends off the digest if anything changed, starting the next period.
+func (w *watcher) flushDigest(now time.Time) {
+	d := w.digest
+	digest := &WatchDigest{Since: d.since, Until: now.UTC(), Changes: d.changes}
+	d.reset(now)
+	if len(digest.Changes) < 1 {
+		return
+	}
+
+	if d.webhook != "" {
+		if err := w.post(d.webhook, digest); err != nil {
+			w.g.log.LogErrf("watch: digest webhook %s: %v\n", d.webhook, err)
+		}
+	}
+	if len(d.emails) >= 1 {
+		if err := d.mail(digest); err != nil {
+			w.g.log.LogErrf("watch: digest email: %v\n", err)
+		}
+	}
+}
+
+func (d *watchDigest) mail(digest *WatchDigest) error {
+	from := os.Getenv(DriveSMTPUserEnvKey)
+
+	var auth smtp.Auth
+	if password := os.Getenv(DriveSMTPPasswordEnvKey); password != "" {
+		host, _, err := net.SplitHostPort(d.smtpAddr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", from, password, host)
+	}
+
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", from)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(d.emails, ", "))
+	fmt.Fprintf(msg, "Subject: drive digest: %d change(s) in watched folders\r\n", len(digest.Changes))
+	fmt.Fprintf(msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.Replace(digestText(digest), "\n", "\r\n", -1))
+
+	return smtp.SendMail(d.smtpAddr, auth, from, d.emails, msg.Bytes())
+}
+
+// digestText is the plain text rendition of a digest, for emails.
+func digestText(digest *WatchDigest) string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Changes from %s to %s:\n\n", digest.Since.Format(time.RFC3339), digest.Until.Format(time.RFC3339))
+	for _, change := range digest.Changes {
+		fmt.Fprintf(buf, "%s %-11s %s", change.Time.Format(time.RFC3339), change.Event, change.Path)
+		if change.User != "" {
+			fmt.Fprintf(buf, " by %s", change.User)
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,58 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchDigestRecord(t *testing.T) {
+	at := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	d := &watchDigest{period: time.Hour}
+	d.reset(at)
+
+	d.record(&WatchAlert{Event: WatchModifiedEvent, Path: "/Shared/a", Id: "a", Time: at})
+	d.record(&WatchAlert{Event: WatchTrashedEvent, Path: "/Shared/b", Id: "b", Time: at})
+	d.record(&WatchAlert{Event: WatchModifiedEvent, Path: "/Shared/b", Id: "b", Time: at.Add(time.Minute), User: "Ann <ann@example.com>"})
+
+	if len(d.changes) != 2 {
+		t.Fatalf("expected each file once, got %d changes", len(d.changes))
+	}
+	if b := d.changes[1]; b.Event != WatchTrashedEvent || b.User == "" {
+		t.Errorf("expected the latest change to keep the more notable event, got %+v", b)
+	}
+
+	if d.due(at.Add(59 * time.Minute)) {
+		t.Errorf("expected the digest not to be due before its period")
+	}
+	if !d.due(at.Add(time.Hour)) {
+		t.Errorf("expected the digest to be due after its period")
+	}
+
+	want := "Changes from 2016-08-01T10:00:00Z to 2016-08-01T11:00:00Z:\n\n" +
+		"2016-08-01T10:00:00Z modified    /Shared/a\n" +
+		"2016-08-01T10:01:00Z trashed     /Shared/b by Ann <ann@example.com>\n"
+	got := digestText(&WatchDigest{Since: at, Until: at.Add(time.Hour), Changes: d.changes})
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+
+	var nilDigest *watchDigest
+	nilDigest.record(&WatchAlert{Id: "c"})
+	if nilDigest.due(at) {
+		t.Errorf("expected no digest to never be due")
+	}
+}
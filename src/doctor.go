@@ -0,0 +1,277 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	GoogleAPIsURL        = "https://www.googleapis.com"
+	GoogleTokenInfoURL   = "https://www.googleapis.com/oauth2/v3/tokeninfo"
+	DriveAboutURL        = "https://www.googleapis.com/drive/v2/about"
+	DriveAPIConsoleURL   = "https://console.developers.google.com/apis/api/drive/overview"
+	MaxTolerantClockSkew = 5 * time.Minute
+)
+
+// diagnostic is a single check run by `drive doctor`. On failure
+// it returns the error and a suggested fix for the user.
+type diagnostic struct {
+	name string
+	fn   func(g *Commands) (detail, fix string, err error)
+}
+
+var diagnostics = []*diagnostic{
+	{name: "connectivity", fn: diagnoseConnectivity},
+	{name: "clock-skew", fn: diagnoseClockSkew},
+	{name: "credentials", fn: diagnoseCredentials},
+	{name: "scopes", fn: diagnoseScopes},
+	{name: "drive-api", fn: diagnoseAPIEnablement},
+	{name: "quota", fn: diagnoseQuota},
+	{name: "compression", fn: diagnoseCompression},
+	{name: "index", fn: diagnoseIndex},
+}
+
+func (g *Commands) Doctor() error {
+	failures := 0
+	for _, d := range diagnostics {
+		detail, fix, err := d.fn(g)
+		if err == nil {
+			g.log.Logf("[ok]    %-14s %s\n", d.name, detail)
+			continue
+		}
+
+		failures += 1
+		g.log.LogErrf("[fail]  %-14s %v\n", d.name, err)
+		if fix != "" {
+			g.log.LogErrf("        fix: %s\n", fix)
+		}
+	}
+
+	if failures >= 1 {
+		return diagnosticsFailedErr(fmt.Errorf("doctor: %d/%d checks failed", failures, len(diagnostics)))
+	}
+
+	return nil
+}
+
+func probeGoogleAPIs() (*http.Response, *url.URL, error) {
+	req, err := http.NewRequest("HEAD", GoogleAPIsURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proxyURL, _ := http.ProxyFromEnvironment(req)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, proxyURL, err
+	}
+	res.Body.Close()
+
+	return res, proxyURL, nil
+}
+
+func diagnoseConnectivity(g *Commands) (detail, fix string, err error) {
+	_, proxyURL, err := probeGoogleAPIs()
+	if err != nil {
+		fix = "check your network connection and DNS settings"
+		if proxyURL != nil {
+			fix = fmt.Sprintf("ensure that proxy %q is reachable or unset HTTPS_PROXY/HTTP_PROXY", proxyURL)
+		}
+		return "", fix, netLookupFailedErr(err)
+	}
+
+	detail = fmt.Sprintf("%s reachable directly", GoogleAPIsURL)
+	if proxyURL != nil {
+		detail = fmt.Sprintf("%s reachable via proxy %q", GoogleAPIsURL, proxyURL)
+	}
+	return detail, "", nil
+}
+
+func diagnoseClockSkew(g *Commands) (detail, fix string, err error) {
+	res, _, err := probeGoogleAPIs()
+	if err != nil {
+		return "", "fix connectivity first", netLookupFailedErr(err)
+	}
+
+	serverTime, err := http.ParseTime(res.Header.Get("Date"))
+	if err != nil {
+		return "", "", fmt.Errorf("could not parse server time: %v", err)
+	}
+
+	skew := time.Now().Sub(serverTime)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if skew > MaxTolerantClockSkew {
+		fix = "synchronize your system clock e.g enable NTP; OAuth tokens are rejected when clocks drift"
+		return "", fix, fmt.Errorf("local clock is off by %v", skew)
+	}
+
+	return fmt.Sprintf("local clock is within %v of the server", skew.Round(time.Second)), "", nil
+}
+
+func diagnoseCredentials(g *Commands) (detail, fix string, err error) {
+	if g.context.GSAJWTConfig == nil && g.context.RefreshToken == "" {
+		return "", fmt.Sprintf("run `drive %s` to authenticate", InitKey), fmt.Errorf("no refresh token found")
+	}
+
+	if _, err = g.rem.accessToken(); err != nil {
+		fix = fmt.Sprintf("your credentials may have been revoked or expired, re-run `drive %s`", InitKey)
+		return "", fix, makeError(err, StatusAuthenticationFailed)
+	}
+
+	if g.context.GSAJWTConfig != nil {
+		return "service account token obtained", "", nil
+	}
+	return "access token obtained", "", nil
+}
+
+func diagnoseScopes(g *Commands) (detail, fix string, err error) {
+	token, err := g.rem.accessToken()
+	if err != nil {
+		return "", "fix credentials first", makeError(err, StatusAuthenticationFailed)
+	}
+
+	res, err := http.Get(fmt.Sprintf("%s?access_token=%s", GoogleTokenInfoURL, url.QueryEscape(token.AccessToken)))
+	if err != nil {
+		return "", "", netLookupFailedErr(err)
+	}
+	defer res.Body.Close()
+
+	info := struct {
+		Scope string `json:"scope"`
+	}{}
+	if err = json.NewDecoder(res.Body).Decode(&info); err != nil {
+		return "", "", err
+	}
+
+	scopes := strings.Fields(info.Scope)
+	for _, scope := range scopes {
+		if scope == DriveScope {
+			return fmt.Sprintf("token grants %q", DriveScope), "", nil
+		}
+	}
+
+	fix = fmt.Sprintf("re-run `drive %s` and grant full Drive access", InitKey)
+	return "", fix, fmt.Errorf("token lacks scope %q, has %v", DriveScope, scopes)
+}
+
+func diagnoseAPIEnablement(g *Commands) (detail, fix string, err error) {
+	about, err := g.rem.About()
+	if err == nil {
+		return fmt.Sprintf("Drive API enabled, signed in as %q", about.Name), "", nil
+	}
+
+	gErr, ok := err.(*googleapi.Error)
+	if !ok || gErr.Code != http.StatusForbidden {
+		return "", "", err
+	}
+
+	for _, item := range gErr.Errors {
+		if item.Reason == "accessNotConfigured" {
+			fix = fmt.Sprintf("enable the Drive API for your client id at %s", DriveAPIConsoleURL)
+			return "", fix, err
+		}
+	}
+
+	return "", "check that your account has access to Google Drive", err
+}
+
+func diagnoseQuota(g *Commands) (detail, fix string, err error) {
+	status, err := g.QuotaStatus(0)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch status {
+	case Exceeded:
+		fix = fmt.Sprintf("free up space e.g `drive %s`", EmptyTrashKey)
+		return "", fix, fmt.Errorf("quota exceeded")
+	case AlmostExceeded:
+		return "warning: over 80% of quota used", "", nil
+	case HalfwayExceeded:
+		return "over 50% of quota used", "", nil
+	}
+
+	return "plenty of space available", "", nil
+}
+
+func diagnoseCompression(g *Commands) (detail, fix string, err error) {
+	if !compressionEnabled() {
+		return fmt.Sprintf("disabled by %s", DriveNoGzipEnvKey), "", nil
+	}
+
+	res, err := g.rem.client.Get(DriveAboutURL + "?fields=kind")
+	if err != nil {
+		return "", "fix connectivity and credentials first", err
+	}
+	res.Body.Close()
+
+	// net/http only marks a response as Uncompressed after un-gzipping it.
+	if !res.Uncompressed {
+		fix = "ensure that any proxies between you and Google don't strip Content-Encoding"
+		return "", fix, fmt.Errorf("metadata responses are not gzip compressed")
+	}
+
+	return "metadata responses are gzip compressed", "", nil
+}
+
+func diagnoseIndex(g *Commands) (detail, fix string, err error) {
+	keysChan, err := g.context.ListKeys("", config.IndicesKey)
+	if err != nil {
+		return "", fmt.Sprintf("ensure %q is readable and not locked by another drive process", config.DbSuffixedPath(g.context.AbsPath)), err
+	}
+
+	// Drain all keys first since the db is held open until the listing completes.
+	var keys []string
+	for key := range keysChan {
+		keys = append(keys, key)
+	}
+
+	corrupt := 0
+	for _, key := range keys {
+		if _, dErr := g.context.DeserializeIndex(key); dErr != nil {
+			corrupt += 1
+		}
+	}
+
+	if corrupt >= 1 {
+		fix = fmt.Sprintf("run `drive %s %s` to repair the indices", IndexKey, IndexFsckKey)
+		return "", fix, fmt.Errorf("%d/%d indices are unreadable", corrupt, len(keys))
+	}
+
+	return fmt.Sprintf("%d indices healthy", len(keys)), "", nil
+}
+
+func (r *Remote) accessToken() (*oauth2.Token, error) {
+	transport, ok := r.client.Transport.(*oauth2.Transport)
+	if !ok || transport.Source == nil {
+		return nil, fmt.Errorf("client has no token source")
+	}
+	return transport.Source.Token()
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"github.com/odeke-em/log"
+)
+
+// diskUsage tallies the cumulative size of a folder for `du`, rolling
+// each file's size up into every folder above it.
+type diskUsage struct {
+	parent *diskUsage
+	size   int64
+	// quiet is set for folders beyond the requested depth,
+	// whose contents are tallied but not printed.
+	quiet bool
+}
+
+// newDiskUsage returns the grand total that the sources of
+// a listing roll up into, if only disk usage is being listed.
+func (g *Commands) newDiskUsage() *diskUsage {
+	if !diskUsageOnly(g.opts.TypeMask) {
+		return nil
+	}
+	return &diskUsage{}
+}
+
+// SharedDrivesDir is the folder that Shared Drives are listed under.
+const SharedDrivesDir = "Shared drives"
+
+// usageSize is the size of f, estimated from the storage it uses for
+// Google-native docs, which have no size of their own.
+func (f *File) usageSize() int64 {
+	if f.Size > 0 || !hasExportLinks(f) {
+		return f.Size
+	}
+	return f.QuotaBytesUsed
+}
+
+func (du *diskUsage) child() *diskUsage {
+	if du == nil {
+		return nil
+	}
+	return &diskUsage{parent: du, quiet: du.quiet}
+}
+
+func (du *diskUsage) add(size int64) {
+	for ; du != nil; du = du.parent {
+		du.size += size
+	}
+}
+
+// prints reports whether f should be printed as it is listed.
+// Folders are only printed once all their contents are tallied.
+func (du *diskUsage) prints(f *File) bool {
+	return du == nil || (!du.quiet && !f.IsDir)
+}
+
+// done prints the cumulative size of the folder at fmtdPath
+// unless the folder itself is beyond the requested depth.
+func (du *diskUsage) done(logy *log.Logger, fmtdPath string) {
+	if du.parent != nil && du.parent.quiet {
+		return
+	}
+	if fmtdPath == "" {
+		fmtdPath = "/"
+	}
+	logy.Logf("%-12v %s\n", du.size, fmtdPath)
+}
+
+func (du *diskUsage) total(logy *log.Logger) {
+	if du == nil {
+		return
+	}
+	logy.Logf("%-12v %s\n", du.size, "total")
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+)
+
+func TestDiskUsageRollsUp(t *testing.T) {
+	total := &diskUsage{}
+	photos := total.child()
+	album := photos.child()
+
+	photos.add(10)
+	album.add(5)
+	album.add(7)
+
+	if album.size != 12 || photos.size != 22 || total.size != 22 {
+		t.Errorf("expected 12, 22 and 22, got %d, %d and %d", album.size, photos.size, total.size)
+	}
+
+	photos.quiet = true
+	if child := photos.child(); !child.quiet {
+		t.Errorf("expected folders beyond the depth to stay quiet")
+	}
+	if photos.prints(&File{}) || (&diskUsage{}).prints(&File{IsDir: true}) {
+		t.Errorf("expected neither quiet files nor folders to be printed as listed")
+	}
+	if !(*diskUsage)(nil).prints(&File{IsDir: true}) {
+		t.Errorf("expected everything to be printed outside of du")
+	}
+}
+
+func TestUsageSize(t *testing.T) {
+	doc := &File{QuotaBytesUsed: 2048, ExportLinks: map[string]string{"application/pdf": "https://example.com/export"}}
+	if got := doc.usageSize(); got != 2048 {
+		t.Errorf("doc: got %d want 2048", got)
+	}
+	blob := &File{Size: 512, QuotaBytesUsed: 1024}
+	if got := blob.usageSize(); got != 512 {
+		t.Errorf("blob: got %d want 512", got)
+	}
+	folder := &File{IsDir: true, QuotaBytesUsed: 1024}
+	if got := folder.usageSize(); got != 0 {
+		t.Errorf("folder: got %d want 0", got)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DuplicateTitlePolicy is how a push picks which of several remote
+// entries with the same name as a local file to update.
+type DuplicateTitlePolicy uint8
+
+const (
+	// DuplicatesClash reports same-named entries as name clashes.
+	DuplicatesClash DuplicateTitlePolicy = iota
+	// DuplicatesError aborts the push at the first same-named entries.
+	DuplicatesError
+	// DuplicatesNewest updates the most recently modified entry.
+	DuplicatesNewest
+	// DuplicatesByIndex updates the only entry that was synced
+	// before, as recorded in the index.
+	DuplicatesByIndex
+)
+
+var duplicateTitlePolicyNames = map[DuplicateTitlePolicy]string{
+	DuplicatesError:   "error",
+	DuplicatesNewest:  "update-newest",
+	DuplicatesByIndex: "update-by-id",
+}
+
+// ParseDuplicateTitlePolicy parses one of error, update-newest or
+// update-by-id, nothing standing for reporting name clashes.
+func ParseDuplicateTitlePolicy(s string) (DuplicateTitlePolicy, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return DuplicatesClash, nil
+	}
+	for policy, name := range duplicateTitlePolicyNames {
+		if name == s {
+			return policy, nil
+		}
+	}
+	return DuplicatesClash, fmt.Errorf("unknown policy %q, expecting error, update-newest or update-by-id", s)
+}
+
+// duplicatePicker returns what picks the remote entry that a push updates
+// out of same-named candidates, nil if clashes are to be reported instead.
+func (g *Commands) duplicatePicker(push bool) func(string, []*File) (*File, error) {
+	if !push || g.opts.DuplicateTitles == DuplicatesClash {
+		return nil
+	}
+	return g.pickDuplicate
+}
+
+func (g *Commands) pickDuplicate(p string, candidates []*File) (*File, error) {
+	var chosen *File
+	switch g.opts.DuplicateTitles {
+	case DuplicatesNewest:
+		chosen = newestOf(candidates)
+	case DuplicatesByIndex:
+		for _, c := range candidates {
+			if index, _ := g.context.DeserializeIndex(c.Id); index == nil {
+				continue
+			}
+			if chosen != nil {
+				return nil, clashesDetectedErr(fmt.Errorf("%s: %d same-named entries, more than one of them indexed", p, len(candidates)))
+			}
+			chosen = c
+		}
+		if chosen == nil {
+			return nil, clashesDetectedErr(fmt.Errorf("%s: %d same-named entries, none of them indexed", p, len(candidates)))
+		}
+	default:
+		return nil, clashesDetectedErr(fmt.Errorf("%s: %d same-named entries", p, len(candidates)))
+	}
+
+	if g.opts.Verbose {
+		g.log.Logf("%s: updating %s of %d same-named entries\n", p, chosen.Id, len(candidates))
+	}
+	return chosen, nil
+}
+
+// newestOf returns the most recently modified of files, the first
+// of them on ties.
+func newestOf(files []*File) (newest *File) {
+	for _, f := range files {
+		if newest == nil || f.ModTime.After(newest.ModTime) {
+			newest = f
+		}
+	}
+	return newest
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDuplicateTitlePolicy(t *testing.T) {
+	policies := map[string]DuplicateTitlePolicy{
+		"":               DuplicatesClash,
+		"error":          DuplicatesError,
+		"Update-Newest ": DuplicatesNewest,
+		"update-by-id":   DuplicatesByIndex,
+	}
+	for spec, want := range policies {
+		if got, err := ParseDuplicateTitlePolicy(spec); err != nil || got != want {
+			t.Errorf("%q: got (%v, %v) want %v", spec, got, err, want)
+		}
+	}
+	if _, err := ParseDuplicateTitlePolicy("oldest"); err == nil {
+		t.Errorf("expected an unknown policy to be rejected")
+	}
+}
+
+func TestMergePicksDuplicates(t *testing.T) {
+	now := time.Now()
+	remotes := []*File{
+		{Id: "old", Name: "report.pdf", ModTime: now.Add(-time.Hour)},
+		{Id: "new", Name: "report.pdf", ModTime: now},
+		{Id: "only", Name: "notes.txt", ModTime: now},
+	}
+	pagePair := func() *paginationPair {
+		errsChan := make(chan error)
+		filesChan := make(chan *File)
+		go func() {
+			for _, r := range remotes {
+				filesChan <- r
+			}
+			close(filesChan)
+			close(errsChan)
+		}()
+		return &paginationPair{errsChan: errsChan, filesChan: filesChan}
+	}
+	locals := func() chan *File {
+		localsChan := make(chan *File, 2)
+		localsChan <- &File{Name: "report.pdf"}
+		localsChan <- &File{Name: "notes.txt"}
+		close(localsChan)
+		return localsChan
+	}
+
+	_, clashes, err := merge(pagePair(), locals(), false, nil)
+	if err != nil || len(clashes) != 2 {
+		t.Errorf("without picking, expected 2 clashes got %d, err %v", len(clashes), err)
+	}
+
+	var picked []string
+	pick := func(name string, candidates []*File) (*File, error) {
+		picked = append(picked, name)
+		return newestOf(candidates), nil
+	}
+	merged, clashes, err := merge(pagePair(), locals(), false, pick)
+	if err != nil || len(clashes) != 0 {
+		t.Fatalf("expected no clashes got %d, err %v", len(clashes), err)
+	}
+	if !reflect.DeepEqual(picked, []string{"report.pdf"}) {
+		t.Errorf("expected only report.pdf to be picked, got %v", picked)
+	}
+	pairs := map[string]string{}
+	for _, dl := range merged {
+		if dl.remote != nil && dl.local != nil {
+			pairs[dl.local.Name] = dl.remote.Id
+		}
+	}
+	if want := map[string]string{"report.pdf": "new", "notes.txt": "only"}; !reflect.DeepEqual(pairs, want) || len(merged) != 2 {
+		t.Errorf("got pairs %v from %d entries want %v", pairs, len(merged), want)
+	}
+}
@@ -42,6 +42,12 @@ const (
 	StatusContentTooLarge             ErrorStatus = 23
 	StatusClashesFixed                ErrorStatus = 24
 	StatusSecurityException           ErrorStatus = 25
+	StatusDiagnosticsFailed           ErrorStatus = 26
+	StatusAccessDenied                ErrorStatus = 27
+	StatusPermanentDeletionRefused    ErrorStatus = 28
+	StatusLeaseHeld                   ErrorStatus = 29
+	StatusInterrupted                 ErrorStatus = 30
+	StatusEmptyFolders                ErrorStatus = 31
 )
 
 type Error struct {
@@ -157,3 +163,27 @@ func contentTooLargeErr(err error) *Error {
 func clashesFixedErr(err error) *Error {
 	return makeError(err, StatusClashesFixed)
 }
+
+func diagnosticsFailedErr(err error) *Error {
+	return makeError(err, StatusDiagnosticsFailed)
+}
+
+func accessDeniedErr(err error) *Error {
+	return makeError(err, StatusAccessDenied)
+}
+
+func permanentDeletionRefusedErr(err error) *Error {
+	return makeError(err, StatusPermanentDeletionRefused)
+}
+
+func leaseHeldErr(err error) *Error {
+	return makeError(err, StatusLeaseHeld)
+}
+
+func interruptedErr(err error) *Error {
+	return makeError(err, StatusInterrupted)
+}
+
+func emptyFoldersErr(err error) *Error {
+	return makeError(err, StatusEmptyFolders)
+}
@@ -0,0 +1,45 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+)
+
+// Exists succeeds only if every source exists remotely. A missing source
+// exits with status 1 while a failed lookup has a status of its own,
+// so that scripts can tell them apart.
+func (g *Commands) Exists(byId bool) error {
+	if len(g.opts.Sources) < 1 {
+		return invalidArgumentsErr(fmt.Errorf("exists: expecting a path or id"))
+	}
+
+	var missing []string
+	for _, source := range g.opts.Sources {
+		exists, err := g.rem.Exists(source, byId)
+		if err != nil {
+			return remoteLookupErr(fmt.Errorf("%s: %v", source, err))
+		}
+		if !exists {
+			missing = append(missing, fmt.Sprintf("%s: %v", customQuote(source), ErrPathNotExists))
+		}
+	}
+
+	if len(missing) < 1 {
+		return nil
+	}
+
+	return makeError(reComposeError(nil, missing...), StatusGeneric)
+}
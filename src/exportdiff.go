@@ -0,0 +1,273 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/odeke-em/drive/config"
+	drive "google.golang.org/api/drive/v2"
+)
+
+// exportRecord is printed, as a line of JSON, for each Google Doc exported
+// because its revision changed since it was last exported.
+type exportRecord struct {
+	Id               string            `json:"id"`
+	Path             string            `json:"path"`
+	MimeType         string            `json:"mime_type"`
+	Revision         string            `json:"revision"`
+	PreviousRevision string            `json:"previous_revision,omitempty"`
+	ModifiedTime     time.Time         `json:"modified_time"`
+	Exports          []*exportedFormat `json:"exports"`
+}
+
+// exportedFormat is either written to Path or, when piped, inlined as
+// Content, or as ContentBase64 if it isn't text.
+type exportedFormat struct {
+	Format        string `json:"format"`
+	MimeType      string `json:"mime_type"`
+	Path          string `json:"path,omitempty"`
+	Content       string `json:"content,omitempty"`
+	ContentBase64 string `json:"content_base64,omitempty"`
+}
+
+// latestRevisionId returns the id of the most recently modified revision,
+// or the file's version if Drive kept no revisions for it.
+func latestRevisionId(revs []*drive.Revision, version int64) string {
+	var latest *drive.Revision
+	var latestTime time.Time
+	for _, rev := range revs {
+		if rev == nil {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, rev.ModifiedDate)
+		if err != nil {
+			continue
+		}
+		if latest == nil || !t.Before(latestTime) {
+			latest, latestTime = rev, t
+		}
+	}
+	if latest == nil {
+		return fmt.Sprintf("v%d", version)
+	}
+	return latest.Id
+}
+
+// PullChangedExports exports the Google Docs among the sources, and within
+// them if they are folders, whose revision changed since they were last
+// exported this way. A JSON object describing each export is printed.
+func (g *Commands) PullChangedExports(byId bool) (err error) {
+	if len(g.opts.Exports) < 1 {
+		return invalidArgumentsErr(fmt.Errorf("-%s needs formats to export to with -%s", CLIOptionExportIfChanged, ExportsKey))
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, source := range g.opts.Sources {
+		var f *File
+		var fErr error
+		relToRoot := source
+		if byId {
+			f, fErr = g.rem.FindById(source)
+			if fErr == nil && f != nil {
+				relToRoot = remotePathJoin("/", f.Name)
+			}
+		} else {
+			f, fErr = g.rem.FindByPath(source)
+		}
+		if fErr == nil && f == nil {
+			fErr = ErrPathNotExists
+		}
+		if fErr != nil {
+			err = combineErrors(err, fmt.Errorf("%s: %v", customQuote(source), fErr))
+			continue
+		}
+
+		if wErr := g.exportChangedRecv(relToRoot, f, g.opts.Depth, enc); wErr != nil {
+			err = combineErrors(err, wErr)
+		}
+	}
+	return err
+}
+
+func (g *Commands) exportChangedRecv(relToRoot string, f *File, depth int, enc *json.Encoder) error {
+	if !f.IsDir {
+		if !hasExportLinks(f) {
+			return nil
+		}
+		return g.exportIfChanged(relToRoot, f, enc)
+	}
+
+	depth = decrementTraversalDepth(depth)
+	if depth == 0 {
+		return nil
+	}
+
+	var children []*File
+	pagePair := g.rem.FindByParentId(f.Id, g.opts.Hidden)
+	errsChan := pagePair.errsChan
+	childrenChan := pagePair.filesChan
+
+	working := true
+	for working {
+		select {
+		case pageErr := <-errsChan:
+			if pageErr != nil {
+				return pageErr
+			}
+		case child, stillHasContent := <-childrenChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if child != nil {
+				children = append(children, child)
+			}
+		}
+	}
+
+	var err error
+	for _, child := range children {
+		childRelToRoot := remotePathJoin(relToRoot, urlToPath(child.Name, true))
+		if cErr := g.exportChangedRecv(childRelToRoot, child, depth, enc); cErr != nil {
+			err = combineErrors(err, cErr)
+		}
+	}
+	return err
+}
+
+// exportIfChanged exports f unless the revision last exported is still its
+// latest one. The revision is only recorded once all formats are exported.
+func (g *Commands) exportIfChanged(relToRoot string, f *File, enc *json.Encoder) error {
+	revs, err := g.rem.listRevisions(f.Id)
+	if err != nil {
+		return remoteLookupErr(fmt.Errorf("%s: revisions: %v", relToRoot, err))
+	}
+	revision := latestRevisionId(revs, f.Version)
+
+	prev, err := g.context.ExportRevision(f.Id)
+	if err != nil {
+		return err
+	}
+	if prev != nil && prev.Revision == revision && !g.opts.Force {
+		if g.opts.Verbose {
+			g.log.LogErrf("%s: revision %s already exported\n", relToRoot, revision)
+		}
+		return nil
+	}
+
+	record := &exportRecord{
+		Id:           f.Id,
+		Path:         relToRoot,
+		MimeType:     f.MimeType,
+		Revision:     revision,
+		ModifiedTime: f.ModTime.UTC(),
+	}
+	if prev != nil {
+		record.PreviousRevision = prev.Revision
+	}
+
+	exportDirPath := ""
+	if !g.opts.Piped {
+		exportDirPath = g.makeExportsDir(g.context.AbsPathOf(relToRoot))
+		if g.opts.ExportsDir != "" {
+			if g.opts.ExportsDumpToSameDirectory {
+				exportDirPath = g.opts.ExportsDir
+			} else {
+				exportDirPath = path.Join(g.opts.ExportsDir, f.Name)
+			}
+		}
+		if err := os.MkdirAll(exportDirPath, os.ModeDir|0755); err != nil {
+			return err
+		}
+	}
+
+	for _, ext := range g.opts.Exports {
+		mimeType := mimeTypeFromExt(ext)
+		exportURL, ok := f.ExportLinks[mimeType]
+		if !ok {
+			continue
+		}
+		exported := &exportedFormat{Format: ext, MimeType: mimeType}
+
+		if g.opts.Piped {
+			var buf bytes.Buffer
+			if err := g.exportTo(&buf, f.Id, exportURL); err != nil {
+				return fmt.Errorf("%s: export %s: %v", relToRoot, ext, err)
+			}
+			if utf8.Valid(buf.Bytes()) {
+				exported.Content = buf.String()
+			} else {
+				exported.ContentBase64 = base64.StdEncoding.EncodeToString(buf.Bytes())
+			}
+		} else {
+			exported.Path = sepJoin(".", filepath.Join(exportDirPath, filepath.Base(f.Name)), ext)
+			if err := g.exportToPath(exported.Path, f.Id, exportURL); err != nil {
+				return fmt.Errorf("%s: export %s: %v", relToRoot, ext, err)
+			}
+		}
+		record.Exports = append(record.Exports, exported)
+	}
+
+	if len(record.Exports) < 1 {
+		return nil
+	}
+	if err := enc.Encode(record); err != nil {
+		return err
+	}
+
+	return g.context.SaveExportRevision(&config.ExportRevision{
+		Id:           f.Id,
+		Revision:     revision,
+		ModifiedTime: record.ModifiedTime,
+	})
+}
+
+func (g *Commands) exportToPath(p, id, exportURL string) (err error) {
+	fo, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		fErr := fo.Close()
+		if err == nil && fErr != nil {
+			err = fErr
+		}
+	}()
+	return g.exportTo(fo, id, exportURL)
+}
+
+func (g *Commands) exportTo(w io.Writer, id, exportURL string) error {
+	blob, err := g.rem.Download(id, exportURL)
+	if err != nil {
+		return err
+	}
+	if blob == nil {
+		return downloadFailedErr(fmt.Errorf("%s: empty export", id))
+	}
+	defer blob.Close()
+
+	_, err = io.Copy(w, blob)
+	return err
+}
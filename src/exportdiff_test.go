@@ -0,0 +1,36 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+func TestLatestRevisionId(t *testing.T) {
+	revs := []*drive.Revision{
+		{Id: "12", ModifiedDate: "2016-05-02T10:00:00.000Z"},
+		{Id: "15", ModifiedDate: "2016-05-03T08:30:00.000Z"},
+		nil,
+		{Id: "13", ModifiedDate: "2016-05-02T11:00:00.000Z"},
+	}
+	if got := latestRevisionId(revs, 40); got != "15" {
+		t.Errorf("expected revision 15, got %q", got)
+	}
+	if got := latestRevisionId(nil, 40); got != "v40" {
+		t.Errorf("without revisions, expected the version v40, got %q", got)
+	}
+}
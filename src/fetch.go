@@ -98,7 +98,9 @@ func (g *Commands) fetch(fetchOp int) (err error) {
 		return status.Error()
 	}
 
-	return g.playFetchChanges(cl, opMap)
+	err = g.playFetchChanges(cl, opMap)
+	g.flushCacheLookups()
+	return err
 }
 
 func loneCountRegister(wg *sync.WaitGroup, progress chan int) {
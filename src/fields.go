@@ -0,0 +1,161 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/odeke-em/log"
+	"google.golang.org/api/googleapi"
+)
+
+// Fields that traversal relies on, requested even if not asked for.
+var traversalFields = []string{"id", "title", "mimeType"}
+
+// SplitFieldsSelector splits a partial response selector such as
+// "id,sharingUser(displayName,emailAddress)" on its top level commas.
+func SplitFieldsSelector(selector string) (fields []string) {
+	depth := 0
+	start := 0
+	for i, r := range selector {
+		switch r {
+		case '(':
+			depth += 1
+		case ')':
+			depth -= 1
+		case ',':
+			if depth == 0 {
+				fields = append(fields, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	fields = append(fields, selector[start:])
+	return NonEmptyTrimmedStrings(fields...)
+}
+
+// fieldKey returns the top level key of a field selector
+// e.g "sharingUser" for both "sharingUser(displayName)" and "sharingUser/displayName".
+func fieldKey(field string) string {
+	if i := strings.IndexAny(field, "(/"); i >= 0 {
+		return field[:i]
+	}
+	return field
+}
+
+func requestedFields(opts *Options) []string {
+	if opts == nil || opts.Meta == nil {
+		return nil
+	}
+	return (*opts.Meta)[FieldsKey]
+}
+
+// listFieldsSelector returns the selector to be sent with a list request.
+func listFieldsSelector(fields []string) googleapi.Field {
+	alreadyPresent := map[string]bool{}
+	for _, field := range fields {
+		alreadyPresent[fieldKey(field)] = true
+	}
+
+	all := append([]string{}, fields...)
+	for _, field := range traversalFields {
+		if !alreadyPresent[field] {
+			all = append(all, field)
+		}
+	}
+
+	return googleapi.Field(fmt.Sprintf("nextPageToken,items(%s)", strings.Join(all, ",")))
+}
+
+// rawFieldValues returns the values of the requested fields of the
+// file as returned by the API, keyed by their top level keys. If no
+// fields were requested, all are returned except media metadata
+// unless media is set.
+func (f *File) rawFieldValues(fields []string, media bool) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if f.raw == nil {
+		return values, nil
+	}
+
+	blob, err := json.Marshal(f.raw)
+	if err != nil {
+		return nil, err
+	}
+
+	all := map[string]interface{}{}
+	if err := json.Unmarshal(blob, &all); err != nil {
+		return nil, err
+	}
+
+	if len(fields) < 1 {
+		if !media {
+			for _, field := range MediaMetadataFields {
+				delete(all, field)
+			}
+		}
+		return all, nil
+	}
+
+	for _, field := range fields {
+		key := fieldKey(field)
+		if value, ok := all[key]; ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func (f *File) prettyFields(logy *log.Logger, opt attribute) {
+	fields := opt.fields
+	fmtdPath := sepJoin("/", opt.parent, f.Name)
+
+	values, err := f.rawFieldValues(fields, opt.media)
+	if err != nil {
+		logy.LogErrf("%s: %v\n", fmtdPath, err)
+		return
+	}
+
+	if opt.json {
+		values["path"] = fmtdPath
+		blob, err := json.Marshal(values)
+		if err != nil {
+			logy.LogErrf("%s: %v\n", fmtdPath, err)
+			return
+		}
+		logy.Logf("%s\n", blob)
+		return
+	}
+
+	columns := []string{}
+	for _, field := range fields {
+		value, ok := values[fieldKey(field)]
+		if !ok {
+			columns = append(columns, "-")
+			continue
+		}
+
+		switch v := value.(type) {
+		case string:
+			columns = append(columns, v)
+		default:
+			blob, _ := json.Marshal(v)
+			columns = append(columns, string(blob))
+		}
+	}
+	columns = append(columns, fmtdPath)
+	logy.Logf("%s\n", strings.Join(columns, "\t"))
+}
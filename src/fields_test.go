@@ -0,0 +1,48 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+)
+
+func TestSplitFieldsSelector(t *testing.T) {
+	samples := []struct {
+		selector string
+		want     []string
+	}{
+		{selector: "", want: nil},
+		{selector: "id", want: []string{"id"}},
+		{selector: "id, md5Checksum,,quotaBytesUsed", want: []string{"id", "md5Checksum", "quotaBytesUsed"}},
+		{
+			selector: "id,sharingUser(displayName,emailAddress),owners/emailAddress",
+			want:     []string{"id", "sharingUser(displayName,emailAddress)", "owners/emailAddress"},
+		},
+	}
+
+	for i, sample := range samples {
+		got := SplitFieldsSelector(sample.selector)
+		if len(got) != len(sample.want) {
+			t.Errorf("#%d: got %v want %v", i, got, sample.want)
+			continue
+		}
+		for j := range got {
+			if got[j] != sample.want[j] {
+				t.Errorf("#%d: got %v want %v", i, got, sample.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/odeke-em/log"
+)
+
+var formatFuncs = template.FuncMap{
+	"bytes": prettyBytes,
+	"join":  strings.Join,
+}
+
+// formattedFile is what a --format template is evaluated against,
+// the file's own fields along with the path that it was listed at.
+type formattedFile struct {
+	*File
+	Path string
+}
+
+func parseListFormat(format string) (*template.Template, error) {
+	tmpl, err := template.New(CLIOptionFormat).Funcs(formatFuncs).Parse(format)
+	if err != nil {
+		return nil, invalidArgumentsErr(fmt.Errorf("--%s: %v", CLIOptionFormat, err))
+	}
+	return tmpl, nil
+}
+
+// prepareListFormat parses the --format template if one was passed in,
+// so that a malformed one is reported before anything is listed.
+func (g *Commands) prepareListFormat() error {
+	if err := g.prepareListColors(); err != nil {
+		return err
+	}
+	if g.listFormat != nil || g.opts.Meta == nil {
+		return nil
+	}
+
+	formats := (*g.opts.Meta)[CLIOptionFormat]
+	if len(formats) < 1 || formats[0] == "" {
+		return nil
+	}
+
+	tmpl, err := parseListFormat(formats[0])
+	if err != nil {
+		return err
+	}
+	g.listFormat = tmpl
+	return nil
+}
+
+func (f *File) prettyFormat(logy *log.Logger, tmpl *template.Template, fmtdPath string) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, &formattedFile{File: f, Path: fmtdPath}); err != nil {
+		logy.LogErrf("%s: %v\n", fmtdPath, err)
+		return
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	logy.Logf("%s", buf.Bytes())
+}
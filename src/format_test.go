@@ -0,0 +1,56 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseListFormat(t *testing.T) {
+	f := &File{Name: "a.jpg", Id: "0Bxyz", Size: 2048, OwnerNames: []string{"Ann", "Bo"}}
+	samples := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{format: "{{.Id}} {{.Size}} {{.Path}}", want: "0Bxyz 2048 photos/a.jpg"},
+		{format: "{{bytes .Size}}\t{{join .OwnerNames \",\"}}", want: "2.00KB\tAnn,Bo"},
+		{format: "{{.Id", wantErr: true},
+	}
+
+	for i, sample := range samples {
+		tmpl, err := parseListFormat(sample.format)
+		if sample.wantErr {
+			if err == nil {
+				t.Errorf("#%d: %q expected an error", i, sample.format)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %q unexpected err %v", i, sample.format, err)
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, &formattedFile{File: f, Path: "photos/a.jpg"}); err != nil {
+			t.Errorf("#%d: %q execute err %v", i, sample.format, err)
+			continue
+		}
+		if got := buf.String(); got != sample.want {
+			t.Errorf("#%d: got %q want %q", i, got, sample.want)
+		}
+	}
+}
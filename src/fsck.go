@@ -0,0 +1,153 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/api/googleapi"
+)
+
+const IndexFsckKey = "fsck"
+
+// indexProblem is an index entry that fsck will remove.
+type indexProblem struct {
+	key    string
+	reason string
+	// stale is set for indices of files deleted or trashed remotely.
+	stale bool
+}
+
+func remoteNotFound(err error) bool {
+	gErr, ok := err.(*googleapi.Error)
+	return ok && gErr != nil && gErr.Code == 404
+}
+
+// ownedByMe tells whether the current user owns f, items of Shared Drives
+// being owned by the drive rather than by anyone.
+func ownedByMe(f *File) bool {
+	for _, owner := range f.Owners {
+		if owner != nil && owner.IsAuthenticatedUser {
+			return true
+		}
+	}
+	return f.UserPermission != nil && f.UserPermission.Role == "owner"
+}
+
+// indexProblems checks every index against the remote. Entries whose
+// remote lookup fails for other reasons than the file not existing are
+// left alone rather than risk dropping good ones.
+func (g *Commands) indexProblems() (problems []*indexProblem, checked int, err error) {
+	entries, unreadable, err := g.context.IndexEntries()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	checked = len(unreadable)
+	for _, key := range unreadable {
+		problems = append(problems, &indexProblem{key: key, reason: "unreadable"})
+	}
+
+	var keys []string
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	spin := g.playabler()
+	spin.play()
+	defer spin.stop()
+
+	for _, key := range keys {
+		index := entries[key]
+		checked += 1
+
+		if index.FileId != key {
+			reason := fmt.Sprintf("stored under a different id %q", index.FileId)
+			if _, ok := entries[index.FileId]; ok {
+				reason = fmt.Sprintf("duplicate of the index of %q", index.FileId)
+			}
+			problems = append(problems, &indexProblem{key: key, reason: reason})
+			continue
+		}
+
+		f, fErr := g.rem.FindById(key)
+		if fErr != nil && !remoteNotFound(fErr) {
+			g.log.LogErrf("fsck: %s: %v\n", key, fErr)
+			continue
+		}
+		if f == nil {
+			problems = append(problems, &indexProblem{key: key, reason: "stale, no longer exists remotely", stale: true})
+			continue
+		}
+		if f.Labels != nil && f.Labels.Trashed {
+			problems = append(problems, &indexProblem{key: key, reason: "stale, in the trash", stale: true})
+			continue
+		}
+
+		// Files shared with me, e.g pulled with `pull -shared`, have
+		// parents of their own that I may not be able to see.
+		if !f.SharedWithMeTime.IsZero() || !ownedByMe(f) {
+			continue
+		}
+		if len(f.Parents) < 1 {
+			problems = append(problems, &indexProblem{key: key, reason: "orphan, in no folder of the drive"})
+		}
+	}
+
+	return problems, checked, nil
+}
+
+// IndexFsck detects and removes unreadable, misfiled, stale and orphaned
+// indices. Removed indices are rebuilt by the next pull or push if needed.
+func (g *Commands) IndexFsck() error {
+	problems, checked, err := g.indexProblems()
+	if err != nil {
+		return err
+	}
+
+	if len(problems) < 1 {
+		g.log.Logf("fsck: %d indices healthy\n", checked)
+		return nil
+	}
+
+	return g.removeIndexProblems(IndexFsckKey, problems, checked)
+}
+
+func (g *Commands) removeIndexProblems(verb string, problems []*indexProblem, checked int) (err error) {
+	for _, problem := range problems {
+		g.log.Logf("%s: %s\n", problem.key, problem.reason)
+	}
+
+	if g.opts.canPrompt() {
+		status := promptForChanges(fmt.Sprintf("Remove these %d indices? [Y/n] ", len(problems)))
+		if !accepted(status) {
+			return status.Error()
+		}
+	}
+
+	removed := 0
+	for _, problem := range problems {
+		if popErr := g.context.PopIndicesKey(problem.key); popErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", problem.key, popErr))
+			continue
+		}
+		removed += 1
+	}
+
+	g.log.Logf("%s: removed %d of %d indices\n", verb, removed, checked)
+	return err
+}
@@ -0,0 +1,40 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+func TestOwnedByMe(t *testing.T) {
+	cases := []struct {
+		f    *File
+		want bool
+	}{
+		{&File{Owners: []*drive.User{{EmailAddress: "a@example.com"}, {IsAuthenticatedUser: true}}}, true},
+		{&File{Owners: []*drive.User{{EmailAddress: "a@example.com"}}}, false},
+		{&File{UserPermission: &drive.Permission{Role: "owner"}}, true},
+		// Items of Shared Drives have no owners.
+		{&File{UserPermission: &drive.Permission{Role: "organizer"}}, false},
+		{&File{}, false},
+	}
+	for i, tc := range cases {
+		if got := ownedByMe(tc.f); got != tc.want {
+			t.Errorf("#%d: expected %v, got %v", i, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/odeke-em/drive/config"
+)
+
+// A .driveignore at the root of the context is made of regular expressions
+// unless it declares this syntax, those in sub folders always use it.
+const DriveIgnoreGitSyntax = "syntax: gitignore"
+
+// ignorePattern is a line of a .driveignore in the gitignore grammar,
+// matched against paths relative to base, the folder of that file.
+type ignorePattern struct {
+	base    string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// parseIgnorePattern returns nil for blank lines and comments.
+func parseIgnorePattern(base, line string) (*ignorePattern, error) {
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" || strings.HasPrefix(line, "#") || line == DriveIgnoreGitSyntax {
+		return nil, nil
+	}
+
+	// Trailing spaces are dropped unless escaped.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" {
+		return nil, nil
+	}
+
+	ip := &ignorePattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		ip.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		ip.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil, nil
+	}
+
+	// A slash anywhere but at the end anchors the pattern to base,
+	// otherwise it matches at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := gitignoreRegexp(line)
+	if !anchored && !strings.HasPrefix(expr, "(?:.*/)?") {
+		expr = "(?:.*/)?" + expr
+	}
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, err
+	}
+	ip.re = re
+	return ip, nil
+}
+
+// gitignoreRegexp translates a gitignore glob into a regular expression.
+// A leading `**/` matches in all folders, a trailing `/**` everything
+// inside and `/**/` zero or more folders. Other `**` are plain `*`.
+func gitignoreRegexp(glob string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			buf.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**") && i+2 == len(glob) && i >= 1 && glob[i-1] == '/':
+			buf.WriteString(".*")
+			i += 1
+		case c == '*':
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+			}
+			buf.WriteString("[^/]*")
+		case c == '?':
+			buf.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			buf.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '[':
+			// A `]` right after the opening `[` or `[!` is a member.
+			start := i + 1
+			if start < len(glob) && glob[start] == '!' {
+				start++
+			}
+			from := start
+			if from < len(glob) && glob[from] == ']' {
+				from++
+			}
+			end := strings.Index(glob[from:], "]")
+			if end < 0 {
+				buf.WriteString("\\[")
+				continue
+			}
+			end += from
+
+			buf.WriteString("[")
+			if start > i+1 {
+				buf.WriteString("^")
+			}
+			if from > start {
+				buf.WriteString("\\]")
+			}
+			buf.WriteString(glob[from:end])
+			buf.WriteString("]")
+			i = end
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return buf.String()
+}
+
+func parseIgnorePatterns(base string, r io.Reader) (patterns []*ignorePattern, err error) {
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		ip, pErr := parseIgnorePattern(base, scanner.Text())
+		if pErr != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, pErr)
+		}
+		if ip != nil {
+			patterns = append(patterns, ip)
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// declaresGitSyntax tells whether the first line that isn't blank
+// declares the gitignore syntax.
+func declaresGitSyntax(clauses []string) bool {
+	for _, clause := range clauses {
+		if clause = strings.TrimSpace(clause); clause != "" {
+			return clause == DriveIgnoreGitSyntax
+		}
+	}
+	return false
+}
+
+// readGitIgnores reads the .driveignore in absDirPath if it is in the
+// gitignore grammar: always in sub folders and when declared at the root.
+func readGitIgnores(absDirPath string) ([]*ignorePattern, error) {
+	p := filepath.Join(absDirPath, DriveIgnoreSuffix)
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := os.Stat(filepath.Join(absDirPath, config.GDDirSuffix)); err == nil {
+		clauses, rErr := fReadFile_(f, nil)
+		if rErr != nil || !declaresGitSyntax(clauses) {
+			return nil, rErr
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	patterns, err := parseIgnorePatterns(filepath.ToSlash(absDirPath), f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", p, err)
+	}
+	return patterns, nil
+}
+
+// lastMatch returns whether the last of patterns to match absPath
+// excludes it, as later patterns override earlier ones.
+func lastMatch(patterns []*ignorePattern, absPath string, isDir bool) (excluded bool) {
+	for _, ip := range patterns {
+		if ip.dirOnly && !isDir {
+			continue
+		}
+		prefix := strings.TrimSuffix(ip.base, "/") + "/"
+		if !strings.HasPrefix(absPath, prefix) {
+			continue
+		}
+		if ip.re.MatchString(absPath[len(prefix):]) {
+			excluded = !ip.negate
+		}
+	}
+	return excluded
+}
+
+// gitIgnored tells whether absPath is excluded by patterns. As with git,
+// nothing within an excluded folder can be re-included.
+func gitIgnored(patterns []*ignorePattern, absPath string, isDir bool) bool {
+	if len(patterns) < 1 {
+		return false
+	}
+	absPath = filepath.ToSlash(absPath)
+
+	var ancestors []string
+	for dir := absPath; ; {
+		parent := strings.TrimSuffix(dir[:strings.LastIndex(dir, "/")+1], "/")
+		if parent == "" || parent == dir {
+			break
+		}
+		ancestors = append([]string{parent}, ancestors...)
+		dir = parent
+	}
+	for _, ancestor := range ancestors {
+		if lastMatch(patterns, ancestor, true) {
+			return true
+		}
+	}
+	return lastMatch(patterns, absPath, isDir)
+}
+
+// IncludeFilter whitelists the files, matched by their path relative to
+// the root of the context, that push and pull operate on. Its patterns
+// are globs as in .driveignore, those with a `!` excepting files again.
+type IncludeFilter struct {
+	patterns []*ignorePattern
+}
+
+// ParseIncludeFilter parses comma separated patterns such as `*.raw,*.xmp`.
+func ParseIncludeFilter(spec string) (*IncludeFilter, error) {
+	inc := &IncludeFilter{}
+	for _, clause := range NonEmptyTrimmedStrings(strings.Split(spec, ",")...) {
+		ip, err := parseIgnorePattern("", clause)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", clause, err)
+		}
+		if ip != nil {
+			inc.patterns = append(inc.patterns, ip)
+		}
+	}
+	if len(inc.patterns) < 1 {
+		return nil, nil
+	}
+	return inc, nil
+}
+
+// admits tells whether the file at relToRoot is whitelisted.
+// Folders are always admitted so that they can still be traversed.
+func (inc *IncludeFilter) admits(relToRoot string, isDir bool) bool {
+	if inc == nil || isDir {
+		return true
+	}
+	return lastMatch(inc.patterns, remotePathJoin("/", filepath.ToSlash(relToRoot)), false)
+}
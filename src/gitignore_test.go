@@ -0,0 +1,114 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGitIgnored(t *testing.T) {
+	root, err := parseIgnorePatterns("/ctx", strings.NewReader(strings.Join([]string{
+		DriveIgnoreGitSyntax,
+		"# comment",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/top.txt",
+		"docs/**/*.tmp",
+		"cache/**",
+		"\\#hash",
+		"vendor/",
+		"!vendor/lib.go",
+		"data[0-9].csv",
+	}, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := parseIgnorePatterns("/ctx/app", strings.NewReader("!debug.log\n/local.txt\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patterns := append(root, sub...)
+
+	cases := []struct {
+		p     string
+		isDir bool
+		want  bool
+	}{
+		{"/ctx/a.log", false, true},
+		{"/ctx/x/y/a.log", false, true},
+		{"/ctx/keep.log", false, false},
+		{"/ctx/build", true, true},
+		{"/ctx/build", false, false},
+		{"/ctx/src/build/out.o", false, true},
+		{"/ctx/top.txt", false, true},
+		{"/ctx/sub/top.txt", false, false},
+		{"/ctx/docs/a.tmp", false, true},
+		{"/ctx/docs/x/y/a.tmp", false, true},
+		{"/ctx/other/a.tmp", false, false},
+		{"/ctx/cache", true, false},
+		{"/ctx/cache/a/b", false, true},
+		{"/ctx/#hash", false, true},
+		{"/ctx/vendor/lib.go", false, true},
+		{"/ctx/data1.csv", false, true},
+		{"/ctx/dataX.csv", false, false},
+		{"/ctx/app/debug.log", false, false},
+		{"/ctx/app/other.log", false, true},
+		{"/ctx/debug.log", false, true},
+		{"/ctx/app/local.txt", false, true},
+		{"/ctx/local.txt", false, false},
+		{"/elsewhere/a.log", false, false},
+	}
+	for _, tc := range cases {
+		if got := gitIgnored(patterns, tc.p, tc.isDir); got != tc.want {
+			t.Errorf("gitIgnored(%q, dir=%v): expected %v, got %v", tc.p, tc.isDir, tc.want, got)
+		}
+	}
+}
+
+func TestIncludeFilter(t *testing.T) {
+	inc, err := ParseIncludeFilter("*.raw, *.xmp, !*-reject.raw, /top/*.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		p     string
+		isDir bool
+		want  bool
+	}{
+		{"/Photos/a.raw", false, true},
+		{"/Photos/2016/b.xmp", false, true},
+		{"/Photos/2016/b-reject.raw", false, false},
+		{"/Photos/c.jpg", false, false},
+		{"/top/c.jpg", false, true},
+		{"/Photos/top/c.jpg", false, false},
+		{"/Photos/2016", true, true},
+	}
+	for _, tc := range cases {
+		if got := inc.admits(tc.p, tc.isDir); got != tc.want {
+			t.Errorf("admits(%q, dir=%v): expected %v, got %v", tc.p, tc.isDir, tc.want, got)
+		}
+	}
+
+	if inc, err := ParseIncludeFilter(" , "); err != nil || inc != nil {
+		t.Errorf("expected no filter for blank patterns, got %v %v", inc, err)
+	}
+	var none *IncludeFilter
+	if !none.admits("/anything", false) {
+		t.Errorf("expected a nil filter to admit everything")
+	}
+}
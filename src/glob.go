@@ -0,0 +1,328 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// hasGlobMeta tells whether p is a shell-style glob rather than a plain path.
+func hasGlobMeta(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// globMatcher matches the paths below the literal base of a glob segment
+// by segment, up to the first segment with a `**` from which on the rest
+// of the glob is matched at any depth.
+type globMatcher struct {
+	base     string
+	globs    []string
+	segments []*regexp.Regexp
+	// deepAt is the index of the first segment with a `**`, -1 if none.
+	deepAt int
+	full   *regexp.Regexp
+}
+
+func parseGlob(p string) (*globMatcher, error) {
+	parts := strings.Split(strings.Trim(filepath.ToSlash(p), "/"), "/")
+	i := 0
+	for i < len(parts) && !hasGlobMeta(parts[i]) {
+		i++
+	}
+	if i >= len(parts) {
+		return nil, invalidArgumentsErr(fmt.Errorf("%q isn't a glob", p))
+	}
+
+	gm := &globMatcher{base: remotePathJoin(parts[:i]...), globs: parts[i:], deepAt: -1}
+	for j, glob := range gm.globs {
+		if strings.Contains(glob, "**") {
+			gm.deepAt = j
+			break
+		}
+		re, err := regexp.Compile("^" + gitignoreRegexp(glob) + "$")
+		if err != nil {
+			return nil, invalidArgumentsErr(fmt.Errorf("%q: %v", p, err))
+		}
+		gm.segments = append(gm.segments, re)
+	}
+
+	full, err := globRegexp(strings.Join(gm.globs, "/"))
+	if err != nil {
+		return nil, invalidArgumentsErr(fmt.Errorf("%q: %v", p, err))
+	}
+	gm.full = full
+	return gm, nil
+}
+
+// globRegexp is gitignoreRegexp except that a `**` anywhere crosses
+// folders, so that `**.pdf` matches PDFs at any depth.
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	expr := ""
+	for i, part := range strings.Split(glob, "**") {
+		if i >= 1 {
+			if strings.HasPrefix(part, "/") {
+				expr += "(?:.*/)?"
+				part = part[1:]
+			} else {
+				expr += ".*"
+			}
+		}
+		expr += gitignoreRegexp(part)
+	}
+	return regexp.Compile("^" + expr + "$")
+}
+
+// visit reports whether rel, a path relative to the base of the glob,
+// matches it and if not, whether the folders within it could.
+func (gm *globMatcher) visit(rel string, isDir bool) (match, descend bool) {
+	segments := strings.Split(rel, "/")
+	depth := len(segments) - 1
+	if gm.deepAt >= 0 && depth >= gm.deepAt {
+		if gm.full.MatchString(rel) {
+			return true, false
+		}
+		return false, isDir
+	}
+	if depth >= len(gm.segments) || !gm.segments[depth].MatchString(segments[depth]) {
+		return false, false
+	}
+	if depth == len(gm.globs)-1 {
+		return true, false
+	}
+	return false, isDir
+}
+
+// titleSearch narrows down the listing of the folders at depth to the
+// titles that can match, by their literal prefix where Drive allows it.
+func (gm *globMatcher) titleSearch(depth int, inTrash bool) (*fuzzyStringsValuePair, error) {
+	pattern := "^.*$"
+	if depth < len(gm.segments) {
+		pattern = gm.segments[depth].String()
+	}
+	return regexTitleSearch([]string{pattern}, inTrash)
+}
+
+// expandGlobSources replaces the sources that are globs by the paths that
+// they match, remote ones for a pull and local ones for a push. Sources
+// that exist as they are named are left alone.
+func (g *Commands) expandGlobSources(push bool) error {
+	side, expand := "remote", g.remoteGlob
+	if push {
+		side, expand = "local", g.localGlob
+	}
+
+	var sources []string
+	seen := map[string]bool{}
+	for _, source := range g.opts.Sources {
+		expanded := []string{source}
+		if _, isId := IdFromSource(source); !isId && hasGlobMeta(source) && !g.sourceExists(source, push) {
+			var err error
+			if expanded, err = expand(source); err != nil {
+				return err
+			}
+			if len(expanded) < 1 {
+				return invalidArgumentsErr(fmt.Errorf("%s: no %s paths match", source, side))
+			}
+			if g.opts.Verbose {
+				g.log.Logf("%s: %d matches\n", source, len(expanded))
+			}
+		}
+		for _, p := range expanded {
+			if !seen[p] {
+				seen[p] = true
+				sources = append(sources, p)
+			}
+		}
+	}
+	g.opts.Sources = sources
+	return nil
+}
+
+func (g *Commands) sourceExists(relToRoot string, push bool) bool {
+	if push {
+		_, err := os.Lstat(g.context.AbsPathOf(relToRoot))
+		return err == nil
+	}
+	f, err := g.rem.FindByPath(relToRoot)
+	return err == nil && f != nil
+}
+
+// remoteGlob lists the remote paths that glob matches, only listing the
+// folders that can hold matches.
+func (g *Commands) remoteGlob(glob string) (matches []string, err error) {
+	gm, err := parseGlob(glob)
+	if err != nil {
+		return nil, err
+	}
+	base, err := g.rem.FindByPath(gm.base)
+	if err == nil && base == nil {
+		err = ErrPathNotExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", gm.base, err)
+	}
+
+	var walk func(dirId, rel string) error
+	walk = func(dirId, rel string) error {
+		depth := 0
+		if rel != "" {
+			depth = strings.Count(rel, "/") + 1
+		}
+		children, err := g.globCandidates(gm, dirId, depth)
+		if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			childRel := path.Join(rel, child.Name)
+			match, descend := gm.visit(childRel, child.IsDir)
+			if match {
+				matches = append(matches, remotePathJoin(gm.base, childRel))
+			} else if descend {
+				if err := walk(child.Id, childRel); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(base.Id, ""); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// globCandidates lists the children of the remote folder dirId that could
+// match gm at depth, narrowed down by title where Drive allows it.
+func (g *Commands) globCandidates(gm *globMatcher, dirId string, depth int) (children []*File, err error) {
+	titleSearch, err := gm.titleSearch(depth, g.opts.InTrash)
+	if err != nil {
+		return nil, err
+	}
+	mq := &matchQuery{
+		inTrash:       g.opts.InTrash,
+		titleSearches: []fuzzyStringsValuePair{*titleSearch},
+	}
+
+	pagePair := g.rem.findMatchesIn(dirId, mq)
+	errsChan := pagePair.errsChan
+	childrenChan := pagePair.filesChan
+
+	working := true
+	for working {
+		select {
+		case pageErr := <-errsChan:
+			if pageErr != nil {
+				return nil, pageErr
+			}
+		case child, stillHasContent := <-childrenChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if child != nil && !isHidden(child.Name, g.opts.Hidden) {
+				children = append(children, child)
+			}
+		}
+	}
+	return children, nil
+}
+
+// globMatch is a remote file that matched a glob, at path.
+type globMatch struct {
+	path string
+	file *File
+}
+
+// globChildren lists the children of parent whose names match the glob pattern.
+func (g *Commands) globChildren(parent *globMatch, pattern string) (matches []*globMatch, err error) {
+	gm, err := parseGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	children, err := g.globCandidates(gm, parent.file.Id, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		if match, _ := gm.visit(child.Name, child.IsDir); match {
+			matches = append(matches, &globMatch{path: path.Join(parent.path, child.Name), file: child})
+		}
+	}
+	return matches, nil
+}
+
+// expandRemoteGlobs replaces each source containing glob patterns with
+// the remote paths that match it. Other sources are left as they are.
+func (g *Commands) expandRemoteGlobs(sources []string) (expanded []string, err error) {
+	for _, source := range sources {
+		if !hasGlobMeta(source) {
+			expanded = append(expanded, source)
+			continue
+		}
+
+		matches, gErr := g.remoteGlob(source)
+		if gErr != nil {
+			return nil, gErr
+		}
+		if len(matches) < 1 {
+			return nil, noMatchesFoundErr(fmt.Errorf("%s: no matches found", source))
+		}
+		g.DebugPrintf("[expandRemoteGlobs] %s => %v\n", source, matches)
+		expanded = append(expanded, matches...)
+	}
+
+	return expanded, nil
+}
+
+// localGlob lists the local paths that glob matches, skipping hidden and
+// ignored paths as a push would.
+func (g *Commands) localGlob(glob string) (matches []string, err error) {
+	gm, err := parseGlob(glob)
+	if err != nil {
+		return nil, err
+	}
+
+	baseAbsPath := g.context.AbsPathOf(gm.base)
+	err = filepath.Walk(baseAbsPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil || p == baseAbsPath {
+			return err
+		}
+		if isHidden(info.Name(), g.opts.Hidden) || anyMatch(g.opts.Ignorer, info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, _ := filepath.Rel(baseAbsPath, p)
+		rel = filepath.ToSlash(rel)
+		match, descend := gm.visit(rel, info.IsDir())
+		if match {
+			matches = append(matches, remotePathJoin(gm.base, rel))
+		}
+		if info.IsDir() && !descend {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return matches, err
+}
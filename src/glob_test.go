@@ -0,0 +1,81 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+)
+
+func TestGlobMatcher(t *testing.T) {
+	gm, err := parseGlob("/reports/2024-*/**.pdf")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gm.base != "/reports" || gm.deepAt != 1 || len(gm.segments) != 1 {
+		t.Fatalf("unexpected matcher %+v", gm)
+	}
+
+	cases := []struct {
+		rel            string
+		isDir          bool
+		match, descend bool
+	}{
+		{rel: "2024-01", isDir: true, descend: true},
+		{rel: "2023-12", isDir: true},
+		{rel: "2024-01.pdf"},
+		{rel: "2024-01/summary.pdf", match: true},
+		{rel: "2024-01/q1/detail.pdf", match: true},
+		{rel: "2024-01/q1", isDir: true, descend: true},
+		{rel: "2024-01/q1/detail.xls"},
+	}
+	for _, tc := range cases {
+		match, descend := gm.visit(tc.rel, tc.isDir)
+		if match != tc.match || descend != tc.descend {
+			t.Errorf("%q: got match=%v descend=%v want match=%v descend=%v", tc.rel, match, descend, tc.match, tc.descend)
+		}
+	}
+
+	gm, err = parseGlob("/a/*/b?.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gm.base != "/a" || gm.deepAt != -1 {
+		t.Fatalf("unexpected matcher %+v", gm)
+	}
+	if match, _ := gm.visit("x/b1.txt", false); !match {
+		t.Errorf("expected x/b1.txt to match")
+	}
+	if match, descend := gm.visit("x/y", true); match || descend {
+		t.Errorf("x/y: got match=%v descend=%v", match, descend)
+	}
+	if match, _ := gm.visit("x/y/b1.txt", false); match {
+		t.Errorf("expected x/y/b1.txt not to match")
+	}
+
+	if gm, err := parseGlob("*.csv"); err != nil || gm.base != "/" {
+		t.Errorf("*.csv: got %+v, %v", gm, err)
+	}
+	if _, err := parseGlob("/a/b"); err == nil {
+		t.Errorf("expected an error for a path without a glob")
+	}
+
+	fz, err := gm.titleSearch(0, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !fz.matchesTitle("anything") || regexTitlePrefix(fz.values[0]) != "" {
+		t.Errorf("unexpected title search %+v", fz)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// grepSnippetBytes is roughly how much of a matching line is printed.
+	grepSnippetBytes = 160
+	// grepBinarySniffBytes are looked at for NUL bytes to skip binary content.
+	grepBinarySniffBytes = 8000
+)
+
+// grepExportMimeTypes are the plain text exports of native docs that are
+// searched, in order of preference.
+var grepExportMimeTypes = []string{
+	"text/plain",
+	"text/csv",
+	"text/tab-separated-values",
+}
+
+// Grep prints the lines of the files within the sources whose content
+// matches re. Candidates are first narrowed down server-side by a full text
+// search for fullText, or for a word that every match has to contain when it
+// is empty, then downloaded, or exported as text, and matched client-side.
+func (g *Commands) Grep(re *regexp.Regexp, fullText string, filesOnly bool) (err error) {
+	if fullText == "" {
+		fullText = fullTextTerm(re.String())
+	}
+	if fullText == "" && g.opts.Verbose {
+		g.log.LogErrf("grep: no word to narrow down %q by, searching all files\n", re.String())
+	}
+
+	candidates, err := g.grepCandidates(fullText)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for p := range candidates {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	matched := 0
+	for _, p := range paths {
+		found, gErr := g.grepFile(p, candidates[p], re, filesOnly)
+		if gErr != nil {
+			g.log.LogErrf("grep: %s: %v\n", p, gErr)
+			err = combineErrors(err, fmt.Errorf("%s: %v", p, gErr))
+			continue
+		}
+		if found {
+			matched += 1
+		}
+	}
+
+	if g.opts.Verbose {
+		g.log.LogErrf("grep: %d of %d candidates matched\n", matched, len(paths))
+	}
+	return err
+}
+
+func (g *Commands) grepCandidates(fullText string) (map[string]*File, error) {
+	pagePair := g.rem.findFullText(fullText, g.opts.Hidden)
+
+	candidates := map[string]*File{}
+	backPaths := map[string][]string{}
+
+	working := true
+	for working {
+		select {
+		case pErr := <-pagePair.errsChan:
+			if pErr != nil {
+				return candidates, pErr
+			}
+		case f, stillHasContent := <-pagePair.filesChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if f == nil || f.raw == nil || !g.opts.admits(f) {
+				continue
+			}
+			for _, p := range g.changedFilePaths(f.raw, backPaths) {
+				if withinAny(p, g.opts.Sources) {
+					candidates[p] = f
+				}
+			}
+		}
+	}
+	return candidates, nil
+}
+
+func (g *Commands) grepFile(p string, f *File, re *regexp.Regexp, filesOnly bool) (found bool, err error) {
+	exportURL := ""
+	if hasExportLinks(f) {
+		for _, mimeType := range grepExportMimeTypes {
+			if exportURL = f.ExportLinks[mimeType]; exportURL != "" {
+				break
+			}
+		}
+		if exportURL == "" {
+			return false, nil
+		}
+	} else if f.BlobAt == "" {
+		return false, nil
+	}
+
+	blob, err := g.rem.Download(f.Id, exportURL)
+	if err != nil {
+		return false, err
+	}
+	if blob == nil {
+		return false, downloadFailedErr(fmt.Errorf("empty download"))
+	}
+	defer blob.Close()
+
+	var content io.Reader = blob
+	if f.compressed {
+		zr, zErr := gzip.NewReader(blob)
+		if zErr != nil {
+			return false, zErr
+		}
+		defer zr.Close()
+		content = zr
+	}
+
+	br := bufio.NewReader(content)
+	if sniffed, _ := br.Peek(grepBinarySniffBytes); bytes.IndexByte(sniffed, 0) >= 0 {
+		if g.opts.Verbose {
+			g.log.LogErrf("grep: %s: binary content, skipped\n", p)
+		}
+		return false, nil
+	}
+
+	for lineNumber := 1; ; lineNumber++ {
+		line, rErr := br.ReadString('\n')
+		if line != "" {
+			line = strings.TrimRight(line, "\r\n")
+			if loc := re.FindStringIndex(line); loc != nil {
+				found = true
+				if filesOnly {
+					g.log.Logln(p)
+					return true, nil
+				}
+				g.log.Logf("%s:%d: %s\n", p, lineNumber, snippet(line, loc, grepSnippetBytes))
+			}
+		}
+		if rErr == io.EOF {
+			return found, nil
+		}
+		if rErr != nil {
+			return found, rErr
+		}
+	}
+}
+
+// snippet returns about max bytes of line around the match at loc,
+// eliding the rest.
+func snippet(line string, loc []int, max int) string {
+	if len(line) <= max {
+		return line
+	}
+	start := loc[0] - (max-(loc[1]-loc[0]))/2
+	if start < 0 {
+		start = 0
+	}
+	end := start + max
+	if end > len(line) {
+		end, start = len(line), len(line)-max
+	}
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start--
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end++
+	}
+
+	s := line[start:end]
+	if start > 0 {
+		s = "..." + s
+	}
+	if end < len(line) {
+		s += "..."
+	}
+	return s
+}
+
+// fullTextTerm returns the longest word that every match of pattern has to
+// contain, for a server-side full text search to narrow the files down by.
+func fullTextTerm(pattern string) string {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+
+	term := ""
+	for _, literal := range requiredLiterals(parsed) {
+		for _, word := range strings.FieldsFunc(literal, func(r rune) bool { return !isWordRune(r) }) {
+			if utf8.RuneCountInString(word) > utf8.RuneCountInString(term) {
+				term = word
+			}
+		}
+	}
+	if utf8.RuneCountInString(term) < 3 {
+		return ""
+	}
+	return strings.ToLower(term)
+}
+
+// requiredLiterals returns the literals that every match of re contains.
+// Words at the edges of a literal that what comes next to it could extend
+// are trimmed, since a full text search only matches whole words.
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var literals []string
+		for i, sub := range re.Sub {
+			subLiterals := requiredLiterals(sub)
+			if len(subLiterals) < 1 {
+				continue
+			}
+			if i > 0 && !nonWordEdge(re.Sub[i-1], false) {
+				subLiterals[0] = strings.TrimLeftFunc(subLiterals[0], isWordRune)
+			}
+			if last := len(subLiterals) - 1; i < len(re.Sub)-1 && !nonWordEdge(re.Sub[i+1], true) {
+				subLiterals[last] = strings.TrimRightFunc(subLiterals[last], isWordRune)
+			}
+			literals = append(literals, subLiterals...)
+		}
+		return literals
+	}
+	return nil
+}
+
+// nonWordEdge reports whether the first, or else last, character that re
+// matches can't be part of a word.
+func nonWordEdge(re *syntax.Regexp, first bool) bool {
+	switch re.Op {
+	case syntax.OpWordBoundary, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return true
+	case syntax.OpLiteral:
+		if len(re.Rune) < 1 {
+			return false
+		}
+		if first {
+			return !isWordRune(re.Rune[0])
+		}
+		return !isWordRune(re.Rune[len(re.Rune)-1])
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			lo, hi := re.Rune[i], re.Rune[i+1]
+			if hi-lo > 0xff {
+				return false
+			}
+			for r := lo; r <= hi; r++ {
+				if isWordRune(r) {
+					return false
+				}
+			}
+		}
+		return true
+	case syntax.OpCapture, syntax.OpPlus:
+		return nonWordEdge(re.Sub[0], first)
+	case syntax.OpRepeat:
+		return re.Min >= 1 && nonWordEdge(re.Sub[0], first)
+	case syntax.OpConcat:
+		if first {
+			return nonWordEdge(re.Sub[0], first)
+		}
+		return nonWordEdge(re.Sub[len(re.Sub)-1], first)
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFullTextTerm(t *testing.T) {
+	tests := []struct {
+		pattern, want string
+	}{
+		{"invoice", "invoice"},
+		{`total:\s+\d+ EUR`, "total"},
+		{`(?i)Quarterly (report|summary)`, "quarterly"},
+		{`foo|barbaz`, ""},
+		{`id=\d+`, ""},
+		{`(deadline)+ missed`, "deadline"},
+		{`colou?r`, ""},
+		{`(dead)+line`, ""},
+		{`\bbudget\d+ approved`, "approved"},
+		{`[`, ""},
+	}
+	for _, tt := range tests {
+		if got := fullTextTerm(tt.pattern); got != tt.want {
+			t.Errorf("fullTextTerm(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	if got := snippet("short line", []int{0, 5}, 20); got != "short line" {
+		t.Errorf("expected short lines as they are, got %q", got)
+	}
+
+	line := strings.Repeat("a", 50) + "needle" + strings.Repeat("b", 50)
+	got := snippet(line, []int{50, 56}, 20)
+	if !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "...") || !strings.Contains(got, "needle") {
+		t.Errorf("expected an elided snippet around the match, got %q", got)
+	}
+
+	got = snippet(strings.Repeat("é", 30), []int{0, 2}, 11)
+	if !utf8.ValidString(got) {
+		t.Errorf("expected a valid UTF-8 snippet, got %q", got)
+	}
+}
@@ -21,22 +21,33 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/odeke-em/drive/config"
 	prettywords "github.com/odeke-em/pretty-words"
 )
 
 const (
 	AboutKey                  = "about"
 	AllKey                    = "all"
+	CacheKey                  = "cache"
+	LeaseKey                  = "lease"
+	SharedDriveKey            = "shared-drive"
+	CompletePathKey           = "complete-path"
 	CopyKey                   = "copy"
 	DeleteKey                 = "delete"
 	DeInitKey                 = "deinit"
 	EditDescriptionKey        = "edit-description"
 	EditDescriptionShortKey   = "edit-desc"
 	ServiceAccountJSONFileKey = "service-account-file"
+	IndexBackendKey           = "index-backend"
+	ApplicationNameKey        = "application-name"
+	QuotaUserKey              = "quota-user"
 	DiffKey                   = "diff"
+	DoctorKey                 = "doctor"
 	AddressKey                = "address"
 	EmptyTrashKey             = "emptytrash"
+	ExpandArchiveKey          = "expand-archive"
 	FeaturesKey               = "features"
+	GrepKey                   = "grep"
 	HelpKey                   = "help"
 	InitKey                   = "init"
 	LinkKey                   = "Link"
@@ -44,6 +55,9 @@ const (
 	DuKey                     = "du"
 	Md5sumKey                 = "md5sum"
 	MoveKey                   = "move"
+	MkdirKey                  = "mkdir"
+	DedupeKey                 = "dedupe"
+	ResolveKey                = "resolve"
 	OcrKey                    = "ocr"
 	ConvertKey                = "convert"
 	OSLinuxKey                = "linux"
@@ -55,7 +69,13 @@ const (
 	RenameKey                 = "rename"
 	QuotaKey                  = "quota"
 	ShareKey                  = "share"
+	ShareLinkKey              = "link"
+	ExistsKey                 = "exists"
+	CatKey                    = "cat"
+	RevisionsKey              = "revisions"
 	StatKey                   = "stat"
+	StatsKey                  = "stats"
+	SyncKey                   = "sync"
 	TouchKey                  = "touch"
 	TrashKey                  = "trash"
 	UnshareKey                = "unshare"
@@ -67,6 +87,8 @@ const (
 	PruneKey                  = "prune"
 	StarKey                   = "star"
 	UnStarKey                 = "unstar"
+	UndoKey                   = "undo"
+	WatchKey                  = "watch"
 
 	CoercedMimeKeyKey        = "coerced-mime"
 	ExportsKey               = "export"
@@ -80,6 +102,7 @@ const (
 	ClashesKey               = "clashes"
 	CommentStr               = "#"
 	DepthKey                 = "depth"
+	ExtensionKey             = "ext"
 	EmailsKey                = "emails"
 	EmailMessageKey          = "emailMessage"
 	ForceKey                 = "force"
@@ -104,6 +127,9 @@ const (
 	TrashedKey               = "trashed"
 	SkipMimeKeyKey           = "skip-mime"
 	MatchMimeKeyKey          = "exact-mime"
+	MimeCategoryKey          = "mime-category"
+	LabelKey                 = "label"
+	IdSourcePrefix           = "id:"
 	ExactTitleKey            = "exact-title"
 	MatchOwnerKey            = "match-owner"
 	ExactOwnerKey            = "exact-owner"
@@ -121,25 +147,56 @@ const (
 	TouchModTimeKey          = "time"
 	TouchTimeFmtSpecifierKey = "format"
 	TouchOffsetDurationKey   = "duration"
+	FieldsKey                = "fields"
+	SharedFromKey            = "from"
+	SharedAfterKey           = "shared-after"
+	SharedBeforeKey          = "shared-before"
+	StatsLastKey             = "last"
+	UndoLastKey              = "last"
+	WatchOnKey               = "on"
+	WatchWebhookKey          = "webhook"
+	WatchRulesKey            = "rules"
+	WatchIntervalKey         = "interval"
+	WatchDigestKey           = "digest"
+	WatchDigestWebhookKey    = "digest-webhook"
+	WatchDigestEmailKey      = "digest-email"
+	WatchSMTPKey             = "smtp"
 )
 
 const (
 	DescAbout                 = "print out information about your Google drive"
 	DescAll                   = "print out the entire help section"
 	DescAllStarred            = "all the starred files"
+	DescCache                 = "inspects, clears or limits the local index cache"
+	DescLease                 = "acquires or releases a best-effort lock on remote files"
+	DescSharedDrive           = "creates a Shared Drive from a local template folder and a permissions manifest"
+	DescCompletePath          = "prints the remote paths that a prefix completes to, for shell completion and editor plugins"
 	DescCopy                  = "copy remote paths to a destination"
-	DescDelete                = "deletes the items permanently. This operation is irreversible"
+	DescDelete                = "moves the items to the trash, or deletes them permanently with `-permanent`"
 	DescDiff                  = "compares local files with their remote equivalent"
+	DescDoctor                = "diagnoses common problems with credentials, network and the drive context"
 	DescEdit                  = "edit the attributes of a file"
-	DescEmptyTrash            = "permanently cleans out your trash"
+	DescEmptyTrash            = "permanently cleans out your trash, requires `-permanent`"
+	DescExpandArchive         = "uploads the contents of a remote zip or tar.gz archive into a remote folder"
 	DescExcludeOps            = "exclude operations"
 	DescFeatures              = "returns information about the features of your drive"
+	DescGrep                  = "prints the lines of remote files that match a regular expression, without pulling them"
 	DescIndex                 = "fetch indices from remote"
 	DescHelp                  = "Get help for a topic"
 	DescInit                  = "initializes a directory and authenticates user"
+	DescIndexBackend          = "where indices are kept: bolt, the default, sqlite for concurrent processes or memory for tests"
+	DescApplicationName       = "application name that prefixes the User-Agent of API requests, to attribute the traffic to a tool"
+	DescQuotaUser             = "user that API requests are attributed to for quota and reporting"
 	DescDeInit                = "removes the user's credentials and initialized files"
 	DescList                  = "lists the contents of remote path"
+	DescLink                  = "shares files with anyone or a domain that has the link and prints their URLs"
+	DescExists                = "exits with status 0 if the paths or ids exist remotely, otherwise 1"
+	DescCat                   = "prints the content of remote files to stdout"
+	DescRevisions             = "lists the revision history of files, downloads and pins revisions"
 	DescMove                  = "move files/folders"
+	DescMkdir                 = "create remote folders"
+	DescDedupe                = "report sets of files with identical content"
+	DescResolve               = "settle the conflicts reported by a push or pull"
 	DescPiped                 = "get content in from standard input (stdin)"
 	DescQuota                 = "prints out information related to your quota space"
 	DescPublish               = "publishes a file and prints its publicly available url"
@@ -150,26 +207,41 @@ const (
 	DescShare                 = "share files with specific emails giving the specified users specifies roles and permissions"
 	DescStar                  = "star files"
 	DescUnStar                = "unstar files"
+	DescUndo                  = "reverses the most recent mutating operations"
+	DescUndoLast              = "the number of most recent operations to undo"
 	DescStat                  = "display information about a file"
+	DescStats                 = "summarizes the recorded push and pull transfer statistics"
+	DescStatsLast             = "how far back to summarize e.g 30d, 2w, 36h"
+	DescSync                  = "propagates the changes made locally or remotely since the last sync to the other side"
 	DescTouch                 = "updates a remote file's modification time to that currently on the server"
 	DescTrash                 = "moves files to trash"
 	DescUnshare               = "revoke a user's access to a file"
 	DescUntrash               = "restores files from trash to their original locations"
 	DescUnpublish             = "revokes public access to a file"
 	DescVersion               = "prints the version"
+	DescWatch                 = "alerts when files in watched folders are trashed or have their permissions changed"
+	DescWatchOn               = "comma separated events to alert on: trashed, permissions"
+	DescWatchWebhook          = "URL to POST a JSON object describing each alert to"
+	DescWatchRules            = "file of `<folder> [events] [webhook]` rules, one per line"
+	DescWatchInterval         = "how often to poll for changes e.g 30s, 5m"
+	DescWatchDigest           = "how often to send a digest of all changes in the watched folders e.g 1d, 12h"
+	DescWatchDigestWebhook    = "URL to POST each digest to as a JSON object"
+	DescWatchDigestEmail      = "comma separated addresses to email each digest to"
+	DescWatchSMTP             = "host:port of the SMTP server that digests are emailed through"
 	DescMd5sum                = "prints a list compatible with md5sum(1)"
 	DescDu                    = "similar to util `du` gives you disk usage"
 	DescAccountTypes          = "\n\t* anyone.\n\t* user.\n\t* domain.\n\t* group"
-	DescRoles                 = "\n\t* owner.\n\t* reader.\n\t* writer.\n\t* commenter."
+	DescRoles                 = "\n\t* owner.\n\t* reader.\n\t* writer.\n\t* commenter.\n\t* organizer, Shared Drive manager.\n\t* fileOrganizer, Shared Drive content manager."
 	DescExplicitylPullExports = "explicitly pull exports"
 	DescIgnoreChecksum        = "avoids computation of checksums as a final check." +
 		"\nUse cases may include:\n\t* when you are low on bandwidth e.g SSHFS." +
 		"\n\t* Are on a low power device"
 	DescIgnoreConflict               = "turns off the conflict resolution safety"
 	DescIgnoreNameClashes            = "ignore name clashes"
-	DescSort                         = "sort items by a combination of attributes\n\t* modtime.\n\t* md5.\n\t* name.\n\t* size.\n\t* type.\n\t* version\ncomma separated e.g modtime,md5_r,name"
+	DescSort                         = "sort items by a combination of attributes\n\t* modtime.\n\t* md5.\n\t* name, name:ci ignoring case or name:locale collated as in $LANG.\n\t* size.\n\t* type.\n\t* version.\n\t* ext.\n\t* depth\ncomma separated e.g modtime,md5_r,name:ci"
 	DescSkipMime                     = "skip elements with mimeTypes derived from these extensions"
 	DescMatchMime                    = "get elements with the exact mimeTypes derived from extensions"
+	DescMimeCategory                 = "get elements of these types, comma separated\n\t* docs.\n\t* sheets.\n\t* slides.\n\t* forms.\n\t* folders.\n\t* pdfs.\n\t* images.\n\t* videos.\n\t* audios."
 	DescMatchTitle                   = "elements with matching titles"
 	DescExactTitle                   = "get elements with the exact titles"
 	DescMatchOwner                   = "elements with matching owners"
@@ -183,9 +255,12 @@ const (
 	DescFixClashes                   = "fix clashes by renaming or trashing all files"
 	DescFixClashesMode               = "set fix policy to rename or trash"
 	DescListClashes                  = "list clashes"
+	DescClashesJSON                  = "list clashes as JSON, grouped by path with the id, md5, size and modifiedTime of each file"
 	DescDescription                  = "set the description"
 	DescQR                           = "open up the QR code for specified files"
 	DescStarred                      = "operate only on starred files"
+	DescListStarred                  = "list the starred files and folders across the whole drive, regardless of the path"
+	DescListOrphans                  = "list the files and folders that you own yet are in no folder, unreachable by path"
 	DescUnifiedDiff                  = "unified diff"
 	DescDiffBaseLocal                = "when set uses local as the base other remote will be used as the base"
 	DescClashesOpById                = "operate on clashes by id instead of by path"
@@ -194,12 +269,106 @@ const (
 	DescReportIssue                  = "report an issue to the project's issue tracker"
 	DescId                           = "retrieve the fileId for the specified paths"
 	DescSkipContentCheck             = "skip diffing actual body content, show only name, time, type changes"
+	DescDryRun                       = "print the permission API calls that would be made for each file without making them"
+	DescDryRunChanges                = "print the changes that would be made without making them"
+	DescDryRunJSON                   = "with -dry-run, print the planned changes as JSON on stdout"
+	DescDiffMetadata                 = "also show changes to starred, trashed, description, properties and permissions since the last pull or push"
 	DescPushDestination              = "specify the final destination of the contents of an operation"
 	DescExponentialBackoffRetryCount = "max number of retries for exponential backoff"
+	DescRetryMaxWait                 = "longest wait between retries of failed requests e.g 2m, 32s by default"
+	DescRetryOn                      = "comma separated failures to retry: statuses e.g 429 or 5xx, reasons e.g rateLimitExceeded or both e.g 403:userRateLimitExceeded"
 	DescEncryptionPassword           = "encryption password"
 	DescDecryptionPassword           = "decryption password"
 	DescWithLink                     = "turn off file indexing so that only those with the link can view it"
 	DescAllowDesktopLinks            = "allows docs + sheets to be pulled as .desktop files or URL linked files"
+	DescFields                       = "comma separated API fields to request and print e.g id,md5Checksum,sharingUser(displayName)"
+	DescJSON                         = "print each item as a JSON object"
+	DescNDJSON                       = "like `-json` but guarantees that each file is printed as soon as its page arrives, refusing options that need buffering"
+	DescResolveShortcuts             = "show the path, or id, that each shortcut points to"
+	DescColor                        = "color paths by type: directories, Google Docs, shared and trashed items, unless NO_COLOR is set"
+	DescColorPalette                 = "comma separated kind=codes SGR colors e.g dir=01;34,doc=36,shared=35,trashed=90"
+	DescResumeToken                  = "continue a listing stopped by `-max-results` from the token that it printed"
+	DescCSV                          = "print a CSV with a header row, for importing into spreadsheets"
+	DescMaxResults                   = "stop after this many results, 0 meaning no limit"
+	DescQuiet                        = "log only errors, or with -quiet=summary also the summary of a run, or with -quiet=silent nothing at all"
+	DescMaxItems                     = "same as `-max-results`, the lower of the two applying if both are set"
+	DescMaxPages                     = "stop after this many pages instead of prompting for more, 0 meaning no limit"
+	DescQPS                          = "most API requests per second, 0 meaning only paced to stay within the quota"
+	DescDownloadRevision             = "write the content of this revision to stdout"
+	DescPinRevision                  = "keep this revision forever instead of letting it be purged"
+	DescUnpinRevision                = "let this revision be purged once it is superseded"
+	DescRange                        = "only the bytes in this range e.g 0-1048575, 1024- or -65536 for the last 64KiB"
+	DescNewerThan                    = "only files modified after this date e.g 2016-05-01 or within this age e.g 7d"
+	DescOlderThan                    = "only files modified before this date e.g 2016-05-01 or longer than this age ago e.g 30d"
+	DescMinSize                      = "only files of at least this size e.g 512, 100K or 10M"
+	DescMaxSize                      = "only files of at most this size e.g 512, 100K or 1.5G"
+	DescPreservePerms                = "record the mode of pushed files and restore it on pull, e.g for backups of servers"
+	DescPreserveOwner                = "with preserve-perms, also record the uid and gid of pushed files and restore them on pull where permitted"
+	DescDuplicateTitles              = "which of several same-named remote entries a pushed file updates: error, update-newest or update-by-id, from the index"
+	DescFullText                     = "narrow the files down server-side by this word instead of the longest one in the pattern"
+	DescFilesWithMatches             = "only print the paths of the files that match"
+	DescIgnoreCase                   = "match case insensitively"
+	DescExtract                      = "download each archive e.g one pushed with -as-archive and unpack it into the folder it was pushed from"
+	DescCompress                     = "gzip compressible files as they are pushed, pull decompressing them transparently"
+	DescCatExport                    = "comma separated formats to print Google Docs exported to, the first available one being used e.g txt,csv"
+	DescMirror                       = "trash remote (push) or delete local (pull) files no longer in the source once everything else is transferred"
+	DescMaxDeletions                 = "with -mirror, most deletions made without asking for confirmation"
+	DescStatsJSON                    = "file to also write the end of run summary to as JSON"
+	DescAllDrives                    = "also list the content of the Shared Drives that you are a member of"
+	DescFromContext                  = "copy from this `context:path` e.g ~/work/gd:Reports, in another drive context"
+	DescToContext                    = "copy to this `context:path` e.g ~/personal/gd:Archive, in another drive context"
+	DescPreservePermissions          = "reapply the source permissions onto the destination where allowed, reporting grants that could not be"
+	DescUntrashTo                    = "restore into this folder, creating it if need be, instead of the original parents"
+	DescPullInTrash                  = "pull the trashed content under the paths instead of what isn't trashed"
+	DescParents                      = "create missing parent folders too, folders that already exist not being an error"
+	DescPrintId                      = "print the id and path of each folder created"
+	DescChangedSince                 = "only pull the files modified remotely since this date e.g 2024-01-01T00:00:00Z or within this age e.g 36h"
+	DescIncremental                  = "only pull what changed since the last incremental pull of the same paths"
+	DescDedupeLocal                  = "hash the local tree for duplicates, only local trees are supported for now"
+	DescRecordDuplicates             = "remember the duplicates found so that `push -skip-duplicates` uploads one copy of each"
+	DescSkipDuplicates               = "push shortcuts to the first copy instead of the duplicates recorded by `dedupe -local -record`"
+	DescInclude                      = "comma separated patterns of the only files to operate on, e.g `*.raw,*.xmp`"
+	DescLeaseHolder                  = "name that the lease is held under, this host's name by default"
+	DescLeaseTTL                     = "how long the lease is held for unless released or renewed, e.g 10m"
+	DescSharedDriveTemplate          = "local folder whose folders and files are uploaded into the new Shared Drive"
+	DescPermsManifest                = "CSV of `type,value,role[,path]` grants to apply to the new Shared Drive or paths within it"
+	DescCompletionMaxAge             = "how old a cached folder listing can be before it is listed again, e.g 1h"
+	DescCompletionOffline            = "only complete from cached folder listings, however old, never querying the remote"
+	DescResolveFrom                  = "conflicts report to resolve, that of the context by default"
+	DescResolveStrategy              = "strategy to settle every conflict with instead of its suggested resolution"
+	DescConflict                     = "settle files changed on both sides instead of aborting\n\t* newer, keeping the most recently modified.\n\t* local.\n\t* remote.\n\t* keep-both, copying the incoming one alongside under a suffixed name.\n\t* skip, leaving both as they are."
+	DescExportIfChanged              = "only export the docs whose revision changed since they were last exported, printing each export as JSON"
+	DescBatch                        = "file of source,destination pairs, one per line, to run as a single batch along with any paths"
+	DescManifest                     = "after pushing, write a CSV manifest of path, size, md5, fileId and revision to this path and push it too"
+	DescPageSize                     = "number of results per pagination"
+	DescCSVColumns                   = "comma separated columns of the CSV output"
+	DescTree                         = "print the listing as a tree with file counts and cumulative sizes per folder"
+	DescRegex                        = "with `-matches`, match titles by Go regular expressions instead of prefixes"
+	DescFormat                       = "Go text/template evaluated per file e.g '{{.Id}} {{.Size}} {{.Path}}'"
+	DescPager                        = "pipe the listing through $PAGER, defaulting to less"
+	DescDepthFirst                   = "explore each folder right after listing it, like find"
+	DescDirsFirst                    = "list folders before files within each folder"
+	DescFilesFirst                   = "list files before folders within each folder"
+	DescSharedFrom                   = "comma separated emails of the people who shared the files with you"
+	DescSharedAfter                  = "only files shared with you since this date or age e.g 2016-05-01 or 30d"
+	DescSharedBefore                 = "only files shared with you before this date or age e.g 2016-05-01 or 30d"
+	DescCapabilities                 = "show what you can do to each file: e(dit), s(hare), d(elete) and m(ove out of drive)"
+	DescPerms                        = "show who each file is shared with and as what, including link sharing"
+	DescWithLabels                   = "show the Drive Labels applied to each file, with their field values"
+	DescLabel                        = "comma separated ids of Drive Labels that files must all have applied"
+	DescOwnerEmails                  = "show the owners' email addresses and who shared each file with you"
+	DescAsArchive                    = "push each folder as a single archive created on the fly"
+	DescArchiveFormat                = "format of the archives pushed with -as-archive: zip or tar.gz"
+	DescSmallFileSize                = "files up to this size e.g 512K or 2M are pushed as small files"
+	DescSmallFileJobs                = "number of small files pushed in parallel, defaulting to 4 times the number of large ones"
+	DescDownloadChunks               = "number of byte ranges of each large file downloaded concurrently, superseded by -chunk-concurrency"
+	DescChunkConcurrency             = "number of byte ranges of each large file downloaded concurrently"
+	DescTransfers                    = "number of files transferred concurrently, DRIVE_GOMAXPROCS by default"
+	DescLowMemory                    = "keep the memory footprint small on constrained devices, with smaller pages, fewer jobs and sorting spilled to disk"
+	DescLinks                        = "how local symlinks are pushed: follow, skip, or shortcut to push them as shortcuts to their targets and pull shortcuts back as symlinks"
+	DescBandwidthLimit               = "most bytes per second transferred each way e.g 2M, optionally by time of the day e.g `08:00-18:00,512k;4M`"
+	DescReportEmpty                  = "report folders that are empty or inaccessible instead of silently skipping them"
+	DescMedia                        = "include image and video metadata: dimensions, camera, duration and location"
 	DescKeepParent                   = "ensures that when moving a file into a destination, that we also retain its original parent so that it will exist in more than one folder"
 
 	DescTouchTimeStr          = "the time each file's modification time should be set to"
@@ -238,6 +407,7 @@ const (
 	CLIOptionFixClashesKey      = "fix-clashes"
 	CLIOptionPiped              = "piped"
 	CLIOptionStarred            = "starred"
+	CLIOptionOrphans            = "orphans"
 	CLIOptionAllStarred         = "all"
 	CLIOptionUnified            = "unified"
 	CLIOptionUnifiedShortKey    = "u"
@@ -249,28 +419,128 @@ const (
 	CLIOptionRenameLocal        = "local"
 	CLIOptionRenameRemote       = "remote"
 	CLIOptionRetryCount         = "retry-count"
+	CLIOptionRetries            = "retries"
+	CLIOptionRetryMaxWait       = "retry-max-wait"
+	CLIOptionRetryOn            = "retry-on"
 	CLIEncryptionPassword       = "encryption-password"
 	CLIDecryptionPassword       = "decryption-password"
 	CLIOptionWithLink           = "with-link"
 	CLIOptionDesktopLinks       = "desktop-links"
 	CLIOptionKeepParent         = "keep-parent"
+	CLIOptionReadOnly           = "read-only"
+	CLIOptionJSON               = "json"
+	CLIOptionNDJSON             = "ndjson"
+	CLIOptionResolveShortcuts   = "resolve-shortcuts"
+	CLIOptionResumeToken        = "resume-token"
+	CLIOptionColor              = "color"
+	CLIOptionColorPalette       = "color-palette"
+	CLIOptionCSV                = "csv"
+	CLIOptionMaxResults         = "max-results"
+	CLIOptionMaxItems           = "max-items"
+	CLIOptionMaxPages           = "max-pages"
+	CLIOptionQPS                = "qps"
+	CLIOptionRange              = "range"
+	CLIOptionNewerThan          = "newer-than"
+	CLIOptionOlderThan          = "older-than"
+	CLIOptionManifest           = "manifest"
+	CLIOptionMinSize            = "min-size"
+	CLIOptionMaxSize            = "max-size"
+	CLIOptionCSVColumns         = "columns"
+	CLIOptionTree               = "tree"
+	CLIOptionRegex              = "regex"
+	CLIOptionMetadata           = "metadata"
+	CLIOptionDryRun             = "dry-run"
+	CLIOptionFormat             = "format"
+	CLIOptionPager              = "pager"
+	CLIOptionDepthFirst         = "depth-first"
+	CLIOptionDirsFirst          = "dirs-first"
+	CLIOptionFilesFirst         = "files-first"
+	CLIOptionReportEmpty        = "report-empty"
+	CLIOptionOwnerEmails        = "owner-emails"
+	CLIOptionCapabilities       = "capabilities"
+	CLIOptionPerms              = "perms"
+	CLIOptionWithLabels         = "with-labels"
+	CLIOptionMedia              = "media"
+	CLIOptionPolicyIgnore       = "ignore"
+	CLIOptionDownload           = "download"
+	CLIOptionPin                = "pin"
+	CLIOptionUnpin              = "unpin"
 
 	CLIOptionUploadChunkSize = "upload-chunk-size"
 	CLIOptionUploadRateLimit = "upload-rate-limit"
+	CLIOptionSmallFileSize   = "small-file-size"
+	CLIOptionSmallFileJobs   = "small-file-jobs"
+	CLIOptionDownloadChunks  = "download-chunks"
+	CLIOptionLowMemory       = "low-memory"
+	CLIOptionBandwidthLimit  = "bwlimit"
+	CLIOptionAsArchive       = "as-archive"
+	CLIOptionArchiveFormat   = "archive-format"
+
+	CLIOptionAnyone    = "anyone"
+	CLIOptionDomain    = "domain"
+	CLIOptionPermanent = "permanent"
+	DeletionModeKey    = "deletion"
 
 	CLIOptionExportsDumpToSameDirectory = "same-exports-dir"
+	CLIOptionPreservePermissions        = "preserve-permissions"
+	CLIOptionUntrashTo                  = "to"
+	CLIOptionInTrash                    = "in-trash"
+	CLIOptionBatch                      = "batch"
+	CLIOptionParents                    = "p"
+	CLIOptionPrintId                    = "print-id"
+	CLIOptionIncremental                = "incremental"
+	CLIOptionChangedSince               = "changed-since"
+	CLIOptionExportIfChanged            = "export-if-changed"
+	CLIOptionInclude                    = "include"
+	CLIOptionConflict                   = "conflict"
+	CLIOptionLinks                      = "links"
+	CLIOptionFromContext                = "from-context"
+	CLIOptionAllDrives                  = "all-drives"
+	CLIOptionCompress                   = "compress"
+	CLIOptionStatsJSON                  = "stats-json"
+	CLIOptionMirror                     = "mirror"
+	CLIOptionMaxDeletions               = "max-deletions"
+	CLIOptionExtract                    = "extract"
+	CLIOptionChunkConcurrency           = "chunk-concurrency"
+	CLIOptionTransfers                  = "transfers"
+	CLIOptionFullText                   = "full-text"
+	CLIOptionFilesWithMatches           = "files-with-matches"
+	CLIOptionIgnoreCase                 = "ignore-case"
+	CLIOptionDuplicateTitles            = "duplicate-titles"
+	CLIOptionPreservePerms              = "preserve-perms"
+	CLIOptionPreserveOwner              = "preserve-owner"
+	CLIOptionToContext                  = "to-context"
+	CLIOptionResolveFrom                = "from"
+	CLIOptionLeaseHolder                = "holder"
+	CLIOptionLeaseTTL                   = "ttl"
+	CLIOptionTemplate                   = "template"
+	CLIOptionPermsManifest              = "perms"
+	CLIOptionCompletionMaxAge           = "max-age"
+	CLIOptionCompletionOffline          = "offline"
+	CLIOptionResolveStrategy            = "strategy"
+	CLIOptionDedupeLocal                = "local"
+	CLIOptionRecordDuplicates           = "record"
+	CLIOptionSkipDuplicates             = "skip-duplicates"
 
 	CLIOptionTrashed = TrashedKey
 )
 
 const (
 	DefaultMaxTraversalDepth = -1
+	DefaultSmallFileSize     = "1M"
+	// Small files are pushed this many times more in parallel than large ones.
+	DefaultSmallFileJobsFactor = 4
 )
 
 const (
 	GoogleApiClientIdEnvKey     = "GOOGLE_API_CLIENT_ID"
 	GoogleApiClientSecretEnvKey = "GOOGLE_API_CLIENT_SECRET"
 	DriveGoMaxProcsKey          = "DRIVE_GOMAXPROCS"
+	DriveNoGzipEnvKey           = "DRIVE_NO_GZIP"
+	DriveNoSpinnerEnvKey        = "DRIVE_NO_SPINNER"
+	NoColorEnvKey               = "NO_COLOR"
+	DriveSMTPUserEnvKey         = "DRIVE_SMTP_USER"
+	DriveSMTPPasswordEnvKey     = "DRIVE_SMTP_PASSWORD"
 	GoMaxProcsKey               = "GOMAXPROCS"
 )
 
@@ -285,6 +555,8 @@ const (
 var (
 	skipChecksumNote = fmt.Sprintf(
 		"\nNote: You can skip checksum verification by passing in flag `-%s`", CLIOptionIgnoreChecksum)
+	DescPermanent                  = "allows unrecoverable deletion instead of moving to the trash"
+	DescDeletionMode               = fmt.Sprintf("what deletions do without `-%s`: %q moves to the trash, %q refuses them", CLIOptionPermanent, DeletionTrash, DeletionPermanent)
 	PermanentDeletionNoPromptError = fmt.Errorf("%q is set yet performing a permanent deletion. Please see issue https://github.com/odeke-em/drive/issues/448", NoPromptKey)
 )
 
@@ -292,34 +564,119 @@ var docMap = map[string][]string{
 	AboutKey: []string{
 		DescAbout,
 	},
+	CacheKey: []string{
+		DescCache, fmt.Sprintf("`%s` prints the number and size of indices and the lookup hit rate", CacheStatusKey),
+		fmt.Sprintf("`%s` removes all indices, they are rebuilt on the next pull or push", CacheClearKey),
+		fmt.Sprintf("`%s <size>` e.g `%s 50M` evicts the least recently indexed entries beyond that budget,", CacheLimitKey, CacheLimitKey),
+		"a size of 0 removes the limit",
+		fmt.Sprintf("`%s` removes the indices of files deleted or trashed remotely", CachePruneKey),
+	},
+	LeaseKey: []string{
+		DescLease, fmt.Sprintf("Usage: drive lease %s|%s [-%s name] [-%s 15m] <paths...>", LeaseAcquireKey, LeaseReleaseKey, CLIOptionLeaseHolder, CLIOptionLeaseTTL),
+		fmt.Sprintf("`%s` fails if another holder's lease on a file hasn't expired, renewing it if already held", LeaseAcquireKey),
+		fmt.Sprintf("`%s` expires the lease, only another holder's if `-%s` is set", LeaseReleaseKey, ForceKey),
+		"Leases are kept in the public properties of files and updated only if the files weren't changed since they were",
+		"read, so that scripts on different machines can coordinate their updates. They aren't enforced on other writers",
+	},
+	SharedDriveKey: []string{
+		DescSharedDrive, fmt.Sprintf("Usage: drive shared-drive [-%s dir] [-%s manifest.csv] [-%s] <name>", CLIOptionTemplate, CLIOptionPermsManifest, CLIOptionNotify),
+		"The manifest is checked against the template before anything is created. A grant without a path is for the",
+		"whole drive, making its grantee a member. Whatever was created before a failure is left in place",
+	},
+	CompletePathKey: []string{
+		DescCompletePath, fmt.Sprintf("Usage: drive complete-path [-%s 10m] [-%s] <prefix>", CLIOptionCompletionMaxAge, CLIOptionCompletionOffline),
+		"Candidates are printed one per line, folders ending in a slash, from the cached listing of the folder of the",
+		fmt.Sprintf("prefix, refreshed once older than `-%s`. Prefixes are relative to the current folder unless they start", CLIOptionCompletionMaxAge),
+		fmt.Sprintf("with a slash. Hidden names are only completed with `-%s` or once the prefix starts with a dot", HiddenKey),
+	},
 	CopyKey: []string{
 		DescCopy,
+		fmt.Sprintf("Use `%s` to also grant each copy the permissions of its source, without notifying anyone", CLIOptionPreservePermissions),
+		fmt.Sprintf("Use `%s context:path` and `%s context:path` to copy between drive contexts, possibly of different accounts,", CLIOptionFromContext, CLIOptionToContext),
+		"server-side for files the destination account can access and downloading then uploading the rest",
+	},
+	GrepKey: []string{
+		DescGrep, "Usage: drive grep [options] <pattern> [path1 path2 ...]",
+		"Files are first narrowed down by a full text search of the drive for the longest word in the pattern, then",
+		"downloaded, or exported as text for docs, and matched line by line. Binary content is skipped",
+		"The full text search only matches whole words, so a pattern made of part of a word may miss files, in which case",
+		fmt.Sprintf("pass a whole word with `%s`", CLIOptionFullText),
+		fmt.Sprintf("Use `%s` to match case insensitively and `%s` to only print the matching paths", CLIOptionIgnoreCase, CLIOptionFilesWithMatches),
+	},
+	ExpandArchiveKey: []string{
+		DescExpandArchive, "Usage: drive expand-archive <archive> <folder>",
+		"The archive is downloaded and its contents uploaded through this client, creating folders as needed",
+		fmt.Sprintf("The format is inferred from the name unless `%s` is set", CLIOptionArchiveFormat),
+		fmt.Sprintf("Files that already exist are skipped unless `%s` is set", ForceKey),
 	},
 	DeleteKey: []string{
 		DescDelete,
+		fmt.Sprintf("Files are moved to the trash unless `%s` is set, which makes the deletion unrecoverable", CLIOptionPermanent),
+		fmt.Sprintf("`%s %s` in a context's .driverc refuses deletions without `%s` instead of trashing",
+			DeletionModeKey, DeletionPermanent, CLIOptionPermanent),
 	},
 	DiffKey: []string{
 		DescDiff, "Accepts multiple remote paths for line by line comparison",
 		skipChecksumNote,
+		fmt.Sprintf("Use `%s` to also show how the remote's starred and trashed states, description, properties and permissions", CLIOptionMetadata),
+		"have drifted from what they were when last pulled or pushed",
+	},
+	DoctorKey: []string{
+		DescDoctor, "Checks connectivity and proxy settings, clock skew, credential validity and scopes,",
+		"whether the Drive API is enabled, quota status and the health of the local index",
+		"For each failed check, a suggested fix is printed",
 	},
 	EditDescriptionShortKey: []string{
 		DescEdit, "Accepts multiple remote paths as well as ids",
 	},
 	EmptyTrashKey: []string{
-		DescEmptyTrash,
+		DescEmptyTrash, fmt.Sprintf("Requires `%s` since it cannot be undone", CLIOptionPermanent),
 	},
 	FeaturesKey: []string{
 		DescFeatures,
 	},
+	IndexKey: []string{
+		DescIndex, fmt.Sprintf("Use `%s` to remove indices of files that no longer exist remotely", CLIOptionPruneIndices),
+		fmt.Sprintf("`drive %s %s` checks every index and removes those that are unreadable, stale,", IndexKey, IndexFsckKey),
+		"stored under the wrong id or orphaned in no folder, which otherwise cause duplicate uploads",
+	},
 	InitKey: []string{
 		DescInit, "Requests for access to your Google Drive",
 		"Creating a folder that contains your credentials",
 		"Note: `init` in an already initialized drive will erase the old credentials",
+		fmt.Sprintf("Use `%s` to choose where indices are kept, `drive %s` fetches them into a new backend", IndexBackendKey, IndexKey),
+		fmt.Sprintf("Use `%s` and `%s` to make the API requests of the context under that application name and quota user,", ApplicationNameKey, QuotaUserKey),
+		"both kept when initializing again. Pass them empty, e.g `-quota-user=`, to stop setting them",
 	},
 	PullKey: []string{
 		DescPull, "Downloads content from the remote drive or modifies",
 		" local content to match that on your Google Drive",
 		skipChecksumNote,
+		fmt.Sprintf("With `%s`, `%s` writes only a byte range of each file e.g `%s 0-1048575`", CLIOptionPiped, CLIOptionRange, CLIOptionRange),
+		fmt.Sprintf("Use `%s` and `%s` to only pull files modified within that time e.g `%s 7d`", CLIOptionNewerThan, CLIOptionOlderThan, CLIOptionNewerThan),
+		fmt.Sprintf("Use `%s` and `%s` to only pull files within those sizes e.g `%s 1G`", CLIOptionMinSize, CLIOptionMaxSize, CLIOptionMaxSize),
+		fmt.Sprintf("Use `%s` to only pull the files matching its comma separated patterns e.g `%s '*.raw,*.xmp'`", CLIOptionInclude, CLIOptionInclude),
+		"Paths can be quoted globs e.g `'reports/2024-*/**.pdf'`, expanded against the remote tree, `**` matching at any depth",
+		fmt.Sprintf("Use `%s newer|local|remote|keep-both|skip` to settle files changed on both sides instead of aborting", CLIOptionConflict),
+		fmt.Sprintf("Use `%s` to print the changes without making them, with `%s` as a JSON plan of each change, its size and the totals", CLIOptionDryRun, CLIOptionJSON),
+		fmt.Sprintf("Use `%s shortcut` to pull shortcuts as symlinks to the local copies of their targets", CLIOptionLinks),
+		fmt.Sprintf("Use `%s` to restore the modes recorded by `push -%s`, and `%s` their uids and gids", CLIOptionPreservePerms, CLIOptionPreservePerms, CLIOptionPreserveOwner),
+		fmt.Sprintf("Use `%s` to delete the local files no longer on the remote only once everything else is pulled, asking before", CLIOptionMirror),
+		fmt.Sprintf("more than `%s` of them, %d by default, and refusing them if that can't be asked", CLIOptionMaxDeletions, DefaultMirrorMaxDeletions),
+		fmt.Sprintf("Use `%s` to download trashed content, e.g before it is purged, without deleting local files that aren't in the trash", CLIOptionInTrash),
+		fmt.Sprintf("Use `%s N` to download large files as N concurrent byte ranges, e.g over high-latency links,", CLIOptionChunkConcurrency),
+		fmt.Sprintf("and `%s N` to transfer N files at once, e.g many for small files and few for huge ones", CLIOptionTransfers),
+		fmt.Sprintf("Use `%s`, `%s` and `%s` to tune how many times, for how long and on which failures requests are retried", CLIOptionRetries, CLIOptionRetryMaxWait, CLIOptionRetryOn),
+		fmt.Sprintf("Use `%s file` to also write the summary of the run, its files transferred, skipped and failed, bytes, throughput,", CLIOptionStatsJSON),
+		"elapsed time and API calls, as JSON e.g for backup logs",
+		fmt.Sprintf("Use `%s` to only pull what the Changes API reports changed since the last incremental pull of the same paths,", CLIOptionIncremental),
+		"the first one walking the paths in full. Files permanently deleted remotely are only reconciled by a full pull",
+		fmt.Sprintf("Use `%s` to only pull the files modified remotely since a date or age, searched for instead of walking the paths", CLIOptionChangedSince),
+		fmt.Sprintf("Use `%s` to unpack archives e.g those pushed with `push -%s` into the folders they were pushed from,", CLIOptionExtract, CLIOptionAsArchive),
+		fmt.Sprintf("the format being recorded on the archive or inferred from its name unless `%s` is set", CLIOptionArchiveFormat),
+		fmt.Sprintf("Use `%s file` to pull many remote paths, each into its own local path in the context, as one batch", CLIOptionBatch),
+		fmt.Sprintf("Use `%s` with `%s` to only export the docs whose latest revision wasn't exported yet, printing a JSON object", CLIOptionExportIfChanged, ExportsKey),
+		fmt.Sprintf("with the revision id, modifiedTime and exports of each. With `%s`, the exports are inlined in the JSON instead of written out", CLIOptionPiped),
 	},
 	PushKey: []string{
 		DescPush, "Uploads content to your Google Drive from your local path",
@@ -327,15 +684,83 @@ var docMap = map[string][]string{
 		"\t* Ordinary push: `drive push path1 path2 path3`",
 		"\t* Mounted push: `drive push -m path1 [path2 path3] drive_context_path`",
 		skipChecksumNote,
+		fmt.Sprintf("Use `%s` and `%s` to only push files modified within that time e.g `%s 7d`", CLIOptionNewerThan, CLIOptionOlderThan, CLIOptionNewerThan),
+		fmt.Sprintf("Use `%s` to only push the files matching its comma separated patterns e.g `%s '*.raw,*.xmp'`", CLIOptionInclude, CLIOptionInclude),
+		"Paths can be quoted globs e.g `'reports/2024-*/**.pdf'`, expanded against the local tree, `**` matching at any depth",
+		fmt.Sprintf("Use `%s newer|local|remote|keep-both|skip` to settle files changed on both sides instead of aborting", CLIOptionConflict),
+		fmt.Sprintf("Use `%s` to print the changes without making them, with `%s` as a JSON plan of each change, its size and the totals", CLIOptionDryRun, CLIOptionJSON),
+		fmt.Sprintf("Use `%s follow|skip|shortcut` to push what symlinks point to, leave them out or push them as shortcuts", CLIOptionLinks),
+		fmt.Sprintf("Use `%s error|update-newest|update-by-id` to pick which of several same-named remote entries a file updates", CLIOptionDuplicateTitles),
+		fmt.Sprintf("Use `%s` to record the mode of each pushed file, and `%s` its uid and gid, to be restored on pull", CLIOptionPreservePerms, CLIOptionPreserveOwner),
+		fmt.Sprintf("Use `%s` to trash the remote files no longer local only once everything else is pushed, asking before", CLIOptionMirror),
+		fmt.Sprintf("more than `%s` of them, %d by default, and refusing them if that can't be asked", CLIOptionMaxDeletions, DefaultMirrorMaxDeletions),
+		fmt.Sprintf("Use `%s` to gzip files that aren't compressed already as they are uploaded, pull decompressing them", CLIOptionCompress),
+		fmt.Sprintf("Use `%s N` to push N large files at once, small ones being pushed %d times as many at once unless `%s` is set", CLIOptionTransfers, DefaultSmallFileJobsFactor, CLIOptionSmallFileJobs),
+		fmt.Sprintf("Use `%s`, `%s` and `%s` to tune how many times, for how long and on which failures requests are retried", CLIOptionRetries, CLIOptionRetryMaxWait, CLIOptionRetryOn),
+		fmt.Sprintf("Use `%s file` to also write the summary of the run, its files transferred, skipped and failed, bytes, throughput,", CLIOptionStatsJSON),
+		"elapsed time and API calls, as JSON e.g for backup logs",
+		fmt.Sprintf("Use `%s path` to record what was pushed in a checksum manifest uploaded alongside the data", CLIOptionManifest),
+		fmt.Sprintf("Use `%s file` to push many local paths, each to its own remote path, as one batch", CLIOptionBatch),
+		fmt.Sprintf("Use `%s` to push shortcuts in place of the duplicates recorded by `dedupe -%s -%s`", CLIOptionSkipDuplicates, CLIOptionDedupeLocal, CLIOptionRecordDuplicates),
+	},
+	DuKey: []string{
+		DescDu, "Usage: drive du [-depth n|-r] <paths...>",
+		"Prints the size of each file, then the cumulative size of each folder once its contents are tallied, and a grand total",
+		"Like du, folders beyond the depth still count towards the totals of those above them, they just aren't printed",
+		"Google-native docs have no size and are counted by the storage they use instead",
+		fmt.Sprintf("Use `%s` to also tally each Shared Drive that you are a member of, under `%s/`", CLIOptionAllDrives, SharedDrivesDir),
 	},
 	ListKey: []string{
 		DescList,
 		"List the information of a remote path not necessarily present locally",
 		"Allows printing of long options and by default does minimal printing",
+		fmt.Sprintf("Use `%s` to request and print exactly the listed API fields", FieldsKey),
+		fmt.Sprintf("and `%s` to print them as JSON objects", CLIOptionJSON),
+		fmt.Sprintf("Use `%s` to page long listings through $PAGER instead of prompting", CLIOptionPager),
+		fmt.Sprintf("Use `%s` to stop after that many pages. Without a terminal on both ends, pages follow each other without prompting", CLIOptionMaxPages),
+		fmt.Sprintf("Use `%s` to list the starred files and folders across the whole drive, regardless of the path", CLIOptionStarred),
+		fmt.Sprintf("Use `%s` to order the output like find, and `%s` or `%s`", CLIOptionDepthFirst, CLIOptionDirsFirst, CLIOptionFilesFirst),
+		"to group folders and files within each folder",
+		fmt.Sprintf("Use `%s` to report empty or inaccessible folders, also reflected in the exit status", CLIOptionReportEmpty),
+		fmt.Sprintf("Use `%s` to show owners by name and email along with who shared each file with you", CLIOptionOwnerEmails),
+		fmt.Sprintf("With `shared`, filter by sharer using `%s` and by date using `%s` and `%s`", SharedFromKey, SharedAfterKey, SharedBeforeKey),
+		fmt.Sprintf("Use `%s` to show a column like `es-m` of whether you can edit, share, delete or move files out of drive", CLIOptionCapabilities),
+		fmt.Sprintf("Use `%s` to show each file's permissions e.g `[alice@example.com:owner anyone(link):reader]`, so that", CLIOptionPerms),
+		"a recursive listing doubles as a sharing audit",
+		fmt.Sprintf("Use `%s` to show image and video metadata, also included with `%s` and `%s`", CLIOptionMedia, FieldsKey, CLIOptionJSON),
+		fmt.Sprintf("Use `%s` to print an RFC 4180 CSV with a header row, its columns set by `%s` from", CLIOptionCSV, CLIOptionCSVColumns),
+		"path, name, id, type, size, modTime, mimeType, md5, version, shared, owners, sharedBy, role and url",
+		"or the name of any field returned by the API",
+		fmt.Sprintf("Use `%s` to print the listing like the tree command, with each folder's file count and cumulative size", CLIOptionTree),
+		fmt.Sprintf("Use `%s` with `%s` to match titles by regular expression e.g '^report_\\d{4}\\.pdf$'", CLIOptionRegex, MatchesKey),
+		fmt.Sprintf("Use `%s` to print each file through a Go text/template, with the file's fields and its `.Path`", CLIOptionFormat),
+		"e.g '{{.Id}} {{.Size}} {{.Path}}'. The functions `bytes` and `join` format sizes and lists of strings",
+		fmt.Sprintf("Use `%s` and `%s` to only list files modified within that time, dates or ages like 7d", CLIOptionNewerThan, CLIOptionOlderThan),
+		fmt.Sprintf("Use `%s` and `%s` to only list files within those sizes, with units like 10M", CLIOptionMinSize, CLIOptionMaxSize),
 	},
 	MoveKey: []string{
 		DescMove,
 		"Moves files/folders between folders",
+		fmt.Sprintf("Use `%s` to keep the permissions inherited from the old folder by granting them directly", CLIOptionPreservePermissions),
+	},
+	MkdirKey: []string{
+		DescMkdir, "Usage: drive mkdir [-p] [-print-id] <paths...>",
+		fmt.Sprintf("With `%s`, missing intermediate folders are created in a single pass down each path", CLIOptionParents),
+		fmt.Sprintf("With `%s`, a tab separated id and path is printed per folder created, the path asked for", CLIOptionPrintId),
+		"coming last even if it already existed, so that scripts can capture ids for later `-id` operations",
+	},
+	DedupeKey: []string{
+		DescDedupe, "Usage: drive dedupe -local [-record] [paths...]",
+		"Files are grouped by size and only those of the same size are checksummed. Each set is printed with its md5",
+		"checksum and size, its paths sorted so that the first is the copy kept, largest wasted space first",
+		fmt.Sprintf("With `%s`, the duplicates are remembered in the context for `push -%s`, which then uploads", CLIOptionRecordDuplicates, CLIOptionSkipDuplicates),
+		"only the first copy of each set and a shortcut to it in place of every other one",
+	},
+	ResolveKey: []string{
+		DescResolve, "Usage: drive resolve [-from conflicts.json] [-strategy newer|local|remote|keep-both|skip]",
+		fmt.Sprintf("Conflicts that abort a push or pull, or that `%s skip` leaves out, are written to %s/%s", CLIOptionConflict, config.GDDirSuffix, config.ConflictsReport),
+		"with the checksum, modTime and size of each side and a suggested resolution, keeping the side modified last",
+		fmt.Sprintf("`drive %s` pushes or pulls those paths again, settling each with its suggested resolution or with `%s`", ResolveKey, CLIOptionResolveStrategy),
 	},
 	OpenKey: []string{
 		DescOpen, fmt.Sprintf("toggle between %q=bool and %q=bool",
@@ -353,10 +778,26 @@ var docMap = map[string][]string{
 		"Specify the emails to share with as well as the message to send them on notification",
 		"Accepted values for:\n+ accountType: ",
 		DescAccountTypes, "\n+ roles:", DescRoles,
+		fmt.Sprintf("Use `%s` to print the permissions that would be inserted without inserting them", CLIOptionDryRun),
 	},
 	StatKey: []string{
 		DescStat, "provides detailed information about a remote file",
 		"Accepts multiple paths",
+		fmt.Sprintf("Use `%s` to include image and video metadata and `%s` to print JSON objects", CLIOptionMedia, CLIOptionJSON),
+	},
+	StatsKey: []string{
+		DescStats, "Every push and pull records its bytes, files, failures and duration",
+		"in the local index. `drive stats -last 30d` summarizes those runs and",
+		"compares the first and second halves of the window to show trends",
+	},
+	SyncKey: []string{
+		DescSync, "Usage: drive sync [-dry-run] [paths...]",
+		"The state of each synced file is recorded in the local index, for the next sync to tell",
+		"which side changed since. Additions, modifications and deletions on one side are carried",
+		"over to the other while files changed on both sides, or that differ without having",
+		"been synced before, are reported as conflicts and left as they are",
+		"Folders are created on the side missing them but never deleted, nor are Google Docs synced",
+		fmt.Sprintf("Remote deletions are moved to the trash unless `%s` is set", CLIOptionPermanent),
 	},
 	TouchKey: []string{
 		DescTouch, "Given a list of remote files `touch` updates their",
@@ -368,12 +809,20 @@ var docMap = map[string][]string{
 	UnshareKey: []string{
 		DescUnshare, "Accepts multiple paths",
 		"Accepted values for accountTypes::", DescAccountTypes,
+		fmt.Sprintf("Use `%s` to print the permissions that would be deleted without deleting them", CLIOptionDryRun),
 	},
 	UntrashKey: []string{
 		DescUntrash, "takes remote files out of the trash",
 		"Note: untrash is a relative path command so any resolutions are made",
 		"relative to the current working directory i.e",
 		"\n\t$ drive trash mnt/logos",
+		fmt.Sprintf("Use `%s <path>` to restore into that folder instead, e.g when the original parent is itself trashed", CLIOptionUntrashTo),
+	},
+	UndoKey: []string{
+		DescUndo, "Pushes of new or modified files, trashes, untrashes, moves, renames, shares and unshares",
+		"are recorded in the local index, up to the last 1000. `drive undo -last N` reverses the N most recent",
+		"of those that haven't yet been undone, most recent first",
+		"Note: modifications of Google Docs and permanent deletions cannot be undone",
 	},
 	UnpubKey: []string{
 		DescUnpublish, "revokes public access to a list of remote files",
@@ -381,9 +830,40 @@ var docMap = map[string][]string{
 	UrlKey: []string{
 		DescUrl, "takes multiple paths or ids",
 	},
+	ShareLinkKey: []string{
+		DescLink, fmt.Sprintf("Usage: drive link [-%s reader|commenter|writer] -%s|-%s <domain> <paths...>", RoleKey, CLIOptionAnyone, CLIOptionDomain),
+		"The files are not made discoverable, only those with the link get access. The role defaults to reader",
+		"Existing permissions granting the same access are reused instead of being added again",
+	},
+	CatKey: []string{
+		DescCat, fmt.Sprintf("Usage: drive cat [-id] [-%s first-last|first-|-suffixLength] [-%s formats] <paths...>", CLIOptionRange, ExportsKey),
+		fmt.Sprintf("Use `%s` to fetch only part of huge files e.g the header of a video or the end of a zip,", CLIOptionRange),
+		"without downloading all of them",
+		fmt.Sprintf("Google Docs have no raw content, use `%s` to print them exported to the first of its comma separated", ExportsKey),
+		fmt.Sprintf("formats that they can be exported to e.g `drive cat -%s txt Notes/standup`", ExportsKey),
+	},
+	RevisionsKey: []string{
+		DescRevisions, fmt.Sprintf("Usage: drive revisions [-id] [-%s <rev-id>|-%s <rev-id>|-%s <rev-id>] <paths...>", CLIOptionDownload, CLIOptionPin, CLIOptionUnpin),
+		"Lists the id, modifier, size, modification date and whether each revision is kept forever",
+		fmt.Sprintf("`%s`, `%s` and `%s` act on a single path or id", CLIOptionDownload, CLIOptionPin, CLIOptionUnpin),
+		"Google Docs revisions have no raw content so they can't be downloaded",
+	},
+	ExistsKey: []string{
+		DescExists, "Usage: drive exists [-id] <paths...>",
+		"Only looks up the paths, without listing or traversing them, to make shell conditionals cheap",
+		"e.g `if drive exists Photos/2016; then ...`. Trashed files are not considered to exist",
+		"A failed lookup exits with a status other than 1",
+	},
 	VersionKey: []string{
 		DescVersion, fmt.Sprintf("current version is: %s", Version),
 	},
+	WatchKey: []string{
+		DescWatch, "Runs until interrupted, polling the changes feed of your drive",
+		fmt.Sprintf("Each argument is a rule `<folder> [events] [webhook]`, more can be read from `%s`", WatchRulesKey),
+		fmt.Sprintf("Events default to `%s` and webhooks to `%s`", WatchOnKey, WatchWebhookKey),
+		"Alerts are logged and POSTed as JSON objects to the rule's webhook if any",
+		fmt.Sprintf("With `%s`, all changes are also summed up periodically and sent to `%s` and `%s`", WatchDigestKey, WatchDigestWebhookKey, WatchDigestEmailKey),
+	},
 }
 
 func createAndRegisterAliases() map[string][]string {
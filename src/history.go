@@ -0,0 +1,201 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/odeke-em/drive/config"
+)
+
+const (
+	HistoryOpPushAdd = "push-add"
+	HistoryOpPushMod = "push-mod"
+	HistoryOpTrash   = "trash"
+	HistoryOpUntrash = "untrash"
+	HistoryOpMove    = "move"
+	HistoryOpRename  = "rename"
+	HistoryOpShare   = "share"
+	HistoryOpUnshare = "unshare"
+)
+
+const (
+	DefaultUndoCount = 1
+)
+
+func (g *Commands) recordHistory(entry *config.HistoryEntry) {
+	if err := g.context.AppendHistory(entry); err != nil {
+		g.log.LogErrf("history: %s %s: %v\n", entry.Op, entry.Path, err)
+	}
+}
+
+func historyEntryDescription(entry *config.HistoryEntry) string {
+	target := entry.Path
+	if target == "" {
+		target = entry.FileId
+	}
+
+	switch entry.Op {
+	case HistoryOpPushAdd:
+		return fmt.Sprintf("trash newly pushed %q", target)
+	case HistoryOpPushMod:
+		return fmt.Sprintf("restore %q to revision %s from before it was pushed", target, entry.OldRevisionId)
+	case HistoryOpTrash:
+		return fmt.Sprintf("untrash %q", target)
+	case HistoryOpUntrash:
+		return fmt.Sprintf("trash %q", target)
+	case HistoryOpMove:
+		return fmt.Sprintf("move %q back to its original parent", target)
+	case HistoryOpRename:
+		return fmt.Sprintf("rename %q back to %q", target, entry.OldName)
+	case HistoryOpShare:
+		return fmt.Sprintf("revoke %s access for %q on %q", entry.Role, entry.Value, target)
+	case HistoryOpUnshare:
+		return fmt.Sprintf("restore %s access for %q on %q", entry.Role, entry.Value, target)
+	}
+
+	return fmt.Sprintf("unknown operation %q on %q", entry.Op, target)
+}
+
+func (g *Commands) undoHistoryEntry(entry *config.HistoryEntry) error {
+	switch entry.Op {
+	case HistoryOpPushAdd, HistoryOpUntrash:
+		return g.rem.Trash(entry.FileId)
+	case HistoryOpTrash:
+		return g.rem.Untrash(entry.FileId)
+	case HistoryOpPushMod:
+		return g.restoreRevision(entry.FileId, entry.OldRevisionId)
+	case HistoryOpMove:
+		var addParentIds []string
+		if entry.OldParentId != "" {
+			addParentIds = append(addParentIds, entry.OldParentId)
+		}
+		return g.rem.reparent(entry.FileId, addParentIds, []string{entry.NewParentId})
+	case HistoryOpRename:
+		_, err := g.rem.rename(entry.FileId, entry.OldName)
+		return err
+	case HistoryOpShare:
+		return g.rem.deletePermission(entry.FileId, entry.PermissionId)
+	case HistoryOpUnshare:
+		perm := permission{
+			fileId:      entry.FileId,
+			value:       entry.Value,
+			role:        reverseRoleResolve(entry.Role),
+			accountType: reverseAccountTypeResolve(entry.AccountType),
+			withLink:    entry.WithLink,
+		}
+		_, err := g.rem.insertPermissions(&perm)
+		return err
+	}
+
+	return illogicalStateErr(fmt.Errorf("cannot undo unknown operation %q", entry.Op))
+}
+
+// headRevisionId returns the id of the revision of f's current content,
+// which only files with content of their own rather than Google Docs have.
+func headRevisionId(f *File) string {
+	if f == nil || f.raw == nil {
+		return ""
+	}
+	return f.raw.HeadRevisionId
+}
+
+// restoreRevision makes the content of revision revId that of fileId again,
+// as a new revision since Drive can't make an older revision the head one.
+func (g *Commands) restoreRevision(fileId, revId string) error {
+	rev, err := g.rem.findRevision(fileId, revId)
+	if err != nil {
+		return remoteLookupErr(fmt.Errorf("revision %s: %v", revId, err))
+	}
+	if rev.DownloadUrl == "" {
+		return googleDocNonExportErr(fmt.Errorf("revision %s has no raw content to restore", revId))
+	}
+
+	body, err := g.rem.Download(fileId, rev.DownloadUrl)
+	if err != nil {
+		return err
+	}
+	if body == nil {
+		return downloadFailedErr(fmt.Errorf("revision %s: empty body", revId))
+	}
+	defer body.Close()
+
+	_, err = g.rem.updateContent(fileId, body, rev.ModifiedDate)
+	return err
+}
+
+func (g *Commands) requestedUndoCount() (int, error) {
+	if g.opts.Meta == nil {
+		return DefaultUndoCount, nil
+	}
+
+	lastL := (*g.opts.Meta)[UndoLastKey]
+	if len(lastL) < 1 || lastL[0] == "" {
+		return DefaultUndoCount, nil
+	}
+
+	n, err := strconv.Atoi(lastL[0])
+	if err != nil || n < 1 {
+		return 0, invalidArgumentsErr(fmt.Errorf("--%s expects a positive number, got %q", UndoLastKey, lastL[0]))
+	}
+
+	return n, nil
+}
+
+func (g *Commands) Undo() (err error) {
+	n, err := g.requestedUndoCount()
+	if err != nil {
+		return err
+	}
+
+	entries, err := g.context.LastHistory(n)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) < 1 {
+		return noMatchesFoundErr(fmt.Errorf("no operations to undo"))
+	}
+
+	if g.opts.canPrompt() {
+		g.log.Logln("The following will be undone, most recent first:")
+		for _, entry := range entries {
+			g.log.Logf("\t%s\n", historyEntryDescription(entry))
+		}
+
+		if status := promptForChanges(); !accepted(status) {
+			return status.Error()
+		}
+	}
+
+	for _, entry := range entries {
+		description := historyEntryDescription(entry)
+		if uErr := g.undoHistoryEntry(entry); uErr != nil {
+			err = reComposeError(err, fmt.Sprintf("undo: %s err: %v", description, uErr))
+			continue
+		}
+
+		if mErr := g.context.MarkHistoryUndone(entry); mErr != nil {
+			g.log.LogErrf("undo: %s: marking as undone: %v\n", description, mErr)
+		}
+
+		if g.opts.Verbose {
+			g.log.Logf("undo: %s\n", description)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/odeke-em/drive/config"
+)
+
+// QuotaUserHeader attributes a request to a user for quota and reporting,
+// as the quotaUser parameter of Google APIs does.
+const QuotaUserHeader = "X-Goog-Quota-User"
+
+// identityTransport tells Google which application, and which user
+// of it, API requests are made on behalf of.
+type identityTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	quotaUser string
+}
+
+func (t *identityTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request it was given.
+	clone := new(http.Request)
+	*clone = *req
+	clone.Header = make(http.Header, len(req.Header))
+	for key, values := range req.Header {
+		clone.Header[key] = append([]string(nil), values...)
+	}
+
+	if t.userAgent != "" {
+		clone.Header.Set("User-Agent", strings.TrimSpace(t.userAgent+" "+clone.Header.Get("User-Agent")))
+	}
+	if t.quotaUser != "" && clone.Header.Get(QuotaUserHeader) == "" {
+		clone.Header.Set(QuotaUserHeader, t.quotaUser)
+	}
+
+	return t.base.RoundTrip(clone)
+}
+
+// withIdentity wraps base to make requests under the application name and
+// quota user configured for context, if any.
+func withIdentity(base http.RoundTripper, context *config.Context) http.RoundTripper {
+	if context == nil || (context.ApplicationName == "" && context.QuotaUser == "") {
+		return base
+	}
+	t := &identityTransport{base: base, quotaUser: context.QuotaUser}
+	if context.ApplicationName != "" {
+		t.userAgent = fmt.Sprintf("%s drive/%s", context.ApplicationName, Version)
+	}
+	return t
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/odeke-em/drive/config"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestWithIdentity(t *testing.T) {
+	var seen *http.Request
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		seen = req
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	if rt := withIdentity(base, &config.Context{}); reflect.ValueOf(rt).Pointer() != reflect.ValueOf(base).Pointer() {
+		t.Errorf("expected the base transport without an identity configured")
+	}
+
+	rt := withIdentity(base, &config.Context{ApplicationName: "reports-sync", QuotaUser: "etl@example.com"})
+	req, _ := http.NewRequest("GET", "https://www.googleapis.com/drive/v2/about", nil)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	wantUA := fmt.Sprintf("reports-sync drive/%s google-api-go-client/0.5", Version)
+	if got := seen.Header.Get("User-Agent"); got != wantUA {
+		t.Errorf("expected User-Agent %q, got %q", wantUA, got)
+	}
+	if got := seen.Header.Get(QuotaUserHeader); got != "etl@example.com" {
+		t.Errorf("expected quota user etl@example.com, got %q", got)
+	}
+	if got := req.Header.Get(QuotaUserHeader); got != "" {
+		t.Errorf("the original request was modified, got quota user %q", got)
+	}
+}
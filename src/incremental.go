@@ -0,0 +1,314 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/odeke-em/drive/config"
+	drive "google.golang.org/api/drive/v2"
+)
+
+// underPath tells whether the remote path p is relToRoot or within it.
+func underPath(p, relToRoot string) bool {
+	if rootLike(relToRoot) || p == relToRoot {
+		return true
+	}
+	return strings.HasPrefix(p, strings.TrimSuffix(relToRoot, RemoteSeparator)+RemoteSeparator)
+}
+
+func withinAny(p string, dirs []string) bool {
+	for _, dir := range dirs {
+		if underPath(p, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// pullIncremental resolves the pull of each source from the changes made
+// since its last incremental pull, or walks it in full the first time.
+// The checkpoints to carry on from are saved once the pull succeeds.
+func (g *Commands) pullIncremental() (cl, clashes []*Change, err error) {
+	startToken := ""
+
+	for _, relToRootPath := range g.opts.Sources {
+		cp, cpErr := g.context.ChangesCheckpoint(relToRootPath)
+		if cpErr != nil {
+			return cl, clashes, cpErr
+		}
+
+		var ccl, cclashes []*Change
+		var cErr error
+		next := &config.ChangesCheckpoint{Path: relToRootPath}
+
+		if cp == nil {
+			// Taken before walking so that nothing changed meanwhile is missed.
+			if startToken == "" {
+				if startToken, err = g.rem.changesStartPageToken(); err != nil {
+					return cl, clashes, err
+				}
+			}
+			next.PageToken = startToken
+			ccl, cclashes, cErr = g.changeListResolve(relToRootPath, g.context.AbsPathOf(relToRootPath), false)
+		} else {
+			var changes []*drive.Change
+			changes, next.PageToken, cErr = g.rem.changesSincePageToken(cp.PageToken)
+			if cErr == nil {
+				ccl, cclashes, cErr = g.resolveRemoteChanges(relToRootPath, changes)
+			}
+		}
+
+		clashes = append(clashes, cclashes...)
+		cl = append(cl, ccl...)
+		if cErr != nil && cErr != ErrClashesDetected {
+			return cl, clashes, cErr
+		}
+		g.changesCheckpoints = append(g.changesCheckpoints, next)
+	}
+
+	if len(clashes) >= 1 {
+		err = ErrClashesDetected
+	}
+	return cl, clashes, err
+}
+
+// resolveRemoteChanges turns the changes within relToRoot into a change
+// list. Folders already present locally are only compared down to their
+// immediate children, while new ones are walked in full. Files renamed or
+// moved are also compared where they were before, as recorded by the last
+// push or pull of them, or else by comparing the folder they are in now
+// for renames in place, so that their old local copies don't linger on.
+func (g *Commands) resolveRemoteChanges(relToRoot string, changes []*drive.Change) (cl, clashes []*Change, err error) {
+	changed := map[string]*drive.File{}
+	current := map[string][]string{}
+	backPaths := map[string][]string{}
+	unplaced := 0
+
+	for _, ch := range changes {
+		if ch == nil {
+			continue
+		}
+		if ch.File == nil {
+			// Permanently deleted, there is nothing left to tell where it was.
+			unplaced += 1
+			continue
+		}
+		paths := g.changedFilePaths(ch.File, backPaths)
+		current[ch.File.Id] = paths
+		for _, p := range paths {
+			if underPath(p, relToRoot) {
+				changed[p] = ch.File
+			}
+		}
+	}
+
+	if unplaced >= 1 && g.opts.Verbose {
+		g.log.Logf("%d permanently deleted files could not be placed, pull without `-%s` to reconcile them\n", unplaced, CLIOptionIncremental)
+	}
+
+	previous := g.checksums.remotePaths()
+
+	for _, parent := range g.unrecordedParents(relToRoot, previous, current) {
+		ccl, cclashes, _, cErr := g.resolveRemoteChange(parent, nil)
+		clashes = append(clashes, cclashes...)
+		cl = append(cl, ccl...)
+		if cErr != nil && cErr != ErrClashesDetected {
+			err = combineErrors(err, cErr)
+		}
+	}
+
+	var paths []string
+	for p := range changed {
+		paths = append(paths, p)
+	}
+	for _, p := range vacatedPaths(relToRoot, previous, current) {
+		if _, ok := changed[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	// Children of folders walked in full are covered already.
+	var walked []string
+	for _, p := range paths {
+		if withinAny(p, walked) {
+			continue
+		}
+
+		f, known := changed[p]
+		if known && f.Labels != nil && f.Labels.Trashed {
+			// Left for resolveRemoteChange to look up, where it will
+			// find the file gone unless another one took its place.
+			known = false
+		}
+
+		var r *File
+		if known {
+			r = NewRemoteFile(f)
+		}
+		ccl, cclashes, full, cErr := g.resolveRemoteChange(p, r)
+		if full {
+			walked = append(walked, p)
+		}
+		clashes = append(clashes, cclashes...)
+		cl = append(cl, ccl...)
+		if cErr != nil && cErr != ErrClashesDetected {
+			err = combineErrors(err, cErr)
+		}
+	}
+
+	// Compared folders cover some of the changed files as well.
+	cl = uniqueChangesByPath(cl)
+
+	if err == nil && len(clashes) >= 1 {
+		err = ErrClashesDetected
+	}
+	return cl, clashes, err
+}
+
+// resolveRemoteChange compares the remote file r at p with the local one,
+// looking r up by path if nil. Folders present on both sides are only
+// compared down to their immediate children, otherwise full tells that
+// the remote folder was walked in full.
+func (g *Commands) resolveRemoteChange(p string, r *File) (cl, clashes []*Change, full bool, err error) {
+	if r == nil {
+		var rErr error
+		r, rErr = g.rem.FindByPath(p)
+		if rErr != nil && rErr != ErrPathNotExists {
+			return nil, nil, false, rErr
+		}
+		if r != nil && r.Labels != nil && r.Labels.Trashed {
+			r = nil
+		}
+	}
+
+	fsPath := g.context.AbsPathOf(p)
+	l, err := g.resolveToLocalFile(p, fsPath)
+	if err != nil || (l == nil && r == nil) {
+		return nil, nil, false, err
+	}
+
+	depth := g.opts.Depth
+	if l != nil && l.IsDir && r != nil && r.IsDir {
+		depth = 2
+	} else if r != nil && r.IsDir {
+		full = true
+	}
+	cl, clashes, err = g.doChangeListRecvDepth(p, fsPath, l, r, false, depth)
+	return cl, clashes, full, err
+}
+
+// vacatedPaths returns the paths within relToRoot that the files changed,
+// keyed by id in current along with their paths now, were previously at
+// but are no longer e.g since renamed or moved.
+func vacatedPaths(relToRoot string, previous, current map[string][]string) []string {
+	var vacated []string
+	for id, paths := range current {
+		for _, prev := range previous[id] {
+			if !underPath(prev, relToRoot) {
+				continue
+			}
+			stillThere := false
+			for _, p := range paths {
+				if p == prev {
+					stillThere = true
+					break
+				}
+			}
+			if !stillThere {
+				vacated = append(vacated, prev)
+			}
+		}
+	}
+	sort.Strings(vacated)
+	return vacated
+}
+
+// unrecordedParents returns the folders within relToRoot of the files
+// changed that were synced before but whose previous paths weren't
+// recorded, for a rename in place to be caught by comparing the folder.
+func (g *Commands) unrecordedParents(relToRoot string, previous, current map[string][]string) []string {
+	seen := map[string]bool{}
+	var parents []string
+	for id, paths := range current {
+		if _, recorded := previous[id]; recorded {
+			continue
+		}
+		if _, iErr := g.context.DeserializeIndex(id); iErr != nil {
+			// Never synced, so there is no old copy to leave behind.
+			continue
+		}
+		for _, p := range paths {
+			parent := path.Dir(p)
+			if parent == p || seen[parent] || !underPath(parent, relToRoot) {
+				continue
+			}
+			seen[parent] = true
+			parents = append(parents, parent)
+		}
+	}
+	sort.Strings(parents)
+	return parents
+}
+
+func uniqueChangesByPath(cl []*Change) []*Change {
+	seen := map[string]bool{}
+	var unique []*Change
+	for _, c := range cl {
+		if c == nil || seen[c.Path] {
+			continue
+		}
+		seen[c.Path] = true
+		unique = append(unique, c)
+	}
+	return unique
+}
+
+// changedFilePaths returns the paths of f from the paths of its parents,
+// which, unlike f, are looked up as it could be trashed by now.
+func (g *Commands) changedFilePaths(f *drive.File, backPaths map[string][]string) []string {
+	var paths []string
+	for _, parent := range f.Parents {
+		if parent == nil {
+			continue
+		}
+		if parent.IsRoot {
+			paths = append(paths, remotePathJoin(urlToPath(f.Title, true)))
+			continue
+		}
+
+		parentPaths, ok := backPaths[parent.Id]
+		if !ok {
+			parentPaths, _ = g.rem.FindBackPaths(parent.Id)
+			backPaths[parent.Id] = parentPaths
+		}
+		for _, parentPath := range parentPaths {
+			paths = append(paths, remotePathJoin(parentPath, urlToPath(f.Title, true)))
+		}
+	}
+	return paths
+}
+
+func (g *Commands) saveChangesCheckpoints() error {
+	var err error
+	for _, cp := range g.changesCheckpoints {
+		err = combineErrors(err, g.context.SaveChangesCheckpoint(cp))
+	}
+	g.changesCheckpoints = nil
+	return err
+}
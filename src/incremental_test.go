@@ -0,0 +1,71 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnderPath(t *testing.T) {
+	cases := []struct {
+		p, relToRoot string
+		want         bool
+	}{
+		{"/a/b", "/", true},
+		{"/a", "/a", true},
+		{"/a/b", "/a", true},
+		{"/a/b", "/a/", true},
+		{"/ab", "/a", false},
+		{"/a b/c", "/a", false},
+	}
+	for _, tc := range cases {
+		if got := underPath(tc.p, tc.relToRoot); got != tc.want {
+			t.Errorf("underPath(%q, %q): expected %v, got %v", tc.p, tc.relToRoot, tc.want, got)
+		}
+	}
+}
+
+func TestVacatedPaths(t *testing.T) {
+	previous := map[string][]string{
+		"renamed":   {"/docs/old.txt"},
+		"moved":     {"/docs/a/moved.txt"},
+		"unchanged": {"/docs/same.txt"},
+		"outside":   {"/other/x.txt"},
+		"stale":     {"/docs/stale.txt", "/docs/kept.txt"},
+	}
+	current := map[string][]string{
+		"renamed":   {"/docs/new.txt"},
+		"moved":     {"/docs/b/moved.txt"},
+		"unchanged": {"/docs/same.txt"},
+		"outside":   {"/docs/x.txt"},
+		"stale":     {"/docs/kept.txt"},
+		"new":       {"/docs/new-file.txt"},
+	}
+
+	got := vacatedPaths("/docs", previous, current)
+	want := []string{"/docs/a/moved.txt", "/docs/old.txt", "/docs/stale.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestUniqueChangesByPath(t *testing.T) {
+	cl := []*Change{{Path: "/a"}, {Path: "/b"}, nil, {Path: "/a"}}
+	got := uniqueChangesByPath(cl)
+	if len(got) != 2 || got[0] != cl[0] || got[1] != cl[1] {
+		t.Errorf("expected the first change of each path, got %v", got)
+	}
+}
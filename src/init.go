@@ -58,7 +58,7 @@ func (g *Commands) InitWithServiceAccount(gsaFilepath string) error {
 		return err
 	}
 
-	jwtConfig, err := google.JWTConfigFromJSON(blob, DriveScope)
+	jwtConfig, err := google.JWTConfigFromJSON(blob, DriveScope, DriveLabelsScope)
 	if err != nil {
 		return err
 	}
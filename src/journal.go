@@ -0,0 +1,53 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"github.com/odeke-em/drive/config"
+)
+
+// uploadedBefore returns the file that an interrupted push already
+// created at relToRoot, if the journal says that one was in flight.
+// Only then is the parent searched since that costs a request per file.
+func (g *Commands) uploadedBefore(relToRoot, parentId string, src *File) *File {
+	intent, err := g.context.UploadIntent(relToRoot)
+	if err != nil {
+		g.log.LogErrf("journal: %s: %v\n", relToRoot, err)
+		return nil
+	}
+	if intent == nil || intent.ParentId != parentId {
+		return nil
+	}
+
+	existing, err := g.rem.findUploaded(parentId, src)
+	if err != nil {
+		g.log.LogErrf("journal: %s: %v\n", relToRoot, err)
+		return nil
+	}
+	return existing
+}
+
+func (g *Commands) journalUploadIntent(relToRoot, parentId string) {
+	intent := &config.UploadIntent{Path: relToRoot, ParentId: parentId}
+	if err := g.context.JournalUploadIntent(intent); err != nil {
+		g.log.LogErrf("journal: %s: %v\n", relToRoot, err)
+	}
+}
+
+func (g *Commands) clearUploadIntent(relToRoot string) {
+	if err := g.context.ClearUploadIntent(relToRoot); err != nil {
+		g.log.LogErrf("journal: %s: %v\n", relToRoot, err)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	drive "google.golang.org/api/drive/v2"
+	drivelabels "google.golang.org/api/drivelabels/v2"
+)
+
+const labelNamePrefix = "labels/"
+
+// fileLabel is a Drive Label applied to a file. Its name and those of
+// its fields are the ids if the Labels API couldn't be asked for them.
+type fileLabel struct {
+	Id     string
+	Name   string
+	Fields []*fileLabelField
+}
+
+type fileLabelField struct {
+	Id     string
+	Name   string
+	Values []string
+}
+
+func (fl *fileLabel) String() string {
+	var fields []string
+	for _, field := range fl.Fields {
+		fields = append(fields, fmt.Sprintf("%s=%s", field.Name, strings.Join(field.Values, "|")))
+	}
+	if len(fields) < 1 {
+		return fl.Name
+	}
+	return fmt.Sprintf("%s{%s}", fl.Name, strings.Join(fields, "; "))
+}
+
+func (f *File) labelsColumn() string {
+	if len(f.labels) < 1 {
+		return "-"
+	}
+	var labels []string
+	for _, label := range f.labels {
+		labels = append(labels, label.String())
+	}
+	return strings.Join(labels, ", ")
+}
+
+// labelIdOf accepts label ids with or without their "labels/" prefix.
+func labelIdOf(s string) string {
+	return strings.TrimPrefix(strings.TrimSpace(s), labelNamePrefix)
+}
+
+// labelStringify matches files with all the labels, letting folders
+// through too if they are to be traversed.
+func labelStringify(labelIds []string, traversesFolders bool) []string {
+	var clauses []string
+	for _, id := range labelIds {
+		clauses = append(clauses, fmt.Sprintf("%s in labels", strconv.Quote(labelNamePrefix+labelIdOf(id))))
+	}
+	if len(clauses) < 1 {
+		return nil
+	}
+	expr := strings.Join(clauses, " and ")
+	if traversesFolders {
+		return []string{fmt.Sprintf("(mimeType = '%s' or (%s))", DriveFolderMimeType, expr)}
+	}
+	return []string{fmt.Sprintf("(%s)", expr)}
+}
+
+func (r *Remote) listLabels(fileId string) ([]*drive.Label, error) {
+	var labels []*drive.Label
+	pageToken := ""
+	for {
+		req := r.service.Files.ListLabels(fileId)
+		if pageToken != "" {
+			req = req.PageToken(pageToken)
+		}
+		res, err := req.Do()
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, res.Items...)
+		if pageToken = res.NextPageToken; pageToken == "" {
+			return labels, nil
+		}
+	}
+}
+
+// labelSchema looks up the names of a label and of its fields and
+// choices. Lookups that failed, e.g for lack of the labels scope,
+// are remembered too so that they aren't retried for every file.
+func (r *Remote) labelSchema(id string) *drivelabels.GoogleAppsDriveLabelsV2Label {
+	r.labelsMu.Lock()
+	defer r.labelsMu.Unlock()
+
+	if schema, ok := r.labelSchemas[id]; ok {
+		return schema
+	}
+
+	var schema *drivelabels.GoogleAppsDriveLabelsV2Label
+	if r.labelsService != nil {
+		schema, _ = r.labelsService.Labels.Get(labelNamePrefix + id).View("LABEL_VIEW_BASIC").Do()
+	}
+	if r.labelSchemas == nil {
+		r.labelSchemas = make(map[string]*drivelabels.GoogleAppsDriveLabelsV2Label)
+	}
+	r.labelSchemas[id] = schema
+	return schema
+}
+
+func (r *Remote) fileLabels(fileId string) ([]*fileLabel, error) {
+	labels, err := r.listLabels(fileId)
+	if err != nil {
+		return nil, err
+	}
+
+	var resolved []*fileLabel
+	for _, label := range labels {
+		resolved = append(resolved, resolveLabel(label, r.labelSchema(label.Id)))
+	}
+	return resolved, nil
+}
+
+func resolveLabel(label *drive.Label, schema *drivelabels.GoogleAppsDriveLabelsV2Label) *fileLabel {
+	fl := &fileLabel{Id: label.Id, Name: label.Id}
+	if schema != nil && schema.Properties != nil && schema.Properties.Title != "" {
+		fl.Name = schema.Properties.Title
+	}
+
+	schemaFields := map[string]*drivelabels.GoogleAppsDriveLabelsV2Field{}
+	if schema != nil {
+		for _, field := range schema.Fields {
+			schemaFields[field.Id] = field
+		}
+	}
+
+	var fieldIds []string
+	for id := range label.Fields {
+		fieldIds = append(fieldIds, id)
+	}
+	sort.Strings(fieldIds)
+
+	for _, id := range fieldIds {
+		schemaField := schemaFields[id]
+		field := &fileLabelField{Id: id, Name: id, Values: labelFieldValues(label.Fields[id], schemaField)}
+		if schemaField != nil && schemaField.Properties != nil && schemaField.Properties.DisplayName != "" {
+			field.Name = schemaField.Properties.DisplayName
+		}
+		fl.Fields = append(fl.Fields, field)
+	}
+	return fl
+}
+
+func labelFieldValues(lf drive.LabelField, schemaField *drivelabels.GoogleAppsDriveLabelsV2Field) []string {
+	values := append([]string{}, lf.DateString...)
+	values = append(values, lf.Text...)
+	for _, n := range lf.Integer {
+		values = append(values, strconv.FormatInt(n, 10))
+	}
+	for _, user := range lf.User {
+		if user != nil {
+			values = append(values, user.EmailAddress)
+		}
+	}
+
+	choices := map[string]string{}
+	if schemaField != nil && schemaField.SelectionOptions != nil {
+		for _, choice := range schemaField.SelectionOptions.Choices {
+			if choice.Properties != nil && choice.Properties.DisplayName != "" {
+				choices[choice.Id] = choice.Properties.DisplayName
+			}
+		}
+	}
+	for _, choiceId := range lf.Selection {
+		if name, ok := choices[choiceId]; ok {
+			choiceId = name
+		}
+		values = append(values, choiceId)
+	}
+	return values
+}
+
+// fetchLabels fills in the labels of f for the labels column.
+func (g *Commands) fetchLabels(f *File, mask int) {
+	if !withLabels(mask) || f.labels != nil {
+		return
+	}
+	labels, err := g.rem.fileLabels(f.Id)
+	if err != nil {
+		g.log.LogErrf("%s: labels: %v\n", f.Name, err)
+		return
+	}
+	f.labels = labels
+	if f.labels == nil {
+		f.labels = []*fileLabel{}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	drive "google.golang.org/api/drive/v2"
+	drivelabels "google.golang.org/api/drivelabels/v2"
+)
+
+func TestLabelStringify(t *testing.T) {
+	if got := labelStringify(nil, true); got != nil {
+		t.Errorf("expected no clauses, got %v", got)
+	}
+
+	got := labelStringify([]string{"abc", "labels/def"}, false)
+	expected := []string{`("labels/abc" in labels and "labels/def" in labels)`}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	got = labelStringify([]string{"abc"}, true)
+	expected = []string{fmt.Sprintf(`(mimeType = '%s' or ("labels/abc" in labels))`, DriveFolderMimeType)}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestResolveLabel(t *testing.T) {
+	label := &drive.Label{
+		Id: "lbl",
+		Fields: map[string]drive.LabelField{
+			"f2": {Selection: []string{"c1", "c9"}},
+			"f1": {Integer: []int64{3}, Text: []string{"x"}},
+		},
+	}
+
+	if got := resolveLabel(label, nil).String(); got != "lbl{f1=x|3; f2=c1|c9}" {
+		t.Errorf("expected ids without a schema, got %q", got)
+	}
+
+	schema := &drivelabels.GoogleAppsDriveLabelsV2Label{
+		Properties: &drivelabels.GoogleAppsDriveLabelsV2LabelProperties{Title: "Contract"},
+		Fields: []*drivelabels.GoogleAppsDriveLabelsV2Field{
+			{Id: "f2", Properties: &drivelabels.GoogleAppsDriveLabelsV2FieldProperties{DisplayName: "Status"},
+				SelectionOptions: &drivelabels.GoogleAppsDriveLabelsV2FieldSelectionOptions{
+					Choices: []*drivelabels.GoogleAppsDriveLabelsV2FieldSelectionOptionsChoice{
+						{Id: "c1", Properties: &drivelabels.GoogleAppsDriveLabelsV2FieldSelectionOptionsChoiceProperties{DisplayName: "Signed"}},
+					},
+				},
+			},
+		},
+	}
+	if got := resolveLabel(label, schema).String(); got != "Contract{f1=x|3; Status=Signed|c9}" {
+		t.Errorf("expected names from the schema, got %q", got)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	drive "google.golang.org/api/drive/v2"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	LeaseAcquireKey = "acquire"
+	LeaseReleaseKey = "release"
+
+	LeaseHolderProperty  = "drive.lease.holder"
+	LeaseExpiresProperty = "drive.lease.expires"
+
+	DefaultLeaseTTL = "15m"
+)
+
+// lease is a best-effort lock on a file, kept in its public properties so
+// that it is seen by every machine and updated only if the file's etag is
+// still the one it was read with.
+type lease struct {
+	holder  string
+	expires time.Time
+}
+
+func leaseOf(props []*drive.Property) *lease {
+	l := &lease{}
+	for _, prop := range props {
+		if prop == nil || prop.Visibility != "PUBLIC" {
+			continue
+		}
+		switch prop.Key {
+		case LeaseHolderProperty:
+			l.holder = prop.Value
+		case LeaseExpiresProperty:
+			l.expires, _ = time.Parse(time.RFC3339, prop.Value)
+		}
+	}
+	if l.holder == "" {
+		return nil
+	}
+	return l
+}
+
+func (l *lease) heldAt(t time.Time) bool {
+	return l != nil && t.Before(l.expires)
+}
+
+// withLease returns props with the lease properties replaced by l's.
+func withLease(props []*drive.Property, l *lease) []*drive.Property {
+	var merged []*drive.Property
+	for _, prop := range props {
+		if prop == nil {
+			continue
+		}
+		if prop.Visibility == "PUBLIC" && (prop.Key == LeaseHolderProperty || prop.Key == LeaseExpiresProperty) {
+			continue
+		}
+		merged = append(merged, &drive.Property{Key: prop.Key, Value: prop.Value, Visibility: prop.Visibility})
+	}
+	return append(merged,
+		&drive.Property{Key: LeaseHolderProperty, Value: l.holder, Visibility: "PUBLIC"},
+		&drive.Property{Key: LeaseExpiresProperty, Value: l.expires.UTC().Format(time.RFC3339), Visibility: "PUBLIC"},
+	)
+}
+
+func preconditionFailed(err error) bool {
+	gErr, ok := err.(*googleapi.Error)
+	return ok && gErr != nil && gErr.Code == http.StatusPreconditionFailed
+}
+
+func (g *Commands) Lease() error {
+	args := g.opts.Sources
+	if len(args) < 2 {
+		return invalidArgumentsErr(fmt.Errorf("lease: expected `%s|%s <paths...>`", LeaseAcquireKey, LeaseReleaseKey))
+	}
+
+	holder, ttl, err := g.leaseArgs()
+	if err != nil {
+		return err
+	}
+
+	var fn func(string, string, time.Duration) error
+	switch subcommand := args[0]; subcommand {
+	case LeaseAcquireKey:
+		fn = g.leaseAcquire
+	case LeaseReleaseKey:
+		fn = g.leaseRelease
+	default:
+		return invalidArgumentsErr(fmt.Errorf("lease: unknown subcommand %q, expected %s or %s", subcommand, LeaseAcquireKey, LeaseReleaseKey))
+	}
+
+	for _, p := range args[1:] {
+		if lErr := fn(p, holder, ttl); lErr != nil {
+			err = combineErrors(err, lErr)
+		}
+	}
+	return err
+}
+
+func (g *Commands) leaseArgs() (holder string, ttl time.Duration, err error) {
+	meta := map[string][]string{}
+	if g.opts.Meta != nil {
+		meta = *g.opts.Meta
+	}
+	if holders := meta[CLIOptionLeaseHolder]; len(holders) >= 1 {
+		holder = holders[0]
+	}
+	if holder == "" {
+		return "", 0, invalidArgumentsErr(fmt.Errorf("lease: -%s cannot be empty", CLIOptionLeaseHolder))
+	}
+
+	spec := DefaultLeaseTTL
+	if ttls := meta[CLIOptionLeaseTTL]; len(ttls) >= 1 && ttls[0] != "" {
+		spec = ttls[0]
+	}
+	if ttl, err = time.ParseDuration(spec); err != nil || ttl <= 0 {
+		return "", 0, invalidArgumentsErr(fmt.Errorf("lease: -%s %q: expected a positive duration e.g 10m", CLIOptionLeaseTTL, spec))
+	}
+	return holder, ttl, nil
+}
+
+// leaseAcquire takes, or renews if holder has it already, the lease on p
+// unless another holder's lease hasn't expired.
+func (g *Commands) leaseAcquire(p, holder string, ttl time.Duration) error {
+	f, current, err := g.leaseLookup(p)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if current.heldAt(now) && current.holder != holder {
+		return leaseHeldErr(fmt.Errorf("%s is leased by %s until %s", p, current.holder, current.expires.Local().Format(time.RFC3339)))
+	}
+
+	acquired := &lease{holder: holder, expires: now.Add(ttl).Round(time.Second)}
+	if _, err := g.rem.patchProperties(f.Id, f.Etag, withLease(f.Properties, acquired)); err != nil {
+		if preconditionFailed(err) {
+			return leaseHeldErr(fmt.Errorf("%s changed while acquiring its lease, try again", p))
+		}
+		return err
+	}
+
+	// Read back in case the conditional update wasn't honoured.
+	if _, current, err = g.leaseLookup(p); err != nil {
+		return err
+	}
+	if current == nil || current.holder != holder {
+		return leaseHeldErr(fmt.Errorf("%s was leased by another holder meanwhile", p))
+	}
+
+	g.log.Logf("%s leased by %s until %s\n", p, holder, acquired.expires.Local().Format(time.RFC3339))
+	return nil
+}
+
+// leaseRelease expires holder's lease on p, another holder's lease only
+// being released if forced.
+func (g *Commands) leaseRelease(p, holder string, _ time.Duration) error {
+	f, current, err := g.leaseLookup(p)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if !current.heldAt(now) {
+		g.log.Logf("%s isn't leased\n", p)
+		return nil
+	}
+	if current.holder != holder && !g.opts.Force {
+		return leaseHeldErr(fmt.Errorf("%s is leased by %s, use -%s to release it anyway", p, current.holder, ForceKey))
+	}
+
+	released := &lease{holder: current.holder, expires: now}
+	if _, err := g.rem.patchProperties(f.Id, f.Etag, withLease(f.Properties, released)); err != nil {
+		if preconditionFailed(err) {
+			return leaseHeldErr(fmt.Errorf("%s changed while releasing its lease, try again", p))
+		}
+		return err
+	}
+
+	g.log.Logf("%s released by %s\n", p, holder)
+	return nil
+}
+
+func (g *Commands) leaseLookup(p string) (*drive.File, *lease, error) {
+	f, err := g.rem.FindByPath(p)
+	if err == nil && f == nil {
+		err = ErrPathNotExists
+	}
+	if err != nil {
+		return nil, nil, remoteLookupErr(fmt.Errorf("%s: %v", p, err))
+	}
+
+	// The full resource for its etag and properties.
+	raw, err := g.rem.service.Files.Get(f.Id).Do()
+	if err != nil {
+		return nil, nil, remoteLookupErr(fmt.Errorf("%s: %v", p, err))
+	}
+	return raw, leaseOf(raw.Properties), nil
+}
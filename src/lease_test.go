@@ -0,0 +1,53 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+	"time"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+func TestLeaseProperties(t *testing.T) {
+	now := time.Date(2016, 3, 14, 15, 0, 0, 0, time.UTC)
+	props := []*drive.Property{
+		{Key: "project", Value: "apollo", Visibility: "PUBLIC"},
+		{Key: LeaseHolderProperty, Value: "mine", Visibility: "PRIVATE"},
+	}
+	if l := leaseOf(props); l != nil || l.heldAt(now) {
+		t.Errorf("expected no lease from private properties, got %+v", l)
+	}
+
+	leased := withLease(props, &lease{holder: "build-01", expires: now.Add(10 * time.Minute)})
+	if len(leased) != 4 || leased[0].Key != "project" || leased[1].Visibility != "PRIVATE" {
+		t.Errorf("expected the other properties to be kept, got %d", len(leased))
+	}
+	l := leaseOf(leased)
+	if l == nil || l.holder != "build-01" || !l.expires.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("expected build-01's lease, got %+v", l)
+	}
+	if !l.heldAt(now) || l.heldAt(now.Add(10*time.Minute)) {
+		t.Errorf("expected the lease to be held for 10 minutes")
+	}
+
+	renewed := withLease(leased, &lease{holder: "build-02", expires: now})
+	if len(renewed) != 4 {
+		t.Errorf("expected the lease properties to be replaced, got %d properties", len(renewed))
+	}
+	if l := leaseOf(renewed); l == nil || l.holder != "build-02" || l.heldAt(now) {
+		t.Errorf("expected build-02's expired lease, got %+v", l)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/odeke-em/drive/config"
+	drive "google.golang.org/api/drive/v2"
+)
+
+// linkRoles are the roles that a shareable link can grant.
+var linkRoles = []Role{Reader, Commenter, Writer}
+
+type linkSpec struct {
+	role        Role
+	accountType AccountType
+	domain      string
+}
+
+func (g *Commands) resolveLinkSpec() (*linkSpec, error) {
+	meta := map[string][]string{}
+	if g.opts.Meta != nil {
+		meta = *g.opts.Meta
+	}
+
+	spec := &linkSpec{role: Reader}
+	if roles := meta[RoleKey]; len(roles) >= 1 && roles[0] != "" {
+		role, err := normalizeRole(roles[0])
+		if err != nil {
+			return nil, err
+		}
+		spec.role = UnknownRole
+		for _, linkRole := range linkRoles {
+			if role == linkRole {
+				spec.role = role
+			}
+		}
+		if spec.role == UnknownRole {
+			return nil, invalidArgumentsErr(fmt.Errorf("link: a link cannot grant %q, expected reader, commenter or writer", role.String()))
+		}
+	}
+
+	accountTypes := meta[AccountTypeKey]
+	if len(accountTypes) != 1 {
+		return nil, invalidArgumentsErr(fmt.Errorf("link: expecting exactly one of `-%s` or `-%s <domain>`", CLIOptionAnyone, CLIOptionDomain))
+	}
+	spec.accountType = reverseAccountTypeResolve(accountTypes[0])
+
+	if spec.accountType == Domain {
+		if domains := meta[CLIOptionDomain]; len(domains) >= 1 {
+			spec.domain = strings.TrimSpace(domains[0])
+		}
+		if spec.domain == "" {
+			return nil, invalidArgumentsErr(fmt.Errorf("link: `-%s` expects a domain e.g example.com", CLIOptionDomain))
+		}
+	}
+
+	return spec, nil
+}
+
+// grantedBy reports whether perm already gives the access that spec asks for.
+func (spec *linkSpec) grantedBy(perm *drive.Permission) bool {
+	if perm == nil || perm.Type != spec.accountType.String() {
+		return false
+	}
+	if spec.accountType == Domain && !strings.EqualFold(perm.Domain, spec.domain) {
+		return false
+	}
+	if perm.Role == spec.role.String() {
+		return true
+	}
+	for _, role := range perm.AdditionalRoles {
+		if role == spec.role.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Commands) linkFile(f *File, spec *linkSpec) error {
+	perms, err := g.rem.listPermissions(f.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, perm := range perms {
+		if spec.grantedBy(perm) {
+			if g.opts.Verbose {
+				g.log.Logf("link: %s is already shared with %s as %s\n", f.Name, spec.accountType.String(), spec.role.String())
+			}
+			return nil
+		}
+	}
+
+	perm := permission{
+		fileId:      f.Id,
+		value:       spec.domain,
+		role:        spec.role,
+		accountType: spec.accountType,
+		withLink:    true,
+	}
+
+	inserted, err := g.rem.insertPermissions(&perm)
+	if err != nil {
+		return err
+	}
+
+	if inserted != nil {
+		g.recordHistory(&config.HistoryEntry{
+			Op:     HistoryOpShare,
+			FileId: f.Id, Path: f.Name,
+			PermissionId: inserted.Id,
+			Role:         spec.role.String(),
+			AccountType:  inserted.Type,
+			Value:        perm.value,
+			WithLink:     inserted.WithLink,
+		})
+	}
+	return nil
+}
+
+// Link shares each source with anyone or a domain that has the link,
+// then prints the resulting URL.
+func (g *Commands) Link(byId bool) (err error) {
+	spec, err := g.resolveLinkSpec()
+	if err != nil {
+		return err
+	}
+
+	linker := func(f *File) interface{} {
+		if f == nil {
+			return ErrPathNotExists
+		}
+		if lErr := g.linkFile(f, spec); lErr != nil {
+			return lErr
+		}
+		return f.Url()
+	}
+
+	for kv := range resolver(g, byId, g.opts.Sources, linker) {
+		switch kv.value.(type) {
+		case error:
+			g.log.LogErrf("%s: %s\n", kv.key, kv.value)
+			err = reComposeError(err, fmt.Sprintf("%s: %v", kv.key, kv.value))
+		default:
+			g.log.Logf("%s: %v\n", kv.key, kv.value)
+		}
+	}
+
+	return err
+}
@@ -16,9 +16,14 @@ package drive
 
 import (
 	"fmt"
+	"path"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/odeke-em/log"
+	drive "google.golang.org/api/drive/v2"
+	"google.golang.org/api/googleapi"
 )
 
 type attribute struct {
@@ -26,6 +31,12 @@ type attribute struct {
 	mask          int
 	parent        string
 	diskUsageOnly bool
+	json          bool
+	fields        []string
+	media         bool
+	csv           *csvListing
+	format        *template.Template
+	palette       colorPalette
 }
 
 type traversalSt struct {
@@ -37,6 +48,97 @@ type traversalSt struct {
 	explicitNoPrompt bool
 	sorters          []string
 	matchQuery       *matchQuery
+	// report if set collects the folders that had nothing to list
+	// instead of them silently ending the traversal.
+	report *traversalReport
+	// node if set collects the traversal for printing as a tree.
+	node *treeNode
+	// usage if set tallies the cumulative size of the traversal for `du`.
+	usage *diskUsage
+	// resume if set is where this listing starts from, also
+	// meaning that it can be resumed from where it stops.
+	resume *resumeToken
+}
+
+type traversalReport struct {
+	empty  []string
+	denied []string
+}
+
+func accessDenied(err error) bool {
+	gErr, ok := err.(*googleapi.Error)
+	if !ok || gErr == nil {
+		return false
+	}
+	switch gErr.Code {
+	case 401, 403, 404:
+		return true
+	}
+	return false
+}
+
+func (g *Commands) newTraversalReport() *traversalReport {
+	if !reportEmpty(g.opts.TypeMask) {
+		return nil
+	}
+	return &traversalReport{}
+}
+
+// Err prints out the folders in the report and returns an error whose
+// status tells apart inaccessible folders from ones with no content.
+func (tr *traversalReport) Err(logy *log.Logger) error {
+	if tr == nil {
+		return nil
+	}
+
+	for _, p := range tr.denied {
+		logy.LogErrf("no access: %s\n", p)
+	}
+	for _, p := range tr.empty {
+		logy.LogErrf("empty: %s\n", p)
+	}
+
+	if len(tr.denied) >= 1 {
+		return accessDeniedErr(fmt.Errorf("%d folder(s) could not be accessed", len(tr.denied)))
+	}
+	if len(tr.empty) >= 1 {
+		return emptyFoldersErr(fmt.Errorf("%d folder(s) had no content", len(tr.empty)))
+	}
+	return nil
+}
+
+func (travSt traversalSt) child(file *File, headPath string, mask int) traversalSt {
+	return traversalSt{
+		depth:            travSt.depth,
+		file:             file,
+		headPath:         headPath,
+		inTrash:          travSt.inTrash,
+		mask:             mask,
+		explicitNoPrompt: travSt.explicitNoPrompt,
+		sorters:          travSt.sorters,
+		matchQuery:       travSt.matchQuery,
+		report:           travSt.report,
+		node:             travSt.node.find(file),
+		usage:            travSt.usage.child(),
+	}
+}
+
+// partitionDirs stably moves folders ahead of files if dirsAhead is
+// set otherwise files ahead of folders, retaining any sorted order.
+func partitionDirs(files []*File, dirsAhead bool) []*File {
+	var dirs, nonDirs []*File
+	for _, f := range files {
+		if f.IsDir {
+			dirs = append(dirs, f)
+		} else {
+			nonDirs = append(nonDirs, f)
+		}
+	}
+
+	if dirsAhead {
+		return append(dirs, nonDirs...)
+	}
+	return append(nonDirs, dirs...)
 }
 
 func sorters(opts *Options) []string {
@@ -66,14 +168,30 @@ func sorters(opts *Options) []string {
 }
 
 func (g *Commands) ListMatches() error {
+	if err := g.prepareListFormat(); err != nil {
+		return err
+	}
+	if err := g.checkStreamable(); err != nil {
+		return err
+	}
+	defer g.pageOutput()()
 
 	inTrash := trashed(g.opts.TypeMask)
 
-	mq := g.createMatchQuery(false)
+	mq, err := g.createMatchQuery(false)
+	if err != nil {
+		return err
+	}
 
-	mq.titleSearches = append(mq.titleSearches, fuzzyStringsValuePair{
+	titleSearch := &fuzzyStringsValuePair{
 		fuzzyLevel: Like, values: g.opts.Sources, inTrash: inTrash, joiner: Or,
-	})
+	}
+	if regexTitles(g.opts.TypeMask) {
+		if titleSearch, err = regexTitleSearch(g.opts.Sources, inTrash); err != nil {
+			return invalidArgumentsErr(fmt.Errorf("--%s: %v", CLIOptionRegex, err))
+		}
+	}
+	mq.titleSearches = append(mq.titleSearches, *titleSearch)
 
 	pagePair := g.rem.FindMatches(mq)
 
@@ -82,6 +200,7 @@ func (g *Commands) ListMatches() error {
 	defer spin.stop()
 
 	traversalCount := 0
+	usage := g.newDiskUsage()
 
 	matches := pagePair.filesChan
 	errsChan := pagePair.errsChan
@@ -98,9 +217,12 @@ func (g *Commands) ListMatches() error {
 				working = false
 				break
 			}
-			if match == nil {
+			if match == nil || !g.opts.admits(match) || !titleSearch.matchesTitle(match.Name) {
 				continue
 			}
+			if g.resultsExhausted() {
+				return nil
+			}
 
 			travSt := traversalSt{
 				depth:    g.opts.Depth,
@@ -109,6 +231,7 @@ func (g *Commands) ListMatches() error {
 				inTrash:  g.opts.InTrash,
 				mask:     g.opts.TypeMask,
 				sorters:  sorters(g.opts),
+				usage:    usage.child(),
 			}
 
 			traversalCount += 1
@@ -121,19 +244,28 @@ func (g *Commands) ListMatches() error {
 
 	if traversalCount < 1 {
 		g.log.LogErrln("no matches found!")
+	} else {
+		usage.total(g.log)
 	}
 
 	return nil
 }
 
-func (g *Commands) createMatchQuery(exactMatch bool) *matchQuery {
+func (g *Commands) createMatchQuery(exactMatch bool) (*matchQuery, error) {
 
 	mimeQuerySearches := []fuzzyStringsValuePair{}
 	titleSearches := []fuzzyStringsValuePair{}
 	ownerSearches := []fuzzyStringsValuePair{}
+	var mimeCategories, labelIds []string
 
 	if g.opts.Meta != nil {
 		meta := *(g.opts.Meta)
+		labelIds = meta[LabelKey]
+		mimeCategories = meta[MimeCategoryKey]
+		if _, err := mimeCategoryClauses(mimeCategories); err != nil {
+			return nil, invalidArgumentsErr(fmt.Errorf("--%s: %v", TypeKey, err))
+		}
+
 		skipMimes, sOk := meta[SkipMimeKeyKey]
 		if sOk {
 			mimeQuerySearches = append(mimeQuerySearches, fuzzyStringsValuePair{
@@ -183,23 +315,39 @@ func (g *Commands) createMatchQuery(exactMatch bool) *matchQuery {
 		mimeQuerySearches: mimeQuerySearches,
 		titleSearches:     titleSearches,
 		ownerSearches:     ownerSearches,
+		modifiedAfter:     g.opts.ModifiedAfter,
+		modifiedBefore:    g.opts.ModifiedBefore,
+		mimeCategories:    mimeCategories,
+		labelIds:          labelIds,
 	}
 
-	return &mq
+	return &mq, nil
 }
 
 func (g *Commands) List(byId bool) error {
+	if err := g.prepareListFormat(); err != nil {
+		return err
+	}
+	if err := g.checkStreamable(); err != nil {
+		return err
+	}
+	defer g.pageOutput()()
 	var kvList []*keyValue
 
-	resolver := g.rem.FindByPath
-	if byId {
-		resolver = g.rem.FindById
+	mq, err := g.createMatchQuery(true)
+	if err != nil {
+		return err
 	}
+	// Folders are only listed by type if they needn't be descended into.
+	mq.traversesFolders = g.opts.Depth != 1
 
-	mq := g.createMatchQuery(true)
+	resume, err := g.listResumeToken()
+	if err != nil {
+		return err
+	}
 
 	for i, relPath := range g.opts.Sources {
-		r, rErr := resolver(relPath)
+		r, resolvedById, rErr := g.findBySource(relPath, byId)
 		g.DebugPrintf("[Commands.List] #%d %q\n", i, relPath)
 		if rErr != nil && rErr != ErrPathNotExists {
 			return illogicalStateErr(fmt.Errorf("%v: '%s'", rErr, relPath))
@@ -211,7 +359,7 @@ func (g *Commands) List(byId bool) error {
 		}
 
 		parentPath := ""
-		if !byId {
+		if !resolvedById {
 			parentPath = g.parentPather(relPath)
 		} else {
 			parentPath = r.Id
@@ -230,6 +378,20 @@ func (g *Commands) List(byId bool) error {
 		kvList = append(kvList, &keyValue{key: parentPath, value: r})
 	}
 
+	if g.opts.AllDrives {
+		drives, dErr := g.rem.sharedDrives()
+		if dErr != nil {
+			return remoteLookupErr(fmt.Errorf("shared drives: %v", dErr))
+		}
+		for _, d := range drives {
+			root := &File{Id: d.Id, Name: sepJoin("/", SharedDrivesDir, d.Name), IsDir: true}
+			kvList = append(kvList, &keyValue{key: "", value: root})
+		}
+	}
+
+	report := g.newTraversalReport()
+	usage := g.newDiskUsage()
+
 	spin := g.playabler()
 	spin.play()
 	for _, kv := range kvList {
@@ -245,6 +407,9 @@ func (g *Commands) List(byId bool) error {
 			mask:       g.opts.TypeMask,
 			sorters:    sorters(g.opts),
 			matchQuery: mq,
+			report:     report,
+			usage:      usage.child(),
+			resume:     resume,
 		}
 
 		if !g.breadthFirst(travSt, spin) {
@@ -252,11 +417,126 @@ func (g *Commands) List(byId bool) error {
 		}
 	}
 	spin.stop()
+	usage.total(g.log)
 
-	return nil
+	if g.resumeFrom != nil {
+		g.log.LogErrf("more results remain, continue with -%s %s\n", CLIOptionResumeToken, g.resumeFrom)
+	}
+
+	return report.Err(g.log)
 }
 
-func (g *Commands) listSharedPerPath(relToRootPath string) ([]*keyValue, error) {
+// listResumeToken returns where a listing starts from if it can be resumed.
+// Only listings of a single folder's contents, in the order that Drive
+// lists them, can be since the token is of a position in its pages.
+func (g *Commands) listResumeToken() (*resumeToken, error) {
+	given := ""
+	if g.opts.Meta != nil {
+		if tokens := (*g.opts.Meta)[CLIOptionResumeToken]; len(tokens) >= 1 {
+			given = tokens[0]
+		}
+	}
+
+	mask := g.opts.TypeMask
+	sortKeys := sorters(g.opts)
+	sortedLocally := len(sortKeys) >= 1 && orderByClause(sortKeys) == ""
+	resumable := len(g.opts.Sources) == 1 && g.opts.Depth == 1 && !sortedLocally &&
+		!treeView(mask) && !diskUsageOnly(mask) && !dirsFirst(mask) && !filesFirst(mask)
+
+	if given == "" {
+		if !resumable || g.opts.MaxResults < 1 {
+			return nil, nil
+		}
+		return &resumeToken{}, nil
+	}
+	if !resumable {
+		return nil, invalidArgumentsErr(fmt.Errorf("--%s only resumes listings of a single folder, without recursion, local sorting or grouping", CLIOptionResumeToken))
+	}
+	rt, err := parseResumeToken(given)
+	if err != nil {
+		return nil, invalidArgumentsErr(fmt.Errorf("--%s: %v", CLIOptionResumeToken, err))
+	}
+	return rt, nil
+}
+
+// sharedFilter narrows down the files shared with the user by
+// who shared them and when they were shared.
+type sharedFilter struct {
+	from   []string
+	after  time.Time
+	before time.Time
+}
+
+func (g *Commands) sharedFilter() (*sharedFilter, error) {
+	if g.opts.Meta == nil {
+		return nil, nil
+	}
+
+	meta := *g.opts.Meta
+	sf := &sharedFilter{from: meta[SharedFromKey]}
+
+	now := time.Now()
+	for _, bound := range []struct {
+		key  string
+		dest *time.Time
+	}{
+		{key: SharedAfterKey, dest: &sf.after},
+		{key: SharedBeforeKey, dest: &sf.before},
+	} {
+		values := meta[bound.key]
+		if len(values) < 1 || values[0] == "" {
+			continue
+		}
+		t, err := parseTimeOrAge(values[0], now)
+		if err != nil {
+			return nil, invalidArgumentsErr(fmt.Errorf("--%s: %v", bound.key, err))
+		}
+		*bound.dest = t
+	}
+
+	if len(sf.from) < 1 && sf.after.IsZero() && sf.before.IsZero() {
+		return nil, nil
+	}
+	return sf, nil
+}
+
+func (sf *sharedFilter) matches(f *File) bool {
+	if sf == nil {
+		return true
+	}
+
+	if !sf.after.IsZero() || !sf.before.IsZero() {
+		if f.SharedWithMeTime.IsZero() {
+			return false
+		}
+		if !sf.after.IsZero() && f.SharedWithMeTime.Before(sf.after) {
+			return false
+		}
+		if !sf.before.IsZero() && !f.SharedWithMeTime.Before(sf.before) {
+			return false
+		}
+	}
+
+	if len(sf.from) < 1 {
+		return true
+	}
+
+	// Files shared directly by their owners might not have a sharingUser set.
+	sharers := f.Owners
+	if f.SharingUser != nil {
+		sharers = []*drive.User{f.SharingUser}
+	}
+	for _, sharer := range sharers {
+		for _, email := range sf.from {
+			if sharer != nil && strings.EqualFold(sharer.EmailAddress, email) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *Commands) listSharedPerPath(relToRootPath string, filter *sharedFilter) ([]*keyValue, error) {
 	pagePair := g.rem.FindByPathShared(relToRootPath)
 	errsChan := pagePair.errsChan
 	sharedRemotes := pagePair.filesChan
@@ -287,7 +567,7 @@ func (g *Commands) listSharedPerPath(relToRootPath string) ([]*keyValue, error)
 				parentPath = ""
 			}
 
-			if s == nil {
+			if s == nil || !filter.matches(s) {
 				continue
 			}
 
@@ -303,20 +583,34 @@ func (g *Commands) listSharedPerPath(relToRootPath string) ([]*keyValue, error)
 }
 
 func (g *Commands) ListShared() (err error) {
+	if err := g.prepareListFormat(); err != nil {
+		return err
+	}
+	if err := g.checkStreamable(); err != nil {
+		return err
+	}
+	defer g.pageOutput()()
 	spin := g.playabler()
 	spin.play()
 	defer spin.stop()
 
+	filter, err := g.sharedFilter()
+	if err != nil {
+		return err
+	}
+
 	var kvList []*keyValue
 
 	for _, relPath := range g.opts.Sources {
-		childKvList, err := g.listSharedPerPath(relPath)
+		childKvList, err := g.listSharedPerPath(relPath, filter)
 		if err != nil {
 			return err
 		}
 		kvList = append(kvList, childKvList...)
 	}
 
+	report := g.newTraversalReport()
+	usage := g.newDiskUsage()
 	for _, kv := range kvList {
 		if kv == nil || kv.value == nil {
 			continue
@@ -328,6 +622,8 @@ func (g *Commands) ListShared() (err error) {
 			headPath: kv.key,
 			inTrash:  g.opts.InTrash,
 			mask:     g.opts.TypeMask,
+			report:   report,
+			usage:    usage.child(),
 		}
 
 		if !g.breadthFirst(travSt, spin) {
@@ -335,22 +631,46 @@ func (g *Commands) ListShared() (err error) {
 		}
 	}
 	spin.stop()
-	return
+	usage.total(g.log)
+	return report.Err(g.log)
 }
 
 func (f *File) pretty(logy *log.Logger, opt attribute) {
+	if opt.csv != nil {
+		opt.csv.write(logy, f, sepJoin("/", opt.parent, f.Name))
+		return
+	}
+
+	if opt.format != nil {
+		f.prettyFormat(logy, opt.format, sepJoin("/", opt.parent, f.Name))
+		return
+	}
+
+	if opt.json || len(opt.fields) >= 1 {
+		f.prettyFields(logy, opt)
+		return
+	}
+
 	fmtdPath := sepJoin("/", opt.parent, f.Name)
 
 	if opt.diskUsageOnly {
-		logy.Logf("%-12v %s\n", f.Size, fmtdPath)
+		logy.Logf("%-12v %s\n", f.usageSize(), fmtdPath)
 		return
 	}
 
+	fmtdPath = opt.palette.paint(f, fmtdPath, trashed(opt.mask))
+
+	if f.shortcutTarget != "" {
+		fmtdPath = fmt.Sprintf("%s -> %s", fmtdPath, f.shortcutTarget)
+	}
+
 	if opt.minimal {
 		logy.Logf("%s", fmtdPath)
 	} else {
 		if f.IsDir {
 			logy.Logf("d")
+		} else if f.isShortcut() {
+			logy.Logf("l")
 		} else {
 			logy.Logf("-")
 		}
@@ -365,7 +685,30 @@ func (f *File) pretty(logy *log.Logger, opt attribute) {
 		}
 	}
 
-	if owners(opt.mask) && len(f.OwnerNames) >= 1 {
+	if capabilities(opt.mask) {
+		logy.Logf(" %s ", f.capabilitiesColumn())
+	}
+
+	if opt.media {
+		logy.Logf(" [%s] ", f.mediaColumn())
+	}
+
+	if permissionsColumn(opt.mask) {
+		logy.Logf(" [%s] ", f.permissionsColumn())
+	}
+
+	if withLabels(opt.mask) {
+		logy.Logf(" [%s] ", f.labelsColumn())
+	}
+
+	if ownerEmails(opt.mask) {
+		if descriptions := f.ownerDescriptions(); len(descriptions) >= 1 {
+			logy.Logf(" %s ", strings.Join(descriptions, " & "))
+		}
+		if f.SharingUser != nil {
+			logy.Logf(" shared by %s ", userDescription(f.SharingUser))
+		}
+	} else if owners(opt.mask) && len(f.OwnerNames) >= 1 {
 		logy.Logf(" %s ", strings.Join(f.OwnerNames, " & "))
 	}
 
@@ -380,14 +723,71 @@ func (f *File) pretty(logy *log.Logger, opt attribute) {
 	}
 }
 
-func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
+// fetchPermissions fills in the permissions of f for the permissions
+// column if the listing did not already include them.
+func (g *Commands) fetchPermissions(f *File, mask int) {
+	if !permissionsColumn(mask) || f.Permissions != nil {
+		return
+	}
+	perms, err := g.rem.listPermissions(f.Id)
+	if err != nil {
+		g.log.LogErrf("%s: permissions: %v\n", f.Name, err)
+		return
+	}
+	f.Permissions = perms
+}
+
+// isShortcut reports whether f is a shortcut to another file or folder.
+// Shortcuts aren't folders so even those to folders aren't descended into.
+func (f *File) isShortcut() bool {
+	return f.MimeType == DriveShortcutMimeType
+}
+
+// resolveShortcut fills in the path that a shortcut points to, or
+// its id if it has no path, for listings to show after the shortcut.
+func (g *Commands) resolveShortcut(f *File, mask int) {
+	if !resolveShortcuts(mask) || !f.isShortcut() || f.ShortcutDetails == nil {
+		return
+	}
+	targetId := f.ShortcutDetails.TargetId
+	f.shortcutTarget = IdSourcePrefix + targetId
+	backPaths, err := g.rem.FindBackPaths(targetId)
+	if err != nil {
+		g.log.LogErrf("%s: shortcut target %s: %v\n", f.Name, targetId, err)
+		return
+	}
+	if len(backPaths) >= 1 {
+		f.shortcutTarget = path.Join("/", backPaths[0])
+	}
+}
 
+// listingAttribute returns how files are to be printed by listings.
+func (g *Commands) listingAttribute(mask int) attribute {
 	opt := attribute{
 		minimal:       isMinimal(g.opts.TypeMask),
 		diskUsageOnly: diskUsageOnly(g.opts.TypeMask),
-		mask:          travSt.mask,
+		mask:          mask,
+		json:          jsonOutput(g.opts.TypeMask),
+		fields:        requestedFields(g.opts),
+		media:         mediaMetadata(g.opts.TypeMask),
+		format:        g.listFormat,
+		palette:       g.listPalette,
+	}
+	if opt.media && len(opt.fields) >= 1 {
+		opt.fields = withMediaFields(opt.fields)
+	}
+	if csvOutput(g.opts.TypeMask) {
+		opt.csv = g.listingCSV()
+	}
+	return opt
+}
+
+func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) (completed bool) {
+	if treeView(g.opts.TypeMask) && travSt.node == nil {
+		return g.treeFirst(travSt, spin)
 	}
 
+	opt := g.listingAttribute(travSt.mask)
 	opt.parent = ""
 	if travSt.headPath != "/" {
 		opt.parent = travSt.headPath
@@ -395,7 +795,16 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 
 	f := travSt.file
 	if !f.IsDir {
-		f.pretty(g.log, opt)
+		travSt.usage.add(f.usageSize())
+		if !g.takeResult() {
+			return false
+		}
+		if travSt.node == nil {
+			g.fetchPermissions(f, opt.mask)
+			g.fetchLabels(f, opt.mask)
+			g.resolveShortcut(f, opt.mask)
+			f.pretty(g.log, opt)
+		}
 		return true
 	}
 
@@ -404,14 +813,32 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 		opt.parent = sepJoin("/", opt.parent, f.Name)
 	}
 
+	usage := travSt.usage
+
 	// A depth of < 0 means traverse as deep as you can
 	if travSt.depth == 0 {
-		// At the end of the line, this was successful.
-		return true
+		if usage == nil {
+			// At the end of the line, this was successful.
+			return true
+		}
+		// Like du, sizes beyond the depth still count, they just aren't printed.
+		usage.quiet = true
 	} else if travSt.depth > 0 {
 		travSt.depth -= 1
 	}
 
+	if usage != nil {
+		defer func() {
+			if completed {
+				usage.done(g.log, opt.parent)
+			}
+		}()
+	}
+
+	if g.resultsExhausted() {
+		return false
+	}
+
 	expr := buildExpression(f.Id, travSt.mask, travSt.inTrash)
 
 	if travSt.matchQuery != nil {
@@ -421,27 +848,124 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 
 	req := g.rem.service.Files.List()
 	req.Q(expr)
-	req.MaxResults(g.opts.PageSize)
+	if g.opts.AllDrives {
+		req.SupportsAllDrives(true).IncludeItemsFromAllDrives(true)
+	}
+	if pageSize := g.pageSize(); pageSize >= 1 {
+		req.MaxResults(pageSize)
+	}
+
+	orderBy := orderByClause(travSt.sorters)
+	if orderBy != "" {
+		req.OrderBy(orderBy)
+	}
+
+	if len(opt.fields) >= 1 {
+		req.Fields(listFieldsSelector(opt.fields))
+	}
 
 	spin.pause()
 
-	canPrompt := !travSt.explicitNoPrompt
+	// A tree is only printed once fully traversed so there is nothing to page through.
+	canPrompt := !travSt.explicitNoPrompt && travSt.node == nil
 	if canPrompt {
-		canPrompt = g.opts.canPrompt()
+		canPrompt = g.opts.canPaginate()
 	}
 
 	spin.play()
 
 	onlyFiles := nonFolderExplicitly(g.opts.TypeMask)
+	streaming := ndjsonOutput(g.opts.TypeMask)
+
+	canRecurse := !travSt.inTrash && !g.opts.InTrash
+	descendImmediately := canRecurse && depthFirst(g.opts.TypeMask)
 
 	iterCount := uint64(0)
+	visited := 0
+
+	// Files are only buffered if they have to be sorted or grouped locally,
+	// otherwise they are visited as they arrive in the order requested.
+	buffered := (len(travSt.sorters) >= 1 && orderBy == "") || dirsFirst(g.opts.TypeMask) || filesFirst(g.opts.TypeMask)
+
+	var children []*File
+	visit := func(file *File) bool {
+		visited += 1
+		if !g.opts.admits(file) {
+			return true
+		}
+		if file.IsDir {
+			children = append(children, file)
+		} else {
+			usage.add(file.usageSize())
+		}
+		if travSt.node != nil {
+			// Folders are kept in the tree to hold their children.
+			travSt.node.add(file)
+		}
+
+		// The case in which only directories wanted is covered by the buildExpression clause
+		// reason being that only folder are allowed to be roots, including the only files clause
+		// would result in incorrect traversal since non-folders don't have children.
+		// Just don't print it, however, the folder will still be explored.
+		if !(onlyFiles && file.IsDir) && usage.prints(file) {
+			if !g.takeResult() {
+				if travSt.resume != nil && !buffered {
+					g.resumeFrom = &resumeToken{pageToken: file.pageToken, index: file.pageIndex}
+				}
+				return false
+			}
+			if travSt.node == nil {
+				g.fetchPermissions(file, opt.mask)
+				g.fetchLabels(file, opt.mask)
+				g.resolveShortcut(file, opt.mask)
+				file.pretty(g.log, opt)
+			}
+			iterCount += 1
+		}
+		if (streaming || g.opts.LowMemory) && file.IsDir {
+			// Only its id and name are needed to descend into it,
+			// so the rest is let go of to keep memory flat.
+			file.raw = nil
+		}
+
+		// Depth first, like find, explores each folder right after printing it.
+		if descendImmediately && file.IsDir {
+			return g.breadthFirst(travSt.child(file, opt.parent, g.opts.TypeMask), spin)
+		}
+		return true
+	}
+
+	localSorters := travSt.sorters
+	if orderBy != "" {
+		localSorters = nil
+	}
+	sortCollected := func(collected []*File) []*File {
+		if len(localSorters) >= 1 {
+			collected = g.sort(collected, localSorters...)
+		}
+		if dirsFirst(g.opts.TypeMask) || filesFirst(g.opts.TypeMask) {
+			collected = partitionDirs(collected, dirsFirst(g.opts.TypeMask))
+		}
+		return collected
+	}
 
 	var collector []*File
+	// With LowMemory, what has to be sorted locally is spilled to disk.
+	var spill *spillSorter
+	if buffered && g.opts.LowMemory {
+		partitioned := dirsFirst(g.opts.TypeMask) || filesFirst(g.opts.TypeMask)
+		spill = newSpillSorter(sortCollected, g.sortLess(localSorters, partitioned, dirsFirst(g.opts.TypeMask)))
+		defer spill.close()
+	}
 
 	// We shouldn't prompt in between the same page otherwise we get
 	// spurious prompts. See Issue https://github.com/odeke-em/drive/issues/724.
 	// We'll only make the prompts in between children.
-	pagePair := reqDoPage(req, g.opts.Hidden, false)
+	var from resumeToken
+	if travSt.resume != nil {
+		from = *travSt.resume
+	}
+	pagePair := _reqDoPage(req, g.opts.Hidden, false, false, from)
 	errsChan := pagePair.errsChan
 	filesChan := pagePair.filesChan
 
@@ -450,6 +974,10 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 		select {
 		case err := <-errsChan:
 			if err != nil {
+				if travSt.report != nil && accessDenied(err) {
+					travSt.report.denied = append(travSt.report.denied, remotePathJoin(opt.parent))
+					return true
+				}
 				g.log.LogErrf("%v", err)
 				return false
 			}
@@ -462,57 +990,62 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 				return false
 			}
 
-			if !isHidden(file.Name, g.opts.Hidden) {
-				collector = append(collector, file)
+			if isHidden(file.Name, g.opts.Hidden) {
+				continue
+			}
+			if buffered {
+				file.relPath = sepJoin("/", opt.parent, file.Name)
+				if spill == nil {
+					collector = append(collector, file)
+				} else if err := spill.add(file); err != nil {
+					g.log.LogErrf("%v\n", err)
+					return false
+				}
+			} else if !visit(file) {
+				return false
 			}
-
 		}
 	}
 
-	if len(travSt.sorters) >= 1 {
-		collector = g.sort(collector, travSt.sorters...)
+	if spill != nil {
+		spilledAll, err := spill.each(visit)
+		if err != nil {
+			g.log.LogErrf("%v\n", err)
+		}
+		if !spilledAll {
+			return false
+		}
+	} else {
+		for _, file := range sortCollected(collector) {
+			if !visit(file) {
+				return false
+			}
+		}
 	}
 
-	var children []*File
-	for _, file := range collector {
-		if file.IsDir {
-			children = append(children, file)
-		}
+	// Unless reported, a folder with no content falls through to return
+	// false below, which silently stops a listing of the trash.
+	if travSt.report != nil && visited < 1 {
+		travSt.report.empty = append(travSt.report.empty, remotePathJoin(opt.parent))
+		return true
+	}
 
-		// The case in which only directories wanted is covered by the buildExpression clause
-		// reason being that only folder are allowed to be roots, including the only files clause
-		// would result in incorrect traversal since non-folders don't have children.
-		// Just don't print it, however, the folder will still be explored.
-		if onlyFiles && file.IsDir {
-			continue
+	if canRecurse {
+		if descendImmediately {
+			return true
 		}
-		file.pretty(g.log, opt)
-		iterCount += 1
-	}
 
-	if !travSt.inTrash && !g.opts.InTrash {
 		// We'll only prompt when traversing children to avoid
 		// spurious prompts that result from asynchronous paging
 		// before children have been retrieved, sorted and printed.
 		// See Issue https://github.com/odeke-em/drive/issues/724.
 		canPage := travSt.depth != 0 && len(children) > 0
-		if canPage && canPrompt && !nextPage() {
+		if canPage && !g.turnPage(canPrompt) {
 			return false
 		}
 
 		for _, file := range children {
-			childSt := traversalSt{
-				depth:            travSt.depth,
-				file:             file,
-				headPath:         opt.parent,
-				inTrash:          travSt.inTrash,
-				mask:             g.opts.TypeMask,
-				explicitNoPrompt: travSt.explicitNoPrompt,
-				sorters:          travSt.sorters,
-				matchQuery:       travSt.matchQuery,
-			}
-
-			if !g.breadthFirst(childSt, spin) {
+			if !g.breadthFirst(travSt.child(file, opt.parent, g.opts.TypeMask), spin) {
 				return false
 			}
 		}
@@ -530,6 +1063,22 @@ func isMinimal(mask int) bool {
 	return (mask & Minimal) != 0
 }
 
+func mediaMetadata(mask int) bool {
+	return (mask & MediaMetadata) != 0
+}
+
+func capabilities(mask int) bool {
+	return (mask & Capabilities) != 0
+}
+
+func permissionsColumn(mask int) bool {
+	return (mask & PermissionsColumn) != 0
+}
+
+func ownerEmails(mask int) bool {
+	return (mask & OwnerEmails) != 0
+}
+
 func owners(mask int) bool {
 	return (mask & Owners) != 0
 }
@@ -538,6 +1087,69 @@ func version(mask int) bool {
 	return (mask & CurrentVersion) != 0
 }
 
+func depthFirst(mask int) bool {
+	return (mask & DepthFirst) != 0
+}
+
+func reportEmpty(mask int) bool {
+	return (mask & ReportEmpty) != 0
+}
+
+func dirsFirst(mask int) bool {
+	return (mask & DirsFirst) != 0
+}
+
+func filesFirst(mask int) bool {
+	return (mask & FilesFirst) != 0
+}
+
+func jsonOutput(mask int) bool {
+	return (mask & JSONOutput) != 0
+}
+
+func ndjsonOutput(mask int) bool {
+	return (mask & NDJSONOutput) != 0
+}
+
+func resolveShortcuts(mask int) bool {
+	return (mask & ResolveShortcuts) != 0
+}
+
+func colorOutput(mask int) bool {
+	return (mask & ColorOutput) != 0
+}
+
+func withLabels(mask int) bool {
+	return (mask & WithLabels) != 0
+}
+
+// checkStreamable ensures that with --ndjson each file can be printed as soon
+// as its page arrives, rather than once the rest of its folder is fetched.
+func (g *Commands) checkStreamable() error {
+	if !ndjsonOutput(g.opts.TypeMask) {
+		return nil
+	}
+	if dirsFirst(g.opts.TypeMask) || filesFirst(g.opts.TypeMask) {
+		return invalidArgumentsErr(fmt.Errorf("--%s cannot group folders and files since that needs whole folders buffered", CLIOptionNDJSON))
+	}
+	if sortKeys := sorters(g.opts); len(sortKeys) >= 1 && orderByClause(sortKeys) == "" {
+		return invalidArgumentsErr(fmt.Errorf("--%s can only sort by keys that Drive sorts by, others need whole folders buffered", CLIOptionNDJSON))
+	}
+	return nil
+}
+
+func csvOutput(mask int) bool {
+	return (mask & CSVOutput) != 0
+}
+
+func treeView(mask int) bool {
+	return (mask & TreeView) != 0
+}
+
+func regexTitles(mask int) bool {
+	return (mask & RegexTitles) != 0
+}
+
 func shared(mask int) bool {
 	return (mask & Shared) != 0
 }
@@ -549,3 +1161,45 @@ func trashed(mask int) bool {
 func starred(mask int) bool {
 	return (mask & Starred) != 0
 }
+
+// takeResult counts a result towards MaxResults and reports
+// whether it is within the limit, if one was set.
+func (g *Commands) takeResult() bool {
+	if g.opts.MaxResults < 1 {
+		return true
+	}
+	if g.results >= g.opts.MaxResults {
+		return false
+	}
+	g.results += 1
+	return true
+}
+
+// turnPage counts the page just listed towards MaxPages and reports
+// whether to carry on to the next one. The user is only asked if both
+// stdin and stdout are terminals, otherwise listings carry on unattended
+// so that scripts never block on a prompt they can't see.
+func (g *Commands) turnPage(canPrompt bool) bool {
+	g.pages += 1
+	if g.opts.MaxPages >= 1 && g.pages >= g.opts.MaxPages {
+		return false
+	}
+	return !canPrompt || nextPage()
+}
+
+func (g *Commands) resultsExhausted() bool {
+	return g.opts.MaxResults >= 1 && g.results >= g.opts.MaxResults
+}
+
+// pageSize is the number of results to request per page,
+// no more than those still wanted if MaxResults was set.
+func (g *Commands) pageSize() int64 {
+	size := g.opts.PageSize
+	if g.opts.MaxResults < 1 {
+		return size
+	}
+	if left := g.opts.MaxResults - g.results; size < 1 || left < size {
+		size = left
+	}
+	return size
+}
@@ -0,0 +1,69 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"os"
+	"testing"
+
+	"github.com/odeke-em/log"
+	drive "google.golang.org/api/drive/v2"
+)
+
+func TestPermissionsColumn(t *testing.T) {
+	f := &File{Permissions: []*drive.Permission{
+		{Type: "user", Role: "owner", EmailAddress: "alice@example.com"},
+		{Type: "user", Role: "reader", AdditionalRoles: []string{"commenter"}, EmailAddress: "bob@example.com"},
+		{Type: "domain", Role: "reader", Domain: "example.com"},
+		{Type: "anyone", Role: "reader", WithLink: true},
+	}}
+
+	want := "alice@example.com:owner bob@example.com:commenter example.com:reader anyone(link):reader"
+	if got := f.permissionsColumn(); got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	if got := (&File{}).permissionsColumn(); got != "?" {
+		t.Errorf("expected unknown permissions to be shown as ?, got %q", got)
+	}
+}
+
+func TestTraversalReportErr(t *testing.T) {
+	logy := log.New(os.Stdin, os.Stdout, os.Stderr)
+
+	var unreported *traversalReport
+	if err := unreported.Err(logy); err != nil {
+		t.Errorf("expected no error without a report, got %v", err)
+	}
+
+	tests := []struct {
+		report *traversalReport
+		want   ErrorStatus
+	}{
+		{report: &traversalReport{}},
+		{report: &traversalReport{empty: []string{"/Trash/a"}}, want: StatusEmptyFolders},
+		{report: &traversalReport{denied: []string{"/Trash/b"}}, want: StatusAccessDenied},
+		{report: &traversalReport{empty: []string{"/Trash/a"}, denied: []string{"/Trash/b"}}, want: StatusAccessDenied},
+	}
+	for i, tt := range tests {
+		err := tt.report.Err(logy)
+		var got ErrorStatus
+		if dErr, ok := err.(*Error); ok {
+			got = dErr.code
+		}
+		if got != tt.want {
+			t.Errorf("#%d: got status %d want %d", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+// With LowMemory, listings request smaller pages, transfers run fewer jobs
+// and files sorted locally are spilled to disk in runs of lowMemorySortRun.
+const (
+	LowMemoryPageSize = 25
+	LowMemoryJobs     = 2
+	lowMemorySortRun  = 500
+)
+
+// applyLowMemory caps the options that hold many files in memory at once.
+func (opts *Options) applyLowMemory() {
+	if !opts.LowMemory {
+		return
+	}
+	if opts.PageSize < 1 || opts.PageSize > LowMemoryPageSize {
+		opts.PageSize = LowMemoryPageSize
+	}
+	if opts.SmallFileJobs < 1 || opts.SmallFileJobs > LowMemoryJobs {
+		opts.SmallFileJobs = LowMemoryJobs
+	}
+	if opts.ChunkConcurrency > LowMemoryJobs {
+		opts.ChunkConcurrency = LowMemoryJobs
+	}
+}
+
+// jobs is the number of transfers run concurrently.
+func (g *Commands) jobs() int {
+	n := g.opts.Transfers
+	if n < 1 {
+		n = maxProcs()
+	}
+	if g.opts.LowMemory && n > LowMemoryJobs {
+		n = LowMemoryJobs
+	}
+	return n
+}
+
+// spilledFile is a listed file as written out to a sort run.
+type spilledFile struct {
+	Raw       *drive.File `json:"raw"`
+	RelPath   string      `json:"relPath,omitempty"`
+	PageToken string      `json:"pageToken,omitempty"`
+	PageIndex int         `json:"pageIndex,omitempty"`
+}
+
+func (sf *spilledFile) file() *File {
+	f := NewRemoteFile(sf.Raw)
+	f.relPath = sf.RelPath
+	f.pageToken = sf.PageToken
+	f.pageIndex = sf.PageIndex
+	return f
+}
+
+// spillSorter orders files like sortFiles does in memory, except that only
+// a run of them is held at a time, each sorted run being written to a
+// temporary file and the runs merged back as the files are visited.
+type spillSorter struct {
+	sortFiles func([]*File) []*File
+	less      func(l, r *File) bool
+	runLen    int
+
+	run   []*File
+	spill []*os.File
+}
+
+func newSpillSorter(sortFiles func([]*File) []*File, less func(l, r *File) bool) *spillSorter {
+	return &spillSorter{sortFiles: sortFiles, less: less, runLen: lowMemorySortRun}
+}
+
+func (ss *spillSorter) add(f *File) error {
+	ss.run = append(ss.run, f)
+	if len(ss.run) < ss.runLen {
+		return nil
+	}
+	return ss.flush()
+}
+
+func (ss *spillSorter) flush() error {
+	if len(ss.run) < 1 {
+		return nil
+	}
+
+	fh, err := ioutil.TempFile("", "drive-sort")
+	if err != nil {
+		return err
+	}
+	ss.spill = append(ss.spill, fh)
+
+	w := bufio.NewWriter(fh)
+	enc := json.NewEncoder(w)
+	for _, f := range ss.sortFiles(ss.run) {
+		sf := &spilledFile{Raw: f.raw, RelPath: f.relPath, PageToken: f.pageToken, PageIndex: f.pageIndex}
+		if err := enc.Encode(sf); err != nil {
+			return err
+		}
+	}
+	ss.run = nil
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err = fh.Seek(0, io.SeekStart)
+	return err
+}
+
+// each visits the files in order until visit returns false, then removes
+// the runs spilled.
+func (ss *spillSorter) each(visit func(*File) bool) (completed bool, err error) {
+	defer ss.close()
+
+	if len(ss.spill) < 1 {
+		for _, f := range ss.sortFiles(ss.run) {
+			if !visit(f) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	if err := ss.flush(); err != nil {
+		return false, err
+	}
+
+	decoders := make([]*json.Decoder, len(ss.spill))
+	heads := make([]*File, len(ss.spill))
+	next := func(i int) error {
+		sf := &spilledFile{}
+		if err := decoders[i].Decode(sf); err != nil {
+			heads[i] = nil
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		heads[i] = sf.file()
+		return nil
+	}
+	for i, fh := range ss.spill {
+		decoders[i] = json.NewDecoder(bufio.NewReader(fh))
+		if err := next(i); err != nil {
+			return false, err
+		}
+	}
+
+	for {
+		min := -1
+		for i, head := range heads {
+			// Ties go to the earlier run to keep the sort stable.
+			if head != nil && (min < 0 || ss.less(head, heads[min])) {
+				min = i
+			}
+		}
+		if min < 0 {
+			return true, nil
+		}
+		if !visit(heads[min]) {
+			return false, nil
+		}
+		if err := next(min); err != nil {
+			return false, err
+		}
+	}
+}
+
+func (ss *spillSorter) close() {
+	for _, fh := range ss.spill {
+		fh.Close()
+		os.Remove(fh.Name())
+	}
+	ss.spill = nil
+	ss.run = nil
+}
+
+// sortLess is the order that g.sort with attrStrValues and, if partitioned,
+// partitionDirs put files in, the last sort key being the most significant.
+func (g *Commands) sortLess(attrStrValues []string, partitioned, dirsAhead bool) func(l, r *File) bool {
+	return func(l, r *File) bool {
+		if partitioned && l.IsDir != r.IsDir {
+			return l.IsDir == dirsAhead
+		}
+		pair := []*File{l, r}
+		for i := len(attrStrValues) - 1; i >= 0; i-- {
+			attrEnum, sortInterface, reverse := attrAtoiSorter(attrStrValues[i], pair)
+			if attrEnum == AttrUnknown {
+				continue
+			}
+			lLess, rLess := sortInterface.Less(0, 1), sortInterface.Less(1, 0)
+			if reverse {
+				lLess, rLess = rLess, lLess
+			}
+			if lLess != rLess {
+				return lLess
+			}
+		}
+		return false
+	}
+}
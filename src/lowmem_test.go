@@ -0,0 +1,120 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+func TestSpillSorter(t *testing.T) {
+	g := &Commands{}
+	sizes := []int64{7, 3, 9, 1, 3, 8, 2}
+	var files []*File
+	for i, size := range sizes {
+		f := NewRemoteFile(&drive.File{Id: fmt.Sprintf("id%d", i), Title: fmt.Sprintf("f%d", i), FileSize: size})
+		f.relPath = "/" + f.Name
+		files = append(files, f)
+	}
+	folder := NewRemoteFile(&drive.File{Id: "dir", Title: "z", MimeType: DriveFolderMimeType})
+	files = append(files, folder)
+
+	sortFiles := func(fl []*File) []*File {
+		return partitionDirs(g.sort(fl, SizeKey), true)
+	}
+	ss := newSpillSorter(sortFiles, g.sortLess([]string{SizeKey}, true, true))
+	ss.runLen = 3
+	for _, f := range files {
+		if err := ss.add(f); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	if len(ss.spill) != 2 {
+		t.Fatalf("expected 2 runs spilled, got %d", len(ss.spill))
+	}
+	spilled := []string{ss.spill[0].Name(), ss.spill[1].Name()}
+
+	var got []string
+	completed, err := ss.each(func(f *File) bool {
+		got = append(got, fmt.Sprintf("%s:%d", f.Id, f.Size))
+		return true
+	})
+	if err != nil || !completed {
+		t.Fatalf("each: completed=%v err=%v", completed, err)
+	}
+
+	var want []string
+	for _, f := range sortFiles(append([]*File{}, files...)) {
+		want = append(want, fmt.Sprintf("%s:%d", f.Id, f.Size))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	for _, p := range spilled {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s: expected the run to be removed, got %v", p, err)
+		}
+	}
+
+	reverse := g.sortLess([]string{SizeKey + "_r"}, false, false)
+	if !reverse(files[0], files[1]) || reverse(files[1], files[0]) {
+		t.Errorf("expected %d before %d in reverse", files[0].Size, files[1].Size)
+	}
+	if g.sortLess([]string{SizeKey}, false, false)(files[1], files[4]) {
+		t.Errorf("expected equal sizes not to be ordered")
+	}
+}
+
+func TestApplyLowMemory(t *testing.T) {
+	opts := &Options{LowMemory: true, PageSize: 100, ChunkConcurrency: 8}
+	opts.applyLowMemory()
+	if opts.PageSize != LowMemoryPageSize || opts.SmallFileJobs != LowMemoryJobs || opts.ChunkConcurrency != LowMemoryJobs {
+		t.Errorf("unexpected options %+v", opts)
+	}
+
+	opts = &Options{LowMemory: true, PageSize: 10, SmallFileJobs: 1, ChunkConcurrency: 1}
+	opts.applyLowMemory()
+	if opts.PageSize != 10 || opts.SmallFileJobs != 1 || opts.ChunkConcurrency != 1 {
+		t.Errorf("expected lower settings to be kept, got %+v", opts)
+	}
+
+	opts = &Options{PageSize: 100}
+	opts.applyLowMemory()
+	if opts.PageSize != 100 || opts.SmallFileJobs != 0 {
+		t.Errorf("expected options to be left alone, got %+v", opts)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	g := &Commands{opts: &Options{Transfers: 3}}
+	if n := g.jobs(); n != 3 {
+		t.Errorf("expected -transfers to set the jobs, got %d", n)
+	}
+	g.opts.LowMemory = true
+	if n := g.jobs(); n != LowMemoryJobs {
+		t.Errorf("expected low memory to cap the jobs at %d, got %d", LowMemoryJobs, n)
+	}
+	g.opts = &Options{}
+	if n := g.jobs(); n != maxProcs() {
+		t.Errorf("expected %d jobs by default, got %d", maxProcs(), n)
+	}
+	if n := g.smallFileJobs(); n != DefaultSmallFileJobsFactor*maxProcs() {
+		t.Errorf("expected small file jobs to follow the transfers, got %d", n)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+var manifestColumns = []string{"path", "size", "md5", "fileId", "revision"}
+
+type manifestEntry struct {
+	path     string
+	size     int64
+	md5      string
+	fileId   string
+	revision int64
+}
+
+// pushManifest collects the files uploaded by a push so that they
+// can be verified against or restored from later on.
+type pushManifest struct {
+	relToRootPath string
+
+	mu      sync.Mutex
+	entries []*manifestEntry
+}
+
+func (g *Commands) newPushManifest() *pushManifest {
+	if g.opts.Meta == nil {
+		return nil
+	}
+	paths := (*g.opts.Meta)[CLIOptionManifest]
+	if len(paths) < 1 || paths[0] == "" {
+		return nil
+	}
+	return &pushManifest{relToRootPath: remotePathJoin("/", paths[0])}
+}
+
+func (pm *pushManifest) record(relToRootPath string, f *File) {
+	if pm == nil || f == nil || f.IsDir {
+		return
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.entries = append(pm.entries, &manifestEntry{
+		path:     relToRootPath,
+		size:     f.Size,
+		md5:      f.Md5Checksum,
+		fileId:   f.Id,
+		revision: f.Version,
+	})
+}
+
+func (pm *pushManifest) writeTo(w io.Writer) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	sort.Sort(byManifestPath(pm.entries))
+
+	cw := csv.NewWriter(w)
+	cw.Write(manifestColumns)
+	for _, entry := range pm.entries {
+		cw.Write([]string{
+			entry.path,
+			fmt.Sprintf("%d", entry.size),
+			entry.md5,
+			entry.fileId,
+			fmt.Sprintf("%d", entry.revision),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type byManifestPath []*manifestEntry
+
+func (bm byManifestPath) Len() int           { return len(bm) }
+func (bm byManifestPath) Less(i, j int) bool { return bm[i].path < bm[j].path }
+func (bm byManifestPath) Swap(i, j int)      { bm[i], bm[j] = bm[j], bm[i] }
+
+// writePushManifest saves the manifest of what was just pushed locally
+// then uploads it to the same path, alongside the data it describes.
+func (g *Commands) writePushManifest() error {
+	pm := g.pushManifest
+	if pm == nil || len(pm.entries) < 1 {
+		return nil
+	}
+	// Detached first so that the manifest does not record itself.
+	g.pushManifest = nil
+
+	absPath := g.context.AbsPathOf(pm.relToRootPath)
+	if err := os.MkdirAll(filepath.Dir(absPath), os.ModeDir|0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(absPath)
+	if err != nil {
+		return err
+	}
+	wErr := pm.writeTo(f)
+	if cErr := f.Close(); wErr == nil {
+		wErr = cErr
+	}
+	if wErr != nil {
+		return wErr
+	}
+
+	fInfo, err := os.Stat(absPath)
+	if err != nil {
+		return err
+	}
+
+	rem, err := g.rem.FindByPath(pm.relToRootPath)
+	if err != nil && err != ErrPathNotExists {
+		return err
+	}
+
+	change := &Change{
+		Path: pm.relToRootPath,
+		Src:  NewLocalFile(absPath, fInfo),
+		Dest: rem,
+		g:    g,
+	}
+	if err := g.remoteMod(change); err != nil {
+		return err
+	}
+
+	g.log.Logf("manifest of %d files: %s\n", len(pm.entries), pm.relToRootPath)
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var MediaMetadataFields = []string{"imageMediaMetadata", "videoMediaMetadata"}
+
+func withMediaFields(fields []string) []string {
+	present := map[string]bool{}
+	for _, field := range fields {
+		present[fieldKey(field)] = true
+	}
+	for _, field := range MediaMetadataFields {
+		if !present[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func (f *File) mediaKeyValues() []*keyValue {
+	var kvList []*keyValue
+
+	if img := f.ImageMediaMetadata; img != nil {
+		if img.Width >= 1 && img.Height >= 1 {
+			kvList = append(kvList, &keyValue{"Dimensions", fmt.Sprintf("%dx%d", img.Width, img.Height)})
+		}
+		if camera := strings.TrimSpace(img.CameraMake + " " + img.CameraModel); camera != "" {
+			kvList = append(kvList, &keyValue{"Camera", camera})
+		}
+		if img.Date != "" {
+			kvList = append(kvList, &keyValue{"TakenAt", img.Date})
+		}
+		if loc := img.Location; loc != nil {
+			kvList = append(kvList, &keyValue{"Location", fmt.Sprintf("%.6f,%.6f", loc.Latitude, loc.Longitude)})
+		}
+	}
+
+	if video := f.VideoMediaMetadata; video != nil {
+		if video.Width >= 1 && video.Height >= 1 {
+			kvList = append(kvList, &keyValue{"Dimensions", fmt.Sprintf("%dx%d", video.Width, video.Height)})
+		}
+		duration := time.Duration(video.DurationMillis) * time.Millisecond
+		kvList = append(kvList, &keyValue{"Duration", duration.String()})
+	}
+
+	return kvList
+}
+
+// mediaColumn summarizes the media metadata in a single listing column.
+func (f *File) mediaColumn() string {
+	var values []string
+	for _, kv := range f.mediaKeyValues() {
+		values = append(values, kv.value.(string))
+	}
+	if len(values) < 1 {
+		return "-"
+	}
+	return strings.Join(values, " ")
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/odeke-em/drive/config"
+)
+
+// metadata returns the metadata of f that is tracked for drift.
+func (f *File) metadata() *config.Metadata {
+	md := &config.Metadata{
+		Description: f.Description,
+		Permissions: permissionsFingerprint(f.Permissions),
+	}
+	if f.Labels != nil {
+		md.Starred, md.Trashed = f.Labels.Starred, f.Labels.Trashed
+	}
+	if f.raw != nil && len(f.raw.Properties) >= 1 {
+		md.Properties = make(map[string]string)
+		for _, prop := range f.raw.Properties {
+			if prop != nil {
+				md.Properties[prop.Key] = prop.Value
+			}
+		}
+	}
+	return md
+}
+
+// metadataDrift describes how current differs from what was recorded.
+// Permissions are only compared if they were recorded.
+func metadataDrift(recorded, current *config.Metadata) []string {
+	var drift []string
+	if recorded.Starred != current.Starred {
+		drift = append(drift, fmt.Sprintf("starred: %v -> %v", recorded.Starred, current.Starred))
+	}
+	if recorded.Trashed != current.Trashed {
+		drift = append(drift, fmt.Sprintf("trashed: %v -> %v", recorded.Trashed, current.Trashed))
+	}
+	if recorded.Description != current.Description {
+		drift = append(drift, fmt.Sprintf("description: %q -> %q", recorded.Description, current.Description))
+	}
+
+	keys := map[string]bool{}
+	for key := range recorded.Properties {
+		keys[key] = true
+	}
+	for key := range current.Properties {
+		keys[key] = true
+	}
+	var sortedKeys []string
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+	for _, key := range sortedKeys {
+		before, hadIt := recorded.Properties[key]
+		after, hasIt := current.Properties[key]
+		switch {
+		case !hadIt:
+			drift = append(drift, fmt.Sprintf("property %s: added %q", key, after))
+		case !hasIt:
+			drift = append(drift, fmt.Sprintf("property %s: removed", key))
+		case before != after:
+			drift = append(drift, fmt.Sprintf("property %s: %q -> %q", key, before, after))
+		}
+	}
+
+	if recorded.Permissions == "" || recorded.Permissions == current.Permissions {
+		return drift
+	}
+	granted, revoked := fingerprintDifference(recorded.Permissions, current.Permissions)
+	for _, entry := range granted {
+		drift = append(drift, fmt.Sprintf("permission granted: %s", entry))
+	}
+	for _, entry := range revoked {
+		drift = append(drift, fmt.Sprintf("permission revoked: %s", entry))
+	}
+	return drift
+}
+
+// fingerprintDifference returns the entries only in current and those only in recorded.
+func fingerprintDifference(recorded, current string) (added, removed []string) {
+	splitSet := func(fingerprint string) map[string]bool {
+		set := map[string]bool{}
+		for _, entry := range strings.Split(fingerprint, ";") {
+			if entry != "" {
+				set[entry] = true
+			}
+		}
+		return set
+	}
+
+	before, after := splitSet(recorded), splitSet(current)
+	for entry := range after {
+		if !before[entry] {
+			added = append(added, entry)
+		}
+	}
+	for entry := range before {
+		if !after[entry] {
+			removed = append(removed, entry)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// diffMetadata prints how the metadata of r has drifted
+// from what it was when it was last pulled or pushed.
+func (g *Commands) diffMetadata(relToRootPath string, r *File) error {
+	index, err := g.context.DeserializeIndex(r.Id)
+	if err != nil || index == nil || index.Metadata == nil {
+		g.log.LogErrf("%s: no metadata recorded yet, pull or push it to record some\n", relToRootPath)
+		return nil
+	}
+
+	current := r.metadata()
+	if index.Metadata.Permissions != "" && r.Permissions == nil {
+		perms, err := g.rem.listPermissions(r.Id)
+		if err != nil {
+			return err
+		}
+		current.Permissions = permissionsFingerprint(perms)
+	}
+
+	drift := metadataDrift(index.Metadata, current)
+	if len(drift) < 1 {
+		return nil
+	}
+
+	g.log.Logf("Metadata: %s\n", relToRootPath)
+	for _, line := range drift {
+		g.log.Logf("* %s\n", line)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/odeke-em/drive/config"
+)
+
+func TestMetadataDrift(t *testing.T) {
+	recorded := &config.Metadata{
+		Description: "q3",
+		Properties:  map[string]string{"team": "infra", "tier": "1"},
+		Permissions: "alice:user:writer:;bob:user:reader:",
+	}
+	current := &config.Metadata{
+		Starred:     true,
+		Description: "q3",
+		Properties:  map[string]string{"team": "core", "env": "prod"},
+		Permissions: "alice:user:writer:;carol:user:reader:",
+	}
+
+	want := []string{
+		"starred: false -> true",
+		`property env: added "prod"`,
+		`property team: "infra" -> "core"`,
+		"property tier: removed",
+		"permission granted: carol:user:reader:",
+		"permission revoked: bob:user:reader:",
+	}
+	if got := metadataDrift(recorded, current); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q want %q", got, want)
+	}
+
+	// Permissions that were not recorded cannot have drifted.
+	recorded.Permissions = ""
+	if got := metadataDrift(recorded, recorded); len(got) != 0 {
+		t.Errorf("expected no drift, got %q", got)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultMirrorMaxDeletions is how many deletions a mirror
+	// makes before asking for confirmation.
+	DefaultMirrorMaxDeletions = 50
+)
+
+// splitDeletions separates the deletions of cl, which a mirror
+// only plays once everything else has been transferred.
+func splitDeletions(cl []*Change) (transfers, deletions []*Change) {
+	for _, c := range cl {
+		if c != nil && c.Op() == OpDelete {
+			deletions = append(deletions, c)
+		} else {
+			transfers = append(transfers, c)
+		}
+	}
+	return transfers, deletions
+}
+
+func (opts *Options) mirrorMaxDeletions() int {
+	if opts.MirrorMaxDeletions >= 1 {
+		return opts.MirrorMaxDeletions
+	}
+	return DefaultMirrorMaxDeletions
+}
+
+// mirrorDeletionVerb is what a mirror does to the files
+// that are no longer in its source.
+func (g *Commands) mirrorDeletionVerb(command string) string {
+	if command == PushKey && !g.opts.Permanent {
+		return "trashed"
+	}
+	return "deleted"
+}
+
+// confirmMirrorDeletions sums up the deletions of a mirror and, if there
+// are more of them than --max-deletions, asks for confirmation. Runs that
+// can't prompt are refused instead, so that a source that went missing
+// isn't mirrored as the deletion of everything.
+func (g *Commands) confirmMirrorDeletions(command string, cl []*Change) error {
+	if !g.opts.Mirror {
+		return nil
+	}
+	_, deletions := splitDeletions(cl)
+	if len(deletions) < 1 {
+		return nil
+	}
+
+	_, size := reduceToSize(deletions, SelectDest)
+	g.log.Logf("%s: %d file(s), %s, no longer in the source will be %s once the rest is transferred\n",
+		command, len(deletions), prettyBytes(size), g.mirrorDeletionVerb(command))
+
+	max := g.opts.mirrorMaxDeletions()
+	if len(deletions) <= max {
+		return nil
+	}
+	if !g.opts.canPrompt() {
+		return cannotPromptErr(fmt.Errorf("%s: %d deletions are more than the %d allowed without confirmation, raise `-%s` to allow them",
+			command, len(deletions), max, CLIOptionMaxDeletions))
+	}
+	status := promptForChanges(fmt.Sprintf("%d deletions are more than %d, proceed? [Y/n]: ", len(deletions), max))
+	if !accepted(status) {
+		return status.Error()
+	}
+	return nil
+}
+
+// mirrorDeletionsAllowed tells whether a mirror can go on to its deletions
+// once the transfers are done. They are skipped if any transfer failed or
+// the run was interrupted, leaving them to the next run.
+func (g *Commands) mirrorDeletionsAllowed(command string, deletions []*Change, failures int64) bool {
+	if g.interrupted() {
+		g.skipUnstarted(len(deletions))
+		return false
+	}
+	if failures >= 1 {
+		g.log.LogErrf("%s: %d file(s) not %s since %d transfer(s) failed\n",
+			command, len(deletions), g.mirrorDeletionVerb(command), failures)
+		g.skipUnstarted(len(deletions))
+		return false
+	}
+	return true
+}
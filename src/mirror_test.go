@@ -0,0 +1,40 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+)
+
+func TestSplitDeletions(t *testing.T) {
+	add := &Change{Path: "/a", Src: &File{Name: "a"}}
+	del1 := &Change{Path: "/b", Dest: &File{Name: "b"}}
+	del2 := &Change{Path: "/c", Dest: &File{Name: "c"}}
+
+	transfers, deletions := splitDeletions([]*Change{del1, add, del2})
+	if len(transfers) != 1 || transfers[0] != add {
+		t.Errorf("transfers: got %v", transfers)
+	}
+	if len(deletions) != 2 || deletions[0] != del1 || deletions[1] != del2 {
+		t.Errorf("deletions: got %v", deletions)
+	}
+
+	if got := (&Options{}).mirrorMaxDeletions(); got != DefaultMirrorMaxDeletions {
+		t.Errorf("default max deletions: got %d", got)
+	}
+	if got := (&Options{MirrorMaxDeletions: 5}).mirrorMaxDeletions(); got != 5 {
+		t.Errorf("max deletions: got %d want 5", got)
+	}
+}
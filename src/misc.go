@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -31,8 +33,6 @@ import (
 	"sync"
 	"time"
 
-	"google.golang.org/api/googleapi"
-
 	expirableCache "github.com/odeke-em/cache"
 	spinner "github.com/odeke-em/cli-spinner"
 	"github.com/odeke-em/drive/config"
@@ -116,7 +116,9 @@ func (cjs *changeJobSt) changeJober(g *Commands) func() (interface{}, error) {
 			g.log.Logf("\033[01m%s::Started %s\033[00m\n", verb, ch.Path)
 		}
 
+		g.transferBegin(ch)
 		err := cjs.fn(ch)
+		g.transferEnd(ch)
 
 		if canPrintSteps {
 			g.log.Logf("\033[04m%s::Done %s\033[00m\n", verb, ch.Path)
@@ -127,54 +129,6 @@ func (cjs *changeJobSt) changeJober(g *Commands) func() (interface{}, error) {
 	}
 }
 
-func retryableErrorCheck(v interface{}) (ok, retryable bool) {
-	pr, pOk := v.(*tuple)
-	if pr == nil || !pOk {
-		retryable = true
-		return
-	}
-
-	if pr.last == nil {
-		ok = true
-		return
-	}
-
-	err, assertOk := pr.last.(*googleapi.Error)
-	// In relation to https://github.com/google/google-api-go-client/issues/93
-	// where not every error is of googleapi.Error instance e.g io timeout errors
-	// etc, let's assume that non-nil errors are retryable
-
-	if !assertOk {
-		retryable = true
-		return
-	}
-
-	if err == nil {
-		ok = true
-		return
-	}
-
-	if strings.EqualFold(err.Message, MsgErrFileNotMutable) {
-		retryable = false
-		return
-	}
-
-	statusCode := err.Code
-	if statusCode >= 500 && statusCode <= 599 {
-		retryable = true
-		return
-	}
-
-	switch statusCode {
-	case 401, 403:
-		retryable = true
-
-		// TODO: Add other errors
-	}
-
-	return
-}
-
 func noopPlayable() *playable {
 	return &playable{
 		play:  noop,
@@ -200,6 +154,8 @@ func internalIgnores() (ignores []string) {
 	if runtime.GOOS == OSLinuxKey {
 		ignores = append(ignores, "\\.\\s*desktop$")
 	}
+	ignores = append(ignores, regexp.QuoteMeta(PartialDownloadSuffix)+"$")
+	ignores = append(ignores, regexp.QuoteMeta(PartialDownloadSuffix+PartialSidecarSuffix)+"$")
 	return ignores
 }
 
@@ -219,7 +175,7 @@ func newPlayable(freq int64) *playable {
 }
 
 func (g *Commands) playabler() *playable {
-	if !g.opts.canPrompt() {
+	if !g.opts.canSpin() {
 		return noopPlayable()
 	}
 	return newPlayable(10)
@@ -475,12 +431,14 @@ func readFile_(p string, ignorer func(string) bool) (clauses []string, err error
 	return
 }
 
-func readCommentedFile(p, comment string) (clauses []string, err error) {
-	ignorer := func(line string) bool {
+func commentedLineIgnorer(comment string) func(string) bool {
+	return func(line string) bool {
 		return strings.HasPrefix(line, comment) || len(line) < 1
 	}
+}
 
-	return readFile_(p, ignorer)
+func readCommentedFile(p, comment string) (clauses []string, err error) {
+	return readFile_(p, commentedLineIgnorer(comment))
 }
 
 func chunkInt64(v int64) chan int {
@@ -699,6 +657,10 @@ func combineIgnores(ignoresPath string) (ignorer func(string) bool, err error) {
 	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
+	if declaresGitSyntax(clauses) {
+		// Matched by path along with those of sub folders, see subtreePolicy.
+		clauses = nil
+	}
 
 	// TODO: Should internalIgnores only be added only
 	// after all the exclusion and exclusion steps.
@@ -953,6 +915,7 @@ type fsListingArg struct {
 	hidden  bool
 	ignore  func(string) bool
 	depth   int
+	links   LinkMode
 }
 
 func list(flArg *fsListingArg) (fileChan chan *File, err error) {
@@ -1001,6 +964,10 @@ func list(flArg *fsListingArg) (fileChan chan *File, err error) {
 
 			if !symlink(file.Mode()) {
 				fileChan <- NewLocalFile(resPath, file)
+			} else if flArg.links != LinksFollow {
+				if lf, lErr := newLocalLink(resPath, file); lErr == nil {
+					fileChan <- lf
+				}
 			} else {
 				var symResolvPath string
 				symResolvPath, err = filepath.EvalSymlinks(resPath)
@@ -1029,10 +996,53 @@ func list(flArg *fsListingArg) (fileChan chan *File, err error) {
 	return
 }
 
-func resolver(g *Commands, byId bool, sources []string, fileOp func(*File) interface{}) (kvChan chan *keyValue) {
-	resolve := g.rem.FindByPath
+// IdFromSource returns the id that a source names if it isn't a path,
+// being either "id:" prefixed or a link copied from the web UI e.g
+// https://drive.google.com/drive/folders/<id> or https://docs.google.com/document/d/<id>/edit
+func IdFromSource(source string) (string, bool) {
+	if strings.HasPrefix(source, IdSourcePrefix) {
+		id := strings.TrimPrefix(source, IdSourcePrefix)
+		return id, id != ""
+	}
+
+	u, err := url.Parse(source)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", false
+	}
+	if u.Host != "google.com" && !strings.HasSuffix(u.Host, ".google.com") {
+		return "", false
+	}
+	if id := u.Query().Get("id"); id != "" {
+		return id, true
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "d" || segments[i] == "folders" {
+			return segments[i+1], segments[i+1] != ""
+		}
+	}
+	return "", false
+}
+
+// findBySource resolves a source by id if byId is set or it names an id,
+// otherwise by path, reporting whether it was resolved by id.
+func (g *Commands) findBySource(source string, byId bool) (f *File, resolvedById bool, err error) {
 	if byId {
-		resolve = g.rem.FindById
+		f, err = g.rem.FindById(source)
+		return f, true, err
+	}
+	if id, ok := IdFromSource(source); ok {
+		f, err = g.rem.FindById(id)
+		return f, true, err
+	}
+	f, err = g.rem.FindByPath(source)
+	return f, false, err
+}
+
+func resolver(g *Commands, byId bool, sources []string, fileOp func(*File) interface{}) (kvChan chan *keyValue) {
+	resolve := func(source string) (*File, error) {
+		f, _, err := g.findBySource(source, byId)
+		return f, err
 	}
 
 	kvChan = make(chan *keyValue)
@@ -1091,6 +1101,9 @@ func remoteOpToChangerTranslator(g *Commands, c *Change) func(*Change) error {
 		fn = g.remoteAdd
 	case OpDelete:
 		fn = g.remoteTrash
+		if g.opts.Permanent {
+			fn = g.remoteDelete
+		}
 	}
 	return fn
 }
@@ -1107,6 +1120,22 @@ const (
 	NonFolder
 	DiskUsageOnly
 	CurrentVersion
+	JSONOutput
+	DepthFirst
+	DirsFirst
+	FilesFirst
+	ReportEmpty
+	OwnerEmails
+	Capabilities
+	MediaMetadata
+	CSVOutput
+	TreeView
+	RegexTitles
+	PermissionsColumn
+	NDJSONOutput
+	ResolveShortcuts
+	ColorOutput
+	WithLabels
 )
 
 func folderExplicitly(mask int) bool    { return (mask & Folder) == Folder }
@@ -1135,6 +1164,55 @@ func makeFileFilter(mask int) driveFileFilter {
 	}
 }
 
+// fileFilter narrows down the files operated on by type and, with
+// --newer-than, --older-than, --min-size or --max-size, by their
+// modification times and sizes.
+func (g *Commands) fileFilter() driveFileFilter {
+	byType := makeFileFilter(g.opts.TypeMask)
+	if !g.opts.boundsFiles() {
+		return byType
+	}
+
+	return func(f *File) bool {
+		return byType(f) && g.opts.admits(f)
+	}
+}
+
+func (opts *Options) boundsFiles() bool {
+	return !opts.ModifiedAfter.IsZero() || !opts.ModifiedBefore.IsZero() || opts.MinSize >= 1 || opts.MaxSize >= 1
+}
+
+// admits reports whether f is within the modification time and size bounds.
+// Folders are always admitted so that they can still be traversed.
+func (opts *Options) admits(f *File) bool {
+	if f == nil || f.IsDir {
+		return true
+	}
+	return modifiedWithin(f.ModTime, opts.ModifiedAfter, opts.ModifiedBefore) &&
+		sizeWithin(f.Size, opts.MinSize, opts.MaxSize)
+}
+
+func modifiedWithin(modTime, after, before time.Time) bool {
+	if !after.IsZero() && !modTime.After(after) {
+		return false
+	}
+	if !before.IsZero() && !modTime.Before(before) {
+		return false
+	}
+	return true
+}
+
+// sizeWithin reports whether min <= size <= max, a bound < 1 being unset.
+func sizeWithin(size, min, max int64) bool {
+	if min >= 1 && size < min {
+		return false
+	}
+	if max >= 1 && size > max {
+		return false
+	}
+	return true
+}
+
 func allTruthsHold(truths ...bool) bool {
 	for _, truth := range truths {
 		if !truth {
@@ -1168,6 +1246,77 @@ func parseDurationOffsetFromNow(durationOffsetStr string) (*time.Time, error) {
 	return &offsetFromNow, nil
 }
 
+// parseAgeDuration extends time.ParseDuration with day "d"
+// and week "w" suffixes e.g "30d" or "2w".
+func parseAgeDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+
+	for suffix, unit := range units {
+		if !strings.HasSuffix(s, suffix) {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n * float64(unit)), nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+// ParseTimeOrAge parses s as a date or as an age relative to now.
+func ParseTimeOrAge(s string) (time.Time, error) {
+	return parseTimeOrAge(s, time.Now())
+}
+
+// parseTimeOrAge parses either an absolute date e.g "2016-05-01" or
+// "2016-05-01T10:00:00Z", or an age relative to now e.g "30d" or "36h".
+func parseTimeOrAge(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	d, err := parseAgeDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected a date like 2016-05-01 or an age like 30d", s)
+	}
+	return now.Add(-d), nil
+}
+
+// ParseByteSize parses sizes such as "512", "100K", "1.5MB" or "2GiB"
+// where the suffixes are powers of 1024.
+func ParseByteSize(size string) (int64, error) {
+	return parseByteSize(size)
+}
+
+func parseByteSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "IB"), "B")
+
+	multiplier := float64(1)
+	for i, suffix := range []string{"K", "M", "G", "T", "P"} {
+		if strings.HasSuffix(s, suffix) {
+			s = strings.TrimSuffix(s, suffix)
+			multiplier = math.Pow(BytesPerKB, float64(i+1))
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return int64(n * multiplier), nil
+}
+
 // Debug returns true if DRIVE_DEBUG is set in the environment.
 // Set it to anything non-empty, for example `DRIVE_DEBUG=true`.
 func Debug() bool {
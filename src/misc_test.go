@@ -233,7 +233,7 @@ func TestRetryableErrorCheck(t *testing.T) {
 	}
 
 	for _, tc := range cases {
-		success, retryable := retryableErrorCheck(tc.value)
+		success, retryable := newRetryPolicy().statusCheck(tc.value)
 		if success != tc.success {
 			t.Errorf("%v success got %v expected %v", tc.value, success, tc.success)
 		}
@@ -461,3 +461,159 @@ func TestCrudToAtoi(t *testing.T) {
 		}
 	}
 }
+
+func TestParseAgeDuration(t *testing.T) {
+	testCases := []struct {
+		specimen string
+		want     time.Duration
+		wantErr  bool
+	}{
+		{specimen: "30d", want: 30 * 24 * time.Hour},
+		{specimen: "2w", want: 14 * 24 * time.Hour},
+		{specimen: "1.5d", want: 36 * time.Hour},
+		{specimen: "36h", want: 36 * time.Hour},
+		{specimen: " 10m ", want: 10 * time.Minute},
+		{specimen: "xd", wantErr: true},
+		{specimen: "", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseAgeDuration(tc.specimen)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("given specimen %q, expected an error", tc.specimen)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("given specimen %q, unexpected err %v", tc.specimen, err)
+			continue
+		}
+
+		if got != tc.want {
+			t.Errorf("given specimen %q, expected %v instead got %v", tc.specimen, tc.want, got)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	testCases := []struct {
+		specimen string
+		want     int64
+		wantErr  bool
+	}{
+		{specimen: "512", want: 512},
+		{specimen: "100K", want: 100 * 1024},
+		{specimen: "1.5MB", want: 1536 * 1024},
+		{specimen: "2GiB", want: 2 * 1024 * 1024 * 1024},
+		{specimen: " 10b ", want: 10},
+		{specimen: "-1M", wantErr: true},
+		{specimen: "lots", wantErr: true},
+		{specimen: "", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseByteSize(tc.specimen)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("given specimen %q, expected an error", tc.specimen)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("given specimen %q, unexpected err %v", tc.specimen, err)
+			continue
+		}
+
+		if got != tc.want {
+			t.Errorf("given specimen %q, expected %v instead got %v", tc.specimen, tc.want, got)
+		}
+	}
+}
+
+func TestParseTimeOrAge(t *testing.T) {
+	now := time.Date(2016, 6, 15, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		specimen string
+		want     time.Time
+		wantErr  bool
+	}{
+		{specimen: "2016-05-01", want: time.Date(2016, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{specimen: "2016-05-01T10:30:00Z", want: time.Date(2016, 5, 1, 10, 30, 0, 0, time.UTC)},
+		{specimen: "2d", want: now.Add(-48 * time.Hour)},
+		{specimen: "36h", want: now.Add(-36 * time.Hour)},
+		{specimen: "last week", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseTimeOrAge(tc.specimen, now)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("given specimen %q, expected an error", tc.specimen)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("given specimen %q, unexpected err %v", tc.specimen, err)
+			continue
+		}
+
+		if !got.Equal(tc.want) {
+			t.Errorf("given specimen %q, expected %v instead got %v", tc.specimen, tc.want, got)
+		}
+	}
+}
+
+func TestOptionsAdmitsSize(t *testing.T) {
+	opts := &Options{MinSize: 1024, MaxSize: 10 * 1024}
+
+	samples := []struct {
+		file *File
+		want bool
+	}{
+		{file: nil, want: true},
+		{file: &File{IsDir: true}, want: true},
+		{file: &File{Size: 1023}, want: false},
+		{file: &File{Size: 1024}, want: true},
+		{file: &File{Size: 10 * 1024}, want: true},
+		{file: &File{Size: 10*1024 + 1}, want: false},
+	}
+
+	for i, sample := range samples {
+		if got := opts.admits(sample.file); got != sample.want {
+			t.Errorf("#%d: got %v want %v", i, got, sample.want)
+		}
+	}
+
+	if !(&Options{}).admits(&File{Size: 1 << 40}) {
+		t.Errorf("expected unset bounds to admit any size")
+	}
+}
+
+func TestIdFromSource(t *testing.T) {
+	samples := []struct {
+		source string
+		id     string
+		ok     bool
+	}{
+		{source: "id:0fM9rt0Yc9RTPaDdsNzg1", id: "0fM9rt0Yc9RTPaDdsNzg1", ok: true},
+		{source: "https://drive.google.com/drive/folders/0fM9rt0Yc9RTPaTVGc?usp=sharing", id: "0fM9rt0Yc9RTPaTVGc", ok: true},
+		{source: "https://drive.google.com/drive/u/0/folders/0fM9rt0Yc9RTPaTVGc", id: "0fM9rt0Yc9RTPaTVGc", ok: true},
+		{source: "https://drive.google.com/file/d/0fM9rt0Yc9RTPV1NaNFp5/view", id: "0fM9rt0Yc9RTPV1NaNFp5", ok: true},
+		{source: "https://docs.google.com/document/d/0fM9rt0Yc9RTPV1NaNFp5/edit", id: "0fM9rt0Yc9RTPV1NaNFp5", ok: true},
+		{source: "https://drive.google.com/open?id=0fM9rt0Yc9RTPSTZ", id: "0fM9rt0Yc9RTPSTZ", ok: true},
+		{source: "https://example.com/file/d/0fM9rt0Yc9RTPV1NaNFp5/view"},
+		{source: "photos/id:0fM9rt0Yc9RTPaDdsNzg1"},
+		{source: "id:"},
+		{source: "/photos/2016"},
+	}
+	for _, sample := range samples {
+		id, ok := IdFromSource(sample.source)
+		if id != sample.id || ok != sample.ok {
+			t.Errorf("%q: got (%q, %v) want (%q, %v)", sample.source, id, ok, sample.id, sample.ok)
+		}
+	}
+}
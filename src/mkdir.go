@@ -0,0 +1,128 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Mkdir creates the folders in g.opts.Sources. With parents, missing
+// intermediate folders are created too and folders that already exist
+// aren't an error. With printIds, the id and path of every folder created
+// are printed, the path asked for being last even if it already existed.
+func (g *Commands) Mkdir(parents, printIds bool) (err error) {
+	for _, relToRootPath := range g.opts.Sources {
+		created, mErr := g.mkdir(relToRootPath, parents)
+		for _, f := range created {
+			if printIds {
+				g.log.Logf("%s\t%s\n", f.Id, f.path)
+			} else if f.created {
+				g.log.Logf("Created %s\n", f.path)
+			}
+		}
+		if mErr != nil {
+			g.log.LogErrf("mkdir: %s: %v\n", relToRootPath, mErr)
+			err = combineErrors(err, mErr)
+		}
+	}
+	return err
+}
+
+type mkdirResult struct {
+	*File
+	path    string
+	created bool
+}
+
+// mkdirSegments splits a path relative to the root into its folder names.
+func mkdirSegments(relToRootPath string) []string {
+	var segments []string
+	for _, segment := range strings.Split(relToRootPath, RemoteSeparator) {
+		if segment != "" && segment != "." {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// mkdir walks down relToRootPath in a single pass, looking up each folder
+// only until the first missing one, below which all are created straight
+// away under the ids of those just created.
+func (g *Commands) mkdir(relToRootPath string, parents bool) (results []*mkdirResult, err error) {
+	segments := mkdirSegments(relToRootPath)
+	if len(segments) < 1 {
+		return nil, invalidArgumentsErr(fmt.Errorf("cannot create the root"))
+	}
+
+	parent, err := g.rem.FindById("root")
+	if err != nil {
+		return nil, err
+	}
+
+	missing := false
+	cur := ""
+	for i, segment := range segments {
+		cur = remotePathJoin(cur, segment)
+		last := i == len(segments)-1
+
+		if !missing {
+			existing, fErr := g.rem.findByPathRecv(parent.Id, []string{segment})
+			switch {
+			case fErr == ErrPathNotExists:
+				if !last && !parents {
+					return results, fmt.Errorf("%s: %v, use `-%s` to create missing parents", cur, ErrPathNotExists, CLIOptionParents)
+				}
+				missing = true
+			case fErr != nil:
+				return results, fErr
+			case !existing.IsDir:
+				return results, illogicalStateErr(fmt.Errorf("%s exists but is not a folder", cur))
+			case last && !parents:
+				return results, fmt.Errorf("%s already exists", cur)
+			default:
+				parent = existing
+				if last {
+					results = append(results, &mkdirResult{File: existing, path: cur})
+				}
+				continue
+			}
+		}
+
+		folder, cErr := g.createFolder(parent.Id, segment)
+		if cErr != nil {
+			return results, cErr
+		}
+		g.mkdirAllCache.Put(cur, newExpirableCacheValue(folder))
+		results = append(results, &mkdirResult{File: folder, path: cur, created: true})
+		parent = folder
+	}
+	return results, nil
+}
+
+func (g *Commands) createFolder(parentId, name string) (*File, error) {
+	upArg := upsertOpt{
+		parentId: parentId,
+		src: &File{
+			IsDir:   true,
+			ModTime: time.Now(),
+			Name:    urlToPath(name, false),
+		},
+		retryCount: g.opts.ExponentialBackoffRetryCount,
+	}
+	folder, _, err := g.rem.upsertByComparison(nil, &upArg)
+	return folder, err
+}
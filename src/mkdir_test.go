@@ -0,0 +1,34 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMkdirSegments(t *testing.T) {
+	cases := map[string][]string{
+		"/":          nil,
+		"/a/b/c":     {"a", "b", "c"},
+		"a//b/./c/":  {"a", "b", "c"},
+		"/reports/q": {"reports", "q"},
+	}
+	for p, expected := range cases {
+		if got := mkdirSegments(p); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%q: expected %v, got %v", p, expected, got)
+		}
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/odeke-em/drive/config"
 )
 
 type moveOpt struct {
@@ -54,9 +56,17 @@ func (g *Commands) Move(byId, keepParent bool) error {
 	}
 
 	rest, dest := g.opts.Sources[:argc-1], g.opts.Sources[argc-1]
+	if !byId {
+		var err error
+		if rest, err = g.expandRemoteGlobs(rest); err != nil {
+			return err
+		}
+	}
 
 	var composedError error = nil
 
+	g.permCarrier = g.newPermissionCarrier()
+
 	for _, src := range rest {
 		prefix := commonPrefix(src, dest)
 
@@ -78,11 +88,15 @@ func (g *Commands) Move(byId, keepParent bool) error {
 		}
 	}
 
+	if err := g.reportCarriedPermissions(g.permCarrier); err != nil {
+		composedError = reComposeError(composedError, err.Error())
+	}
+
 	return composedError
 }
 
 func (g *Commands) move(opt *moveOpt) (err error) {
-	var newParent, remSrc *File
+	var newParent, oldParent, remSrc *File
 
 	srcResolver := g.rem.FindByPath
 	if opt.byId {
@@ -107,7 +121,8 @@ func (g *Commands) move(opt *moveOpt) (err error) {
 
 	if !opt.byId {
 		parentPath := g.parentPather(opt.src)
-		oldParent, parErr := g.rem.FindByPath(parentPath)
+		var parErr error
+		oldParent, parErr = g.rem.FindByPath(parentPath)
 		if parErr != nil && parErr != ErrPathNotExists {
 			return parErr
 		}
@@ -142,31 +157,34 @@ func (g *Commands) move(opt *moveOpt) (err error) {
 		return illogicalStateErr(fmt.Errorf("move: cannot move '%s' to itself", opt.src))
 	}
 
-	if err = g.rem.insertParent(remSrc.Id, newParent.Id); err != nil {
-		return err
+	entry := &config.HistoryEntry{
+		Op:     HistoryOpMove,
+		FileId: remSrc.Id, Path: opt.src,
+		NewParentId: newParent.Id,
 	}
 
-	if opt.byId { // TODO: Also take out this current parent
-		return nil
-	}
-
-	if !opt.keepParent {
-		err = g.removeParent(remSrc.Id, opt.src)
+	// TODO: Also take out the current parent of moves by id
+	var removeParentIds []string
+	if !opt.byId && !opt.keepParent {
+		if oldParent == nil {
+			return illogicalStateErr(fmt.Errorf("non existent parent '%s' for src", g.parentPather(opt.src)))
+		}
+		removeParentIds = append(removeParentIds, oldParent.Id)
+		entry.OldParentId = oldParent.Id
 	}
 
-	return err
-}
+	// Snapshotted before the move since those inherited from the old parent are lost.
+	perms := g.snapshotPermissions(g.permCarrier, remSrc, opt.src)
 
-func (g *Commands) removeParent(fileId, relToRootPath string) error {
-	parentPath := g.parentPather(relToRootPath)
-	parent, pErr := g.rem.FindByPath(parentPath)
-	if pErr != nil {
-		return pErr
-	}
-	if parent == nil {
-		return illogicalStateErr(fmt.Errorf("non existent parent '%s' for src", parentPath))
+	// Added to the new parent and removed from the old one at once, since
+	// items of Shared Drives can't have two parents even for a moment.
+	if err = g.rem.reparent(remSrc.Id, []string{newParent.Id}, removeParentIds); err != nil {
+		return err
 	}
-	return g.rem.removeParent(fileId, parent.Id)
+
+	g.recordHistory(entry)
+	g.carryPermissions(g.permCarrier, perms, remSrc, newFullPath)
+	return nil
 }
 
 func (g *Commands) renameLocal(oldRelToRootPath, newRelToRootPath string) error {
@@ -231,6 +249,11 @@ func (g *Commands) Rename(byId bool) error {
 			return err
 		}
 		renamedRemote = true
+		g.recordHistory(&config.HistoryEntry{
+			Op:     HistoryOpRename,
+			FileId: remSrc.Id, Path: sepJoin(RemoteSeparator, parentPath, newName),
+			OldName: remSrc.Name,
+		})
 	}
 
 	if canRenameLocal(g.opts.RenameMode) {
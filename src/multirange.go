@@ -0,0 +1,140 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Below this size, a range isn't worth its own request.
+const minDownloadChunkSize = 4 * 1024 * 1024
+
+// splitIntoRanges splits size bytes into at most n contiguous ranges
+// of roughly equal sizes, none smaller than minDownloadChunkSize.
+func splitIntoRanges(size int64, n int) []*byteRange {
+	if size < 1 {
+		return nil
+	}
+	if maxN := size / minDownloadChunkSize; int64(n) > maxN {
+		n = int(maxN)
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	var ranges []*byteRange
+	chunkSize := size / int64(n)
+	for i, start := 0, int64(0); i < n; i++ {
+		end := start + chunkSize - 1
+		if i == n-1 {
+			end = size - 1
+		}
+		ranges = append(ranges, &byteRange{start: start, end: end})
+		start = end + 1
+	}
+	return ranges
+}
+
+// multiRange tells whether dlArg is downloaded as several concurrent
+// ranges. Exports are generated on the fly so they can't be ranged, nor
+// can encrypted or compressed content be decoded from an offset.
+func (g *Commands) multiRange(dlArg *downloadArg) bool {
+	return dlArg.chunks > 1 && dlArg.exportURL == "" && g.rem.decrypter == nil && !dlArg.gunzip &&
+		dlArg.size >= 2*minDownloadChunkSize
+}
+
+// offsetWriter writes sequentially into f from an offset, reporting
+// the bytes written as progress.
+type offsetWriter struct {
+	f        *os.File
+	offset   int64
+	progress func(n int)
+}
+
+func (ow *offsetWriter) Write(p []byte) (int, error) {
+	n, err := ow.f.WriteAt(p, ow.offset)
+	ow.offset += int64(n)
+	if ow.progress != nil {
+		ow.progress(n)
+	}
+	return n, err
+}
+
+// multiRangeDownload fetches the content of dlArg.id with concurrent
+// ranged GETs into a file pre-allocated to its full size.
+func (g *Commands) multiRangeDownload(dlArg *downloadArg) (err error) {
+	fo, err := os.Create(dlArg.path)
+	if err != nil {
+		g.log.LogErrf("create: %s %v\n", dlArg.path, err)
+		return err
+	}
+	defer func() {
+		fErr := fo.Close()
+		if err == nil && fErr != nil {
+			err = fErr
+		}
+	}()
+
+	if err = fo.Truncate(dlArg.size); err != nil {
+		return err
+	}
+
+	progress := func(n int) {
+		if !dlArg.ackByteProgress {
+			n = 0
+		}
+		g.rem.progress(dlArg.relToRootPath, n)
+	}
+
+	ranges := splitIntoRanges(dlArg.size, dlArg.chunks)
+	errsChan := make(chan error, len(ranges))
+
+	var wg sync.WaitGroup
+	wg.Add(len(ranges))
+	for _, br := range ranges {
+		go func(br *byteRange) {
+			defer wg.Done()
+			errsChan <- g.downloadRangeTo(dlArg.id, br, &offsetWriter{f: fo, offset: br.start, progress: progress})
+		}(br)
+	}
+	wg.Wait()
+	close(errsChan)
+
+	for rErr := range errsChan {
+		err = combineErrors(err, rErr)
+	}
+	if err == nil {
+		err = fo.Sync()
+	}
+	return err
+}
+
+func (g *Commands) downloadRangeTo(id string, br *byteRange, w io.Writer) error {
+	blob, err := g.rem.DownloadRange(id, br)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	expected := br.end - br.start + 1
+	n, err := io.Copy(w, blob)
+	if err == nil && n != expected {
+		err = downloadFailedErr(fmt.Errorf("download: %q range %s was served %d bytes instead of %d", id, br.header(), n, expected))
+	}
+	return err
+}
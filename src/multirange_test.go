@@ -0,0 +1,45 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+)
+
+func TestSplitIntoRanges(t *testing.T) {
+	const mb = 1024 * 1024
+	if got := splitIntoRanges(0, 4); got != nil {
+		t.Errorf("expected no ranges for empty content, got %v", got)
+	}
+	if got := splitIntoRanges(5*mb, 4); len(got) != 1 || got[0].start != 0 || got[0].end != 5*mb-1 {
+		t.Errorf("expected a single range for content below two chunks, got %v", got)
+	}
+
+	size := int64(100*mb + 3)
+	ranges := splitIntoRanges(size, 3)
+	if len(ranges) != 3 {
+		t.Fatalf("expected 3 ranges, got %d", len(ranges))
+	}
+	next := int64(0)
+	for _, br := range ranges {
+		if br.start != next || br.end < br.start {
+			t.Errorf("expected a range starting at %d, got %+v", next, br)
+		}
+		next = br.end + 1
+	}
+	if next != size {
+		t.Errorf("expected the ranges to cover %d bytes, got %d", size, next)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+// orphansExpression narrows a search down to what can be orphaned: what
+// the user owns and hasn't trashed. Drive can't search for files without
+// parents so those with parents are then filtered out as they are listed.
+func orphansExpression(typeMask int) string {
+	return buildExpression("", typeMask, false) + " and 'me' in owners"
+}
+
+// ListOrphans lists the files and folders that are in no folder at all,
+// often left behind when their folder is deleted by someone else.
+// They can't be reached by path yet still count towards the quota.
+func (g *Commands) ListOrphans() error {
+	if err := g.prepareListFormat(); err != nil {
+		return err
+	}
+	if err := g.checkStreamable(); err != nil {
+		return err
+	}
+	defer g.pageOutput()()
+
+	mask := g.opts.TypeMask
+	opt := g.listingAttribute(mask)
+
+	req := g.rem.service.Files.List()
+	req.Q(orphansExpression(mask))
+	if pageSize := g.pageSize(); pageSize >= 1 {
+		req.MaxResults(pageSize)
+	}
+	sortKeys := sorters(g.opts)
+	orderBy := orderByClause(sortKeys)
+	if orderBy != "" {
+		req.OrderBy(orderBy)
+	}
+	buffered := len(sortKeys) >= 1 && orderBy == ""
+	if len(opt.fields) >= 1 {
+		// Parents are needed to tell orphans apart.
+		req.Fields(listFieldsSelector(append([]string{"parents"}, opt.fields...)))
+	}
+
+	spin := g.playabler()
+	spin.play()
+	defer spin.stop()
+
+	onlyFiles := nonFolderExplicitly(mask)
+
+	var collector []*File
+	orphans := 0
+	printOrphan := func(f *File) bool {
+		if !g.takeResult() {
+			return false
+		}
+		f.pretty(g.log, opt)
+		return true
+	}
+
+	pagePair := reqDoPage(req, g.opts.Hidden, false)
+	errsChan := pagePair.errsChan
+	filesChan := pagePair.filesChan
+
+	working := true
+	for working {
+		select {
+		case err := <-errsChan:
+			if err != nil {
+				return err
+			}
+		case f, stillHasContent := <-filesChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if f == nil || len(f.Parents) >= 1 || (onlyFiles && f.IsDir) || !g.opts.admits(f) {
+				continue
+			}
+
+			orphans += 1
+			g.fetchPermissions(f, mask)
+			g.fetchLabels(f, mask)
+			g.resolveShortcut(f, mask)
+			if buffered {
+				collector = append(collector, f)
+			} else if !printOrphan(f) {
+				return nil
+			}
+		}
+	}
+
+	for _, f := range g.sort(collector, sortKeys...) {
+		if !printOrphan(f) {
+			break
+		}
+	}
+	if orphans < 1 {
+		g.log.LogErrln("no orphans found!")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestOrphansExpression(t *testing.T) {
+	want := "trashed=false and 'me' in owners"
+	if got := orphansExpression(0); got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	want = fmt.Sprintf("trashed=false and mimeType = '%s' and 'me' in owners", DriveFolderMimeType)
+	if got := orphansExpression(Folder); got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultRequestsPer100Seconds is the default per user quota of the Drive API.
+	// See https://developers.google.com/drive/v2/web/handle-errors#403_user_rate_limit_exceeded
+	DefaultRequestsPer100Seconds = 1000
+	quotaWindow                  = 100 * time.Second
+)
+
+// pacer spaces out requests so that they stay within the quota instead
+// of bursting until rate limited and then stalling in backoff. Once half
+// of the quota of the current window has been used, requests are evenly
+// spread out over what is left of it.
+type pacer struct {
+	// calls counts the requests sent, kept first for 64-bit alignment.
+	calls int64
+
+	mu sync.Mutex
+
+	// interval if set is the least time between requests, from --qps.
+	interval time.Duration
+	budget   int
+	window   time.Duration
+
+	last time.Time
+	sent []time.Time
+}
+
+func newPacer() *pacer {
+	return &pacer{budget: DefaultRequestsPer100Seconds, window: quotaWindow}
+}
+
+func (p *pacer) setQPS(qps int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.interval = 0
+	if qps >= 1 {
+		p.interval = time.Second / time.Duration(qps)
+	}
+}
+
+// reserve books the next slot for a request made at now
+// and returns the time at which it may be sent.
+func (p *pacer) reserve(now time.Time) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	windowStart := now.Add(-p.window)
+	i := 0
+	for i < len(p.sent) && !p.sent[i].After(windowStart) {
+		i += 1
+	}
+	p.sent = p.sent[i:]
+
+	at := now
+	interval := p.interval
+	if p.budget >= 1 && len(p.sent) >= p.budget/2 {
+		if even := p.window / time.Duration(p.budget); even > interval {
+			interval = even
+		}
+	}
+	if interval > 0 && !p.last.IsZero() {
+		if next := p.last.Add(interval); next.After(at) {
+			at = next
+		}
+	}
+	if p.budget >= 1 && len(p.sent) >= p.budget {
+		if next := p.sent[len(p.sent)-p.budget].Add(p.window); next.After(at) {
+			at = next
+		}
+	}
+
+	p.last = at
+	p.sent = append(p.sent, at)
+	return at
+}
+
+func (p *pacer) wait() {
+	now := time.Now()
+	if at := p.reserve(now); at.After(now) {
+		time.Sleep(at.Sub(now))
+	}
+}
+
+// requests is the number of requests sent so far, retries included.
+func (p *pacer) requests() int64 {
+	return atomic.LoadInt64(&p.calls)
+}
+
+// pacingTransport holds back each request until the pacer allows it.
+type pacingTransport struct {
+	base  http.RoundTripper
+	pacer *pacer
+}
+
+func (t *pacingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.pacer.wait()
+	atomic.AddInt64(&t.pacer.calls, 1)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPacerReserve(t *testing.T) {
+	start := time.Date(2016, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	p := &pacer{budget: 10, window: 10 * time.Second}
+	p.setQPS(2)
+
+	// Requests are spaced out by the --qps ceiling.
+	for i := 0; i < 4; i++ {
+		want := start.Add(time.Duration(i) * 500 * time.Millisecond)
+		if got := p.reserve(start); !got.Equal(want) {
+			t.Errorf("#%d: got %v want %v", i, got, want)
+		}
+	}
+
+	// Past half of the budget, the rest of it is spread over the window.
+	p.reserve(start)
+	if got, want := p.reserve(start), start.Add(3*time.Second); !got.Equal(want) {
+		t.Errorf("spread: got %v want %v", got, want)
+	}
+
+	// Requests that have left the window no longer count.
+	later := start.Add(time.Minute)
+	if got := p.reserve(later); !got.Equal(later) {
+		t.Errorf("after the window: got %v want %v", got, later)
+	}
+}
+
+func TestPacerCountsRequests(t *testing.T) {
+	p := newPacer()
+	transport := &pacingTransport{pacer: p, base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})}
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("GET", "https://www.googleapis.com/drive/v2/about", nil)
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := p.requests(); got != 3 {
+		t.Errorf("requests: got %d want 3", got)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/odeke-em/log"
+)
+
+const (
+	PagerEnvKey  = "PAGER"
+	DefaultPager = "less"
+	// Like git, let less quit if the output fits on one screen and pass colors through.
+	DefaultLessEnv = "FRX"
+)
+
+func pagerCommand() []string {
+	pager := strings.TrimSpace(os.Getenv(PagerEnvKey))
+	if pager == "" {
+		pager = DefaultPager
+	}
+	return strings.Fields(pager)
+}
+
+// startPager redirects the output of g to the user's pager, disabling the
+// interactive pagination prompts in the meantime. The returned func must be
+// called to flush the output and wait for the user to quit the pager.
+func (g *Commands) startPager() (stop func(), err error) {
+	argv := pagerCommand()
+	if len(argv) < 1 || argv[0] == "cat" {
+		return func() {}, nil
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), fmt.Sprintf("LESS=%s", DefaultLessEnv))
+	}
+
+	pagerIn, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	prevLog, prevNoPrompt := g.log, g.opts.NoPrompt
+	g.log = log.New(os.Stdin, pagerIn, os.Stderr)
+	g.opts.NoPrompt = true
+
+	stop = func() {
+		pagerIn.Close()
+		if wErr := cmd.Wait(); wErr != nil {
+			g.DebugPrintf("pager %q: %v\n", argv[0], wErr)
+		}
+		g.log, g.opts.NoPrompt = prevLog, prevNoPrompt
+	}
+
+	return stop, nil
+}
+
+// pageOutput starts the pager if one was requested and stdout is a terminal.
+func (g *Commands) pageOutput() func() {
+	if !g.opts.Pager || !g.opts.StdoutIsTty || g.opts.Quiet {
+		return func() {}
+	}
+
+	stop, err := g.startPager()
+	if err != nil {
+		g.log.LogErrf("pager: %v\n", err)
+		return func() {}
+	}
+	return stop
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// plannedChange is a change that a push or pull would make.
+type plannedChange struct {
+	Op           string `json:"op"`
+	Path         string `json:"path"`
+	Id           string `json:"id,omitempty"`
+	IsDir        bool   `json:"isDir,omitempty"`
+	Size         int64  `json:"size"`
+	PreviousSize int64  `json:"previousSize,omitempty"`
+	ModifiedTime string `json:"modifiedTime,omitempty"`
+}
+
+type planTotal struct {
+	Count int64 `json:"count"`
+	Size  int64 `json:"size"`
+}
+
+// changePlan is printed by a dry run for wrappers to inspect or approve,
+// sizes being those transferred, or of what is deleted.
+type changePlan struct {
+	Direction string                `json:"direction"`
+	Changes   []*plannedChange      `json:"changes"`
+	Totals    map[string]*planTotal `json:"totals"`
+}
+
+var planOpNames = map[Operation]string{
+	OpAdd:           "add",
+	OpDelete:        "delete",
+	OpMod:           "modify",
+	OpModConflict:   "modify",
+	OpIndexAddition: "index",
+}
+
+func makeChangePlan(cl []*Change, push bool) *changePlan {
+	plan := &changePlan{Direction: PullKey, Changes: []*plannedChange{}, Totals: map[string]*planTotal{}}
+	if push {
+		plan.Direction = PushKey
+	}
+
+	for _, c := range cl {
+		name, ok := planOpNames[c.Op()]
+		if !ok {
+			continue
+		}
+
+		f := c.Src
+		if f == nil {
+			f = c.Dest
+		}
+		pc := &plannedChange{Op: name, Path: c.Path, Id: f.Id, IsDir: f.IsDir}
+		if !f.IsDir {
+			pc.Size = f.Size
+		}
+		if !f.ModTime.IsZero() {
+			pc.ModifiedTime = f.ModTime.UTC().Format(time.RFC3339)
+		}
+		if c.Src != nil && c.Dest != nil {
+			if pc.Id == "" {
+				pc.Id = c.Dest.Id
+			}
+			if !c.Dest.IsDir {
+				pc.PreviousSize = c.Dest.Size
+			}
+		}
+		plan.Changes = append(plan.Changes, pc)
+
+		total, ok := plan.Totals[name]
+		if !ok {
+			total = &planTotal{}
+			plan.Totals[name] = total
+		}
+		total.Count += 1
+		total.Size += pc.Size
+	}
+	return plan
+}
+
+// dryRun prints the changes in cl instead of making them, as JSON on
+// stdout if requested.
+func (g *Commands) dryRun(cl []*Change, push bool) error {
+	if !jsonOutput(g.opts.TypeMask) {
+		if len(cl) < 1 {
+			g.log.Logln("Everything is up-to-date.")
+			return nil
+		}
+		clArg := &changeListArg{logy: g.log, changes: cl}
+		previewChanges(clArg, true, opChangeCount(cl))
+		return nil
+	}
+
+	blob, err := json.MarshalIndent(makeChangePlan(cl, push), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(append(blob, '\n'))
+	return err
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMakeChangePlan(t *testing.T) {
+	modTime := time.Date(2016, 3, 14, 15, 9, 26, 0, time.UTC)
+	cl := []*Change{
+		{Path: "/a/new.txt", Src: &File{Name: "new.txt", Size: 10, ModTime: modTime}},
+		{Path: "/a/dir", Src: &File{Name: "dir", IsDir: true, Size: 4096, ModTime: modTime}},
+		{Path: "/a/old.txt", Dest: &File{Id: "old-id", Name: "old.txt", Size: 7}},
+		{
+			Path:           "/a/mod.txt",
+			Src:            &File{Name: "mod.txt", Size: 5, ModTime: modTime},
+			Dest:           &File{Id: "mod-id", Name: "mod.txt", Size: 3},
+			IgnoreConflict: true,
+		},
+		{Path: "/a/same.txt", Src: &File{Name: "same.txt", Size: 1, ModTime: modTime}, Dest: &File{Name: "same.txt", Size: 1, ModTime: modTime}},
+	}
+
+	plan := makeChangePlan(cl, true)
+	if plan.Direction != PushKey {
+		t.Errorf("expected a push, got %q", plan.Direction)
+	}
+
+	want := []*plannedChange{
+		{Op: "add", Path: "/a/new.txt", Size: 10, ModifiedTime: "2016-03-14T15:09:26Z"},
+		{Op: "add", Path: "/a/dir", IsDir: true, ModifiedTime: "2016-03-14T15:09:26Z"},
+		{Op: "delete", Path: "/a/old.txt", Id: "old-id", Size: 7},
+		{Op: "modify", Path: "/a/mod.txt", Id: "mod-id", Size: 5, PreviousSize: 3, ModifiedTime: "2016-03-14T15:09:26Z"},
+	}
+	if len(plan.Changes) != len(want) {
+		t.Fatalf("expected %d changes, got %d", len(want), len(plan.Changes))
+	}
+	for i, pc := range plan.Changes {
+		if !reflect.DeepEqual(pc, want[i]) {
+			t.Errorf("#%d: expected %+v, got %+v", i, want[i], pc)
+		}
+	}
+
+	wantTotals := map[string]*planTotal{
+		"add":    {Count: 2, Size: 10},
+		"delete": {Count: 1, Size: 7},
+		"modify": {Count: 1, Size: 5},
+	}
+	if !reflect.DeepEqual(plan.Totals, wantTotals) {
+		t.Errorf("expected totals %v, got %v", wantTotals, plan.Totals)
+	}
+
+	if empty := makeChangePlan(nil, false); empty.Direction != PullKey || empty.Changes == nil || len(empty.Changes) != 0 {
+		t.Errorf("expected an empty pull plan, got %+v", empty)
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"os"
+	"strings"
+
+	"github.com/odeke-em/namespace"
+)
+
+// subtreePolicy holds the sync rules declared by a .driverc inside
+// a folder, locally or remotely. The rules apply to that folder and
+// its descendants, with deeper declarations overriding shallower ones
+// and local declarations overriding remote ones in the same folder.
+type subtreePolicy struct {
+	exports  []string
+	convert  *bool
+	readOnly bool
+
+	ignoreClauses []string
+	ignorer       func(string) bool
+
+	// gitIgnores are the patterns of the .driveignore files in the
+	// gitignore grammar of the folder and its ancestors, outermost first.
+	gitIgnores []*ignorePattern
+}
+
+func policyMappings(nsRCMap map[string]map[string]string, command string) (map[string]interface{}, error) {
+	grouped := make(map[string]map[string]interface{})
+	for _, key := range []string{namespace.GlobalNamespaceKey, command} {
+		ns, ok := nsRCMap[key]
+		if !ok {
+			continue
+		}
+		parsed, pErr := parseRCValues(ns)
+		if pErr != nil {
+			return nil, pErr
+		}
+		grouped[key] = parsed
+	}
+
+	return mergeNamespaces(grouped, command), nil
+}
+
+func readSubtreePolicyMappings(absDirPath, command string) (map[string]interface{}, error) {
+	p := rcPath(absDirPath)
+	if _, err := os.Stat(p); err != nil {
+		return nil, err
+	}
+
+	nsRCMap, err := kvifyCommentedFile(p, CommentStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return policyMappings(nsRCMap, command)
+}
+
+func (g *Commands) readRemotePolicyMappings(rc *File, command string) (map[string]interface{}, error) {
+	body, err := g.rem.Download(rc.Id, "")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	clauses, err := fReadFile_(body, commentedLineIgnorer(CommentStr))
+	if err != nil {
+		return nil, err
+	}
+
+	nsRCMap, err := kvifyClauses(clauses)
+	if err != nil {
+		return nil, err
+	}
+
+	return policyMappings(nsRCMap, command)
+}
+
+// derive returns the policy in effect for absDirPath, given that
+// sp is the policy in effect for its parent.
+func (sp *subtreePolicy) derive(absDirPath, command string) *subtreePolicy {
+	gitIgnores, gErr := readGitIgnores(absDirPath)
+	if gErr != nil && !os.IsNotExist(gErr) {
+		DebugPrintf("policy: %s: %v", absDirPath, gErr)
+	}
+
+	mappings, err := readSubtreePolicyMappings(absDirPath, command)
+	if err != nil && !os.IsNotExist(err) {
+		DebugPrintf("policy: %s: %v", absDirPath, err)
+	}
+
+	policy := sp
+	if len(gitIgnores) >= 1 {
+		policy = &subtreePolicy{}
+		if sp != nil {
+			*policy = *sp
+		}
+		policy.gitIgnores = append(append([]*ignorePattern{}, policy.gitIgnores...), gitIgnores...)
+	}
+
+	return policy.apply(mappings, absDirPath)
+}
+
+// deriveRemote returns the policy declared by the remote .driverc rc
+// for its folder, given that sp is the policy in effect for its parent.
+func (g *Commands) deriveRemote(sp *subtreePolicy, rc *File, command string) *subtreePolicy {
+	mappings, err := g.readRemotePolicyMappings(rc, command)
+	if err != nil {
+		g.DebugPrintf("policy: remote %s (%s): %v", rc.Name, rc.Id, err)
+		return sp
+	}
+
+	return sp.apply(mappings, rc.Id)
+}
+
+// apply returns sp overridden by mappings, read from the .driverc at origin.
+func (sp *subtreePolicy) apply(mappings map[string]interface{}, origin string) *subtreePolicy {
+	if len(mappings) < 1 {
+		return sp
+	}
+
+	derived := &subtreePolicy{}
+	if sp != nil {
+		*derived = *sp
+	}
+
+	if exports, ok := mappings[ExportsKey].(string); ok {
+		derived.exports = NonEmptyTrimmedStrings(strings.Split(exports, ",")...)
+	}
+
+	if convert, ok := mappings[ConvertKey].(bool); ok {
+		derived.convert = &convert
+	}
+
+	if readOnly, ok := mappings[CLIOptionReadOnly].(bool); ok {
+		derived.readOnly = readOnly
+	}
+
+	if ignores, ok := mappings[CLIOptionPolicyIgnore].(string); ok {
+		clauses := NonEmptyTrimmedStrings(strings.Split(ignores, ",")...)
+		derived.ignoreClauses = append(append([]string{}, derived.ignoreClauses...), clauses...)
+		ignorer, iErr := ignorerByClause(derived.ignoreClauses...)
+		if iErr != nil {
+			DebugPrintf("policy: %s: ignore clauses: %v", origin, iErr)
+		} else {
+			derived.ignorer = ignorer
+		}
+	}
+
+	return derived
+}
+
+// siftDriveRC passes on the files of pagePair, less the hidden ones unless
+// hidden is set, setting *rc to the remote .driverc amongst them, if any,
+// by the time all of them have been received.
+func siftDriveRC(pagePair *paginationPair, hidden bool, rc **File) *paginationPair {
+	filesChan := make(chan *File)
+	go func() {
+		defer close(filesChan)
+		for f := range pagePair.filesChan {
+			if f == nil {
+				continue
+			}
+			if !f.IsDir && f.Name == DriveResourceConfiguration {
+				*rc = f
+			}
+			if isHidden(f.Name, hidden) {
+				continue
+			}
+			filesChan <- f
+		}
+	}()
+
+	return &paginationPair{errsChan: pagePair.errsChan, filesChan: filesChan}
+}
+
+// folderPolicy returns the policy in effect for a folder given parent,
+// the policy in effect for its parent, its remote .driverc rc if any
+// and its local copy l if any.
+func (g *Commands) folderPolicy(parent *subtreePolicy, rc, l *File, localBase, command string) *subtreePolicy {
+	policy := parent
+	if rc != nil {
+		policy = g.deriveRemote(policy, rc, command)
+	}
+	if l != nil && l.IsDir {
+		policy = policy.derive(g.context.AbsPathOf(localBase), command)
+	}
+	return policy
+}
+
+func (sp *subtreePolicy) ignores(args ...string) bool {
+	if sp == nil {
+		return false
+	}
+	return anyMatch(sp.ignorer, args...)
+}
+
+// ignoresPath tells whether the .driveignore files in the gitignore
+// grammar exclude absPath.
+func (sp *subtreePolicy) ignoresPath(absPath string, isDir bool) bool {
+	if sp == nil {
+		return false
+	}
+	return gitIgnored(sp.gitIgnores, absPath, isDir)
+}
+
+func (sp *subtreePolicy) isReadOnly() bool {
+	return sp != nil && sp.readOnly
+}
+
+func (sp *subtreePolicy) exportsOr(fallback []string) []string {
+	if sp == nil || len(sp.exports) < 1 {
+		return fallback
+	}
+	return sp.exports
+}
+
+func (sp *subtreePolicy) typeMask(mask int) int {
+	if sp == nil || sp.convert == nil {
+		return mask
+	}
+	if *sp.convert {
+		return mask | OptConvert
+	}
+	return mask &^ OptConvert
+}
+
+func policyCommand(push bool) string {
+	if push {
+		return PushKey
+	}
+	return PullKey
+}
+
+// policyFor returns the policy in effect for relToRootDir by
+// deriving it from every ancestor folder, starting at the root.
+func (g *Commands) policyFor(relToRootDir, command string) *subtreePolicy {
+	var policy *subtreePolicy
+
+	segments := NonEmptyTrimmedStrings(strings.Split(relToRootDir, RemoteSeparator)...)
+	cur, remoteDir := "", &File{Id: "root", IsDir: true}
+	policy = g.derivePolicyAt(policy, remoteDir, cur, command)
+	for _, segment := range segments {
+		cur = sepJoin(RemoteSeparator, cur, segment)
+		remoteDir = g.remoteChild(remoteDir, segment)
+		policy = g.derivePolicyAt(policy, remoteDir, cur, command)
+	}
+
+	return policy
+}
+
+// derivePolicyAt returns the policy in effect for the folder at relToRoot,
+// given remoteDir its remote copy if any, and that sp is the policy in
+// effect for its parent.
+func (g *Commands) derivePolicyAt(sp *subtreePolicy, remoteDir *File, relToRoot, command string) *subtreePolicy {
+	if rc := g.remoteChild(remoteDir, DriveResourceConfiguration); rc != nil && !rc.IsDir {
+		sp = g.deriveRemote(sp, rc, command)
+	}
+	return sp.derive(g.context.AbsPathOf(relToRoot), command)
+}
+
+// remoteChild returns the child named name of the remote folder dir, if any.
+func (g *Commands) remoteChild(dir *File, name string) *File {
+	if dir == nil || !dir.IsDir {
+		return nil
+	}
+	child, err := g.rem.findByPathRecv(dir.Id, []string{name})
+	if err != nil {
+		return nil
+	}
+	return child
+}
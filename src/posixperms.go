@@ -0,0 +1,113 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Private properties recording the POSIX metadata of pushed files.
+const (
+	PosixModeProperty = "drivePosixMode"
+	PosixUidProperty  = "drivePosixUid"
+	PosixGidProperty  = "drivePosixGid"
+)
+
+// posixMode converts m to its octal POSIX bits e.g 04755.
+func posixMode(m os.FileMode) uint32 {
+	bits := uint32(m.Perm())
+	if m&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if m&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if m&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+	return bits
+}
+
+// fileMode converts octal POSIX bits back to an os.FileMode.
+func fileMode(bits uint32) os.FileMode {
+	m := os.FileMode(bits & 0777)
+	if bits&04000 != 0 {
+		m |= os.ModeSetuid
+	}
+	if bits&02000 != 0 {
+		m |= os.ModeSetgid
+	}
+	if bits&01000 != 0 {
+		m |= os.ModeSticky
+	}
+	return m
+}
+
+// posixProperties describes the mode, and if withOwner the uid and gid,
+// of the local file that info was obtained from.
+func posixProperties(info os.FileInfo, withOwner bool) map[string]string {
+	props := map[string]string{
+		PosixModeProperty: fmt.Sprintf("%04o", posixMode(info.Mode())),
+	}
+	if uid, gid, ok := fileOwner(info); ok && withOwner {
+		props[PosixUidProperty] = strconv.Itoa(uid)
+		props[PosixGidProperty] = strconv.Itoa(gid)
+	}
+	return props
+}
+
+// restorePosixPerms applies onto absPath the POSIX metadata recorded on f
+// when it was pushed, if any.
+func (g *Commands) restorePosixPerms(absPath string, f *File) error {
+	if f == nil || f.raw == nil {
+		return nil
+	}
+	props := make(map[string]string)
+	for _, prop := range f.raw.Properties {
+		if prop != nil {
+			props[prop.Key] = prop.Value
+		}
+	}
+
+	var err error
+	if mode, ok := props[PosixModeProperty]; ok {
+		bits, pErr := strconv.ParseUint(mode, 8, 32)
+		if pErr != nil {
+			err = combineErrors(err, fmt.Errorf("%s: mode %q: %v", absPath, mode, pErr))
+		} else if cErr := os.Chmod(absPath, fileMode(uint32(bits))); cErr != nil {
+			err = combineErrors(err, cErr)
+		}
+	}
+
+	if !g.opts.PreserveOwner {
+		return err
+	}
+	uidStr, hasUid := props[PosixUidProperty]
+	gidStr, hasGid := props[PosixGidProperty]
+	if !hasUid || !hasGid {
+		return err
+	}
+	uid, uErr := strconv.Atoi(uidStr)
+	gid, gErr := strconv.Atoi(gidStr)
+	if uErr != nil || gErr != nil {
+		return combineErrors(err, fmt.Errorf("%s: owner %s:%s is invalid", absPath, uidStr, gidStr))
+	}
+	if cErr := os.Lchown(absPath, uid, gid); cErr != nil {
+		err = combineErrors(err, cErr)
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPosixMode(t *testing.T) {
+	modes := map[os.FileMode]uint32{
+		0644:                              0644,
+		0755 | os.ModeDir:                 0755,
+		0755 | os.ModeSetuid:              04755,
+		0775 | os.ModeSetgid:              02775,
+		0777 | os.ModeSticky | os.ModeDir: 01777,
+	}
+	for mode, want := range modes {
+		if got := posixMode(mode); got != want {
+			t.Errorf("%v: got %04o want %04o", mode, got, want)
+		}
+		if got := fileMode(want); got != mode&^os.ModeDir {
+			t.Errorf("%04o: got %v want %v", want, got, mode&^os.ModeDir)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/odeke-em/drive/config"
+	drive "google.golang.org/api/drive/v2"
+)
+
+// permissionCarrier reapplies the permissions that sources had onto
+// their copies or onto themselves once moved, keeping a tally of
+// the grants that could not be carried over.
+type permissionCarrier struct {
+	mu      sync.Mutex
+	carried int
+	failed  []string
+}
+
+func (g *Commands) newPermissionCarrier() *permissionCarrier {
+	if !g.opts.PreservePermissions {
+		return nil
+	}
+	return &permissionCarrier{}
+}
+
+// carryOver reapplies the permissions of src onto dest.
+func (g *Commands) carryOver(src, dest *File, relToRootPath string) {
+	pc := g.permCarrier
+	g.carryPermissions(pc, g.snapshotPermissions(pc, src, relToRootPath), dest, relToRootPath)
+}
+
+// snapshotPermissions returns the effective permissions of f, including
+// those that it inherits from its parents, to be reapplied later on.
+func (g *Commands) snapshotPermissions(pc *permissionCarrier, f *File, relToRootPath string) []*drive.Permission {
+	if pc == nil || f == nil {
+		return nil
+	}
+	perms, err := g.rem.listPermissions(f.Id)
+	if err != nil {
+		pc.fail(relToRootPath, fmt.Sprintf("listing permissions: %v", err))
+		return nil
+	}
+	return perms
+}
+
+// carryPermissions grants dest whatever perms it does not already have.
+// Ownership cannot be granted this way so it is reported instead.
+func (g *Commands) carryPermissions(pc *permissionCarrier, perms []*drive.Permission, dest *File, relToRootPath string) {
+	if pc == nil || dest == nil || len(perms) < 1 {
+		return
+	}
+
+	existing, err := g.rem.listPermissions(dest.Id)
+	if err != nil {
+		pc.fail(relToRootPath, fmt.Sprintf("listing permissions: %v", err))
+		return
+	}
+
+	for _, perm := range perms {
+		if perm == nil || permissionIn(perm, existing) {
+			continue
+		}
+		if perm.Role == "owner" {
+			pc.fail(relToRootPath, fmt.Sprintf("%s: ownership cannot be carried over", permissionLabel(perm)))
+			continue
+		}
+
+		granted, err := g.rem.grantPermission(dest.Id, perm)
+		if err != nil {
+			pc.fail(relToRootPath, fmt.Sprintf("%s: %v", permissionLabel(perm), err))
+			continue
+		}
+
+		pc.mu.Lock()
+		pc.carried += 1
+		pc.mu.Unlock()
+
+		g.recordHistory(&config.HistoryEntry{
+			Op:     HistoryOpShare,
+			FileId: dest.Id, Path: relToRootPath,
+			PermissionId: granted.Id,
+			Role:         granted.Role,
+			AccountType:  granted.Type,
+			Value:        permissionValue(perm),
+			WithLink:     granted.WithLink,
+		})
+	}
+}
+
+func (pc *permissionCarrier) fail(relToRootPath, reason string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.failed = append(pc.failed, fmt.Sprintf("%s: %s", relToRootPath, reason))
+}
+
+// reportCarriedPermissions logs how many grants were carried over and returns
+// an error listing those that could not be, if any.
+func (g *Commands) reportCarriedPermissions(pc *permissionCarrier) error {
+	if pc == nil {
+		return nil
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	g.log.Logf("permissions: %d grant(s) carried over\n", pc.carried)
+	if len(pc.failed) < 1 {
+		return nil
+	}
+
+	g.log.LogErrf("permissions: %d grant(s) could not be carried over\n", len(pc.failed))
+	return makeError(reComposeError(nil, pc.failed...), StatusGeneric)
+}
+
+// permissionIn reports whether the addressee of perm already has its roles in perms.
+func permissionIn(perm *drive.Permission, perms []*drive.Permission) bool {
+	for _, other := range perms {
+		if other == nil || other.Id != perm.Id || other.Type != perm.Type {
+			continue
+		}
+		if other.Role == "owner" || other.Role == "organizer" {
+			return true
+		}
+		if other.Role == perm.Role && stringsContainAll(other.AdditionalRoles, perm.AdditionalRoles) {
+			return true
+		}
+		if perm.Role == "reader" && other.Role == "writer" {
+			return true
+		}
+	}
+	return false
+}
+
+func stringsContainAll(haystack, needles []string) bool {
+	for _, needle := range needles {
+		found := false
+		for _, s := range haystack {
+			if s == needle {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func permissionLabel(perm *drive.Permission) string {
+	addressee := permissionValue(perm)
+	if addressee == "" {
+		addressee = perm.Type
+	}
+	return fmt.Sprintf("%s %s", perm.Role, addressee)
+}
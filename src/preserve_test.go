@@ -0,0 +1,46 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+func TestPermissionIn(t *testing.T) {
+	existing := []*drive.Permission{
+		{Id: "alice", Type: "user", Role: "writer"},
+		{Id: "bob", Type: "user", Role: "reader", AdditionalRoles: []string{"commenter"}},
+	}
+
+	samples := []struct {
+		perm *drive.Permission
+		want bool
+	}{
+		{perm: &drive.Permission{Id: "alice", Type: "user", Role: "writer"}, want: true},
+		{perm: &drive.Permission{Id: "alice", Type: "user", Role: "reader"}, want: true},
+		{perm: &drive.Permission{Id: "bob", Type: "user", Role: "reader"}, want: true},
+		{perm: &drive.Permission{Id: "bob", Type: "user", Role: "reader", AdditionalRoles: []string{"commenter"}}, want: true},
+		{perm: &drive.Permission{Id: "bob", Type: "user", Role: "writer"}, want: false},
+		{perm: &drive.Permission{Id: "anyone", Type: "anyone", Role: "reader"}, want: false},
+	}
+
+	for i, sample := range samples {
+		if got := permissionIn(sample.perm, existing); got != sample.want {
+			t.Errorf("#%d: got %v want %v", i, got, sample.want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	progressRefresh = 250 * time.Millisecond
+	// progressNameRunes is how much of the path of a file is shown.
+	progressNameRunes = 40
+)
+
+type fileProgress struct {
+	name  string
+	size  int64
+	done  int64
+	start time.Time
+}
+
+// transferProgress draws a line for each file being transferred, with its
+// percentage and speed, followed by a line for the bytes of all the files
+// and the time left, redrawing them in place on a terminal.
+type transferProgress struct {
+	mu    sync.Mutex
+	w     io.Writer
+	total int64
+	done  int64
+	start time.Time
+	files map[string]*fileProgress
+	order []string
+	// drawn is the number of lines last drawn, to be drawn over.
+	drawn int
+
+	stop    chan bool
+	stopped chan bool
+}
+
+func newTransferProgress(w io.Writer, total int64) *transferProgress {
+	tp := &transferProgress{
+		w:       w,
+		total:   total,
+		start:   time.Now(),
+		files:   make(map[string]*fileProgress),
+		stop:    make(chan bool),
+		stopped: make(chan bool),
+	}
+	go tp.run()
+	return tp
+}
+
+func (tp *transferProgress) run() {
+	defer close(tp.stopped)
+	tick := time.NewTicker(progressRefresh)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			tp.draw()
+		case <-tp.stop:
+			tp.draw()
+			return
+		}
+	}
+}
+
+func (tp *transferProgress) begin(name string, size int64) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if _, ok := tp.files[name]; !ok {
+		tp.order = append(tp.order, name)
+	}
+	tp.files[name] = &fileProgress{name: name, size: size, start: time.Now()}
+}
+
+func (tp *transferProgress) end(name string) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if _, ok := tp.files[name]; !ok {
+		return
+	}
+	delete(tp.files, name)
+	for i, other := range tp.order {
+		if other == name {
+			tp.order = append(tp.order[:i], tp.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// fileAdd counts n bytes of the file transferred. Bytes of files that
+// aren't being shown are only counted by add.
+func (tp *transferProgress) fileAdd(name string, n int64) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if fp, ok := tp.files[name]; ok {
+		fp.done += n
+	}
+}
+
+func (tp *transferProgress) add(n int64) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	tp.done += n
+}
+
+func (tp *transferProgress) finish() {
+	close(tp.stop)
+	<-tp.stopped
+}
+
+func (tp *transferProgress) draw() {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	now := time.Now()
+	var lines []string
+	for _, name := range tp.order {
+		fp := tp.files[name]
+		lines = append(lines, fmt.Sprintf("%-*s %4s %10s/s",
+			progressNameRunes, elideLeft(fp.name, progressNameRunes),
+			percentOf(fp.done, fp.size), prettyBytes(bytesPerSecond(fp.done, now.Sub(fp.start)))))
+	}
+	elapsed := now.Sub(tp.start)
+	lines = append(lines, fmt.Sprintf("%s / %s %s %s/s ETA %s",
+		prettyBytes(tp.done), prettyBytes(tp.total), percentOf(tp.done, tp.total),
+		prettyBytes(bytesPerSecond(tp.done, elapsed)), formatETA(eta(tp.done, tp.total, elapsed))))
+
+	// Move up to the first line drawn before, clearing what is left
+	// of the lines that are no longer needed.
+	if tp.drawn > 0 {
+		fmt.Fprintf(tp.w, "\033[%dA", tp.drawn)
+	}
+	for _, line := range lines {
+		fmt.Fprintf(tp.w, "\r\033[2K%s\n", line)
+	}
+	for i := len(lines); i < tp.drawn; i++ {
+		fmt.Fprint(tp.w, "\r\033[2K\n")
+	}
+	if extra := tp.drawn - len(lines); extra > 0 {
+		fmt.Fprintf(tp.w, "\033[%dA", extra)
+	}
+	tp.drawn = len(lines)
+}
+
+func percentOf(done, total int64) string {
+	if total <= 0 {
+		return "-"
+	}
+	if done > total {
+		done = total
+	}
+	return fmt.Sprintf("%d%%", done*100/total)
+}
+
+func bytesPerSecond(n int64, elapsed time.Duration) int64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(n) / elapsed.Seconds())
+}
+
+// eta returns how long is left to transfer total bytes at the rate that
+// done were transferred in elapsed, or a negative duration if unknown.
+func eta(done, total int64, elapsed time.Duration) time.Duration {
+	if done <= 0 || elapsed <= 0 {
+		return -1
+	}
+	if done >= total {
+		return 0
+	}
+	return time.Duration(float64(elapsed) * float64(total-done) / float64(done))
+}
+
+func formatETA(d time.Duration) string {
+	if d < 0 {
+		return "--"
+	}
+	return ((d + time.Second/2) / time.Second * time.Second).String()
+}
+
+// elideLeft shortens s to max runes, keeping its end, e.g the name of a file.
+func elideLeft(s string, max int) string {
+	n := utf8.RuneCountInString(s)
+	if n <= max {
+		return s
+	}
+	runes := []rune(s)
+	return "..." + string(runes[n-max+3:])
+}
+
+// transferStart shows the progress of transferring total bytes, as a line per
+// file on a terminal, else as done by taskStart.
+func (g *Commands) transferStart(total int64) {
+	if total < 1 || !g.opts.canSpin() {
+		g.taskStart(total)
+		return
+	}
+	g.transfers = newTransferProgress(os.Stdout, total)
+	g.rem.transfers = g.transfers
+}
+
+// transferBegin adds a line for the change, if shown.
+func (g *Commands) transferBegin(c *Change) {
+	if g.transfers == nil || c == nil {
+		return
+	}
+	f := c.Src
+	if f == nil {
+		f = c.Dest
+	}
+	if f == nil || f.IsDir {
+		return
+	}
+	g.transfers.begin(c.Path, f.Size)
+}
+
+func (g *Commands) transferEnd(c *Change) {
+	if g.transfers != nil && c != nil {
+		g.transfers.end(c.Path)
+	}
+}
+
+// progress counts n bytes of the file at relToRootPath transferred.
+func (r *Remote) progress(relToRootPath string, n int) {
+	if r.transfers != nil && relToRootPath != "" {
+		r.transfers.fileAdd(relToRootPath, int64(n))
+	}
+	r.progressChan <- n
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestETA(t *testing.T) {
+	tests := []struct {
+		done, total int64
+		elapsed     time.Duration
+		want        string
+	}{
+		{0, 100, time.Second, "--"},
+		{50, 100, 10 * time.Second, "10s"},
+		{25, 100, 10 * time.Second, "30s"},
+		{100, 100, 10 * time.Second, "0s"},
+		{10, 100, 0, "--"},
+	}
+	for _, tt := range tests {
+		if got := formatETA(eta(tt.done, tt.total, tt.elapsed)); got != tt.want {
+			t.Errorf("eta(%d, %d, %v): got %q want %q", tt.done, tt.total, tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestProgressLineHelpers(t *testing.T) {
+	if got := percentOf(150, 100); got != "100%" {
+		t.Errorf("percentOf over the total: got %q", got)
+	}
+	if got := percentOf(1, 0); got != "-" {
+		t.Errorf("percentOf of an empty file: got %q", got)
+	}
+	if got := elideLeft("/a/b/c.txt", 20); got != "/a/b/c.txt" {
+		t.Errorf("elideLeft of a short path: got %q", got)
+	}
+	if got := elideLeft("/photos/été/plage.jpg", 12); got != "...plage.jpg" || utf8.RuneCountInString(got) != 12 {
+		t.Errorf("elideLeft of a long path: got %q", got)
+	}
+}
@@ -15,6 +15,7 @@
 package drive
 
 import (
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
@@ -23,6 +24,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/odeke-em/drive/config"
@@ -43,6 +45,7 @@ const (
 	TypeMatches
 	TypeStarred
 	TypeAllStarred
+	TypeShared
 )
 
 type urlMimeTypeExt struct {
@@ -56,6 +59,14 @@ type downloadArg struct {
 	path            string
 	exportURL       string
 	ackByteProgress bool
+	size            int64
+	chunks          int
+	// gunzip decompresses content that was pushed gzipped.
+	gunzip bool
+	// relToRootPath is the path whose progress the bytes are counted to.
+	relToRootPath string
+	// md5 if set is the checksum that a resumed download is verified against.
+	md5 string
 }
 
 type renameOp struct {
@@ -79,6 +90,12 @@ func (g *Commands) PullAllStarred() error {
 	return pull(g, TypeAllStarred)
 }
 
+// PullShared pulls everything shared with me by the owners in
+// ExactOwnerKey, or by anyone if none are set, into the destination.
+func (g *Commands) PullShared() error {
+	return pull(g, TypeShared)
+}
+
 func (g *Commands) PullMatchLike() error {
 	pt := TypeNone
 	if g.opts.Match {
@@ -94,6 +111,15 @@ func pull(g *Commands, pt pullType) error {
 	g.rem.encrypter = g.opts.Encrypter
 	g.rem.decrypter = g.opts.Decrypter
 
+	if g.opts.InTrash {
+		// Local files missing from the trash are just not trashed,
+		// they mustn't be deleted to match it.
+		g.opts.ExcludeCrudMask |= Delete
+	}
+
+	defer g.handleInterrupts(nil)()
+	defer g.flushChecksums()
+
 	cl, clashes, err := pullLikeResolve(g, pt)
 
 	if len(clashes) >= 1 {
@@ -114,6 +140,14 @@ func pull(g *Commands, pt pullType) error {
 		return err
 	}
 
+	var links []*shortcutLink
+	if g.opts.Links != LinksFollow {
+		cl, _, links = g.linkChanges(cl, false)
+		if len(links) >= 1 {
+			g.log.Logf("%d shortcuts will be pulled as symlinks\n", len(links))
+		}
+	}
+
 	nonConflictsPtr, conflictsPtr := g.resolveConflicts(cl, false)
 	if conflictsPtr != nil {
 		warnConflictsPersist(g.log, *conflictsPtr)
@@ -121,6 +155,9 @@ func pull(g *Commands, pt pullType) error {
 	}
 
 	nonConflicts := *nonConflictsPtr
+	if g.opts.DryRun {
+		return g.dryRun(nonConflicts, false)
+	}
 
 	clArg := &changeListArg{
 		logy:       g.log,
@@ -131,11 +168,20 @@ func pull(g *Commands, pt pullType) error {
 	}
 
 	status, opMap := printChangeList(clArg)
+	if notApplicable(status) {
+		return combineErrors(g.pullShortcutLinks(links), g.saveChangesCheckpoints())
+	}
 	if !accepted(status) {
 		return status.Error()
 	}
+	if err := g.confirmMirrorDeletions(PullKey, nonConflicts); err != nil {
+		return err
+	}
 
-	return g.playPullChanges(nonConflicts, g.opts.Exports, opMap)
+	if err := g.playPullChanges(nonConflicts, g.opts.Exports, opMap); err != nil {
+		return err
+	}
+	return combineErrors(g.pullShortcutLinks(links), g.saveChangesCheckpoints())
 }
 
 func typeById(pt pullType) bool {
@@ -150,8 +196,11 @@ func typeByAllStarred(pt pullType) bool {
 	return (pt & TypeAllStarred) != 0
 }
 
+func typeByShared(pt pullType) bool {
+	return (pt & TypeShared) != 0
+}
+
 func pullLikeResolve(g *Commands, pt pullType) (cl, clashes []*Change, err error) {
-	// TODO: (@odeke-em) allow pull-trashed
 	g.log.Logln("Resolving...")
 
 	spin := g.playabler()
@@ -164,10 +213,16 @@ func pullLikeResolve(g *Commands, pt pullType) (cl, clashes []*Change, err error
 		resolver = g.pullById
 	} else if typeByAllStarred(pt) {
 		resolver = g.pullAllStarred
+	} else if typeByShared(pt) {
+		resolver = g.pullShared
 	} else if typeByMatchLike(pt) {
 		resolver = func() (cl, cll []*Change, err error) {
 			return g.pullLikeMatchesResolver(pt)
 		}
+	} else if !g.opts.ChangedSince.IsZero() {
+		resolver = g.pullChangedSince
+	} else if g.opts.Incremental {
+		resolver = g.pullIncremental
 	}
 
 	return resolver()
@@ -186,6 +241,8 @@ func matchQuerier(g *Commands, pt pullType) *matchQuery {
 		titleSearches: []fuzzyStringsValuePair{
 			{fuzzyLevel: fuzzLevel, values: g.opts.Sources},
 		},
+		modifiedAfter:  g.opts.ModifiedAfter,
+		modifiedBefore: g.opts.ModifiedBefore,
 	}
 }
 
@@ -233,6 +290,112 @@ func (g *Commands) pullAllStarred() (cl, clashes []*Change, err error) {
 	return
 }
 
+// sharedAncestors walks up the accessible parents of f, nearest first.
+// covered is set if one of them is itself in shared, in which case f
+// is pulled along with that ancestor. Inaccessible parents, usually the
+// sharer's own folders, end the walk.
+func (g *Commands) sharedAncestors(f *File, shared map[string]*File, lookedUp map[string]*File) (ancestors []*File, covered bool) {
+	visited := map[string]bool{f.Id: true}
+	for cur := f; ; {
+		var parent *File
+		for _, p := range cur.Parents {
+			if p == nil || p.IsRoot || visited[p.Id] {
+				continue
+			}
+			if _, ok := shared[p.Id]; ok {
+				return ancestors, true
+			}
+
+			pf, ok := lookedUp[p.Id]
+			if !ok {
+				pf, _ = g.rem.FindById(p.Id)
+				lookedUp[p.Id] = pf
+			}
+			if pf != nil {
+				parent = pf
+				break
+			}
+		}
+
+		if parent == nil {
+			return ancestors, false
+		}
+		visited[parent.Id] = true
+		ancestors = append(ancestors, parent)
+		cur = parent
+	}
+}
+
+func (g *Commands) pullShared() (cl, clashes []*Change, err error) {
+	if len(g.opts.Sources) != 1 {
+		return cl, clashes, invalidArgumentsErr(fmt.Errorf("pull shared: expecting exactly one destination, got %v", g.opts.Sources))
+	}
+	dest := g.opts.Sources[0]
+
+	var owners []string
+	if g.opts.Meta != nil {
+		owners = (*g.opts.Meta)[ExactOwnerKey]
+	}
+
+	pagePair := g.rem.FindSharedByOwners(owners, g.opts.Hidden)
+	errsChan := pagePair.errsChan
+	sharedChan := pagePair.filesChan
+
+	shared := map[string]*File{}
+	var sharedFiles []*File
+
+	working := true
+	for working {
+		select {
+		case err := <-errsChan:
+			if err != nil {
+				return cl, clashes, err
+			}
+		case f, stillHasContent := <-sharedChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if f == nil {
+				continue
+			}
+			if _, seen := shared[f.Id]; !seen {
+				shared[f.Id] = f
+				sharedFiles = append(sharedFiles, f)
+			}
+		}
+	}
+
+	lookedUp := map[string]*File{}
+	for _, f := range sharedFiles {
+		ancestors, covered := g.sharedAncestors(f, shared, lookedUp)
+		if covered {
+			continue
+		}
+
+		segments := []string{dest}
+		for i := len(ancestors) - 1; i >= 0; i-- {
+			segments = append(segments, ancestors[i].Name)
+		}
+		relToRoot := path.Join(append(segments, f.Name)...)
+		fsPath := g.context.AbsPathOf(relToRoot)
+
+		ccl, cclashes, cErr := g.byRemoteResolve(relToRoot, fsPath, f, false)
+		if cErr != nil {
+			if cErr != ErrClashesDetected {
+				return cl, clashes, cErr
+			}
+			clashes = append(clashes, cclashes...)
+		}
+		cl = append(cl, ccl...)
+	}
+
+	if len(clashes) >= 1 {
+		err = ErrClashesDetected
+	}
+	return cl, clashes, err
+}
+
 func clCombiner(from, to *[]*Change, done chan bool) {
 	*to = append(*to, *from...)
 	done <- true
@@ -262,9 +425,12 @@ func (g *Commands) pullLikeMatchesResolver(pt pullType) (cl, clashes []*Change,
 				break
 			}
 
-			if match == nil {
+			if match == nil || !g.opts.admits(match) {
 				continue
 			}
+			if !g.takeResult() {
+				return cl, clashes, nil
+			}
 			relToRoot := filepath.Join(g.opts.Path, match.Name)
 			fsPath := g.context.AbsPathOf(relToRoot)
 
@@ -305,19 +471,24 @@ func (g *Commands) PullPiped(byId bool) (err error) {
 	resolver := g.rem.FindByPathM
 	if byId {
 		resolver = g.rem.FindByIdM
+	} else if g.opts.InTrash {
+		resolver = g.rem.FindByPathTrashedM
 	}
 
-	// TODO: (@odeke-em) allow pull-trashed
+	br, err := g.requestedByteRange()
+	if err != nil {
+		return err
+	}
 
 	for _, relToRootPath := range g.opts.Sources {
-		if err := g.pullPipedPerResolver(relToRootPath, resolver); err != nil {
+		if err := g.pullPipedPerResolver(relToRootPath, resolver, br); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (g *Commands) pullPipedPerResolver(arg string, resolver func(string) *paginationPair) error {
+func (g *Commands) pullPipedPerResolver(arg string, resolver func(string) *paginationPair, br *byteRange) error {
 	pagePair := resolver(arg)
 	errsChan := pagePair.errsChan
 	matchesChan := pagePair.filesChan
@@ -341,7 +512,7 @@ func (g *Commands) pullPipedPerResolver(arg string, resolver func(string) *pagin
 				continue
 			}
 
-			err = g.pullAndDownload(arg, os.Stdout, rem, true)
+			err = g.pullAndDownload(arg, os.Stdout, rem, br)
 			if err != nil {
 				return err
 			}
@@ -354,31 +525,11 @@ func (g *Commands) pullPipedPerResolver(arg string, resolver func(string) *pagin
 
 func (g *Commands) pullById() (cl, clashes []*Change, err error) {
 	for _, srcId := range g.opts.Sources {
-		rem, remErr := g.rem.FindById(srcId)
-		if remErr != nil {
-			return cl, clashes, makeErrorWithStatus(fmt.Sprintf("pullById: %s", srcId), remErr, StatusPullFailed)
-		}
-
-		if rem == nil {
-			g.log.LogErrf("%s does not exist\n", srcId)
-			continue
-		}
-
-		relToRootPath := filepath.Join(g.opts.Path, rem.Name)
-		curAbsPath := g.context.AbsPathOf(relToRootPath)
-		local, resErr := g.resolveToLocalFile(rem.Name, curAbsPath)
-		if resErr != nil {
-			return cl, clashes, resErr
-		}
-
-		ccl, cclashes, clErr := g.doChangeListRecv(relToRootPath, curAbsPath, local, rem, false)
-		if clErr != nil {
-			if clErr != ErrClashesDetected {
-				return cl, clashes, clErr
-			} else {
-				clashes = append(clashes, cclashes...)
-			}
+		ccl, cclashes, idErr := g.pullOneById(srcId)
+		if idErr != nil {
+			return cl, clashes, idErr
 		}
+		clashes = append(clashes, cclashes...)
 		cl = append(cl, ccl...)
 	}
 
@@ -389,8 +540,50 @@ func (g *Commands) pullById() (cl, clashes []*Change, err error) {
 	return cl, clashes, err
 }
 
+// pullOneById resolves the changes to pull the file or folder
+// of srcId into the current directory, returning only fatal errors.
+func (g *Commands) pullOneById(srcId string) (cl, clashes []*Change, err error) {
+	rem, remErr := g.rem.FindById(srcId)
+	if remErr != nil {
+		return cl, clashes, makeErrorWithStatus(fmt.Sprintf("pullById: %s", srcId), remErr, StatusPullFailed)
+	}
+
+	if rem == nil {
+		g.log.LogErrf("%s does not exist\n", srcId)
+		return cl, clashes, nil
+	}
+
+	relToRootPath := filepath.Join(g.opts.Path, rem.Name)
+	curAbsPath := g.context.AbsPathOf(relToRootPath)
+	local, resErr := g.resolveToLocalFile(rem.Name, curAbsPath)
+	if resErr != nil {
+		return cl, clashes, resErr
+	}
+
+	cl, clashes, err = g.doChangeListRecv(relToRootPath, curAbsPath, local, rem, false)
+	if err == ErrClashesDetected {
+		err = nil
+	}
+	return cl, clashes, err
+}
+
 func (g *Commands) pullByPath() (cl, clashes []*Change, err error) {
+	if err := g.expandGlobSources(false); err != nil {
+		return cl, clashes, err
+	}
+
 	for _, relToRootPath := range g.opts.Sources {
+		// Ids and links from the web UI can be mixed in with paths.
+		if srcId, ok := IdFromSource(relToRootPath); ok {
+			ccl, cclashes, idErr := g.pullOneById(srcId)
+			if idErr != nil {
+				return cl, clashes, idErr
+			}
+			clashes = append(clashes, cclashes...)
+			cl = append(cl, ccl...)
+			continue
+		}
+
 		fsPath := g.context.AbsPathOf(relToRootPath)
 		ccl, cclashes, cErr := g.changeListResolve(relToRootPath, fsPath, false)
 		if len(cclashes) > 0 {
@@ -404,6 +597,13 @@ func (g *Commands) pullByPath() (cl, clashes []*Change, err error) {
 		}
 	}
 
+	bcl, bclashes, bErr := g.pullBatchResolve()
+	clashes = append(clashes, bclashes...)
+	cl = append(cl, bcl...)
+	if bErr != nil && bErr != ErrClashesDetected {
+		err = combineErrors(err, bErr)
+	}
+
 	if len(clashes) >= 1 {
 		err = ErrClashesDetected
 	}
@@ -411,14 +611,27 @@ func (g *Commands) pullByPath() (cl, clashes []*Change, err error) {
 	return cl, clashes, err
 }
 
-func (g *Commands) pullAndDownload(relToRootPath string, fh io.Writer, rem *File, piped bool) error {
+// pullAndDownload writes the content of rem to fh, only the bytes within br if set.
+func (g *Commands) pullAndDownload(relToRootPath string, fh io.Writer, rem *File, br *byteRange) error {
+	exportURL := ""
 	if hasExportLinks(rem) {
-		return googleDocNonExportErr(
-			fmt.Errorf("'%s' is a GoogleDoc/Sheet document cannot be pulled from raw, only exported.\n", relToRootPath),
-		)
-
+		exportURL = firstExportURL(rem, g.opts.Exports)
+		if exportURL == "" {
+			return googleDocNonExportErr(
+				fmt.Errorf("'%s' is a GoogleDoc/Sheet document cannot be pulled from raw, only exported e.g with `-%s pdf`.\n", relToRootPath, ExportsKey),
+			)
+		}
+		if br != nil {
+			return invalidArgumentsErr(fmt.Errorf("%s: a byte range of an export cannot be fetched", relToRootPath))
+		}
+	}
+	var blobHandle io.ReadCloser
+	var dlErr error
+	if br != nil {
+		blobHandle, dlErr = g.rem.DownloadRange(rem.Id, br)
+	} else {
+		blobHandle, dlErr = g.rem.Download(rem.Id, exportURL)
 	}
-	blobHandle, dlErr := g.rem.Download(rem.Id, "")
 	if dlErr != nil {
 		return dlErr
 	}
@@ -434,33 +647,8 @@ func (g *Commands) pullAndDownload(relToRootPath string, fh io.Writer, rem *File
 	return downloadFailedErr(err)
 }
 
-func (g *Commands) playPullChanges(cl []*Change, exports []string, opMap *map[Operation]sizeCounter) (err error) {
-	if opMap == nil {
-		result := opChangeCount(cl)
-		opMap = &result
-	}
-
-	totalSize := int64(0)
-	ops := *opMap
-
-	for op, counter := range ops {
-		totalSize += counter.sizeByOperation(op)
-	}
-
-	g.taskStart(totalSize)
-
-	defer close(g.rem.progressChan)
-
-	go func() {
-		for n := range g.rem.progressChan {
-			g.taskAdd(int64(n))
-		}
-	}()
-
-	// TODO: Only provide precedence ordering if all the other options are allowed
-	sort.Sort(ByPrecedence(cl))
-
-	n := maxProcs()
+// runPullJobs plays cl with n jobs at a time, returning how many failed.
+func (g *Commands) runPullJobs(cl []*Change, exports []string, n int) (failures int64, err error) {
 	jobsChan := make(chan semalim.Job)
 
 	go func() {
@@ -468,6 +656,10 @@ func (g *Commands) playPullChanges(cl []*Change, exports []string, opMap *map[Op
 		throttle := time.Tick(time.Duration(1e9 / n))
 
 		for i, c := range cl {
+			if g.interrupted() {
+				g.skipUnstarted(len(cl) - i)
+				break
+			}
 			if c == nil {
 				g.log.LogErrf("BUGON:: pull : nil change found for change index %d\n", i)
 				continue
@@ -475,7 +667,7 @@ func (g *Commands) playPullChanges(cl []*Change, exports []string, opMap *map[Op
 
 			fn := localOpToChangerTranslator(g, c)
 			conformingFn := func(c *Change) error {
-				return fn(c, exports)
+				return fn(c, c.policy.exportsOr(exports))
 			}
 
 			if fn == nil {
@@ -499,13 +691,71 @@ func (g *Commands) playPullChanges(cl []*Change, exports []string, opMap *map[Op
 	for result := range results {
 		res, rErr := result.Value(), result.Err()
 		if rErr != nil {
+			failures += 1
 			msg := fmt.Sprintf("%v err: %v\n", res, rErr)
 			err = reComposeError(err, msg)
 		}
 	}
+	return failures, err
+}
+
+// firstExportURL returns the link to export f to the first of exports
+// that it can be exported to, or "" if there is none.
+func firstExportURL(f *File, exports []string) string {
+	for _, ext := range exports {
+		if exportURL, ok := f.ExportLinks[mimeTypeFromExt(ext)]; ok {
+			return exportURL
+		}
+	}
+	return ""
+}
+
+func (g *Commands) playPullChanges(cl []*Change, exports []string, opMap *map[Operation]sizeCounter) (err error) {
+	if opMap == nil {
+		result := opChangeCount(cl)
+		opMap = &result
+	}
+
+	totalSize := int64(0)
+	ops := *opMap
+
+	for op, counter := range ops {
+		totalSize += counter.sizeByOperation(op)
+	}
+
+	g.transferStart(totalSize)
+
+	defer close(g.rem.progressChan)
+
+	start := time.Now()
+	transferred := int64(0)
+	go func() {
+		for n := range g.rem.progressChan {
+			atomic.AddInt64(&transferred, int64(n))
+			g.taskAdd(int64(n))
+		}
+	}()
+
+	// TODO: Only provide precedence ordering if all the other options are allowed
+	sort.Sort(ByPrecedence(cl))
+
+	transfers, deletions := cl, []*Change(nil)
+	if g.opts.Mirror {
+		transfers, deletions = splitDeletions(cl)
+	}
+
+	n := g.jobs()
+	failures, err := g.runPullJobs(transfers, exports, n)
+	if len(deletions) >= 1 && g.mirrorDeletionsAllowed(PullKey, deletions, failures) {
+		dFailures, dErr := g.runPullJobs(deletions, exports, n)
+		failures += dFailures
+		err = combineErrors(err, dErr)
+	}
 
 	g.taskFinish()
-	return err
+	g.recordRunStat(PullKey, start, atomic.LoadInt64(&transferred), int64(len(cl)), failures)
+	g.flushCacheLookups()
+	return combineErrors(err, g.interruptionErr(PullKey, len(cl)))
 }
 
 func (g *Commands) localAddIndex(change *Change, conform []string) (err error) {
@@ -514,7 +764,7 @@ func (g *Commands) localAddIndex(change *Change, conform []string) (err error) {
 		if f != nil {
 			chunks := chunkInt64(change.Src.Size)
 			for n := range chunks {
-				g.rem.progressChan <- n
+				g.rem.progress(change.Path, n)
 			}
 		}
 	}()
@@ -554,13 +804,19 @@ func (g *Commands) localMod(change *Change, exports []string) (err error) {
 	}
 
 	err = os.Chtimes(destAbsPath, change.Src.ModTime, change.Src.ModTime)
+	if err == nil && downloadPerformed {
+		g.rememberPulled(change)
+	}
+	if err == nil && g.opts.PreservePerms {
+		err = g.restorePosixPerms(destAbsPath, change.Src)
+	}
 
 	// Update progress for the case in which you are only Chtime-ing
 	// since progress for downloaded files is already handled separately
 	if !downloadPerformed {
 		chunks := chunkInt64(change.Src.Size)
 		for n := range chunks {
-			g.rem.progressChan <- n
+			g.rem.progress(change.Path, n)
 		}
 	}
 
@@ -603,7 +859,14 @@ func (g *Commands) localAdd(change *Change, exports []string) (err error) {
 		}
 	}
 
-	return os.Chtimes(destAbsPath, change.Src.ModTime, change.Src.ModTime)
+	if err = os.Chtimes(destAbsPath, change.Src.ModTime, change.Src.ModTime); err != nil {
+		return err
+	}
+	g.rememberPulled(change)
+	if g.opts.PreservePerms {
+		return g.restorePosixPerms(destAbsPath, change.Src)
+	}
+	return nil
 }
 
 func (g *Commands) localDelete(change *Change, conform []string) (err error) {
@@ -611,7 +874,7 @@ func (g *Commands) localDelete(change *Change, conform []string) (err error) {
 		if err == nil {
 			chunks := chunkInt64(change.Dest.Size)
 			for n := range chunks {
-				g.rem.progressChan <- n
+				g.rem.progress(change.Path, n)
 			}
 
 			dest := change.Dest
@@ -716,7 +979,7 @@ func (g *Commands) export(f *File, destAbsPath string, exports []string) (manife
 				exportURL:       urlMExt.url,
 			}
 
-			err = g.singleDownload(&dlArg)
+			err = g.downloadAtomically(&dlArg)
 			if err == nil {
 				manifest = append(manifest, exportPath)
 			}
@@ -754,9 +1017,13 @@ func (g *Commands) download(change *Change, exports []string) error {
 			path:            destAbsPath,
 			id:              change.Src.Id,
 			ackByteProgress: true,
+			size:            change.Src.Size,
+			chunks:          g.opts.ChunkConcurrency,
+			gunzip:          change.Src.compressed,
+			relToRootPath:   change.Path,
+			md5:             change.Src.Md5Checksum,
 		}
-
-		return g.singleDownload(&dlArg)
+		return g.downloadAtomically(&dlArg)
 	}
 
 	// We need to touch the empty file to
@@ -809,8 +1076,26 @@ func (g *Commands) download(change *Change, exports []string) error {
 }
 
 func (g *Commands) singleDownload(dlArg *downloadArg) (err error) {
+	resumable := g.resumable(dlArg)
+	offset := int64(0)
+	if resumable {
+		offset = resumeOffset(dlArg)
+	}
+	if offset < 1 && g.multiRange(dlArg) {
+		return g.multiRangeDownload(dlArg)
+	}
+
 	var fo *os.File
-	fo, err = os.Create(dlArg.path)
+	if offset >= 1 {
+		fo, err = os.OpenFile(dlArg.path, os.O_WRONLY, 0)
+		if err == nil {
+			if err = fo.Truncate(offset); err == nil {
+				_, err = fo.Seek(offset, io.SeekStart)
+			}
+		}
+	} else {
+		fo, err = os.Create(dlArg.path)
+	}
 	if err != nil {
 		g.log.LogErrf("create: %s %v\n", dlArg.path, err)
 		return
@@ -825,6 +1110,33 @@ func (g *Commands) singleDownload(dlArg *downloadArg) (err error) {
 		}
 	}()
 
+	written := int64(0)
+	if resumable {
+		sc := &partialSidecar{Id: dlArg.id, Md5: dlArg.md5, Size: dlArg.size, Offset: offset}
+		if sErr := sc.save(dlArg.path); sErr != nil {
+			g.DebugPrintf("singleDownload: %s sidecar: %v\n", dlArg.path, sErr)
+		}
+		// Record how far the download got should it fail midway.
+		defer func() {
+			if err != nil {
+				sc.Offset = offset + written
+				sc.save(dlArg.path)
+			}
+		}()
+	}
+
+	if offset >= 1 {
+		g.log.Logf("Resuming %s from %s of %s\n", dlArg.relToRootPath, prettyBytes(offset), prettyBytes(dlArg.size))
+		if dlArg.ackByteProgress {
+			for n := range chunkInt64(offset) {
+				g.rem.progress(dlArg.relToRootPath, n)
+			}
+		}
+		if offset >= dlArg.size {
+			return nil
+		}
+	}
+
 	var blob io.ReadCloser
 	defer func() {
 		if blob != nil {
@@ -832,18 +1144,32 @@ func (g *Commands) singleDownload(dlArg *downloadArg) (err error) {
 		}
 	}()
 
-	blob, err = g.rem.Download(dlArg.id, dlArg.exportURL)
+	if offset >= 1 {
+		blob, err = g.rem.DownloadRange(dlArg.id, &byteRange{start: offset, end: dlArg.size - 1})
+	} else {
+		blob, err = g.rem.Download(dlArg.id, dlArg.exportURL)
+	}
 	if err != nil {
 		return err
 	}
 
+	var content io.Reader = blob
+	if dlArg.gunzip {
+		zr, zErr := gzip.NewReader(blob)
+		if zErr != nil {
+			return zErr
+		}
+		defer zr.Close()
+		content = zr
+	}
+
 	ws := statos.NewWriter(fo)
 
 	go func() {
 		commChan := ws.ProgressChan()
 		if dlArg.ackByteProgress {
 			for n := range commChan {
-				g.rem.progressChan <- n
+				g.rem.progress(dlArg.relToRootPath, n)
 			}
 		} else { // Just drain the progress channel
 			for _ = range commChan {
@@ -852,7 +1178,9 @@ func (g *Commands) singleDownload(dlArg *downloadArg) (err error) {
 		}
 	}()
 
-	_, err = io.Copy(ws, blob)
+	if written, err = io.Copy(ws, content); err == nil {
+		err = fo.Sync()
+	}
 
 	return
 }
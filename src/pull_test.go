@@ -0,0 +1,40 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+)
+
+func TestFirstExportURL(t *testing.T) {
+	f := &File{ExportLinks: map[string]string{
+		mimeTypeFromExt("pdf"): "https://docs.google.com/export?format=pdf",
+		mimeTypeFromExt("txt"): "https://docs.google.com/export?format=txt",
+	}}
+	tests := []struct {
+		exports []string
+		want    string
+	}{
+		{[]string{"txt", "pdf"}, "https://docs.google.com/export?format=txt"},
+		{[]string{"odt", "pdf"}, "https://docs.google.com/export?format=pdf"},
+		{[]string{"odt"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := firstExportURL(f, tt.exports); got != tt.want {
+			t.Errorf("%v: got %q want %q", tt.exports, got, tt.want)
+		}
+	}
+}
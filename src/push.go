@@ -17,12 +17,12 @@ package drive
 import (
 	"fmt"
 	"os"
-	"os/signal"
 	gopath "path"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/odeke-em/drive/config"
@@ -39,6 +39,7 @@ func (g *Commands) Push() error {
 	g.rem.decrypter = g.opts.Decrypter
 
 	defer g.clearMountPoints()
+	defer g.flushChecksums()
 
 	var cl []*Change
 
@@ -48,14 +49,10 @@ func (g *Commands) Push() error {
 	spin.play()
 
 	// To Ensure mount points are cleared in the event of external exceptions
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, os.Kill)
-	go func() {
-		_ = <-c
+	defer g.handleInterrupts(func() {
 		spin.stop()
 		g.clearMountPoints()
-		os.Exit(1)
-	}()
+	})()
 
 	clashes := []*Change{}
 
@@ -65,6 +62,10 @@ func (g *Commands) Push() error {
 	if err != nil {
 		return err
 	}
+	if err := g.expandGlobSources(true); err != nil {
+		spin.stop()
+		return err
+	}
 	for _, relToRootPath := range g.opts.Sources {
 		fsAbsPath := g.context.AbsPathOf(relToRootPath)
 		// Join this relative path to that of the remote relative path of the destination.
@@ -81,6 +82,14 @@ func (g *Commands) Push() error {
 		}
 	}
 
+	bcl, bclashes, bErr := g.pushBatchResolve()
+	clashes = append(clashes, bclashes...)
+	if bErr != nil {
+		spin.stop()
+		return bErr
+	}
+	cl = append(cl, bcl...)
+
 	mount := g.opts.Mount
 	if mount != nil {
 		for _, mt := range mount.Points {
@@ -108,6 +117,25 @@ func (g *Commands) Push() error {
 
 	spin.stop()
 
+	var skippedDups []*skippedDuplicate
+	if g.opts.SkipDuplicates {
+		if cl, skippedDups, err = g.skipLocalDuplicates(cl); err != nil {
+			return err
+		}
+		if len(skippedDups) >= 1 {
+			g.log.Logf("%d duplicates will be pushed as shortcuts\n", len(skippedDups))
+		}
+	}
+
+	if g.opts.Links != LinksFollow {
+		var linked []*skippedDuplicate
+		cl, linked, _ = g.linkChanges(cl, true)
+		if len(linked) >= 1 {
+			g.log.Logf("%d symlinks will be pushed as shortcuts\n", len(linked))
+		}
+		skippedDups = append(skippedDups, linked...)
+	}
+
 	nonConflictsPtr, conflictsPtr := g.resolveConflicts(cl, true)
 	if conflictsPtr != nil {
 		warnConflictsPersist(g.log, *conflictsPtr)
@@ -115,6 +143,9 @@ func (g *Commands) Push() error {
 	}
 
 	nonConflicts := *nonConflictsPtr
+	if g.opts.DryRun {
+		return g.dryRun(nonConflicts, true)
+	}
 
 	pushSize, modSize := reduceToSize(cl, SelectDest|SelectSrc)
 
@@ -157,11 +188,20 @@ func (g *Commands) Push() error {
 	}
 
 	status, opMap := printChangeList(&clArg)
+	if notApplicable(status) {
+		return g.pushDuplicateShortcuts(skippedDups)
+	}
 	if !accepted(status) {
 		return status.Error()
 	}
+	if err := g.confirmMirrorDeletions(PushKey, nonConflicts); err != nil {
+		return err
+	}
 
-	return g.playPushChanges(nonConflicts, opMap)
+	if err := g.playPushChanges(nonConflicts, opMap); err != nil {
+		return err
+	}
+	return g.pushDuplicateShortcuts(skippedDups)
 }
 
 func (g *Commands) PushPiped() error {
@@ -216,9 +256,10 @@ func (g *Commands) PushPiped() error {
 			nonStatable:     true,
 			ignoreChecksum:  g.opts.IgnoreChecksum,
 			retryCount:      g.opts.ExponentialBackoffRetryCount,
+			uploadRateLimit: g.opts.UploadRateLimit,
 		}
 
-		rem, _, rErr := g.rem.upsertByComparison(os.Stdin, args)
+		rem, rErr := g.rem.streamingUpsert(os.Stdin, args)
 		if rErr != nil {
 			g.log.LogErrf("%s: %v\n", relToRootPath, rErr)
 			return rErr
@@ -256,29 +297,86 @@ func (g *Commands) playPushChanges(cl []*Change, opMap *map[Operation]sizeCounte
 
 	totalSize := int64(0)
 	ops := *opMap
+	if counter, ok := ops[OpDelete]; ok && counter.count >= 1 {
+		if _, err := g.permanentDeletion(PushKey); err != nil {
+			return err
+		}
+	}
+
 	for op, counter := range ops {
 		totalSize += counter.sizeByOperation(op)
 	}
 
-	g.taskStart(totalSize)
+	g.transferStart(totalSize)
+	g.pushManifest = g.newPushManifest()
 
 	defer close(g.rem.progressChan)
 
+	start := time.Now()
+	transferred := int64(0)
 	go func() {
 		for n := range g.rem.progressChan {
+			atomic.AddInt64(&transferred, int64(n))
 			g.taskAdd(int64(n))
 		}
 	}()
 
-	type workPair struct {
-		fn  func(*Change) error
-		arg *Change
+	smallFileSize, err := g.smallFileSize()
+	if err != nil {
+		return err
 	}
-
-	n := maxProcs()
+	smallN, largeN := g.smallFileJobs(), g.jobs()
 
 	sort.Sort(ByPrecedence(cl))
 
+	transfers, deletions := cl, []*Change(nil)
+	if g.opts.Mirror {
+		transfers, deletions = splitDeletions(cl)
+	}
+
+	failures := int64(0)
+	small, large := partitionBySize(g.prepareRemoteDirs(transfers, smallN), smallFileSize)
+
+	// Small files are dominated by per request latency rather than
+	// bandwidth so they are pushed with their own, higher, parallelism.
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	collect := func(res interface{}, resErr error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures += 1
+		err = reComposeError(err, fmt.Sprintf("push: %s err: %v\n", res, resErr))
+	}
+	for _, batch := range []struct {
+		cl []*Change
+		n  int
+	}{{cl: small, n: smallN}, {cl: large, n: largeN}} {
+		if len(batch.cl) < 1 {
+			continue
+		}
+		wg.Add(1)
+		go func(cl []*Change, n int) {
+			defer wg.Done()
+			g.runPushJobs(cl, n, collect)
+		}(batch.cl, batch.n)
+	}
+	wg.Wait()
+
+	if len(deletions) >= 1 && g.mirrorDeletionsAllowed(PushKey, deletions, failures) {
+		g.runPushJobs(deletions, largeN, collect)
+	}
+
+	g.taskFinish()
+	g.recordRunStat(PushKey, start, atomic.LoadInt64(&transferred), int64(len(cl)), failures)
+	if mErr := g.writePushManifest(); mErr != nil {
+		err = reComposeError(err, fmt.Sprintf("push: manifest err: %v\n", mErr))
+	}
+	g.flushCacheLookups()
+	return combineErrors(err, g.interruptionErr(PushKey, len(cl)))
+}
+
+// runPushJobs plays cl with n jobs at a time, invoking onErr for each failure.
+func (g *Commands) runPushJobs(cl []*Change, n int, onErr func(interface{}, error)) {
 	jobsChan := make(chan semalim.Job)
 
 	go func() {
@@ -286,6 +384,10 @@ func (g *Commands) playPushChanges(cl []*Change, opMap *map[Operation]sizeCounte
 		throttle := time.Tick(time.Duration(1e9 / n))
 
 		for i, c := range cl {
+			if g.interrupted() {
+				g.skipUnstarted(len(cl) - i)
+				break
+			}
 			if c == nil {
 				g.log.LogErrf("BUGON:: push: nil change found for change index %d\n", i)
 				continue
@@ -312,14 +414,168 @@ func (g *Commands) playPushChanges(cl []*Change, opMap *map[Operation]sizeCounte
 
 	results := semalim.Run(jobsChan, uint64(n))
 	for result := range results {
-		res, resErr := result.Value(), result.Err()
-		if resErr != nil {
-			err = reComposeError(err, fmt.Sprintf("push: %s err: %v\n", res, resErr))
+		if resErr := result.Err(); resErr != nil {
+			onErr(result.Value(), resErr)
 		}
 	}
+}
 
-	g.taskFinish()
-	return err
+func (g *Commands) smallFileJobs() int {
+	if g.opts.SmallFileJobs >= 1 {
+		return g.opts.SmallFileJobs
+	}
+	return DefaultSmallFileJobsFactor * g.jobs()
+}
+
+func (g *Commands) smallFileSize() (int64, error) {
+	size := DefaultSmallFileSize
+	if g.opts.Meta != nil {
+		if sizeL := (*g.opts.Meta)[CLIOptionSmallFileSize]; len(sizeL) >= 1 && sizeL[0] != "" {
+			size = sizeL[0]
+		}
+	}
+
+	n, err := parseByteSize(size)
+	if err != nil {
+		return 0, invalidArgumentsErr(fmt.Errorf("--%s: %v", CLIOptionSmallFileSize, err))
+	}
+	return n, nil
+}
+
+// partitionBySize separates the changes whose transfers are at most
+// threshold bytes from the larger ones, retaining their order.
+func partitionBySize(cl []*Change, threshold int64) (small, large []*Change) {
+	for _, c := range cl {
+		if c != nil && c.Src != nil && !c.Src.IsDir && c.Src.Size > threshold {
+			large = append(large, c)
+		} else {
+			small = append(small, c)
+		}
+	}
+	return small, large
+}
+
+// prepareRemoteDirs seeds the mkdirAll cache with the folders already
+// resolved remotely and creates the new folders a level at a time, n
+// siblings at once, so that uploads don't each look up their parents.
+// It returns the changes left to play, including any folders that
+// couldn't be created so that they are retried the usual way, or that
+// weren't since the push was interrupted.
+func (g *Commands) prepareRemoteDirs(cl []*Change, n int) (remaining []*Change) {
+	levels := map[int][]*Change{}
+	maxLevel := -1
+
+	mkdirAllMu.Lock()
+	for _, c := range cl {
+		if c == nil {
+			remaining = append(remaining, c)
+			continue
+		}
+
+		if c.Dest != nil && c.Dest.IsDir && c.Dest.Id != "" {
+			g.mkdirAllCache.Put(c.Path, newExpirableCacheValue(c.Dest))
+		} else if c.Dest != nil && len(c.Dest.Parents) == 1 && c.Dest.Parents[0] != nil {
+			parentPath := g.parentPather(c.Path)
+			if _, ok := g.mkdirAllCache.Get(parentPath); !ok {
+				parent := &File{Id: c.Dest.Parents[0].Id, IsDir: true}
+				g.mkdirAllCache.Put(parentPath, newExpirableCacheValue(parent))
+			}
+		}
+
+		if c.Op() != OpAdd || c.Src == nil || !c.Src.IsDir || c.Dest != nil {
+			remaining = append(remaining, c)
+			continue
+		}
+
+		level := strings.Count(strings.Trim(c.Path, "/"), "/")
+		levels[level] = append(levels[level], c)
+		if level > maxLevel {
+			maxLevel = level
+		}
+	}
+	mkdirAllMu.Unlock()
+
+	for level := 0; level <= maxLevel; level++ {
+		dirs := levels[level]
+		if len(dirs) < 1 {
+			continue
+		}
+		if g.interrupted() {
+			// Left for the push to skip along with the rest.
+			remaining = append(remaining, dirs...)
+			continue
+		}
+
+		jobsChan := make(chan semalim.Job)
+		go func() {
+			defer close(jobsChan)
+			for i, c := range dirs {
+				c := c
+				jobsChan <- jobSt{id: uint64(i), do: func() (interface{}, error) {
+					return c, g.remoteMkdirFromCache(c)
+				}}
+			}
+		}()
+
+		for result := range semalim.Run(jobsChan, uint64(n)) {
+			if result.Err() == nil {
+				continue
+			}
+			c := result.Value().(*Change)
+			g.log.LogErrf("push: mkdir %s: %v\n", c.Path, result.Err())
+			remaining = append(remaining, c)
+		}
+	}
+
+	return remaining
+}
+
+// remoteMkdirFromCache creates the folder of an added change under its
+// parent which must already be cached, falling back to remoteMkdirAll.
+func (g *Commands) remoteMkdirFromCache(change *Change) error {
+	parentPath := g.parentPather(change.Path)
+
+	mkdirAllMu.Lock()
+	cachedValue, ok := g.mkdirAllCache.Get(parentPath)
+	mkdirAllMu.Unlock()
+
+	var parent *File
+	if ok && cachedValue != nil {
+		parent, _ = cachedValue.Value().(*File)
+	}
+	if parent == nil {
+		var err error
+		if parent, err = g.remoteMkdirAll(parentPath); err != nil {
+			return err
+		}
+		if parent == nil {
+			return errCannotMkdirAll(parentPath)
+		}
+	}
+
+	args := &upsertOpt{
+		parentId:   parent.Id,
+		src:        change.Src,
+		mask:       change.policy.typeMask(g.opts.TypeMask),
+		debug:      g.opts.Verbose && g.opts.canPreview(),
+		retryCount: g.opts.ExponentialBackoffRetryCount,
+	}
+
+	cur, err := g.rem.UpsertByComparison(args)
+	if err != nil {
+		return err
+	}
+	if cur == nil {
+		return errCannotMkdirAll(change.Path)
+	}
+	change.Src.Id = cur.Id
+
+	mkdirAllMu.Lock()
+	g.mkdirAllCache.Put(change.Path, newExpirableCacheValue(cur))
+	mkdirAllMu.Unlock()
+
+	g.recordPushed(change, change.Src, cur)
+	return nil
 }
 
 func (g *Commands) pathSplitter(absPath string) (dir, base string) {
@@ -378,12 +634,36 @@ func (g *Commands) remoteMod(change *Change) (err error) {
 		uploadRateLimit: g.opts.UploadRateLimit,
 		parentId:        parent.Id,
 		fsAbsPath:       absPath,
+		relToRootPath:   change.Path,
 		src:             change.Src,
 		dest:            change.Dest,
-		mask:            g.opts.TypeMask,
+		mask:            change.policy.typeMask(g.opts.TypeMask),
 		ignoreChecksum:  g.opts.IgnoreChecksum,
 		debug:           g.opts.Verbose && g.opts.canPreview(),
 		retryCount:      g.opts.ExponentialBackoffRetryCount,
+		sessions:        g.context,
+	}
+
+	if g.opts.PreservePerms && change.Src != nil {
+		if info, sErr := os.Stat(absPath); sErr == nil {
+			args.properties = posixProperties(info, g.opts.PreserveOwner)
+		}
+	}
+
+	var compression map[string]string
+	if g.opts.Compress && change.Src != nil && !change.Src.IsDir && compressible(change.Src.Name) {
+		args.compress = true
+		compression = compressionProperties(change.Src)
+	} else if change.Dest != nil && change.Dest.compressed {
+		// Properties are only ever added to, so the file is marked as
+		// no longer compressed rather than having them removed.
+		compression = map[string]string{CompressionProperty: compressionNone}
+	}
+	if len(compression) >= 1 && args.properties == nil {
+		args.properties = make(map[string]string)
+	}
+	for key, value := range compression {
+		args.properties[key] = value
 	}
 
 	coercedMimeKey, ok := g.coercedMimeKey()
@@ -393,14 +673,44 @@ func (g *Commands) remoteMod(change *Change) (err error) {
 		args.mimeKey = filepath.Ext(args.src.Name)
 	}
 
-	rem, err := g.rem.UpsertByComparison(args)
-	if err != nil {
-		g.log.LogErrf("%s: %v\n", change.Path, err)
-		return
+	// Creations are journaled for a push interrupted midway to not
+	// create the same file again once it is retried.
+	creating := change.Dest == nil && args.src != nil && !args.src.IsDir && args.src.Id == ""
+	var rem *File
+	if creating {
+		rem = g.uploadedBefore(change.Path, parent.Id, args.src)
+	}
+	if rem == nil {
+		if creating {
+			g.journalUploadIntent(change.Path, parent.Id)
+		}
+		rem, err = g.rem.UpsertByComparison(args)
+		if err != nil {
+			g.log.LogErrf("%s: %v\n", change.Path, err)
+			return
+		}
+	}
+	if creating {
+		g.clearUploadIntent(change.Path)
 	}
 	if rem == nil {
 		return
 	}
+	g.recordPushed(change, args.src, rem)
+	return
+}
+
+// recordPushed keeps track of change having been pushed as rem, in the
+// undo history if it was created or its content replaced, the push
+// manifest, the local checksums and the index.
+func (g *Commands) recordPushed(change *Change, src, rem *File) {
+	if change.Dest == nil {
+		g.recordHistory(&config.HistoryEntry{Op: HistoryOpPushAdd, FileId: rem.Id, Path: change.Path})
+	} else if oldRev := headRevisionId(change.Dest); oldRev != "" && oldRev != headRevisionId(rem) {
+		g.recordHistory(&config.HistoryEntry{Op: HistoryOpPushMod, FileId: rem.Id, Path: change.Path, OldRevisionId: oldRev})
+	}
+	g.pushManifest.record(change.Path, rem)
+	g.rememberPushed(src, rem)
 	index := rem.ToIndex()
 	wErr := g.context.SerializeIndex(index)
 
@@ -408,7 +718,6 @@ func (g *Commands) remoteMod(change *Change) (err error) {
 	if wErr != nil {
 		g.log.LogErrf("serializeIndex %s: %v\n", rem.Name, wErr)
 	}
-	return
 }
 
 func (g *Commands) remoteAdd(change *Change) error {
@@ -416,6 +725,12 @@ func (g *Commands) remoteAdd(change *Change) error {
 }
 
 func (g *Commands) remoteUntrash(change *Change) error {
+	return g.remoteUntrashTo(change, nil)
+}
+
+// remoteUntrashTo untrashes change.Src and, if to is set, moves it out of
+// its original parents, which may themselves still be in the trash, into to.
+func (g *Commands) remoteUntrashTo(change *Change, to *File) error {
 	target := change.Src
 	defer func() {
 		g.taskAdd(target.Size)
@@ -424,6 +739,13 @@ func (g *Commands) remoteUntrash(change *Change) error {
 	if err := g.rem.Untrash(target.Id); err != nil {
 		return err
 	}
+	g.recordHistory(&config.HistoryEntry{Op: HistoryOpUntrash, FileId: target.Id, Path: change.Path})
+
+	if to != nil {
+		if err := g.reparent(target, to, change.Path); err != nil {
+			return err
+		}
+	}
 
 	index := target.ToIndex()
 	wErr := g.context.SerializeIndex(index)
@@ -435,6 +757,36 @@ func (g *Commands) remoteUntrash(change *Change) error {
 	return nil
 }
 
+func (g *Commands) reparent(target, to *File, p string) error {
+	for _, parent := range target.Parents {
+		if parent != nil && parent.Id == to.Id {
+			return nil
+		}
+	}
+
+	entry := &config.HistoryEntry{
+		Op:     HistoryOpMove,
+		FileId: target.Id, Path: p,
+		NewParentId: to.Id,
+	}
+	var oldParentIds []string
+	for _, parent := range target.Parents {
+		if parent == nil {
+			continue
+		}
+		oldParentIds = append(oldParentIds, parent.Id)
+		// Undoing a move only restores a single parent.
+		if entry.OldParentId == "" {
+			entry.OldParentId = parent.Id
+		}
+	}
+	if err := g.rem.reparent(target.Id, []string{to.Id}, oldParentIds); err != nil {
+		return err
+	}
+	g.recordHistory(entry)
+	return nil
+}
+
 func remoteRemover(g *Commands, change *Change, fn func(string) error) error {
 	defer func() {
 		g.taskAdd(change.Dest.Size)
@@ -461,7 +813,11 @@ func remoteRemover(g *Commands, change *Change, fn func(string) error) error {
 }
 
 func (g *Commands) remoteTrash(change *Change) error {
-	return remoteRemover(g, change, g.rem.Trash)
+	err := remoteRemover(g, change, g.rem.Trash)
+	if err == nil {
+		g.recordHistory(&config.HistoryEntry{Op: HistoryOpTrash, FileId: change.Dest.Id, Path: change.Path})
+	}
+	return err
 }
 
 func (g *Commands) remoteDelete(change *Change) error {
@@ -0,0 +1,41 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+
+	expirableCache "github.com/odeke-em/cache"
+)
+
+func TestPrepareRemoteDirsInterrupted(t *testing.T) {
+	g := &Commands{opts: &Options{}, mkdirAllCache: expirableCache.New(), interrupts: 1}
+	cl := []*Change{
+		{Path: "/a", Src: &File{Name: "a", IsDir: true}},
+		{Path: "/a/b", Src: &File{Name: "b", IsDir: true}},
+		{Path: "/a/c.txt", Src: &File{Name: "c.txt"}},
+	}
+
+	// No folder is created once interrupted, g has no remote to create them with.
+	remaining := g.prepareRemoteDirs(cl, 2)
+	if len(remaining) != len(cl) {
+		t.Fatalf("expected all %d changes to be left to the push to skip, got %d", len(cl), len(remaining))
+	}
+	for _, c := range cl {
+		if c.Src.Id != "" {
+			t.Errorf("%s: expected no folder to be created, got id %q", c.Path, c.Src.Id)
+		}
+	}
+}
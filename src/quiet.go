@@ -0,0 +1,83 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// QuietLevel is how little is logged, from everything to nothing at all.
+type QuietLevel int
+
+const (
+	QuietNone QuietLevel = iota
+	// QuietSummary logs only errors and the summary at the end of a run.
+	QuietSummary
+	// QuietErrors logs only errors, as a bare --quiet always has.
+	QuietErrors
+	// QuietSilent logs nothing at all, leaving only the exit status.
+	QuietSilent
+)
+
+var quietLevelNames = map[QuietLevel]string{
+	QuietNone:    "false",
+	QuietSummary: "summary",
+	QuietErrors:  "errors",
+	QuietSilent:  "silent",
+}
+
+func (ql QuietLevel) String() string {
+	return quietLevelNames[ql]
+}
+
+// ParseQuietLevel parses the value of --quiet, where true
+// is the same as errors and false the same as no value.
+func ParseQuietLevel(s string) (QuietLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "false":
+		return QuietNone, nil
+	case "true", "errors":
+		return QuietErrors, nil
+	case "summary":
+		return QuietSummary, nil
+	case "silent":
+		return QuietSilent, nil
+	}
+	return QuietNone, fmt.Errorf("unknown quiet level %q, expecting one of errors, summary or silent", s)
+}
+
+// canSpin reports whether the spinner can be shown. It is never shown
+// when stdout isn't a terminal, since its bytes would otherwise end
+// up in the logs of cron jobs and CI systems, nor if DRIVE_NO_SPINNER is set.
+// Neither is it shown amidst newline-delimited JSON.
+func (opts *Options) canSpin() bool {
+	if opts == nil || !opts.StdoutIsTty || opts.Quiet || ndjsonOutput(opts.TypeMask) {
+		return false
+	}
+	return os.Getenv(DriveNoSpinnerEnvKey) == ""
+}
+
+// logRunSummary logs a line summing up a transfer, which
+// unlike the rest of the output is still logged at QuietSummary.
+func (g *Commands) logRunSummary(rs *runSummary) {
+	elapsed := time.Duration(rs.Elapsed * float64(time.Second))
+	elapsed -= elapsed % time.Millisecond
+	g.summary.Logf("%s: %d file(s), %s in %v, %d failure(s), %d skipped, %s/s, %d API call(s)\n",
+		rs.Command, rs.Transferred, prettyBytes(rs.Bytes), elapsed, rs.Failed, rs.Skipped,
+		prettyBytes(rs.Throughput), rs.APICalls)
+}
@@ -45,6 +45,10 @@ func kvifyCommentedFile(p, comment string) (map[string]map[string]string, error)
 		return nil, err
 	}
 
+	return kvifyClauses(clauses)
+}
+
+func kvifyClauses(clauses []string) (map[string]map[string]string, error) {
 	linesChan := make(chan string)
 	go func() {
 		defer close(linesChan)
@@ -128,14 +132,31 @@ func parseRCValues(rcMap map[string]string) (valueMappings map[string]interface{
 				CLIOptionIgnoreNameClashes, CLIOptionIgnoreChecksum, CLIOptionFixClashesKey,
 				CLIOptionDesktopLinks, CLIOptionExportsDumpToSameDirectory, CLIOptionTrashed,
 				CLIOptionStarred, CLIOptionPiped, CLIOptionExplicitlyExport,
-				CLIOptionDirectories, CLIOptionAllStarred,
+				CLIOptionDirectories, CLIOptionAllStarred, CLIOptionReadOnly,
+				CLIOptionJSON, CLIOptionNDJSON, CLIOptionPager, CLIOptionDepthFirst,
+				CLIOptionDirsFirst, CLIOptionFilesFirst, CLIOptionReportEmpty,
+				CLIOptionOwnerEmails, CLIOptionCapabilities, CLIOptionMedia, CLIOptionPerms,
+				CLIOptionAsArchive, CLIOptionCSV, CLIOptionTree, CLIOptionRegex,
+				CLIOptionColor, CLIOptionWithLabels, CLIOptionIncremental, CLIOptionExportIfChanged,
+				CLIOptionSkipDuplicates, CLIOptionLowMemory,
+				CLIOptionPreservePerms, CLIOptionPreserveOwner, CLIOptionAllDrives,
+				CLIOptionCompress, CLIOptionExtract, CLIOptionMirror,
 			},
 		},
 		{
 			resolver: _intfer, keys: []string{
 				PageSizeKey,
+				CLIOptionMaxResults,
+				CLIOptionMaxItems,
+				CLIOptionMaxPages,
 				DepthKey,
 				CLIOptionRetryCount,
+				CLIOptionSmallFileJobs,
+				CLIOptionDownloadChunks,
+				CLIOptionChunkConcurrency,
+				CLIOptionTransfers,
+				CLIOptionMaxDeletions,
+				CLIOptionQPS,
 			},
 		},
 		{
@@ -145,7 +166,17 @@ func parseRCValues(rcMap map[string]string) (valueMappings map[string]interface{
 				CLIEncryptionPassword, CLIDecryptionPassword, SortKey,
 				CLIOptionNotOwner, ExportsDirKey, CLIOptionExactTitle, AddressKey,
 				CLIOptionPushDestination, CLIOptionSkipMime, CLIOptionMatchMime,
-				ExportsKey,
+				ExportsKey, StatsLastKey, CLIOptionPolicyIgnore, FieldsKey,
+				SharedFromKey, SharedAfterKey, SharedBeforeKey,
+				WatchOnKey, WatchWebhookKey, WatchRulesKey, WatchIntervalKey,
+				WatchDigestKey, WatchDigestWebhookKey, WatchDigestEmailKey, WatchSMTPKey,
+				CLIOptionSmallFileSize, CLIOptionArchiveFormat, DeletionModeKey,
+				CLIOptionCSVColumns, CLIOptionFormat,
+				CLIOptionNewerThan, CLIOptionOlderThan, CLIOptionManifest,
+				CLIOptionMinSize, CLIOptionMaxSize, CLIOptionColorPalette,
+				CLIOptionBandwidthLimit, CLIOptionInclude, CLIOptionConflict, CLIOptionChangedSince,
+				CLIOptionLinks, CLIOptionDuplicateTitles, LabelKey,
+				CLIOptionRetryMaxWait, CLIOptionRetryOn, CLIOptionStatsJSON,
 			},
 		},
 		{
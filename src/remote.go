@@ -21,7 +21,9 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/context"
@@ -33,8 +35,8 @@ import (
 	"github.com/odeke-em/drive/config"
 	"github.com/odeke-em/statos"
 
-	expb "github.com/odeke-em/exponential-backoff"
 	drive "google.golang.org/api/drive/v2"
+	drivelabels "google.golang.org/api/drivelabels/v2"
 	"google.golang.org/api/googleapi"
 )
 
@@ -45,6 +47,9 @@ const (
 	// OAuth 2.0 full Drive scope used for authorization.
 	DriveScope = "https://www.googleapis.com/auth/drive"
 
+	// OAuth 2.0 scope for naming the Drive Labels applied to files.
+	DriveLabelsScope = "https://www.googleapis.com/auth/drive.labels.readonly"
+
 	// OAuth 2.0 access type for offline/refresh access.
 	AccessType = "offline"
 
@@ -76,6 +81,8 @@ var (
 	ErrGoogleAPIInvalidQueryHardCoded = invalidGoogleAPIQueryErr(fmt.Errorf("GoogleAPI: Error 400: Invalid query, invalid"))
 
 	errNilParent = nonExistantRemoteErr(fmt.Errorf("remote parent doesn't exist"))
+
+	ErrPermanentDeletionRefused = permanentDeletionRefusedErr(fmt.Errorf("permanent deletion requires `-%s`", CLIOptionPermanent))
 )
 
 var (
@@ -93,6 +100,26 @@ type Remote struct {
 	encrypter    func(io.Reader) (io.Reader, error)
 	decrypter    func(io.Reader) (io.ReadCloser, error)
 	progressChan chan int
+	// permanentDeletion must be set for any unrecoverable deletion to go
+	// through, so that no code path can delete instead of trashing by accident.
+	permanentDeletion bool
+	// pageSize if set is the number of results requested per page of queries.
+	pageSize int64
+	pacer    *pacer
+	// retry is how failed requests are retried, from --retries et al.
+	retry *retryPolicy
+	// transfers if set is shown the progress of each file.
+	transfers *transferProgress
+
+	// uploadThrottle and downloadThrottle if set hold transfers to --bwlimit.
+	uploadThrottle   *throttle
+	downloadThrottle *throttle
+
+	// labelsService names the Drive Labels applied to files, labelSchemas
+	// caching what it was asked for.
+	labelsService *drivelabels.Service
+	labelsMu      sync.Mutex
+	labelSchemas  map[string]*drivelabels.GoogleAppsDriveLabelsV2Label
 }
 
 // NewRemoteContextFromServiceAccount returns a remote initialized
@@ -103,26 +130,38 @@ type Remote struct {
 //
 // You'll also need to configure access to Google Drive.
 func NewRemoteContextFromServiceAccount(jwtConfig *jwt.Config) (*Remote, error) {
-	client := jwtConfig.Client(context.Background())
-	return remoteFromClient(client)
+	return remoteFromServiceAccount(jwtConfig, nil)
+}
+
+func remoteFromServiceAccount(jwtConfig *jwt.Config, context *config.Context) (*Remote, error) {
+	p, rp := newPacer(), newRetryPolicy()
+	client := jwtConfig.Client(transportContext(p, rp, context))
+	return remoteFromClient(client, p, rp)
 }
 
 func NewRemoteContext(context *config.Context) (*Remote, error) {
-	client := newOAuthClient(context)
-	return remoteFromClient(client)
+	p, rp := newPacer(), newRetryPolicy()
+	client := newOAuthClient(context, p, rp)
+	return remoteFromClient(client, p, rp)
 }
 
-func remoteFromClient(client *http.Client) (*Remote, error) {
+func remoteFromClient(client *http.Client, p *pacer, rp *retryPolicy) (*Remote, error) {
 	service, err := drive.New(client)
 	if err != nil {
 		return nil, err
 	}
 
+	// Labels are still listed by id if they can't be named.
+	labelsService, _ := drivelabels.New(client)
+
 	progressChan := make(chan int)
 	rem := &Remote{
-		progressChan: progressChan,
-		service:      service,
-		client:       client,
+		progressChan:  progressChan,
+		service:       service,
+		client:        client,
+		pacer:         p,
+		retry:         rp,
+		labelsService: labelsService,
 	}
 	return rem, nil
 }
@@ -165,15 +204,71 @@ func (r *Remote) changes(startChangeId int64) (chan *drive.Change, error) {
 	return changeChan, nil
 }
 
+// changesSince returns all the changes from startChangeId onwards
+// along with the largest change id known to the server.
+func (r *Remote) changesSince(startChangeId int64) (changes []*drive.Change, largestChangeId int64, err error) {
+	req := r.service.Changes.List().StartChangeId(startChangeId).IncludeDeleted(true)
+
+	pageToken := ""
+	for {
+		if pageToken != "" {
+			req = req.PageToken(pageToken)
+		}
+		res, err := req.Do()
+		if err != nil {
+			return changes, largestChangeId, err
+		}
+		changes = append(changes, res.Items...)
+		largestChangeId = res.LargestChangeId
+		pageToken = res.NextPageToken
+		if pageToken == "" {
+			return changes, largestChangeId, nil
+		}
+	}
+}
+
+func (r *Remote) changesStartPageToken() (string, error) {
+	res, err := r.service.Changes.GetStartPageToken().Do()
+	if err != nil {
+		return "", err
+	}
+	return res.StartPageToken, nil
+}
+
+// changesSincePageToken returns all the changes from pageToken onwards
+// along with the token to carry on from the next time around.
+func (r *Remote) changesSincePageToken(pageToken string) (changes []*drive.Change, newStartPageToken string, err error) {
+	for pageToken != "" {
+		res, err := r.service.Changes.List().PageToken(pageToken).IncludeDeleted(true).Do()
+		if err != nil {
+			return changes, "", err
+		}
+		changes = append(changes, res.Items...)
+		if res.NewStartPageToken != "" {
+			return changes, res.NewStartPageToken, nil
+		}
+		pageToken = res.NextPageToken
+	}
+	return changes, "", illogicalStateErr(fmt.Errorf("changes: no page token to carry on from"))
+}
+
 func buildExpression(parentId string, typeMask int, inTrash bool) string {
 	var exprBuilder []string
 
-	exprBuilder = append(exprBuilder, fmt.Sprintf("'%s' in parents and trashed=%t", parentId, inTrash))
+	// Without a parent the whole drive is searched.
+	if parentId == "" {
+		exprBuilder = append(exprBuilder, fmt.Sprintf("trashed=%t", inTrash))
+	} else {
+		exprBuilder = append(exprBuilder, fmt.Sprintf("'%s' in parents and trashed=%t", parentId, inTrash))
+	}
 
 	// Folder and NonFolder are mutually exclusive.
 	if (typeMask & Folder) != 0 {
 		exprBuilder = append(exprBuilder, fmt.Sprintf("mimeType = '%s'", DriveFolderMimeType))
 	}
+	if starred(typeMask) {
+		exprBuilder = append(exprBuilder, "starred=true")
+	}
 	return strings.Join(exprBuilder, " and ")
 }
 
@@ -268,19 +363,6 @@ func (r *Remote) FindById(id string) (*File, error) {
 	return NewRemoteFile(f), nil
 }
 
-func retryableChangeOp(fn func() (interface{}, error), debug bool, retryCount int) *expb.ExponentialBacker {
-	if retryCount < 0 {
-		retryCount = MaxFailedRetryCount
-	}
-
-	return &expb.ExponentialBacker{
-		Do:          fn,
-		Debug:       debug,
-		RetryCount:  uint32(retryCount),
-		StatusCheck: retryableErrorCheck,
-	}
-}
-
 func (r *Remote) findByPathM(p string, trashed bool) *paginationPair {
 	if rootLike(p) {
 		return r.FindByIdM("root")
@@ -324,7 +406,30 @@ func (r *Remote) FindByPathTrashedM(p string) *paginationPair {
 }
 
 func reqDoPage(req *drive.FilesListCall, hidden bool, promptOnPagination bool) *paginationPair {
-	return _reqDoPage(req, hidden, promptOnPagination, false)
+	return _reqDoPage(req, hidden, promptOnPagination, false, resumeToken{})
+}
+
+// resumeToken continues a listing from the index'th file, counting
+// hidden files too, of the page that pageToken fetches.
+type resumeToken struct {
+	pageToken string
+	index     int
+}
+
+func (rt *resumeToken) String() string {
+	return fmt.Sprintf("%d:%s", rt.index, rt.pageToken)
+}
+
+func parseResumeToken(s string) (*resumeToken, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed resume token %q", s)
+	}
+	index, err := strconv.Atoi(parts[0])
+	if err != nil || index < 0 {
+		return nil, fmt.Errorf("malformed resume token %q", s)
+	}
+	return &resumeToken{pageToken: parts[1], index: index}, nil
 }
 
 type paginationPair struct {
@@ -332,7 +437,7 @@ type paginationPair struct {
 	filesChan chan *File
 }
 
-func _reqDoPage(req *drive.FilesListCall, hidden bool, promptOnPagination, nilOnNoMatch bool) *paginationPair {
+func _reqDoPage(req *drive.FilesListCall, hidden bool, promptOnPagination, nilOnNoMatch bool, from resumeToken) *paginationPair {
 	filesChan := make(chan *File)
 	errsChan := make(chan error)
 
@@ -344,7 +449,8 @@ func _reqDoPage(req *drive.FilesListCall, hidden bool, promptOnPagination, nilOn
 			close(filesChan)
 		}()
 
-		pageToken := ""
+		pageToken := from.pageToken
+		skip := from.index
 		for pageIterCount := uint64(0); ; pageIterCount++ {
 			if pageToken != "" {
 				req = req.PageToken(pageToken)
@@ -356,13 +462,19 @@ func _reqDoPage(req *drive.FilesListCall, hidden bool, promptOnPagination, nilOn
 			}
 
 			iterCount := uint64(0)
-			for _, f := range results.Items {
+			for i, f := range results.Items {
+				if i < skip {
+					continue
+				}
 				if isHidden(f.Title, hidden) { // ignore hidden files
 					continue
 				}
 				iterCount += 1
-				filesChan <- NewRemoteFile(f)
+				rf := NewRemoteFile(f)
+				rf.pageToken, rf.pageIndex = pageToken, i
+				filesChan <- rf
 			}
+			skip = 0
 
 			pageToken = results.NextPageToken
 			if pageToken == "" {
@@ -404,6 +516,9 @@ func (r *Remote) FindByParentIdTrashed(parentId string, hidden bool) *pagination
 }
 
 func (r *Remote) EmptyTrash() error {
+	if !r.permanentDeletion {
+		return ErrPermanentDeletionRefused
+	}
 	return r.service.Files.EmptyTrash().Do()
 }
 
@@ -418,6 +533,9 @@ func (r *Remote) Untrash(id string) error {
 }
 
 func (r *Remote) Delete(id string) error {
+	if !r.permanentDeletion {
+		return ErrPermanentDeletionRefused
+	}
 	return r.service.Files.Delete(id).Do()
 }
 
@@ -430,19 +548,28 @@ func (r *Remote) idForEmail(email string) (string, error) {
 }
 
 func (r *Remote) listPermissions(id string) ([]*drive.Permission, error) {
-	res, err := r.service.Permissions.List(id).Do()
+	res, err := r.service.Permissions.List(id).SupportsAllDrives(true).Do()
 	if err != nil {
 		return nil, err
 	}
 	return res.Items, nil
 }
 
+// apiRoles returns the role and additional roles that the API takes for
+// role, Drive v2 only taking commenter as an additional role of readers.
+func apiRoles(role Role) (string, []string) {
+	if role == Commenter {
+		return "reader", []string{"commenter"}
+	}
+	return role.String(), nil
+}
+
 func (r *Remote) insertPermissions(permInfo *permission) (*drive.Permission, error) {
 	perm := &drive.Permission{
-		Role:     permInfo.role.String(),
 		Type:     permInfo.accountType.String(),
 		WithLink: permInfo.withLink,
 	}
+	perm.Role, perm.AdditionalRoles = apiRoles(permInfo.role)
 
 	if permInfo.value != "" {
 		perm.Value = permInfo.value
@@ -457,35 +584,63 @@ func (r *Remote) insertPermissions(permInfo *permission) (*drive.Permission, err
 	return req.Do()
 }
 
-func (r *Remote) revokePermissions(p *permission) (err error) {
+// grantPermission gives fileId the same access as perm, without notifying anyone.
+func (r *Remote) grantPermission(fileId string, perm *drive.Permission) (*drive.Permission, error) {
+	grant := &drive.Permission{
+		Role:            perm.Role,
+		AdditionalRoles: perm.AdditionalRoles,
+		Type:            perm.Type,
+		Value:           permissionValue(perm),
+		WithLink:        perm.WithLink,
+	}
+	return r.service.Permissions.Insert(fileId, grant).SupportsAllDrives(true).SendNotificationEmails(false).Do()
+}
+
+func (r *Remote) listRevisions(id string) ([]*drive.Revision, error) {
+	res, err := r.service.Revisions.List(id).Do()
+	if err != nil {
+		return nil, err
+	}
+	return res.Items, nil
+}
+
+func (r *Remote) findRevision(id, revId string) (*drive.Revision, error) {
+	return r.service.Revisions.Get(id, revId).Do()
+}
+
+// patchRevision sends Pinned even when false, so that unpinning is not dropped.
+func (r *Remote) patchRevision(id, revId string, pinned bool) (*drive.Revision, error) {
+	rev := &drive.Revision{Pinned: pinned, ForceSendFields: []string{"Pinned"}}
+	return r.service.Revisions.Patch(id, revId, rev).Do()
+}
+
+func (r *Remote) revokePermissions(p *permission) (revoked []*drive.Permission, err error) {
 	foundPermissionsChan, fErr := r.findPermissions(p)
 	if fErr != nil {
-		return fErr
+		return nil, fErr
 	}
 
-	successes := 0
 	for perm := range foundPermissionsChan {
 		if perm == nil {
 			continue
 		}
 
-		req := r.service.Permissions.Delete(p.fileId, perm.Id)
-		if delErr := req.Do(); delErr != nil {
+		if delErr := r.deletePermission(p.fileId, perm.Id); delErr != nil {
 			err = reComposeError(err, fmt.Sprintf("err: %v fileId: %s permissionId %s", delErr, p.fileId, perm.Id))
 		} else {
-			successes += 1
+			revoked = append(revoked, perm)
 		}
 	}
 
 	if err != nil {
-		return err
+		return revoked, err
 	}
 
-	if successes < 1 {
+	if len(revoked) < 1 {
 		err = noMatchesFoundErr(fmt.Errorf("no matches found!"))
 	}
 
-	return err
+	return revoked, err
 }
 
 func stringifyPermissionForMatch(p *permission) string {
@@ -528,6 +683,10 @@ func stringifyDrivePermissionForMatch(p *drive.Permission) string {
 	return sepJoin("", preprocessBeforePermissionMatch(repr)...)
 }
 
+func (r *Remote) deletePermission(fileId, permissionId string) error {
+	return r.service.Permissions.Delete(fileId, permissionId).Do()
+}
+
 func (r *Remote) findPermissions(pquery *permission) (permChan chan *drive.Permission, err error) {
 	permChan = make(chan *drive.Permission)
 
@@ -622,7 +781,28 @@ func (r *Remote) Download(id string, exportURL string) (io.ReadCloser, error) {
 		body = decR
 	}
 
-	return body, err
+	return r.downloadThrottle.readCloser(body), err
+}
+
+// DownloadRange downloads only the bytes of id within br.
+func (r *Remote) DownloadRange(id string, br *byteRange) (io.ReadCloser, error) {
+	if r.decrypter != nil {
+		return nil, invalidArgumentsErr(fmt.Errorf("download: a range of encrypted content cannot be decrypted"))
+	}
+
+	req := r.service.Files.Get(id)
+	req.Header().Set("Range", br.header())
+
+	resp, err := req.Download()
+	if err != nil {
+		return nil, err
+	}
+	// A server that ignores the range would send back the entire file.
+	if resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, downloadFailedErr(fmt.Errorf("download: %q was not served as a byte range. StatusCode: %v", id, resp.StatusCode))
+	}
+	return r.downloadThrottle.readCloser(resp.Body), nil
 }
 
 func (r *Remote) Touch(id string) (*File, error) {
@@ -697,6 +877,12 @@ type upsertOpt struct {
 	retryCount      int
 	uploadChunkSize int
 	uploadRateLimit int
+	// properties are set as private custom properties of the file.
+	properties map[string]string
+	// sessions if set journals resumable uploads of large files.
+	sessions uploadSessions
+	// compress gzips the content as it is uploaded.
+	compress bool
 }
 
 func togglePropertiesInsertCall(req *drive.FilesInsertCall, mask int) *drive.FilesInsertCall {
@@ -751,7 +937,8 @@ func (args *upsertOpt) shouldUploadBody() bool {
 	return checksumDiffers(mask)
 }
 
-func (r *Remote) upsertByComparison(body io.Reader, args *upsertOpt) (f *File, mediaInserted bool, err error) {
+// upsertMetadata is the metadata that args.src is created or updated with.
+func upsertMetadata(args *upsertOpt) *drive.File {
 	uploaded := &drive.File{
 		// Must ensure that the path is prepared for a URL upload
 		Title:   urlToPath(args.src.Name, false),
@@ -762,19 +949,10 @@ func (r *Remote) upsertByComparison(body io.Reader, args *upsertOpt) (f *File, m
 		uploaded.MimeType = DriveFolderMimeType
 	}
 
-	if r.encrypter != nil && body != nil {
-		encR, encErr := r.encrypter(body)
-		if encErr != nil {
-			err = encErr
-			return
-		}
-		body = encR
+	for key, value := range args.properties {
+		uploaded.Properties = append(uploaded.Properties, &drive.Property{Key: key, Value: value, Visibility: "PRIVATE"})
 	}
 
-	// throttled reader: implement upload bandwidth limit
-	// uploadRateLimit is in KiB/s
-	reader := flowrate.NewReader(body, int64(args.uploadRateLimit*1024))
-
 	if args.src.MimeType != "" {
 		uploaded.MimeType = args.src.MimeType
 	}
@@ -785,6 +963,28 @@ func (r *Remote) upsertByComparison(body io.Reader, args *upsertOpt) (f *File, m
 
 	// Ensure that the ModifiedDate is retrieved from local
 	uploaded.ModifiedDate = toUTCString(args.src.ModTime)
+	return uploaded
+}
+
+func (r *Remote) upsertByComparison(body io.Reader, args *upsertOpt) (f *File, mediaInserted bool, err error) {
+	uploaded := upsertMetadata(args)
+
+	if args.compress && body != nil {
+		body = gzipReader(body)
+	}
+
+	if r.encrypter != nil && body != nil {
+		encR, encErr := r.encrypter(body)
+		if encErr != nil {
+			err = encErr
+			return
+		}
+		body = encR
+	}
+
+	// throttled reader: implement upload bandwidth limit
+	// uploadRateLimit is in KiB/s
+	reader := r.uploadThrottle.reader(flowrate.NewReader(body, int64(args.uploadRateLimit*1024)))
 
 	var mediaOptions []googleapi.MediaOption
 	if args.uploadChunkSize > 0 {
@@ -831,6 +1031,39 @@ func (r *Remote) upsertByComparison(body io.Reader, args *upsertOpt) (f *File, m
 	return
 }
 
+// findUploaded finds a child of parentId that an earlier attempt at creating
+// src already uploaded, telling it apart from namesakes by its checksum.
+func (r *Remote) findUploaded(parentId string, src *File) (*File, error) {
+	if r.encrypter != nil {
+		// The checksums of encrypted content never match.
+		return nil, nil
+	}
+	checksum := md5Checksum(src)
+	if checksum == "" {
+		return nil, nil
+	}
+
+	req := r.service.Files.List()
+	req.Q(fmt.Sprintf("%s in parents and title = %s and trashed=false",
+		customQuote(parentId), customQuote(urlToPath(src.Name, false))))
+	// Only looked up by the retries of an upload, which retry it themselves.
+	req.Context(retriedByCaller(context.Background()))
+
+	files, err := req.Do()
+	if err != nil {
+		return nil, err
+	}
+	if files == nil {
+		return nil, nil
+	}
+	for _, item := range files.Items {
+		if item.Md5Checksum == checksum {
+			return NewRemoteFile(item), nil
+		}
+	}
+	return nil, nil
+}
+
 func (r *Remote) byFileIdUpdater(fileId string, f *drive.File) (*File, error) {
 	req := r.service.Files.Update(fileId, f)
 	uploaded, err := req.Do()
@@ -870,16 +1103,119 @@ func (r *Remote) updateStarred(fileId string, star bool) (*File, error) {
 	return r.byFileIdUpdater(fileId, f)
 }
 
+// patchProperties replaces the properties of a file only if its etag is
+// still etag, failing with 412 Precondition Failed otherwise.
+func (r *Remote) patchProperties(fileId, etag string, props []*drive.Property) (*File, error) {
+	req := r.service.Files.Patch(fileId, &drive.File{Properties: props})
+	req.UpdateViewedDate(false)
+	req.Header().Set("If-Match", etag)
+
+	patched, err := req.Do()
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteFile(patched), nil
+}
+
+// updateContent replaces the content of fileId with body, setting its
+// modification time to modTime, an RFC 3339 date, if set.
+func (r *Remote) updateContent(fileId string, body io.Reader, modTime string) (*File, error) {
+	repr := &drive.File{ModifiedDate: modTime}
+	req := r.service.Files.Update(fileId, repr).Media(body).SupportsAllDrives(true)
+	if modTime != "" {
+		req.SetModifiedDate(true)
+	}
+
+	updated, err := req.Do()
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteFile(updated), nil
+}
+
 func (r *Remote) removeParent(fileId, parentId string) error {
-	return r.service.Parents.Delete(fileId, parentId).Do()
+	return r.reparent(fileId, nil, []string{parentId})
 }
 
 func (r *Remote) insertParent(fileId, parentId string) error {
-	parent := &drive.ParentReference{Id: parentId}
-	_, err := r.service.Parents.Insert(fileId, parent).Do()
+	return r.reparent(fileId, []string{parentId}, nil)
+}
+
+// reparent adds fileId to and removes it from parents in a single request,
+// since items of Shared Drives can't have more than one parent at a time.
+func (r *Remote) reparent(fileId string, addParentIds, removeParentIds []string) error {
+	req := r.service.Files.Patch(fileId, &drive.File{}).SupportsAllDrives(true)
+	if len(addParentIds) >= 1 {
+		req = req.AddParents(strings.Join(addParentIds, ","))
+	}
+	if len(removeParentIds) >= 1 {
+		req = req.RemoveParents(strings.Join(removeParentIds, ","))
+	}
+	_, err := req.Do()
 	return err
 }
 
+func (r *Remote) createShortcut(parentId, name, targetId string) (*File, error) {
+	f := &drive.File{
+		Title:           urlToPath(name, false),
+		MimeType:        DriveShortcutMimeType,
+		Parents:         []*drive.ParentReference{&drive.ParentReference{Id: parentId}},
+		ShortcutDetails: &drive.FileShortcutDetails{TargetId: targetId},
+	}
+	created, err := r.service.Files.Insert(f).Do()
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteFile(created), nil
+}
+
+// sharedDrives lists the Shared Drives that the user is a member of.
+func (r *Remote) sharedDrives() ([]*drive.Drive, error) {
+	var drives []*drive.Drive
+	pageToken := ""
+	for {
+		req := r.service.Drives.List().MaxResults(100)
+		if pageToken != "" {
+			req = req.PageToken(pageToken)
+		}
+		results, err := req.Do()
+		if err != nil {
+			return drives, err
+		}
+		drives = append(drives, results.Items...)
+		if pageToken = results.NextPageToken; pageToken == "" {
+			return drives, nil
+		}
+	}
+}
+
+func (r *Remote) createSharedDrive(requestId, name string) (*drive.Drive, error) {
+	return r.service.Drives.Insert(requestId, &drive.Drive{Name: name}).Do()
+}
+
+// insertIntoSharedDrive creates a folder, or a file with body as content,
+// within parentId, a folder of a Shared Drive or the drive itself.
+func (r *Remote) insertIntoSharedDrive(parentId, name, mimeType string, body io.Reader) (*File, error) {
+	f := &drive.File{
+		Title:    urlToPath(name, false),
+		MimeType: mimeType,
+		Parents:  []*drive.ParentReference{&drive.ParentReference{Id: parentId}},
+	}
+	req := r.service.Files.Insert(f).SupportsAllDrives(true)
+	if body != nil {
+		req = req.Media(body)
+	}
+	created, err := req.Do()
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteFile(created), nil
+}
+
+func (r *Remote) grantSharedDrivePermission(fileId string, perm *drive.Permission, notify bool) (*drive.Permission, error) {
+	return r.service.Permissions.Insert(fileId, perm).SupportsAllDrives(true).SendNotificationEmails(notify).Do()
+}
+
 func (r *Remote) copy(newName, parentId string, srcFile *File) (*File, error) {
 	f := &drive.File{
 		Title:        urlToPath(newName, false),
@@ -888,7 +1224,7 @@ func (r *Remote) copy(newName, parentId string, srcFile *File) (*File, error) {
 	if parentId != "" {
 		f.Parents = []*drive.ParentReference{&drive.ParentReference{Id: parentId}}
 	}
-	copied, err := r.service.Files.Copy(srcFile.Id, f).Do()
+	copied, err := r.service.Files.Copy(srcFile.Id, f).SupportsAllDrives(true).Do()
 	if err != nil {
 		return nil, err
 	}
@@ -908,13 +1244,15 @@ func (r *Remote) UpsertByComparison(args *upsertOpt) (f *File, err error) {
 
 	var body io.Reader
 	var cleanUp func() error
+	var resumable *os.File
+	var fsAbsPath string
 
 	if !args.src.IsDir {
 		// In relation to issue #612, since we are not only resolving
 		// relative to the current working directory, we should try reading
 		// first from the source's original local fsAbsPath aka `BlobAt`
 		// because the resolved path might be different from the original path.
-		fsAbsPath := args.src.BlobAt
+		fsAbsPath = args.src.BlobAt
 		if fsAbsPath == "" {
 			fsAbsPath = args.fsAbsPath
 		}
@@ -929,6 +1267,9 @@ func (r *Remote) UpsertByComparison(args *upsertOpt) (f *File, err error) {
 			// See Issue https://github.com/odeke-em/drive/issues/711.
 			cleanUp = file.Close
 			body = file
+			if args.resumable(r) {
+				resumable = file
+			}
 		}
 	}
 
@@ -937,7 +1278,7 @@ func (r *Remote) UpsertByComparison(args *upsertOpt) (f *File, err error) {
 	go func() {
 		commChan := bd.ProgressChan()
 		for n := range commChan {
-			r.progressChan <- n
+			r.progress(args.relToRootPath, n)
 		}
 	}()
 
@@ -948,14 +1289,25 @@ func (r *Remote) UpsertByComparison(args *upsertOpt) (f *File, err error) {
 			defer cleanUp()
 		}
 
+		attempts := 0
 		emitter := func() (interface{}, error) {
+			attempts += 1
+			// A failed creation may have gone through regardless, so
+			// retries look for what it created before creating it again.
+			if attempts > 1 && args.src.Id == "" && !args.src.IsDir {
+				if existing, _ := r.findUploaded(args.parentId, args.src); existing != nil {
+					return &tuple{first: existing, second: false, last: nil}, nil
+				}
+			}
+			if resumable != nil {
+				f, err := r.resumableUpsert(resumable, fsAbsPath, args)
+				return &tuple{first: f, second: true, last: err}, err
+			}
 			f, mediaInserted, err := r.upsertByComparison(bd, args)
 			return &tuple{first: f, second: mediaInserted, last: err}, err
 		}
 
-		retrier := retryableChangeOp(emitter, args.debug, args.retryCount)
-
-		res, err := expb.ExponentialBackOffSync(retrier)
+		res, err := r.retry.do(args.retryCount, emitter)
 		resultLoad <- &tuple{first: res, last: err}
 	}()
 
@@ -977,7 +1329,7 @@ func (r *Remote) UpsertByComparison(args *upsertOpt) (f *File, err error) {
 		if mediaOk && !mediaInserted && f != nil {
 			chunks := chunkInt64(f.Size)
 			for n := range chunks {
-				r.progressChan <- n
+				r.progress(args.relToRootPath, n)
 			}
 		}
 
@@ -1001,6 +1353,23 @@ func (r *Remote) findShared(p []string) *paginationPair {
 	return reqDoPage(req, false, false)
 }
 
+// FindSharedByOwners finds the untrashed files shared with me
+// that are owned by any of owners, or by anyone if it is empty.
+func (r *Remote) FindSharedByOwners(owners []string, hidden bool) *paginationPair {
+	var ownerQueries []string
+	for _, owner := range owners {
+		ownerQueries = append(ownerQueries, fmt.Sprintf("(%s in owners)", strconv.Quote(owner)))
+	}
+
+	expr := "sharedWithMe=true and trashed=false"
+	if len(ownerQueries) >= 1 {
+		expr = fmt.Sprintf("%s and (%s)", expr, strings.Join(ownerQueries, " or "))
+	}
+
+	req := r.service.Files.List().Q(expr)
+	return reqDoPage(req, hidden, false)
+}
+
 func (r *Remote) FindByPathShared(p string) *paginationPair {
 	if p == "/" || p == "root" {
 		return r.findShared([]string{})
@@ -1020,8 +1389,7 @@ func (r *Remote) FindByPathShared(p string) *paginationPair {
 
 func (r *Remote) FindStarred(trashed, hidden bool) *paginationPair {
 	req := r.service.Files.List()
-	expr := fmt.Sprintf("(starred=true) and (trashed=%v)", trashed)
-	req.Q(expr)
+	req.Q(buildExpression("", Starred, trashed))
 	return reqDoPage(req, hidden, false)
 }
 
@@ -1033,16 +1401,49 @@ func (r *Remote) FindMatches(mq *matchQuery) *paginationPair {
 		}
 		return wrapInPaginationPair(parent, err)
 	}
+	return r.findMatchesIn(parent.Id, mq)
+}
 
+// findMatchesIn is FindMatches within the folder parentId, ignoring mq.dirPath.
+func (r *Remote) findMatchesIn(parentId string, mq *matchQuery) *paginationPair {
 	req := r.service.Files.List()
 
-	parQuery := fmt.Sprintf("(%s in parents)", customQuote(parent.Id))
+	parQuery := fmt.Sprintf("(%s in parents)", customQuote(parentId))
 	expr := sepJoinNonEmpty(" and ", parQuery, mq.Stringer())
 
 	req.Q(expr)
+	if r.pageSize >= 1 {
+		req.MaxResults(r.pageSize)
+	}
 	return reqDoPage(req, true, false)
 }
 
+// findModifiedSince finds the files, not folders, modified after t
+// anywhere in the drive.
+func (r *Remote) findModifiedSince(t time.Time, hidden bool) *paginationPair {
+	req := r.service.Files.List()
+	req.Q(fmt.Sprintf("mimeType != '%s' and modifiedDate > '%s' and trashed=false", DriveFolderMimeType, t.UTC().Format(time.RFC3339)))
+	if r.pageSize >= 1 {
+		req.MaxResults(r.pageSize)
+	}
+	return reqDoPage(req, hidden, false)
+}
+
+// findFullText finds the files, not folders, whose content or metadata
+// contains term anywhere in the drive, all of them if term is empty.
+func (r *Remote) findFullText(term string, hidden bool) *paginationPair {
+	req := r.service.Files.List()
+	expr := fmt.Sprintf("mimeType != '%s' and trashed=false", DriveFolderMimeType)
+	if term != "" {
+		expr = fmt.Sprintf("fullText contains %s and %s", customQuote(term), expr)
+	}
+	req.Q(expr)
+	if r.pageSize >= 1 {
+		req.MaxResults(r.pageSize)
+	}
+	return reqDoPage(req, hidden, false)
+}
+
 func (r *Remote) findChildren(parentId string, trashed bool) *paginationPair {
 	req := r.service.Files.List()
 	req.Q(fmt.Sprintf("%s in parents and trashed=%v", customQuote(parentId), trashed))
@@ -1080,7 +1481,7 @@ func (r *Remote) findByPathRecvRawM(parentId string, p []string, trashed bool) *
 		}
 
 		req.Q(expr)
-		pager := _reqDoPage(req, true, false, true)
+		pager := _reqDoPage(req, true, false, true, resumeToken{})
 
 		if len(rest) < 1 {
 			chanOChan <- pager
@@ -1187,6 +1588,48 @@ func (r *Remote) findByPathRecvRaw(parentId string, p []string, trashed bool) (*
 	return r.findByPathRecvRaw(first.Id, p[1:], trashed)
 }
 
+// existsByPath walks p from parentId requesting only ids,
+// which is all that is needed to tell whether it exists.
+func (r *Remote) existsByPath(parentId string, p []string) (bool, error) {
+	if len(p) < 1 {
+		return true, nil
+	}
+
+	req := r.service.Files.List()
+	req.Q(fmt.Sprintf("%s in parents and title = %s and trashed=false",
+		customQuote(parentId), customQuote(urlToPath(p[0], false))))
+	req.MaxResults(1)
+	req.Fields("items(id)")
+
+	files, err := req.Do()
+	if err != nil {
+		return false, err
+	}
+	if files == nil || len(files.Items) < 1 {
+		return false, nil
+	}
+	return r.existsByPath(files.Items[0].Id, p[1:])
+}
+
+// Exists reports whether the path or id exists outside of the trash.
+func (r *Remote) Exists(p string, byId bool) (bool, error) {
+	if !byId {
+		if rootLike(p) {
+			return true, nil
+		}
+		return r.existsByPath("root", strings.Split(p, RemoteSeparator)[1:])
+	}
+
+	f, err := r.service.Files.Get(p).Fields("id", "labels/trashed").Do()
+	if err != nil {
+		if remoteNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return f.Labels == nil || !f.Labels.Trashed, nil
+}
+
 func (r *Remote) findByPathRecv(parentId string, p []string) (*File, error) {
 	return r.findByPathRecvRaw(parentId, p, false)
 }
@@ -1209,11 +1652,11 @@ func newAuthConfig(context *config.Context) *oauth2.Config {
 		ClientSecret: context.ClientSecret,
 		RedirectURL:  RedirectURL,
 		Endpoint:     google.Endpoint,
-		Scopes:       []string{DriveScope},
+		Scopes:       []string{DriveScope, DriveLabelsScope},
 	}
 }
 
-func newOAuthClient(configContext *config.Context) *http.Client {
+func newOAuthClient(configContext *config.Context, p *pacer, rp *retryPolicy) *http.Client {
 	config := newAuthConfig(configContext)
 
 	token := oauth2.Token{
@@ -1221,5 +1664,5 @@ func newOAuthClient(configContext *config.Context) *http.Client {
 		Expiry:       time.Now().Add(1 * time.Hour),
 	}
 
-	return config.Client(context.Background(), &token)
+	return config.Client(transportContext(p, rp, configContext), &token)
 }
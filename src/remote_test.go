@@ -0,0 +1,116 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+func TestParseResumeToken(t *testing.T) {
+	rt, err := parseResumeToken("3:EAIaKwoN:Eg")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if rt.index != 3 || rt.pageToken != "EAIaKwoN:Eg" {
+		t.Errorf("got %+v", rt)
+	}
+	if got := rt.String(); got != "3:EAIaKwoN:Eg" {
+		t.Errorf("expected the token to round trip, got %q", got)
+	}
+
+	if rt, err := parseResumeToken("0:"); err != nil || rt.index != 0 || rt.pageToken != "" {
+		t.Errorf("expected the first page to be resumable, got %+v %v", rt, err)
+	}
+	for _, malformed := range []string{"", "EAIaKwoN", "-1:EAIaKwoN", "x:EAIaKwoN"} {
+		if _, err := parseResumeToken(malformed); err == nil {
+			t.Errorf("%q: expected an error", malformed)
+		}
+	}
+}
+
+func TestReparentInOneRequest(t *testing.T) {
+	var requests []*http.Request
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id":"f1"}`)),
+		}, nil
+	})
+	service, err := drive.New(&http.Client{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Remote{service: service}
+
+	if err := r.reparent("f1", []string{"to"}, []string{"from1", "from2"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected a single request, got %d", len(requests))
+	}
+	req := requests[0]
+	if req.Method != "PATCH" || !strings.HasSuffix(req.URL.Path, "/files/f1") {
+		t.Errorf("got %s %s, want PATCH of /files/f1", req.Method, req.URL.Path)
+	}
+	query := req.URL.Query()
+	if got := query.Get("addParents"); got != "to" {
+		t.Errorf("addParents got %q want %q", got, "to")
+	}
+	if got := query.Get("removeParents"); got != "from1,from2" {
+		t.Errorf("removeParents got %q want %q", got, "from1,from2")
+	}
+	if got := query.Get("supportsAllDrives"); got != "true" {
+		t.Errorf("expected supportsAllDrives for items of Shared Drives, got %q", got)
+	}
+}
+
+func TestInsertPermissionsCommenter(t *testing.T) {
+	var sent drive.Permission
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+			t.Errorf("decoding the request body: %v", err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id":"p1"}`)),
+		}, nil
+	})
+	service, err := drive.New(&http.Client{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Remote{service: service}
+
+	perm := &permission{fileId: "f1", role: Commenter, accountType: Anyone, withLink: true}
+	if _, err := r.insertPermissions(perm); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Role != "reader" || !reflect.DeepEqual(sent.AdditionalRoles, []string{"commenter"}) {
+		t.Errorf("expected commenter as an additional role of a reader, got role %q additional %v", sent.Role, sent.AdditionalRoles)
+	}
+	if sent.Type != "anyone" || !sent.WithLink {
+		t.Errorf("expected a link for anyone, got type %q withLink %v", sent.Type, sent.WithLink)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mxk/go-flowrate/flowrate"
+	"github.com/odeke-em/drive/config"
+	drive "google.golang.org/api/drive/v2"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	DriveUploadURL = "https://www.googleapis.com/upload/drive/v2/files"
+
+	// Files that fit in a single chunk gain nothing from being resumable.
+	resumableUploadThreshold = googleapi.DefaultUploadChunkSize
+
+	// Chunks other than the last must be multiples of 256KiB.
+	resumableChunkAlignment = 256 * 1024
+
+	// uploadSessionLifetime is how long Drive keeps an unfinished session.
+	uploadSessionLifetime = 7 * 24 * time.Hour
+
+	statusResumeIncomplete = 308
+)
+
+var errUploadSessionExpired = errors.New("resumable upload session expired")
+
+// uploadSessions is where the sessions of resumable uploads are journaled.
+type uploadSessions interface {
+	UploadSession(p string) (*config.UploadSession, error)
+	JournalUploadSession(session *config.UploadSession) error
+	ClearUploadSession(p string) error
+}
+
+// resumable tells whether the body of args.src should be uploaded through a
+// journaled session. Encrypted content can't be resumed, since it would be
+// encrypted differently the next time around, nor can compressed content
+// whose size isn't known ahead.
+func (args *upsertOpt) resumable(r *Remote) bool {
+	return args.sessions != nil && r.encrypter == nil && !args.compress && !args.nonStatable && args.src.Size >= resumableUploadThreshold
+}
+
+func resumableChunkSize(chunkSize int) int64 {
+	if chunkSize <= 0 {
+		return googleapi.DefaultUploadChunkSize
+	}
+	if rem := chunkSize % resumableChunkAlignment; rem != 0 {
+		chunkSize += resumableChunkAlignment - rem
+	}
+	return int64(chunkSize)
+}
+
+// confirmedOffset parses the Range header of a 308 response, e.g
+// "bytes=0-42", into the offset of the next byte to send.
+func confirmedOffset(rangeHeader string) (int64, error) {
+	if rangeHeader == "" {
+		return 0, nil
+	}
+	bounds := strings.SplitN(strings.TrimPrefix(rangeHeader, "bytes="), "-", 2)
+	if len(bounds) != 2 {
+		return 0, fmt.Errorf("unexpected upload range %q", rangeHeader)
+	}
+	last, err := strconv.ParseInt(bounds[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected upload range %q: %v", rangeHeader, err)
+	}
+	return last + 1, nil
+}
+
+func resumableUploadParams(mask int) url.Values {
+	params := url.Values{"uploadType": {"resumable"}}
+	if ocr(mask) {
+		params.Set("ocr", "true")
+	}
+	if convert(mask) {
+		params.Set("convert", "true")
+	}
+	if pin(mask) {
+		params.Set("pinned", "true")
+	}
+	if indexContent(mask) {
+		params.Set("useContentAsIndexableText", "true")
+	}
+	return params
+}
+
+// resumeUploadSession returns the journaled session for p if it is still
+// for the same content and destination.
+func resumeUploadSession(args *upsertOpt, p string, size int64, modTime time.Time) *config.UploadSession {
+	session, err := args.sessions.UploadSession(p)
+	if err != nil || session == nil {
+		return nil
+	}
+	if session.Size != size || !session.ModTime.Equal(modTime) ||
+		session.FileId != args.src.Id || session.ParentId != args.parentId ||
+		time.Since(session.Time) >= uploadSessionLifetime {
+		return nil
+	}
+	return session
+}
+
+// startUploadSession starts a resumable upload of size bytes, or of
+// a length only known once the upload ends if size is negative.
+func (r *Remote) startUploadSession(args *upsertOpt, size int64) (string, error) {
+	uploaded := upsertMetadata(args)
+	metadata, err := json.Marshal(uploaded)
+	if err != nil {
+		return "", err
+	}
+
+	method, uploadURL := "POST", DriveUploadURL
+	params := resumableUploadParams(args.mask)
+	if args.src.Id != "" {
+		method, uploadURL = "PUT", DriveUploadURL+"/"+url.QueryEscape(args.src.Id)
+		// We always want it to match up with the local time
+		params.Set("setModifiedDate", "true")
+	}
+
+	req, err := http.NewRequest(method, uploadURL+"?"+params.Encode(), bytes.NewReader(metadata))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	if size >= 0 {
+		req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+	}
+	if uploaded.MimeType != "" {
+		req.Header.Set("X-Upload-Content-Type", uploaded.MimeType)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if err := googleapi.CheckResponse(res); err != nil {
+		return "", err
+	}
+	location := res.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("no session URI in the response to starting a resumable upload")
+	}
+	return location, nil
+}
+
+// sendUploadChunk PUTs body as the bytes from offset of the upload, returning
+// the offset that Drive has confirmed up to or, once all bytes are in, the file.
+// A nil body asks for the confirmed offset without sending anything, and
+// a negative size is that of an upload whose length isn't known yet.
+func (r *Remote) sendUploadChunk(uri string, body io.Reader, offset, n, size int64) (int64, *File, error) {
+	req, err := http.NewRequest("PUT", uri, body)
+	if err != nil {
+		return offset, nil, err
+	}
+	req.ContentLength = n
+	total := "*"
+	if size >= 0 {
+		total = strconv.FormatInt(size, 10)
+	}
+	if body == nil {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes */%s", total))
+	} else {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", offset, offset+n-1, total))
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return offset, nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case statusResumeIncomplete:
+		confirmed, err := confirmedOffset(res.Header.Get("Range"))
+		return confirmed, nil, err
+	case http.StatusNotFound, http.StatusGone:
+		return offset, nil, errUploadSessionExpired
+	}
+
+	if err := googleapi.CheckResponse(res); err != nil {
+		return offset, nil, err
+	}
+	uploaded := &drive.File{}
+	if err := json.NewDecoder(res.Body).Decode(uploaded); err != nil {
+		return offset, nil, err
+	}
+	return size, NewRemoteFile(uploaded), nil
+}
+
+// resumableUpsert uploads file in chunks through a session journaled under p,
+// carrying on from what Drive last confirmed if an earlier attempt, even one
+// from a push that was interrupted, left a session for the same content.
+func (r *Remote) resumableUpsert(file *os.File, p string, args *upsertOpt) (*File, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size, modTime := info.Size(), info.ModTime().UTC()
+
+	var offset int64
+	session := resumeUploadSession(args, p, size, modTime)
+	if session != nil {
+		confirmed, f, err := r.sendUploadChunk(session.URI, nil, 0, 0, size)
+		switch {
+		case err == errUploadSessionExpired:
+			session = nil
+		case err != nil:
+			return nil, err
+		case f != nil:
+			args.sessions.ClearUploadSession(p)
+			r.reportUploaded(args.relToRootPath, size)
+			return f, nil
+		default:
+			offset = confirmed
+		}
+	}
+
+	if session == nil {
+		uri, err := r.startUploadSession(args, size)
+		if err != nil {
+			return nil, err
+		}
+		session = &config.UploadSession{
+			Path:     p,
+			URI:      uri,
+			FileId:   args.src.Id,
+			ParentId: args.parentId,
+			Size:     size,
+			ModTime:  modTime,
+		}
+		if err := args.sessions.JournalUploadSession(session); err != nil {
+			return nil, err
+		}
+	}
+
+	r.reportUploaded(args.relToRootPath, offset)
+
+	chunkSize := resumableChunkSize(args.uploadChunkSize)
+	for {
+		n := size - offset
+		if n > chunkSize {
+			n = chunkSize
+		}
+		chunk := r.uploadThrottle.reader(flowrate.NewReader(io.NewSectionReader(file, offset, n), int64(args.uploadRateLimit*1024)))
+		confirmed, f, err := r.sendUploadChunk(session.URI, chunk, offset, n, size)
+		if err == errUploadSessionExpired {
+			args.sessions.ClearUploadSession(p)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if f == nil && confirmed <= offset {
+			return nil, fmt.Errorf("%s: no bytes were confirmed past %d of %d", p, offset, size)
+		}
+
+		r.reportUploaded(args.relToRootPath, confirmed-offset)
+		offset = confirmed
+		if f != nil {
+			args.sessions.ClearUploadSession(p)
+			return f, nil
+		}
+	}
+}
+
+// streamingUpsert uploads body, whose length is only known once it ends,
+// through a resumable session a chunk at a time so that arbitrarily large
+// streams e.g `tar czf - dir | drive push -piped backups/dir.tgz` are
+// never held in memory beyond the chunk being sent.
+func (r *Remote) streamingUpsert(body io.Reader, args *upsertOpt) (*File, error) {
+	if r.encrypter != nil {
+		encR, err := r.encrypter(body)
+		if err != nil {
+			return nil, err
+		}
+		body = encR
+	}
+
+	uri, err := r.startUploadSession(args, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := make([]byte, resumableChunkSize(args.uploadChunkSize))
+	offset := int64(0)
+	for {
+		n, rErr := io.ReadFull(body, chunk)
+		last := rErr == io.EOF || rErr == io.ErrUnexpectedEOF
+		if rErr != nil && !last {
+			return nil, rErr
+		}
+
+		size := int64(-1)
+		if last {
+			size = offset + int64(n)
+		}
+		f, err := r.sendStreamChunk(uri, chunk[:n], offset, size, args)
+		if err != nil {
+			return nil, err
+		}
+		offset += int64(n)
+		if f != nil {
+			return f, nil
+		}
+		if last {
+			return nil, fmt.Errorf("%s: the upload wasn't completed by its last %d bytes", args.fsAbsPath, size)
+		}
+	}
+}
+
+// sendStreamChunk sends chunk as the bytes of a streamed upload from offset,
+// resending what Drive didn't confirm of it, and asking for what it has
+// after a failed attempt. size is negative until the last chunk.
+func (r *Remote) sendStreamChunk(uri string, chunk []byte, offset, size int64, args *upsertOpt) (*File, error) {
+	sent, failures := int64(0), 0
+	for {
+		var body io.Reader
+		rest := chunk[sent:]
+		if len(rest) >= 1 {
+			body = r.uploadThrottle.reader(flowrate.NewReader(bytes.NewReader(rest), int64(args.uploadRateLimit*1024)))
+		}
+		confirmed, f, err := r.sendUploadChunk(uri, body, offset+sent, int64(len(rest)), size)
+		if err == errUploadSessionExpired {
+			return nil, err
+		}
+		if err != nil {
+			if failures += 1; failures > args.retryCount {
+				return nil, err
+			}
+			if confirmed, f, err = r.sendUploadChunk(uri, nil, 0, 0, size); err != nil {
+				continue
+			}
+		}
+		if f != nil {
+			return f, nil
+		}
+		if confirmed < offset || confirmed > offset+int64(len(chunk)) {
+			return nil, fmt.Errorf("%s: %d bytes were confirmed, outside of the chunk from %d", args.fsAbsPath, confirmed, offset)
+		}
+		sent = confirmed - offset
+		if sent == int64(len(chunk)) && size < 0 {
+			return nil, nil
+		}
+	}
+}
+
+func (r *Remote) reportUploaded(relToRootPath string, n int64) {
+	for chunk := range chunkInt64(n) {
+		r.progress(relToRootPath, chunk)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestConfirmedOffset(t *testing.T) {
+	cases := map[string]int64{"": 0, "bytes=0-0": 1, "bytes=0-8388607": 8388608}
+	for header, expected := range cases {
+		if got, err := confirmedOffset(header); err != nil || got != expected {
+			t.Errorf("%q: expected %d, got %d %v", header, expected, got, err)
+		}
+	}
+	if _, err := confirmedOffset("bytes=0"); err == nil {
+		t.Errorf("expected a malformed range to be rejected")
+	}
+
+	if got := resumableChunkSize(0); got != googleapi.DefaultUploadChunkSize {
+		t.Errorf("expected the default chunk size, got %d", got)
+	}
+	if got := resumableChunkSize(300 * 1024); got != 512*1024 {
+		t.Errorf("expected chunks to be rounded up to 256KiB multiples, got %d", got)
+	}
+}
+
+// sessionServer plays Drive's side of a resumable upload, confirming
+// at most accept bytes of each request.
+type sessionServer struct {
+	accept   int
+	received []byte
+	ranges   []string
+}
+
+func (ss *sessionServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	res := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(`{"id":"f1"}`))}
+	if req.Method == "POST" {
+		res.Header.Set("Location", "https://www.googleapis.com/upload/session")
+		return res, nil
+	}
+
+	contentRange := req.Header.Get("Content-Range")
+	ss.ranges = append(ss.ranges, contentRange)
+	total := contentRange[strings.LastIndex(contentRange, "/")+1:]
+	if req.Body != nil {
+		body, _ := ioutil.ReadAll(req.Body)
+		if len(body) > ss.accept {
+			body = body[:ss.accept]
+		}
+		ss.received = append(ss.received, body...)
+	}
+	if total != "*" && total == fmt.Sprint(len(ss.received)) {
+		return res, nil
+	}
+	res.StatusCode = statusResumeIncomplete
+	if len(ss.received) >= 1 {
+		res.Header.Set("Range", fmt.Sprintf("bytes=0-%d", len(ss.received)-1))
+	}
+	return res, nil
+}
+
+func TestStreamingUpsert(t *testing.T) {
+	chunkSize := 2 * resumableChunkAlignment
+	tests := []struct {
+		size   int
+		accept int
+	}{
+		{0, chunkSize},
+		{100, chunkSize},
+		{2 * chunkSize, chunkSize},
+		{2*chunkSize + 100, resumableChunkAlignment},
+	}
+	for _, tt := range tests {
+		ss := &sessionServer{accept: tt.accept}
+		r := &Remote{client: &http.Client{Transport: ss}}
+		args := &upsertOpt{src: &File{Name: "dir.tgz"}, parentId: "root", uploadChunkSize: chunkSize, fsAbsPath: "backups/dir.tgz"}
+
+		content := bytes.Repeat([]byte("x"), tt.size)
+		f, err := r.streamingUpsert(bytes.NewReader(content), args)
+		if err != nil {
+			t.Errorf("size %d: %v", tt.size, err)
+			continue
+		}
+		if f == nil || f.Id != "f1" {
+			t.Errorf("size %d: expected the uploaded file, got %v", tt.size, f)
+		}
+		if !bytes.Equal(ss.received, content) {
+			t.Errorf("size %d: %d bytes received, ranges %v", tt.size, len(ss.received), ss.ranges)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// PartialSidecarSuffix is appended to the name of a partial download
+// for the sidecar recording how far it got.
+const PartialSidecarSuffix = ".json"
+
+// partialSidecar records how far the download of a file got, for an
+// interrupted pull to resume it with a Range request instead of starting over.
+type partialSidecar struct {
+	Id     string `json:"id"`
+	Md5    string `json:"md5"`
+	Size   int64  `json:"size"`
+	Offset int64  `json:"offset"`
+}
+
+func sidecarPath(partialPath string) string {
+	return partialPath + PartialSidecarSuffix
+}
+
+func readPartialSidecar(partialPath string) (*partialSidecar, error) {
+	data, err := ioutil.ReadFile(sidecarPath(partialPath))
+	if err != nil {
+		return nil, err
+	}
+	sc := &partialSidecar{}
+	if err := json.Unmarshal(data, sc); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+func (sc *partialSidecar) save(partialPath string) error {
+	data, err := json.Marshal(sc)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(sidecarPath(partialPath), append(data, '\n'), 0600)
+}
+
+// removePartial removes a partial download along with its sidecar.
+func removePartial(partialPath string) {
+	os.Remove(partialPath)
+	os.Remove(sidecarPath(partialPath))
+}
+
+// checkpointPartial records the bytes of partialPath written so far in its
+// sidecar, returning false if it has none i.e it cannot be resumed.
+func checkpointPartial(partialPath string) bool {
+	sc, err := readPartialSidecar(partialPath)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(partialPath)
+	if err != nil {
+		return false
+	}
+	sc.Offset = info.Size()
+	return sc.save(partialPath) == nil
+}
+
+// resumable tells whether the download of dlArg can be resumed from an
+// offset. Like multiRange, that rules out exports, encrypted and
+// compressed content, and without an md5 a resumed file can't be verified.
+func (g *Commands) resumable(dlArg *downloadArg) bool {
+	return dlArg.md5 != "" && dlArg.exportURL == "" && g.rem.decrypter == nil && !dlArg.gunzip
+}
+
+// resumeOffset returns the offset that the download of dlArg into its
+// partial file can resume from, 0 if its sidecar doesn't match the file
+// being downloaded anymore and it must start over.
+func resumeOffset(dlArg *downloadArg) int64 {
+	sc, err := readPartialSidecar(dlArg.path)
+	if err != nil || sc.Id != dlArg.id || sc.Md5 != dlArg.md5 || sc.Size != dlArg.size {
+		return 0
+	}
+	info, err := os.Stat(dlArg.path)
+	if err != nil {
+		return 0
+	}
+	offset := sc.Offset
+	if info.Size() < offset {
+		offset = info.Size()
+	}
+	if offset < 0 || offset > dlArg.size {
+		return 0
+	}
+	return offset
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResumeOffset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-resume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	partialPath := filepath.Join(dir, "big.iso"+PartialDownloadSuffix)
+	if err := ioutil.WriteFile(partialPath, make([]byte, 300), 0600); err != nil {
+		t.Fatal(err)
+	}
+	dlArg := &downloadArg{id: "id0", path: partialPath, size: 1000, md5: "abc"}
+
+	if got := resumeOffset(dlArg); got != 0 {
+		t.Errorf("without a sidecar: got offset %d want 0", got)
+	}
+
+	sc := &partialSidecar{Id: "id0", Md5: "abc", Size: 1000}
+	if err := sc.save(partialPath); err != nil {
+		t.Fatal(err)
+	}
+	if !checkpointPartial(partialPath) {
+		t.Fatalf("checkpointPartial: expected the sidecar to be updated")
+	}
+	if got := resumeOffset(dlArg); got != 300 {
+		t.Errorf("after a checkpoint: got offset %d want 300", got)
+	}
+
+	// A sidecar past the bytes on disk resumes from what is there.
+	sc.Offset = 500
+	sc.save(partialPath)
+	if got := resumeOffset(dlArg); got != 300 {
+		t.Errorf("sidecar ahead of the file: got offset %d want 300", got)
+	}
+
+	// Once the remote file changed, the download starts over.
+	changed := *dlArg
+	changed.md5 = "def"
+	if got := resumeOffset(&changed); got != 0 {
+		t.Errorf("changed remotely: got offset %d want 0", got)
+	}
+
+	removePartial(partialPath)
+	if checkpointPartial(partialPath) {
+		t.Errorf("checkpointPartial: expected nothing to checkpoint once removed")
+	}
+}
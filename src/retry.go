@@ -0,0 +1,321 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	// DefaultRetryMaxWait is the longest wait between retries.
+	DefaultRetryMaxWait = 32 * time.Second
+	// DefaultRetryOn are the failures retried unless --retry-on is set.
+	DefaultRetryOn = "5xx,429,401,403"
+
+	retryInitialWait = time.Second
+	// retryErrorBodyBytes is how much of a failed response is read for the
+	// reasons of the failure.
+	retryErrorBodyBytes = 64 * 1024
+)
+
+// RetryCondition matches the failures of API requests to retry: those with
+// HTTP status Code, or else of the class of Class e.g 5 for 5xx, and with
+// Reason amongst the reasons given by the API, if set. A failure whose
+// reasons aren't known matches any Reason.
+type RetryCondition struct {
+	Code   int
+	Class  int
+	Reason string
+}
+
+func (rc RetryCondition) String() string {
+	status := ""
+	switch {
+	case rc.Code != 0:
+		status = strconv.Itoa(rc.Code)
+	case rc.Class != 0:
+		status = fmt.Sprintf("%dxx", rc.Class)
+	}
+	return sepJoinNonEmpty(":", status, rc.Reason)
+}
+
+func (rc RetryCondition) matches(code int, reasons []string) bool {
+	if rc.Code != 0 && rc.Code != code {
+		return false
+	}
+	if rc.Class != 0 && rc.Class != code/100 {
+		return false
+	}
+	if rc.Reason == "" || len(reasons) < 1 {
+		return true
+	}
+	for _, reason := range reasons {
+		if strings.EqualFold(reason, rc.Reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRetryConditions parses comma separated conditions each being a
+// status e.g 429, a class of statuses e.g 5xx, a reason e.g
+// rateLimitExceeded or both e.g 403:rateLimitExceeded.
+func ParseRetryConditions(s string) ([]RetryCondition, error) {
+	var conditions []RetryCondition
+	for _, spec := range NonEmptyTrimmedStrings(strings.Split(s, ",")...) {
+		status, reason := spec, ""
+		if i := strings.IndexAny(spec, ": "); i >= 0 {
+			status, reason = strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+		}
+
+		rc := RetryCondition{Reason: reason}
+		lower := strings.ToLower(status)
+		switch {
+		case len(lower) == 3 && strings.HasSuffix(lower, "xx") && lower[0] >= '1' && lower[0] <= '5':
+			rc.Class = int(lower[0] - '0')
+		case status != "" && strings.Trim(status, "0123456789") == "":
+			code, err := strconv.Atoi(status)
+			if err != nil || code < 100 || code > 599 {
+				return nil, fmt.Errorf("%q: expecting an HTTP status between 100 and 599", spec)
+			}
+			rc.Code = code
+		case reason == "" && status != "" && unicode.IsLetter(rune(status[0])):
+			// Only a reason e.g rateLimitExceeded.
+			rc.Reason = status
+		default:
+			return nil, fmt.Errorf("%q: expecting a status e.g 429 or 5xx, a reason or status:reason", spec)
+		}
+		conditions = append(conditions, rc)
+	}
+	return conditions, nil
+}
+
+// retryPolicy is how many times, and on which failures, API requests are
+// retried with exponential backoff.
+type retryPolicy struct {
+	mu         sync.Mutex
+	retries    int
+	maxWait    time.Duration
+	conditions []RetryCondition
+}
+
+func newRetryPolicy() *retryPolicy {
+	conditions, _ := ParseRetryConditions(DefaultRetryOn)
+	return &retryPolicy{maxWait: DefaultRetryMaxWait, conditions: conditions}
+}
+
+// configure sets the policy from the options, a negative number of retries
+// standing for MaxFailedRetryCount and unset fields for the defaults.
+func (rp *retryPolicy) configure(retries int, maxWait time.Duration, conditions []RetryCondition) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if retries < 0 {
+		retries = MaxFailedRetryCount
+	}
+	rp.retries = retries
+	if maxWait > 0 {
+		rp.maxWait = maxWait
+	}
+	if len(conditions) >= 1 {
+		rp.conditions = conditions
+	}
+}
+
+func (rp *retryPolicy) settings() (retries int, maxWait time.Duration, conditions []RetryCondition) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return rp.retries, rp.maxWait, rp.conditions
+}
+
+// wait returns how long to wait before the attempt-th retry.
+func (rp *retryPolicy) wait(attempt int) time.Duration {
+	_, maxWait, _ := rp.settings()
+	wait := retryInitialWait
+	for i := 1; i < attempt && wait < maxWait; i++ {
+		wait *= 2
+	}
+	if wait > maxWait {
+		wait = maxWait
+	}
+	return wait
+}
+
+func (rp *retryPolicy) retryableStatus(code int, reasons []string) bool {
+	_, _, conditions := rp.settings()
+	for _, rc := range conditions {
+		if rc.matches(code, reasons) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableErr reports whether a request that failed with err is retried.
+// Failures other than API errors, e.g timeouts, are always retried.
+func (rp *retryPolicy) retryableErr(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return true
+	}
+	if strings.EqualFold(apiErr.Message, MsgErrFileNotMutable) {
+		return false
+	}
+	var reasons []string
+	for _, item := range apiErr.Errors {
+		reasons = append(reasons, item.Reason)
+	}
+	return rp.retryableStatus(apiErr.Code, reasons)
+}
+
+// statusCheck reports whether v, the *tuple returned by a change, is a
+// success, or else whether the change is retried.
+func (rp *retryPolicy) statusCheck(v interface{}) (ok, retryable bool) {
+	pr, pOk := v.(*tuple)
+	if pr == nil || !pOk {
+		return false, true
+	}
+	if pr.last == nil {
+		return true, false
+	}
+	// In relation to https://github.com/google/google-api-go-client/issues/93
+	// where not every error is of googleapi.Error instance e.g io timeout errors
+	// etc, let's assume that non-nil errors are retryable
+	if apiErr, isAPIErr := pr.last.(*googleapi.Error); isAPIErr && apiErr == nil {
+		return true, false
+	}
+	err, isErr := pr.last.(error)
+	return false, !isErr || rp.retryableErr(err)
+}
+
+// do invokes fn until it succeeds, fails unretryably or has been retried
+// retries times, returning what it returned last.
+func (rp *retryPolicy) do(retries int, fn func() (interface{}, error)) (interface{}, error) {
+	if retries < 0 {
+		retries = MaxFailedRetryCount
+	}
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if ok, retryable := rp.statusCheck(v); ok || !retryable || attempt > retries {
+			return v, err
+		}
+		time.Sleep(rp.wait(attempt))
+	}
+}
+
+type retriedByCallerKey struct{}
+
+// retriedByCaller returns ctx marked for the requests made with it not to
+// be retried by retryTransport, for reads made from within retryPolicy.do
+// not to be retried over again on each of its attempts.
+func retriedByCaller(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retriedByCallerKey{}, true)
+}
+
+// retryTransport retries the idempotent requests, i.e those that read,
+// which fail as the policy says to retry. Requests that write are retried
+// where they are made, knowing what they wrote. Unauthorized requests are
+// left to the oauth2 transport above, since sending them again with the
+// same token can't succeed.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy *retryPolicy
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retries, _, _ := t.policy.settings()
+	if (req.Method != "GET" && req.Method != "HEAD") || req.Body != nil {
+		return t.base.RoundTrip(req)
+	}
+	if req.Context().Value(retriedByCallerKey{}) != nil {
+		return t.base.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := t.base.RoundTrip(req)
+		if attempt > retries {
+			return res, err
+		}
+
+		wait := t.policy.wait(attempt)
+		if err == nil {
+			if res.StatusCode < 400 || res.StatusCode == http.StatusUnauthorized {
+				return res, nil
+			}
+			body, reasons := errorReasons(res)
+			if !t.policy.retryableStatus(res.StatusCode, reasons) {
+				res.Body = ioutil.NopCloser(bytes.NewReader(body))
+				return res, nil
+			}
+			if after := retryAfter(res); after > wait {
+				_, maxWait, _ := t.policy.settings()
+				if wait = after; wait > maxWait {
+					wait = maxWait
+				}
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// errorReasons reads the body of a failed response, returning it along
+// with the reasons of the failure that the API gave in it.
+func errorReasons(res *http.Response) (body []byte, reasons []string) {
+	body, _ = ioutil.ReadAll(io.LimitReader(res.Body, retryErrorBodyBytes))
+	res.Body.Close()
+
+	var reply struct {
+		Error struct {
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &reply) == nil {
+		for _, item := range reply.Error.Errors {
+			reasons = append(reasons, item.Reason)
+		}
+	}
+	return body, reasons
+}
+
+// retryAfter returns the wait asked for by a Retry-After header in seconds.
+func retryAfter(res *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(res.Header.Get("Retry-After")))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
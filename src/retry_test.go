@@ -0,0 +1,181 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestParseRetryConditions(t *testing.T) {
+	conditions, err := ParseRetryConditions("429, 5xx,403:rateLimitExceeded, userRateLimitExceeded,403 sharingRateLimitExceeded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RetryCondition{
+		{Code: 429},
+		{Class: 5},
+		{Code: 403, Reason: "rateLimitExceeded"},
+		{Reason: "userRateLimitExceeded"},
+		{Code: 403, Reason: "sharingRateLimitExceeded"},
+	}
+	if !reflect.DeepEqual(conditions, want) {
+		t.Errorf("got %v want %v", conditions, want)
+	}
+
+	for _, spec := range []string{"600", "42", "9xx", "4x:reason"} {
+		if _, err := ParseRetryConditions(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+
+	rp := newRetryPolicy()
+	rp.configure(3, 0, conditions[2:3])
+	tests := []struct {
+		code      int
+		reasons   []string
+		retryable bool
+	}{
+		{403, []string{"rateLimitExceeded"}, true},
+		{403, []string{"insufficientPermissions"}, false},
+		{403, nil, true},
+		{500, nil, false},
+	}
+	for _, tt := range tests {
+		if got := rp.retryableStatus(tt.code, tt.reasons); got != tt.retryable {
+			t.Errorf("%d %v: retryable got %v want %v", tt.code, tt.reasons, got, tt.retryable)
+		}
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	rp := newRetryPolicy()
+	rp.configure(-1, 5*time.Second, nil)
+	if retries, _, _ := rp.settings(); retries != MaxFailedRetryCount {
+		t.Errorf("expected a negative count to stand for %d retries, got %d", MaxFailedRetryCount, retries)
+	}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := rp.wait(attempt + 1); got != want {
+			t.Errorf("attempt %d: wait got %v want %v", attempt+1, got, want)
+		}
+	}
+}
+
+type scriptedTransport struct {
+	statuses []int
+	requests int
+}
+
+func (st *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := st.statuses[st.requests]
+	st.requests += 1
+	body := `{"error":{"errors":[{"reason":"rateLimitExceeded"}]}}`
+	if status == http.StatusForbidden {
+		body = `{"error":{"errors":[{"reason":"insufficientPermissions"}]}}`
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	conditions, _ := ParseRetryConditions("403:rateLimitExceeded,429,401")
+	rp := newRetryPolicy()
+	rp.configure(2, time.Millisecond, conditions)
+
+	tests := []struct {
+		method   string
+		statuses []int
+		status   int
+		requests int
+	}{
+		{"GET", []int{429, 429, 200}, 200, 3},
+		{"GET", []int{429, 429, 429}, 429, 3},
+		{"GET", []int{403}, 403, 1},
+		{"GET", []int{401}, 401, 1},
+		{"POST", []int{429}, 429, 1},
+	}
+	for _, tt := range tests {
+		st := &scriptedTransport{statuses: tt.statuses}
+		req, _ := http.NewRequest(tt.method, "https://www.googleapis.com/drive/v2/files", nil)
+		res, err := (&retryTransport{base: st, policy: rp}).RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.status || st.requests != tt.requests {
+			t.Errorf("%s %v: got status %d after %d requests, want %d after %d", tt.method, tt.statuses, res.StatusCode, st.requests, tt.status, tt.requests)
+		}
+		if body, _ := ioutil.ReadAll(res.Body); len(body) < 1 {
+			t.Errorf("%s %v: expected the body of the last response to be readable", tt.method, tt.statuses)
+		}
+	}
+}
+
+func TestRetryTransportLeftToCaller(t *testing.T) {
+	rp := newRetryPolicy()
+	rp.configure(2, time.Millisecond, nil)
+
+	st := &scriptedTransport{statuses: []int{429, 200}}
+	req, _ := http.NewRequest("GET", "https://www.googleapis.com/drive/v2/files", nil)
+	req = req.WithContext(retriedByCaller(req.Context()))
+	res, err := (&retryTransport{base: st, policy: rp}).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 429 || st.requests != 1 {
+		t.Errorf("got status %d after %d requests, want 429 after 1", res.StatusCode, st.requests)
+	}
+}
+
+func TestRetryTransportCanceled(t *testing.T) {
+	rp := newRetryPolicy()
+	rp.configure(2, time.Hour, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	st := &scriptedTransport{statuses: []int{429, 200}}
+	req, _ := http.NewRequest("GET", "https://www.googleapis.com/drive/v2/files", nil)
+	req = req.WithContext(ctx)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	done := make(chan error)
+	go func() {
+		_, err := (&retryTransport{base: st, policy: rp}).RoundTrip(req)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("got %v want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("still waiting to retry after the request was canceled")
+	}
+	if st.requests != 1 {
+		t.Errorf("got %d requests want 1", st.requests)
+	}
+}
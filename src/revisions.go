@@ -0,0 +1,158 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Revisions lists the revision history of each source. With a revision
+// given to download, pin or unpin, it acts on that revision of the
+// only source instead.
+func (g *Commands) Revisions(byId bool) error {
+	if len(g.opts.Sources) < 1 {
+		return invalidArgumentsErr(fmt.Errorf("revisions: expecting a path or id"))
+	}
+
+	download, pin, unpin := g.revisionsMeta(CLIOptionDownload), g.revisionsMeta(CLIOptionPin), g.revisionsMeta(CLIOptionUnpin)
+	if download == "" && pin == "" && unpin == "" {
+		for _, source := range g.opts.Sources {
+			f, err := g.revisionsSource(source, byId)
+			if err != nil {
+				return err
+			}
+			if err := g.listRevisions(source, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(g.opts.Sources) != 1 {
+		return invalidArgumentsErr(fmt.Errorf("revisions: expecting exactly one path or id to act on"))
+	}
+	f, err := g.revisionsSource(g.opts.Sources[0], byId)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case download != "":
+		return g.downloadRevision(f, download, os.Stdout)
+	case pin != "":
+		return g.pinRevision(f, pin, true)
+	default:
+		return g.pinRevision(f, unpin, false)
+	}
+}
+
+func (g *Commands) revisionsMeta(key string) string {
+	if g.opts.Meta == nil {
+		return ""
+	}
+	values := (*g.opts.Meta)[key]
+	if len(values) < 1 {
+		return ""
+	}
+	return values[0]
+}
+
+func (g *Commands) revisionsSource(source string, byId bool) (*File, error) {
+	var f *File
+	var err error
+	if byId {
+		f, err = g.rem.FindById(source)
+	} else {
+		f, err = g.rem.FindByPath(source)
+	}
+	if err == ErrPathNotExists {
+		return nil, nonExistantRemoteErr(fmt.Errorf("%s: %v", customQuote(source), err))
+	}
+	if err != nil {
+		return nil, remoteLookupErr(fmt.Errorf("%s: %v", source, err))
+	}
+	if f == nil {
+		return nil, nonExistantRemoteErr(fmt.Errorf("%s: %v", customQuote(source), ErrPathNotExists))
+	}
+	if f.IsDir {
+		return nil, invalidArgumentsErr(fmt.Errorf("%s: folders have no revisions", customQuote(source)))
+	}
+	return f, nil
+}
+
+func (g *Commands) listRevisions(source string, f *File) error {
+	revs, err := g.rem.listRevisions(f.Id)
+	if err != nil {
+		return err
+	}
+
+	if len(g.opts.Sources) > 1 {
+		g.log.Logf("\n%s\n", source)
+	}
+	g.log.Logf("%-44s %-24s %-10s %-26s %s\n", "Id", "Modifier", "Size", "Modified", "KeepForever")
+	for _, rev := range revs {
+		if rev == nil {
+			continue
+		}
+		size := "-"
+		if rev.DownloadUrl != "" {
+			size = prettyBytes(rev.FileSize)
+		}
+		modifier := rev.LastModifyingUserName
+		if modifier == "" {
+			modifier = "-"
+		}
+		g.log.Logf("%-44s %-24s %-10s %-26s %v\n", rev.Id, modifier, size, rev.ModifiedDate, rev.Pinned)
+	}
+	return nil
+}
+
+// downloadRevision writes the content of revision revId of f to w.
+// Google Docs revisions only have export links so they can't be fetched this way.
+func (g *Commands) downloadRevision(f *File, revId string, w io.Writer) error {
+	rev, err := g.rem.findRevision(f.Id, revId)
+	if err != nil {
+		return remoteLookupErr(fmt.Errorf("revision %s: %v", revId, err))
+	}
+	if rev.DownloadUrl == "" {
+		return googleDocNonExportErr(fmt.Errorf("revision %s of %s has no raw content to download", revId, customQuote(f.Name)))
+	}
+
+	g.rem.decrypter = g.opts.Decrypter
+	body, err := g.rem.Download(f.Id, rev.DownloadUrl)
+	if err != nil {
+		return err
+	}
+	if body == nil {
+		return downloadFailedErr(fmt.Errorf("revision %s: empty body", revId))
+	}
+	defer body.Close()
+
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// pinRevision sets whether revision revId of f is kept forever, instead
+// of being purged 30 days after it is superseded.
+func (g *Commands) pinRevision(f *File, revId string, pinned bool) error {
+	rev, err := g.rem.patchRevision(f.Id, revId, pinned)
+	if err != nil {
+		return err
+	}
+	g.log.Logf("%s revision %s keepForever: %v\n", customQuote(f.Name), rev.Id, rev.Pinned)
+	return nil
+}
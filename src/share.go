@@ -19,7 +19,9 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/odeke-em/drive/config"
 	"github.com/odeke-em/log"
+	drive "google.golang.org/api/drive/v2"
 )
 
 type AccountType int
@@ -40,12 +42,17 @@ const (
 	Reader
 	Writer
 	Commenter
+	// Organizer and FileOrganizer are the manager and content manager roles of Shared Drives.
+	Organizer
+	FileOrganizer
 )
 
 const (
 	NoopOnShare = 1 << iota
 	Notify
 	WithLink
+	// DryRun prints the permission API calls that would be made instead of making them.
+	DryRun
 )
 
 type shareChange struct {
@@ -59,6 +66,8 @@ type shareChange struct {
 	// withLink turns off public file indexing so that
 	// the file will only be shared to those with the link
 	withLink bool
+	// paths are the remote paths of files keyed by their ids.
+	paths map[string]string
 }
 
 type permission struct {
@@ -82,6 +91,10 @@ func (r *Role) String() string {
 		return "writer"
 	case Commenter:
 		return "commenter"
+	case Organizer:
+		return "organizer"
+	case FileOrganizer:
+		return "fileOrganizer"
 	}
 	return "unknown"
 }
@@ -108,68 +121,196 @@ func unknownAccountType(a string) bool {
 	return strings.ToLower(a) == "unknown"
 }
 
-func stringToRole() func(string) Role {
-	roleMap := make(map[string]Role)
-	roles := []Role{UnknownRole, Owner, Reader, Writer, Commenter}
-	for _, role := range roles {
-		roleMap[role.String()] = role
-	}
-	return func(s string) Role {
-		r, ok := roleMap[strings.ToLower(s)]
-		if !ok {
-			return Reader
+var shareRoles = []Role{Owner, Organizer, FileOrganizer, Writer, Commenter, Reader}
+var shareAccountTypes = []AccountType{User, Group, Domain, Anyone}
+
+// roleAliases are the other names that roles are commonly asked for by,
+// keyed by their normalizedShareName.
+var roleAliases = map[string]Role{
+	"read":           Reader,
+	"view":           Reader,
+	"viewer":         Reader,
+	"write":          Writer,
+	"edit":           Writer,
+	"editor":         Writer,
+	"comment":        Commenter,
+	"manager":        Organizer,
+	"contentmanager": FileOrganizer,
+}
+
+var accountTypeAliases = map[string]AccountType{
+	"email":    User,
+	"users":    User,
+	"groups":   Group,
+	"domains":  Domain,
+	"public":   Anyone,
+	"everyone": Anyone,
+}
+
+// normalizedShareName folds the case and separators of role and
+// account type names, so that "File-Organizer" is "fileorganizer".
+func normalizedShareName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == ' ' {
+			return -1
 		}
 		return r
-	}
+	}, strings.ToLower(strings.TrimSpace(s)))
 }
 
-func stringToAccountType() func(string) AccountType {
-	accountMap := make(map[string]AccountType)
-	accounts := []AccountType{UnknownAccountType, Anyone, User, Domain, Group}
-	for _, account := range accounts {
-		accountMap[account.String()] = account
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
 	}
-	return func(s string) AccountType {
-		a, ok := accountMap[strings.ToLower(s)]
-		if !ok {
-			return User
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
 		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
 		return a
 	}
+	return b
 }
 
-var reverseRoleResolve = stringToRole()
-var reverseAccountTypeResolve = stringToAccountType()
+// suggestion is the closest of the candidates to s, if any is close enough
+// to likely be what was meant.
+func suggestion(s string, candidates []string) string {
+	best, bestDist := "", 3
+	for _, candidate := range candidates {
+		if dist := editDistance(normalizedShareName(s), normalizedShareName(candidate)); dist < bestDist {
+			best, bestDist = candidate, dist
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean %q?", best)
+}
+
+func normalizeRole(s string) (Role, error) {
+	key := normalizedShareName(s)
+	var names []string
+	for _, role := range shareRoles {
+		role := role
+		if key == normalizedShareName(role.String()) {
+			return role, nil
+		}
+		names = append(names, role.String())
+	}
+	if role, ok := roleAliases[key]; ok {
+		return role, nil
+	}
+	return UnknownRole, invalidArgumentsErr(fmt.Errorf("unknown role %q%s, expecting one of %s", s, suggestion(s, names), strings.Join(names, ", ")))
+}
 
-func reverseRolesResolver(roleArgv ...string) (roles []Role) {
+func normalizeAccountType(s string) (AccountType, error) {
+	key := normalizedShareName(s)
+	var names []string
+	for _, accountType := range shareAccountTypes {
+		accountType := accountType
+		if key == accountType.String() {
+			return accountType, nil
+		}
+		names = append(names, accountType.String())
+	}
+	if accountType, ok := accountTypeAliases[key]; ok {
+		return accountType, nil
+	}
+	return UnknownAccountType, invalidArgumentsErr(fmt.Errorf("unknown account type %q%s, expecting one of %s", s, suggestion(s, names), strings.Join(names, ", ")))
+}
+
+// reverseRoleResolve is for roles that were already accepted by the API,
+// such as those recorded in the history, falling back to reader.
+func reverseRoleResolve(s string) Role {
+	role, err := normalizeRole(s)
+	if err != nil {
+		return Reader
+	}
+	return role
+}
+
+func reverseAccountTypeResolve(s string) AccountType {
+	accountType, err := normalizeAccountType(s)
+	if err != nil {
+		return User
+	}
+	return accountType
+}
+
+func reverseRolesResolver(roleArgv ...string) (roles []Role, err error) {
 	alreadySeen := map[Role]bool{}
 	for _, roleStr := range roleArgv {
-		role := reverseRoleResolve(roleStr)
+		role, err := normalizeRole(roleStr)
+		if err != nil {
+			return nil, err
+		}
 		if _, seen := alreadySeen[role]; !seen {
 			roles = append(roles, role)
 			alreadySeen[role] = true
 		}
 	}
 
-	return roles
+	return roles, nil
 }
 
-func reverseAccountTypesResolver(accArgv ...string) (accountTypes []AccountType) {
+func reverseAccountTypesResolver(accArgv ...string) (accountTypes []AccountType, err error) {
+	alreadySeen := map[AccountType]bool{}
 	for _, accStr := range accArgv {
-		accountTypes = append(accountTypes, reverseAccountTypeResolve(accStr))
+		accountType, err := normalizeAccountType(accStr)
+		if err != nil {
+			return nil, err
+		}
+		if _, seen := alreadySeen[accountType]; !seen {
+			accountTypes = append(accountTypes, accountType)
+			alreadySeen[accountType] = true
+		}
 	}
 
-	return accountTypes
+	return accountTypes, nil
 }
 
-func (g *Commands) resolveRemotePaths(relToRootPaths []string, byId bool) (files []*File) {
+// validateShareGrants rejects the role and account type pairs that
+// the API would refuse, before any file is shared.
+func validateShareGrants(roles []Role, accountTypes []AccountType) error {
+	for _, accountType := range accountTypes {
+		for _, role := range roles {
+			switch {
+			case role == Owner && accountType != User:
+				return invalidArgumentsErr(fmt.Errorf("only a user can be made an owner, not %q", accountType.String()))
+			case (role == Organizer || role == FileOrganizer) && accountType == Anyone:
+				return invalidArgumentsErr(fmt.Errorf("%q is only for members of a Shared Drive and cannot be granted to anyone", role.String()))
+			}
+		}
+	}
+	return nil
+}
+
+// resolveRemotePaths returns the remote files at relToRootPaths, or by their
+// ids if byId is set, along with their remote paths keyed by their ids.
+func (g *Commands) resolveRemotePaths(relToRootPaths []string, byId bool) (files []*File, paths map[string]string) {
 	var wg sync.WaitGroup
+	var mu sync.Mutex
 
 	resolver := g.rem.FindByPath
 	if byId {
 		resolver = g.rem.FindById
 	}
 
+	paths = make(map[string]string)
+
 	wg.Add(len(relToRootPaths))
 	for _, relToRoot := range relToRootPaths {
 		go func(p string, wgg *sync.WaitGroup) {
@@ -178,11 +319,23 @@ func (g *Commands) resolveRemotePaths(relToRootPaths []string, byId bool) (files
 			if err != nil || file == nil {
 				return
 			}
+
+			remotePath := p
+			if byId {
+				remotePath = file.Name
+				if backPaths, _ := g.rem.FindBackPaths(file.Id); len(backPaths) >= 1 {
+					remotePath = backPaths[0]
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
 			files = append(files, file)
+			paths[file.Id] = remotePathJoin(remotePath)
 		}(relToRoot, &wg)
 	}
 	wg.Wait()
-	return files
+	return files, paths
 }
 
 func emailsToIds(g *Commands, emails []string) map[string]string {
@@ -268,24 +421,64 @@ func showPromptShareChanges(logy *log.Logger, change *shareChange) Agreement {
 	return promptForChanges()
 }
 
+// pathOf returns the remote path of file, falling back to its name.
+func (change *shareChange) pathOf(file *File) string {
+	if p, ok := change.paths[file.Id]; ok {
+		return p
+	}
+	return file.Name
+}
+
 func (c *Commands) playShareChanges(change *shareChange) (err error) {
-	if c.opts.canPrompt() {
+	dryRun := (c.opts.TypeMask & DryRun) != 0
+	if !dryRun && c.opts.canPrompt() {
 		if status := showPromptShareChanges(c.log, change); !accepted(status) {
 			return status.Error()
 		}
 	}
 
 	fnName := "unshare"
-	fn := c.rem.revokePermissions
+	fn := func(file *File, perm *permission) error {
+		revoked, err := c.rem.revokePermissions(perm)
+		for _, rperm := range revoked {
+			c.recordHistory(&config.HistoryEntry{
+				Op:     HistoryOpUnshare,
+				FileId: file.Id, Path: change.pathOf(file),
+				PermissionId: rperm.Id,
+				Role:         rperm.Role,
+				AccountType:  rperm.Type,
+				Value:        permissionValue(rperm),
+				WithLink:     rperm.WithLink,
+			})
+		}
+		return err
+	}
 
 	if !change.revoke {
 		fnName = "share"
-		fn = func(perm *permission) error {
-			_, err := c.rem.insertPermissions(perm)
+		fn = func(file *File, perm *permission) error {
+			inserted, err := c.rem.insertPermissions(perm)
+			if err == nil && inserted != nil {
+				c.recordHistory(&config.HistoryEntry{
+					Op:     HistoryOpShare,
+					FileId: file.Id, Path: change.pathOf(file),
+					PermissionId: inserted.Id,
+					Role:         inserted.Role,
+					AccountType:  inserted.Type,
+					Value:        perm.value,
+				})
+			}
 			return err
 		}
 	}
 
+	if dryRun {
+		fnName = "dry-run " + fnName
+		fn = func(file *File, perm *permission) error {
+			return c.dryRunShare(file, perm, change.revoke)
+		}
+	}
+
 	successes := 0
 
 	for _, file := range change.files {
@@ -309,7 +502,7 @@ func (c *Commands) playShareChanges(change *shareChange) (err error) {
 						withLink: change.withLink,
 					}
 
-					if ferr := fn(&perm); ferr != nil {
+					if ferr := fn(file, &perm); ferr != nil {
 						err = reComposeError(err, fmt.Sprintf("%s err %s: %v\n", fnName, file.Name, ferr))
 					} else {
 						successes += 1
@@ -334,8 +527,47 @@ func (c *Commands) playShareChanges(change *shareChange) (err error) {
 	return nil
 }
 
+// dryRunShare prints the permission API calls that sharing or
+// unsharing file with perm would make, without making them.
+func (c *Commands) dryRunShare(file *File, perm *permission, revoke bool) error {
+	if !revoke {
+		c.log.Logf("permissions.insert fileId=%s role=%s type=%s value=%q withLink=%v sendNotificationEmails=%v\t# %s\n",
+			file.Id, perm.role.String(), perm.accountType.String(), perm.value, perm.withLink, perm.notify, file.Name)
+		return nil
+	}
+
+	foundPermissionsChan, err := c.rem.findPermissions(perm)
+	if err != nil {
+		return err
+	}
+
+	found := 0
+	for foundPerm := range foundPermissionsChan {
+		if foundPerm == nil {
+			continue
+		}
+		found += 1
+		c.log.Logf("permissions.delete fileId=%s permissionId=%s\t# %s: %s\n",
+			file.Id, foundPerm.Id, file.Name, permissionLabel(foundPerm))
+	}
+
+	if found < 1 {
+		return noMatchesFoundErr(fmt.Errorf("no matches found!"))
+	}
+	return nil
+}
+
+// permissionValue returns the addressee that a permission
+// was granted to, as accepted by insertPermissions.
+func permissionValue(perm *drive.Permission) string {
+	if perm.EmailAddress != "" {
+		return perm.EmailAddress
+	}
+	return perm.Domain
+}
+
 func (c *Commands) share(revoke, byId bool) (err error) {
-	files := c.resolveRemotePaths(c.opts.Sources, byId)
+	files, paths := c.resolveRemotePaths(c.opts.Sources, byId)
 
 	var emails []string
 	var emailMessage string
@@ -369,12 +601,16 @@ func (c *Commands) share(revoke, byId bool) (err error) {
 
 		roleList, rOk := meta[RoleKey]
 		if rOk && len(roleList) >= 1 {
-			roles = reverseRolesResolver(roleList...)
+			if roles, err = reverseRolesResolver(roleList...); err != nil {
+				return err
+			}
 		}
 
 		accountTypeList, aOk := meta[AccountTypeKey]
 		if aOk && len(accountTypeList) >= 1 {
-			accountTypes = reverseAccountTypesResolver(accountTypeList...)
+			if accountTypes, err = reverseAccountTypesResolver(accountTypeList...); err != nil {
+				return err
+			}
 		}
 
 		emailMessageList, emOk := meta[EmailMessageKey]
@@ -383,6 +619,12 @@ func (c *Commands) share(revoke, byId bool) (err error) {
 		}
 	}
 
+	if !revoke {
+		if err := validateShareGrants(roles, accountTypes); err != nil {
+			return err
+		}
+	}
+
 	if revoke && len(emails) < 1 {
 		// Account for case for unshare where certain types do not include the emails
 		// e.g revoking access to the entire domain, or user groups yet no email
@@ -403,6 +645,7 @@ func (c *Commands) share(revoke, byId bool) (err error) {
 
 	change := shareChange{
 		files:  files,
+		paths:  paths,
 		revoke: revoke,
 		roles:  roles,
 		emails: emails,
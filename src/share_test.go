@@ -0,0 +1,62 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeRole(t *testing.T) {
+	cases := map[string]Role{
+		"fileOrganizer":   FileOrganizer,
+		"File-Organizer":  FileOrganizer,
+		"content_manager": FileOrganizer,
+		"ORGANIZER":       Organizer,
+		"viewer":          Reader,
+		" editor ":        Writer,
+		"commenter":       Commenter,
+	}
+	for s, expected := range cases {
+		if got, err := normalizeRole(s); err != nil || got != expected {
+			t.Errorf("%q: expected %v, got %v %v", s, expected.String(), got.String(), err)
+		}
+	}
+
+	_, err := normalizeRole("wrtier")
+	if err == nil || !strings.Contains(err.Error(), `did you mean "writer"`) {
+		t.Errorf("expected a suggestion, got %v", err)
+	}
+	if _, err := normalizeRole("superuser"); err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("expected an error without a suggestion, got %v", err)
+	}
+
+	if got, err := normalizeAccountType("Public"); err != nil || got != Anyone {
+		t.Errorf("expected anyone, got %v %v", got.String(), err)
+	}
+	if _, err := normalizeAccountType("gruop"); err == nil {
+		t.Errorf("expected an unknown account type to be rejected")
+	}
+
+	if err := validateShareGrants([]Role{FileOrganizer}, []AccountType{Anyone}); err == nil {
+		t.Errorf("expected fileOrganizer for anyone to be rejected")
+	}
+	if err := validateShareGrants([]Role{Owner}, []AccountType{Group}); err == nil {
+		t.Errorf("expected a group owner to be rejected")
+	}
+	if err := validateShareGrants([]Role{Reader, FileOrganizer}, []AccountType{User, Group}); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+// permissionGrant is an entry of a permissions manifest, granting role to
+// value at path within a new Shared Drive, the drive itself being "/".
+type permissionGrant struct {
+	path        string
+	accountType AccountType
+	value       string
+	role        Role
+}
+
+// parsePermissionsManifest reads "type,value,role[,path]" grants, one per
+// line, with blank lines and lines starting with # skipped.
+func parsePermissionsManifest(r io.Reader) ([]*permissionGrant, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var grants []*permissionGrant
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return grants, nil
+		}
+		if err != nil {
+			return nil, invalidArgumentsErr(err)
+		}
+
+		n := len(grants) + 1
+		if len(record) != 3 && len(record) != 4 {
+			return nil, invalidArgumentsErr(fmt.Errorf("grant #%d: expecting type,value,role[,path], got %q", n, record))
+		}
+		accountType, err := normalizeAccountType(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, invalidArgumentsErr(fmt.Errorf("grant #%d: %v", n, err))
+		}
+		role, err := normalizeRole(strings.TrimSpace(record[2]))
+		if err != nil {
+			return nil, invalidArgumentsErr(fmt.Errorf("grant #%d: %v", n, err))
+		}
+		if role == Owner {
+			return nil, invalidArgumentsErr(fmt.Errorf("grant #%d: files in Shared Drives have no owner, use \"organizer\" instead", n))
+		}
+		if err := validateShareGrants([]Role{role}, []AccountType{accountType}); err != nil {
+			return nil, invalidArgumentsErr(fmt.Errorf("grant #%d: %v", n, err))
+		}
+
+		grant := &permissionGrant{accountType: accountType, value: strings.TrimSpace(record[1]), role: role, path: "/"}
+		if accountType != Anyone && grant.value == "" {
+			return nil, invalidArgumentsErr(fmt.Errorf("grant #%d: %s grants need an email address or domain", n, accountType.String()))
+		}
+		if len(record) == 4 && strings.TrimSpace(record[3]) != "" {
+			grant.path = remotePathJoin("/", filepath.ToSlash(strings.TrimSpace(record[3])))
+		}
+		grants = append(grants, grant)
+	}
+}
+
+func readPermissionsManifest(p string) ([]*permissionGrant, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	grants, err := parsePermissionsManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", p, err)
+	}
+	return grants, nil
+}
+
+func sharedDriveRequestId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateSharedDrive creates a Shared Drive called name, uploads the folders
+// and files of templateDir into it if set and then applies the grants of
+// the permissions manifest at permsPath if set. The manifest is checked
+// against the template before anything is created.
+func (g *Commands) CreateSharedDrive(name, templateDir, permsPath string) error {
+	if strings.TrimSpace(name) == "" {
+		return invalidArgumentsErr(fmt.Errorf("%s: expecting the name of the Shared Drive", SharedDriveKey))
+	}
+
+	var grants []*permissionGrant
+	if permsPath != "" {
+		var err error
+		if grants, err = readPermissionsManifest(permsPath); err != nil {
+			return err
+		}
+	}
+
+	var template []*File
+	if templateDir != "" {
+		var err error
+		if template, err = g.templateFiles(templateDir); err != nil {
+			return err
+		}
+	}
+
+	paths := map[string]bool{"/": true}
+	for _, f := range template {
+		paths[templatePath(templateDir, f)] = true
+	}
+	for _, grant := range grants {
+		if !paths[grant.path] {
+			return invalidArgumentsErr(fmt.Errorf("%s: %s isn't in the template", permsPath, grant.path))
+		}
+	}
+
+	requestId, err := sharedDriveRequestId()
+	if err != nil {
+		return err
+	}
+	created, err := g.rem.createSharedDrive(requestId, name)
+	if err != nil {
+		return err
+	}
+	g.log.Logf("Created Shared Drive %q %s\n", created.Name, created.Id)
+
+	// Failures from here on leave the Shared Drive as far as it got.
+	ids := map[string]string{"/": created.Id}
+	for _, f := range template {
+		p := templatePath(templateDir, f)
+		parentId := ids[remoteParentPath(p)]
+
+		uploaded, uErr := g.uploadToSharedDrive(parentId, f)
+		if uErr != nil {
+			return fmt.Errorf("%s: %s: %v", created.Id, p, uErr)
+		}
+		ids[p] = uploaded.Id
+		if g.opts.Verbose {
+			g.log.Logf("+ %s\n", p)
+		}
+	}
+	if len(template) >= 1 {
+		g.log.Logf("Uploaded %d folders and files from %s\n", len(template), templateDir)
+	}
+
+	for _, grant := range grants {
+		perm := &drive.Permission{
+			Type:  grant.accountType.String(),
+			Value: grant.value,
+		}
+		perm.Role, perm.AdditionalRoles = apiRoles(grant.role)
+		if _, pErr := g.rem.grantSharedDrivePermission(ids[grant.path], perm, (g.opts.TypeMask&Notify) == Notify); pErr != nil {
+			return fmt.Errorf("%s: granting %s %s to %s %q: %v", created.Id, grant.path, grant.role.String(), grant.accountType.String(), grant.value, pErr)
+		}
+		g.log.Logf("%s: %s %q is now %s\n", grant.path, grant.accountType.String(), grant.value, grant.role.String())
+	}
+	return nil
+}
+
+// templateFiles lists the folders and files of templateDir, parents first,
+// skipping hidden and ignored paths as a push would.
+func (g *Commands) templateFiles(templateDir string) (files []*File, err error) {
+	info, err := os.Stat(templateDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, invalidArgumentsErr(fmt.Errorf("%s: the template must be a folder", templateDir))
+	}
+
+	err = filepath.Walk(templateDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || p == templateDir {
+			return err
+		}
+
+		skip := isHidden(info.Name(), g.opts.Hidden) || anyMatch(g.opts.Ignorer, info.Name())
+		if skip && info.IsDir() {
+			return filepath.SkipDir
+		}
+		if skip || (!info.IsDir() && !info.Mode().IsRegular()) {
+			return nil
+		}
+		files = append(files, NewLocalFile(p, info))
+		return nil
+	})
+	return files, err
+}
+
+func templatePath(templateDir string, f *File) string {
+	rel, _ := filepath.Rel(templateDir, f.BlobAt)
+	return remotePathJoin("/", filepath.ToSlash(rel))
+}
+
+func remoteParentPath(p string) string {
+	parent := p[:strings.LastIndex(p, "/")+1]
+	if parent != "/" {
+		parent = strings.TrimSuffix(parent, "/")
+	}
+	return parent
+}
+
+func (g *Commands) uploadToSharedDrive(parentId string, f *File) (*File, error) {
+	if f.IsDir {
+		return g.rem.insertIntoSharedDrive(parentId, f.Name, DriveFolderMimeType, nil)
+	}
+
+	body, err := os.Open(f.BlobAt)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return g.rem.insertIntoSharedDrive(parentId, f.Name, "", body)
+}
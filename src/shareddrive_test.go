@@ -0,0 +1,70 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePermissionsManifest(t *testing.T) {
+	manifest := `# type,value,role[,path]
+group,apollo@example.com,fileOrganizer
+
+user, lead@example.com, manager
+domain,example.com,reader,Handbook/
+anyone,,reader,/Public
+`
+	grants, err := parsePermissionsManifest(strings.NewReader(manifest))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []*permissionGrant{
+		{path: "/", accountType: Group, value: "apollo@example.com", role: FileOrganizer},
+		{path: "/", accountType: User, value: "lead@example.com", role: Organizer},
+		{path: "/Handbook", accountType: Domain, value: "example.com", role: Reader},
+		{path: "/Public", accountType: Anyone, role: Reader},
+	}
+	if !reflect.DeepEqual(grants, expected) {
+		for i, grant := range grants {
+			t.Logf("#%d: %+v", i, grant)
+		}
+		t.Fatalf("unexpected grants")
+	}
+
+	invalid := []string{
+		"user,lead@example.com",
+		"user,lead@example.com,owner",
+		"anyone,,organizer",
+		"user,,reader",
+		"robot,r2@example.com,reader",
+		"user,lead@example.com,reader,/a,extra",
+	}
+	for _, line := range invalid {
+		if _, err := parsePermissionsManifest(strings.NewReader(line)); err == nil {
+			t.Errorf("%q: expected an error", line)
+		}
+	}
+}
+
+func TestRemoteParentPath(t *testing.T) {
+	for p, want := range map[string]string{"/a": "/", "/a/b": "/a", "/a/b/c.txt": "/a/b"} {
+		if got := remoteParentPath(p); got != want {
+			t.Errorf("%q: got %q want %q", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// PartialDownloadSuffix is appended to the names of files being
+// downloaded, which are only moved into place once complete.
+const PartialDownloadSuffix = ".drive-partial"
+
+// partialDownloads tracks the files being downloaded, to be
+// removed if a run is aborted midway.
+type partialDownloads struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+func (pd *partialDownloads) add(p string) {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	if pd.paths == nil {
+		pd.paths = make(map[string]bool)
+	}
+	pd.paths[p] = true
+}
+
+func (pd *partialDownloads) remove(p string) {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	delete(pd.paths, p)
+}
+
+// removeAll removes the partial downloads that can't be resumed,
+// checkpointing the others for the next pull to resume them.
+func (pd *partialDownloads) removeAll() {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	for p := range pd.paths {
+		if !checkpointPartial(p) {
+			os.Remove(p)
+		}
+	}
+	pd.paths = nil
+}
+
+// handleInterrupts makes a first SIGINT or SIGTERM stop new changes from
+// being started, letting those in flight finish and be indexed, and a
+// second one abort right away, running abort if set after removing the
+// partial downloads that can't be resumed. The returned func restores
+// the default handling.
+func (g *Commands) handleInterrupts(abort func()) (stop func()) {
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	done := make(chan bool)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-c:
+			}
+
+			if atomic.AddInt32(&g.interrupts, 1) == 1 {
+				g.log.LogErrln("\nInterrupted, finishing the changes in flight. Interrupt again to abort")
+				continue
+			}
+
+			g.partials.removeAll()
+			if abort != nil {
+				abort()
+			}
+			os.Exit(int(StatusInterrupted))
+		}
+	}()
+
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+func (g *Commands) interrupted() bool {
+	return atomic.LoadInt32(&g.interrupts) >= 1
+}
+
+// skipUnstarted records that n changes were left unstarted.
+func (g *Commands) skipUnstarted(n int) {
+	atomic.AddInt64(&g.unstarted, int64(n))
+}
+
+// interruptionErr sums up an interrupted run of total changes, and how
+// to resume it, nil if the run wasn't interrupted.
+func (g *Commands) interruptionErr(verb string, total int) error {
+	if !g.interrupted() {
+		return nil
+	}
+	left := atomic.LoadInt64(&g.unstarted)
+	return interruptedErr(fmt.Errorf("%s interrupted: %d of %d changes played, %d left. Run it again to resume",
+		verb, int64(total)-left, total, left))
+}
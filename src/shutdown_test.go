@@ -0,0 +1,56 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPartialDownloads(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-partials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var pd partialDownloads
+	kept, dropped := filepath.Join(dir, "kept"+PartialDownloadSuffix), filepath.Join(dir, "dropped"+PartialDownloadSuffix)
+	for _, p := range []string{kept, dropped} {
+		if err := ioutil.WriteFile(p, []byte("partial"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		pd.add(p)
+	}
+	pd.remove(kept)
+	pd.removeAll()
+
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("expected %s to be kept: %v", kept, err)
+	}
+	if _, err := os.Stat(dropped); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got %v", dropped, err)
+	}
+
+	ignorer, err := combineIgnores(filepath.Join(dir, DriveIgnoreSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ignorer("video.mkv"+PartialDownloadSuffix) || ignorer("video.mkv") {
+		t.Errorf("expected only partial downloads to be ignored")
+	}
+}
@@ -15,8 +15,13 @@
 package drive
 
 import (
+	"os"
+	"path"
 	"sort"
 	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
 )
 
 const (
@@ -29,6 +34,8 @@ const (
 	AttrMd5Checksum
 	AttrMimeType
 	AttrName
+	AttrExtension
+	AttrDepth
 )
 
 type attr int
@@ -150,6 +157,25 @@ func (fl sizeFlist) Swap(i, j int) {
 	fl[i], fl[j] = fl[j], fl[i]
 }
 
+// lessFlist sorts by any comparison, for sort keys with modifiers
+// and those that don't need a type of their own.
+type lessFlist struct {
+	fl   fileList
+	less func(*File, *File) bool
+}
+
+func (fl lessFlist) Less(i, j int) bool {
+	return fl.less(fl.fl[i], fl.fl[j])
+}
+
+func (fl lessFlist) Len() int {
+	return len(fl.fl)
+}
+
+func (fl lessFlist) Swap(i, j int) {
+	fl.fl[i], fl.fl[j] = fl.fl[j], fl.fl[i]
+}
+
 func attrAtoiSorter(a string, fl []*File) (attr, sort.Interface, bool) {
 	aLower := strings.ToLower(a)
 	if len(aLower) < 1 {
@@ -158,11 +184,30 @@ func attrAtoiSorter(a string, fl []*File) (attr, sort.Interface, bool) {
 
 	reverse := hasAnySuffix(aLower, "_r", "-")
 
-	if hasAnyPrefix(aLower, Md5Key) {
-		return AttrMd5Checksum, md5Flist(fl), reverse
+	// Modifiers follow the key e.g name:ci or name:locale:ci
+	modifiers := strings.Split(aLower, ":")
+	aLower, modifiers = modifiers[0], modifiers[1:]
+	if n := len(modifiers); n >= 1 {
+		modifiers[n-1] = strings.TrimSuffix(strings.TrimSuffix(modifiers[n-1], "_r"), "-")
 	}
+
 	if hasAnyPrefix(aLower, NameKey) {
-		return AttrName, nameFlist(fl), reverse
+		if len(modifiers) < 1 {
+			return AttrName, nameFlist(fl), reverse
+		}
+		less := nameCmpLessWith(modifiers)
+		if less == nil {
+			return AttrUnknown, nil, false
+		}
+		return AttrName, lessFlist{fl: fl, less: less}, reverse
+	}
+	if len(modifiers) >= 1 {
+		// Only names can be collated.
+		return AttrUnknown, nil, false
+	}
+
+	if hasAnyPrefix(aLower, Md5Key) {
+		return AttrMd5Checksum, md5Flist(fl), reverse
 	}
 	if hasAnyPrefix(aLower, SizeKey) {
 		return AttrSize, sizeFlist(fl), reverse
@@ -179,10 +224,103 @@ func attrAtoiSorter(a string, fl []*File) (attr, sort.Interface, bool) {
 	if hasAnyPrefix(aLower, VersionKey) {
 		return AttrVersion, versionFlist(fl), reverse
 	}
+	if hasAnyPrefix(aLower, ExtensionKey) {
+		return AttrExtension, lessFlist{fl: fl, less: extensionCmpLess}, reverse
+	}
+	if hasAnyPrefix(aLower, DepthKey) {
+		return AttrDepth, lessFlist{fl: fl, less: depthCmpLess}, reverse
+	}
 
 	return AttrUnknown, nil, false
 }
 
+// nameCmpLessWith compares names as modified by "ci" to ignore case and
+// "locale" to collate them as in the user's locale, like file managers do.
+// It returns nil if any of the modifiers is unknown.
+func nameCmpLessWith(modifiers []string) func(*File, *File) bool {
+	ignoreCase, collated := false, false
+	for _, modifier := range modifiers {
+		switch modifier {
+		case "ci":
+			ignoreCase = true
+		case "locale":
+			collated = true
+		default:
+			return nil
+		}
+	}
+
+	if collated {
+		var options []collate.Option
+		if ignoreCase {
+			options = append(options, collate.IgnoreCase)
+		}
+		collator := collate.New(userLocale(), options...)
+		return nilCmpOrProceed(func(l, r *File) bool { return collator.CompareString(l.Name, r.Name) < 0 })
+	}
+	return nilCmpOrProceed(func(l, r *File) bool { return strings.ToLower(l.Name) < strings.ToLower(r.Name) })
+}
+
+// userLocale is the collation locale of the environment as POSIX systems
+// set it e.g en_US.UTF-8, falling back to the root collation.
+func userLocale() language.Tag {
+	for _, key := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		if i := strings.IndexAny(value, ".@"); i >= 0 {
+			value = value[:i]
+		}
+		tag, err := language.Parse(strings.Replace(value, "_", "-", -1))
+		if err != nil || value == "C" || value == "POSIX" {
+			return language.Und
+		}
+		return tag
+	}
+	return language.Und
+}
+
+var (
+	extensionCmpLess = nilCmpOrProceed(func(l, r *File) bool {
+		return strings.ToLower(path.Ext(l.Name)) < strings.ToLower(path.Ext(r.Name))
+	})
+	depthCmpLess = nilCmpOrProceed(func(l, r *File) bool {
+		return strings.Count(l.relPath, "/") < strings.Count(r.relPath, "/")
+	})
+)
+
+// orderByFields are the API's equivalents of the sort
+// attributes that a listing can be ordered by server side.
+var orderByFields = map[attr]string{
+	AttrName:               "title",
+	AttrModTime:            "modifiedDate",
+	AttrLastViewedByMeTime: "lastViewedByMeDate",
+}
+
+// orderByClause translates sort keys into the orderBy parameter of
+// a listing, or returns "" if any of them can only be sorted locally.
+func orderByClause(sortKeys []string) string {
+	var fields []string
+	// Each sort key is applied in turn so the last one is the most significant.
+	for i := len(sortKeys) - 1; i >= 0; i-- {
+		if strings.Contains(sortKeys[i], ":") {
+			// Drive orders titles by neither locale nor case.
+			return ""
+		}
+		attrEnum, _, reverse := attrAtoiSorter(sortKeys[i], nil)
+		field, ok := orderByFields[attrEnum]
+		if !ok {
+			return ""
+		}
+		if reverse {
+			field += " desc"
+		}
+		fields = append(fields, field)
+	}
+	return strings.Join(fields, ",")
+}
+
 func nilCmpOrProceed(fallback func(*File, *File) bool) func(*File, *File) bool {
 	return func(l, r *File) bool {
 		if l == nil {
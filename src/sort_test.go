@@ -0,0 +1,84 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrderByClause(t *testing.T) {
+	samples := []struct {
+		sortKeys []string
+		want     string
+	}{
+		{sortKeys: []string{"name"}, want: "title"},
+		{sortKeys: []string{"modt_r"}, want: "modifiedDate desc"},
+		{sortKeys: []string{"name", "modt-"}, want: "modifiedDate desc,title"},
+		{sortKeys: []string{"lvt", "name_r"}, want: "title desc,lastViewedByMeDate"},
+		{sortKeys: []string{"name", "size"}, want: ""},
+		{sortKeys: []string{"md5"}, want: ""},
+		{sortKeys: []string{"name:ci"}, want: ""},
+		{sortKeys: []string{"unknown"}, want: ""},
+		{sortKeys: nil, want: ""},
+	}
+
+	for i, sample := range samples {
+		if got := orderByClause(sample.sortKeys); got != sample.want {
+			t.Errorf("#%d: %v got %q want %q", i, sample.sortKeys, got, sample.want)
+		}
+	}
+}
+
+func TestSortKeyModifiers(t *testing.T) {
+	files := []*File{
+		{Name: "b.TXT", relPath: "/docs/old/b.TXT"},
+		{Name: "C.go", relPath: "/C.go"},
+		{Name: "a.md", relPath: "/docs/a.md"},
+	}
+	names := func(fl []*File) string {
+		var ordered []string
+		for _, f := range fl {
+			ordered = append(ordered, f.Name)
+		}
+		return strings.Join(ordered, ",")
+	}
+
+	g := &Commands{}
+	samples := []struct {
+		sortKey string
+		want    string
+	}{
+		{sortKey: "name", want: "C.go,a.md,b.TXT"},
+		{sortKey: "name:ci", want: "a.md,b.TXT,C.go"},
+		{sortKey: "name:ci_r", want: "C.go,b.TXT,a.md"},
+		{sortKey: "ext", want: "C.go,a.md,b.TXT"},
+		{sortKey: "depth", want: "C.go,a.md,b.TXT"},
+		{sortKey: "depth-", want: "b.TXT,a.md,C.go"},
+	}
+	for _, sample := range samples {
+		fl := append([]*File{}, files...)
+		if got := names(g.sort(fl, sample.sortKey)); got != sample.want {
+			t.Errorf("%s: got %q want %q", sample.sortKey, got, sample.want)
+		}
+	}
+
+	if attrEnum, _, _ := attrAtoiSorter("size:ci", nil); attrEnum != AttrUnknown {
+		t.Errorf("expected only names to take modifiers")
+	}
+	if attrEnum, _, _ := attrAtoiSorter("name:bogus", nil); attrEnum != AttrUnknown {
+		t.Errorf("expected unknown modifiers to be rejected")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"path"
+)
+
+// ListStarred lists the starred files and folders across the whole drive
+// regardless of the path, each under every path that leads to it. Starred
+// folders aren't descended into since whatever is starred within them
+// is listed by itself.
+func (g *Commands) ListStarred() error {
+	if err := g.prepareListFormat(); err != nil {
+		return err
+	}
+	if err := g.checkStreamable(); err != nil {
+		return err
+	}
+	defer g.pageOutput()()
+
+	mask := g.opts.TypeMask | Starred
+	opt := g.listingAttribute(mask)
+
+	req := g.rem.service.Files.List()
+	req.Q(buildExpression("", mask, g.opts.InTrash))
+	if pageSize := g.pageSize(); pageSize >= 1 {
+		req.MaxResults(pageSize)
+	}
+	sortKeys := sorters(g.opts)
+	orderBy := orderByClause(sortKeys)
+	if orderBy != "" {
+		req.OrderBy(orderBy)
+	}
+	// Starred files come from all over so sorting them e.g by depth
+	// means that they are only printed once all have been found.
+	buffered := len(sortKeys) >= 1 && orderBy == ""
+	if len(opt.fields) >= 1 {
+		req.Fields(listFieldsSelector(opt.fields))
+	}
+
+	spin := g.playabler()
+	spin.play()
+	defer spin.stop()
+
+	onlyFiles := nonFolderExplicitly(mask)
+
+	var collector []*File
+	printAtPath := func(f *File) bool {
+		if !g.takeResult() {
+			return false
+		}
+		opt.parent = path.Dir(f.relPath)
+		if rootLike(opt.parent) {
+			opt.parent = ""
+		}
+		f.pretty(g.log, opt)
+		return true
+	}
+
+	pagePair := reqDoPage(req, g.opts.Hidden, false)
+	errsChan := pagePair.errsChan
+	filesChan := pagePair.filesChan
+
+	working := true
+	for working {
+		select {
+		case err := <-errsChan:
+			if err != nil {
+				return err
+			}
+		case f, stillHasContent := <-filesChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if f == nil || (onlyFiles && f.IsDir) || !g.opts.admits(f) {
+				continue
+			}
+
+			backPaths, err := g.rem.FindBackPaths(f.Id)
+			if err != nil {
+				g.log.LogErrf("%s: %v\n", f.Name, err)
+				continue
+			}
+
+			g.fetchPermissions(f, mask)
+			g.fetchLabels(f, mask)
+			g.resolveShortcut(f, mask)
+			for _, backPath := range backPaths {
+				// Each path gets its own copy, to be sorted by its own depth.
+				fAtPath := *f
+				fAtPath.relPath = path.Join("/", backPath)
+				if buffered {
+					collector = append(collector, &fAtPath)
+				} else if !printAtPath(&fAtPath) {
+					return nil
+				}
+			}
+		}
+	}
+
+	for _, f := range g.sort(collector, sortKeys...) {
+		if !printAtPath(f) {
+			break
+		}
+	}
+	return nil
+}
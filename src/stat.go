@@ -100,10 +100,23 @@ func prettyFileStat(logf log.Loggerf, relToRootPath string, file *File) {
 		&keyValue{"ModTime", fmt.Sprintf("%v", file.ModTime)},
 		&keyValue{"LastViewedByMe", fmt.Sprintf("%v", file.LastViewedByMeTime)},
 		&keyValue{"Shared", fmt.Sprintf("%v", file.Shared)},
-		&keyValue{"Owners", sepJoin(" & ", file.OwnerNames...)},
+		&keyValue{"Owners", sepJoin(" & ", file.ownerDescriptions()...)},
 		&keyValue{"LastModifyingUsername", file.LastModifyingUsername},
 	}
 
+	if file.SharingUser != nil {
+		kvList = append(kvList, &keyValue{"SharingUser", userDescription(file.SharingUser)})
+	}
+
+	if caps := file.Capabilities; caps != nil {
+		kvList = append(kvList,
+			&keyValue{"CanEdit", fmt.Sprintf("%v", caps.CanEdit)},
+			&keyValue{"CanShare", fmt.Sprintf("%v", caps.CanShare)},
+			&keyValue{"CanDelete", fmt.Sprintf("%v", caps.CanDelete)},
+			&keyValue{"CanMoveItemOutOfDrive", fmt.Sprintf("%v", caps.CanMoveItemOutOfDrive)},
+		)
+	}
+
 	if file.Description != "" {
 		kvList = append(kvList, &keyValue{"Description", fmt.Sprintf("%q", file.Description)})
 	}
@@ -138,8 +151,28 @@ func (g *Commands) stat(relToRootPath string, file *File, depth int) error {
 		if file.Md5Checksum != "" {
 			g.log.Logf("%32s  %s\n", file.Md5Checksum, strings.TrimPrefix(relToRootPath, "/"))
 		}
+	} else if jsonOutput(g.opts.TypeMask) {
+		opt := attribute{
+			json:   true,
+			parent: strings.TrimSuffix(relToRootPath, "/"+file.Name),
+			media:  mediaMetadata(g.opts.TypeMask),
+		}
+		file.prettyFields(g.log, opt)
 	} else {
 		prettyFileStat(g.log.Logf, relToRootPath, file)
+		if mediaMetadata(g.opts.TypeMask) {
+			for _, kv := range file.mediaKeyValues() {
+				g.log.Logf("%-25s %-30v\n", kv.key, kv.value.(string))
+			}
+		}
+		// Labels are only informative, and not every account can list them.
+		if labels, labelsErr := g.rem.fileLabels(file.Id); labelsErr != nil {
+			g.log.LogErrf("%s: labels: %v\n", relToRootPath, labelsErr)
+		} else {
+			for _, label := range labels {
+				g.log.Logf("%-25s %-30v\n", "Label", label.String())
+			}
+		}
 		perms, permErr := g.rem.listPermissions(file.Id)
 		if permErr != nil {
 			return permErr
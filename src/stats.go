@@ -0,0 +1,213 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync/atomic"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+)
+
+const (
+	DefaultStatsLast = "30d"
+)
+
+func (g *Commands) recordRunStat(command string, start time.Time, bytes, files, failures int64) {
+	stat := &config.RunStat{
+		Command:   command,
+		StartTime: start,
+		Duration:  int64(time.Since(start)),
+		Bytes:     bytes,
+		Files:     files,
+		Failures:  failures,
+	}
+
+	if err := g.context.RecordRunStat(stat); err != nil {
+		g.DebugPrintf("recordRunStat: %v", err)
+	}
+
+	summary := g.newRunSummary(stat)
+	g.logRunSummary(summary)
+	if err := g.writeRunSummary(summary); err != nil {
+		g.log.LogErrf("%s: --%s: %v\n", command, CLIOptionStatsJSON, err)
+	}
+}
+
+// runSummary sums up a push or pull once it is done.
+type runSummary struct {
+	Command     string  `json:"cmd"`
+	Transferred int64   `json:"transferred"`
+	Skipped     int64   `json:"skipped"`
+	Failed      int64   `json:"failed"`
+	Bytes       int64   `json:"bytes"`
+	Throughput  int64   `json:"bytesPerSecond"`
+	Elapsed     float64 `json:"elapsedSeconds"`
+	APICalls    int64   `json:"apiCalls"`
+}
+
+// newRunSummary sums up stat. Files that were up to date or left
+// unstarted by an interrupt are counted as skipped.
+func (g *Commands) newRunSummary(stat *config.RunStat) *runSummary {
+	unstarted := atomic.LoadInt64(&g.unstarted)
+	elapsed := time.Duration(stat.Duration)
+	summary := &runSummary{
+		Command:     stat.Command,
+		Transferred: stat.Files - stat.Failures - unstarted,
+		Skipped:     atomic.LoadInt64(&g.upToDate) + unstarted,
+		Failed:      stat.Failures,
+		Bytes:       stat.Bytes,
+		Throughput:  throughput(stat.Bytes, elapsed),
+		Elapsed:     elapsed.Seconds(),
+	}
+	if summary.Transferred < 0 {
+		summary.Transferred = 0
+	}
+	if g.rem != nil && g.rem.pacer != nil {
+		summary.APICalls = g.rem.pacer.requests()
+	}
+	return summary
+}
+
+// throughput is the average bytes per second moved in elapsed.
+func throughput(bytes int64, elapsed time.Duration) int64 {
+	if elapsed < time.Millisecond {
+		return 0
+	}
+	return int64(float64(bytes) / elapsed.Seconds())
+}
+
+// writeRunSummary writes summary as JSON to the file from --stats-json, if any.
+func (g *Commands) writeRunSummary(summary *runSummary) error {
+	if g.opts == nil || g.opts.StatsJSON == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(g.opts.StatsJSON, append(data, '\n'), 0644)
+}
+
+func (g *Commands) requestedStatsSince() (time.Time, error) {
+	last := DefaultStatsLast
+	if g.opts.Meta != nil {
+		if lastL := (*g.opts.Meta)[StatsLastKey]; len(lastL) >= 1 && lastL[0] != "" {
+			last = lastL[0]
+		}
+	}
+
+	d, err := parseAgeDuration(last)
+	if err != nil {
+		return time.Time{}, invalidArgumentsErr(fmt.Errorf("--%s: %v", StatsLastKey, err))
+	}
+
+	return time.Now().Add(-d), nil
+}
+
+type runStatsSummary struct {
+	runs     int64
+	bytes    int64
+	files    int64
+	failures int64
+	duration time.Duration
+}
+
+func (rs *runStatsSummary) add(stat *config.RunStat) {
+	rs.runs += 1
+	rs.bytes += stat.Bytes
+	rs.files += stat.Files
+	rs.failures += stat.Failures
+	rs.duration += time.Duration(stat.Duration)
+}
+
+func (rs *runStatsSummary) averageBytes() int64 {
+	if rs.runs < 1 {
+		return 0
+	}
+	return rs.bytes / rs.runs
+}
+
+func (rs *runStatsSummary) averageDuration() time.Duration {
+	if rs.runs < 1 {
+		return 0
+	}
+	return rs.duration / time.Duration(rs.runs)
+}
+
+func (g *Commands) Stats() error {
+	since, err := g.requestedStatsSince()
+	if err != nil {
+		return err
+	}
+
+	stats, err := g.context.RunStatsSince(since)
+	if err != nil {
+		return err
+	}
+
+	if len(stats) < 1 {
+		return noMatchesFoundErr(fmt.Errorf("no runs recorded since %v", since.Format(time.RFC3339)))
+	}
+
+	g.log.Logf("%-6s %-26s %-12s %-8s %-8s %s\n", "Cmd", "Started", "Bytes", "Files", "Failed", "Duration")
+
+	// Split the window into halves to report whether runs are growing or slowing down.
+	var earlier, later runStatsSummary
+	perCommand := map[string]*runStatsSummary{}
+	order := []string{}
+	mid := since.Add(time.Since(since) / 2)
+
+	for _, stat := range stats {
+		g.log.Logf("%-6s %-26s %-12s %-8d %-8d %v\n", stat.Command,
+			stat.StartTime.Local().Format(time.RFC3339), prettyBytes(stat.Bytes),
+			stat.Files, stat.Failures, time.Duration(stat.Duration).Round(time.Millisecond))
+
+		summary, ok := perCommand[stat.Command]
+		if !ok {
+			summary = &runStatsSummary{}
+			perCommand[stat.Command] = summary
+			order = append(order, stat.Command)
+		}
+		summary.add(stat)
+
+		if stat.StartTime.Before(mid) {
+			earlier.add(stat)
+		} else {
+			later.add(stat)
+		}
+	}
+
+	g.log.Logln()
+	for _, command := range order {
+		summary := perCommand[command]
+		g.log.Logf("%s: %d runs, %s in %d files, %d failures, avg duration %v\n",
+			command, summary.runs, prettyBytes(summary.bytes), summary.files,
+			summary.failures, summary.averageDuration().Round(time.Millisecond))
+	}
+
+	if earlier.runs >= 1 && later.runs >= 1 {
+		g.log.Logf("\nTrend (first half vs second half of the window):\n")
+		g.log.Logf("  avg bytes per run:    %s -> %s\n", prettyBytes(earlier.averageBytes()), prettyBytes(later.averageBytes()))
+		g.log.Logf("  avg duration per run: %v -> %v\n",
+			earlier.averageDuration().Round(time.Millisecond), later.averageDuration().Round(time.Millisecond))
+		g.log.Logf("  failures:             %d -> %d\n", earlier.failures, later.failures)
+	}
+
+	return nil
+}
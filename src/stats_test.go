@@ -0,0 +1,38 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThroughput(t *testing.T) {
+	tests := []struct {
+		bytes   int64
+		elapsed time.Duration
+		want    int64
+	}{
+		{1 << 20, time.Second, 1 << 20},
+		{1 << 20, 4 * time.Second, 1 << 18},
+		{100, 0, 0},
+		{0, time.Minute, 0},
+	}
+	for _, tt := range tests {
+		if got := throughput(tt.bytes, tt.elapsed); got != tt.want {
+			t.Errorf("throughput(%d, %v): got %d want %d", tt.bytes, tt.elapsed, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LinkMode is how local symlinks are pushed and pulled.
+type LinkMode uint8
+
+const (
+	// LinksFollow pushes what symlinks point to as if it was in their place.
+	LinksFollow LinkMode = iota
+	// LinksSkip leaves symlinks alone, neither pushing nor replacing them.
+	LinksSkip
+	// LinksShortcut pushes symlinks as shortcuts to the remote copies of
+	// their targets, and pulls shortcuts back as symlinks.
+	LinksShortcut
+)
+
+var linkModeNames = map[LinkMode]string{
+	LinksFollow:   "follow",
+	LinksSkip:     "skip",
+	LinksShortcut: "shortcut",
+}
+
+func (lm LinkMode) String() string {
+	return linkModeNames[lm]
+}
+
+// ParseLinkMode parses one of follow, skip or shortcut, nothing standing
+// for following symlinks.
+func ParseLinkMode(s string) (LinkMode, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return LinksFollow, nil
+	}
+	for lm, name := range linkModeNames {
+		if name == s {
+			return lm, nil
+		}
+	}
+	return LinksFollow, fmt.Errorf("unknown mode %q, expecting follow, skip or shortcut", s)
+}
+
+// newLocalLink describes the symlink at absPath itself rather than what it
+// points to, info being that of the symlink.
+func newLocalLink(absPath string, info os.FileInfo) (*File, error) {
+	target, err := os.Readlink(absPath)
+	if err != nil {
+		return nil, err
+	}
+	f := NewLocalFile(absPath, info)
+	f.linkTarget = target
+	return f, nil
+}
+
+func (f *File) isLink() bool {
+	return f != nil && f.linkTarget != ""
+}
+
+// shortcutLink is a shortcut pulled as a symlink to where its target is.
+type shortcutLink struct {
+	path     string
+	targetId string
+}
+
+// linkChanges takes out of cl the changes that would replace or delete
+// local symlinks, or push them, so that they are left alone. With
+// LinksShortcut, the symlinks that would be pushed anew are returned to be
+// made shortcuts to the remote copies of their targets, and the shortcuts
+// that would be pulled anew to be made symlinks.
+func (g *Commands) linkChanges(cl []*Change, push bool) (kept []*Change, shortcuts []*skippedDuplicate, links []*shortcutLink) {
+	rootAbsPath := g.context.AbsPathOf("")
+	for _, c := range cl {
+		if c == nil {
+			kept = append(kept, c)
+			continue
+		}
+
+		local, remote := c.Dest, c.Src
+		if push {
+			local, remote = c.Src, c.Dest
+		}
+
+		if !local.isLink() {
+			if !push && g.opts.Links == LinksShortcut && local == nil && remote != nil && remote.isShortcut() && remote.ShortcutDetails != nil {
+				links = append(links, &shortcutLink{path: c.Path, targetId: remote.ShortcutDetails.TargetId})
+				continue
+			}
+			kept = append(kept, c)
+			continue
+		}
+
+		if !push || g.opts.Links != LinksShortcut {
+			continue
+		}
+		if remote != nil {
+			if !remote.isShortcut() {
+				g.log.LogErrf("%s: a symlink locally but not a shortcut remotely, leaving both as they are\n", c.Path)
+			}
+			continue
+		}
+
+		targetAbsPath, err := filepath.EvalSymlinks(local.BlobAt)
+		if err != nil {
+			g.log.LogErrf("%s: symlink to %s: %v\n", c.Path, local.linkTarget, err)
+			continue
+		}
+		targetRelToRoot, err := filepath.Rel(rootAbsPath, targetAbsPath)
+		if err != nil || targetRelToRoot == ".." || strings.HasPrefix(targetRelToRoot, ".."+string(filepath.Separator)) {
+			g.log.LogErrf("%s: symlink to %s points outside of the drive context\n", c.Path, local.linkTarget)
+			continue
+		}
+		linkRelToRoot, _ := filepath.Rel(rootAbsPath, local.BlobAt)
+
+		// The target is expected at the same place relative to the
+		// destination that the symlink is pushed to.
+		target := strings.TrimSuffix(c.Path, remotePathJoin("/", filepath.ToSlash(linkRelToRoot))) + remotePathJoin("/", filepath.ToSlash(targetRelToRoot))
+		shortcuts = append(shortcuts, &skippedDuplicate{path: c.Path, name: local.Name, canonical: target})
+	}
+	return kept, shortcuts, links
+}
+
+// pullShortcutLinks makes each shortcut a symlink to the local copy of its
+// target, relative so that the context can be moved around.
+func (g *Commands) pullShortcutLinks(links []*shortcutLink) (err error) {
+	for _, l := range links {
+		backPaths, bErr := g.rem.FindBackPaths(l.targetId)
+		if bErr == nil && len(backPaths) < 1 {
+			bErr = fmt.Errorf("its target isn't within your drive")
+		}
+		if bErr != nil {
+			err = combineErrors(err, fmt.Errorf("%s: %v", l.path, bErr))
+			continue
+		}
+
+		targetRelToRoot := remotePathJoin("/", backPaths[0])
+		linkAbsPath := g.context.AbsPathOf(l.path)
+		target, rErr := filepath.Rel(filepath.Dir(linkAbsPath), g.context.AbsPathOf(targetRelToRoot))
+		if rErr != nil {
+			err = combineErrors(err, fmt.Errorf("%s: %v", l.path, rErr))
+			continue
+		}
+
+		if mErr := os.MkdirAll(filepath.Dir(linkAbsPath), os.ModeDir|0755); mErr != nil {
+			err = combineErrors(err, mErr)
+			continue
+		}
+		if sErr := os.Symlink(target, linkAbsPath); sErr != nil {
+			err = combineErrors(err, fmt.Errorf("%s: %v", l.path, sErr))
+			continue
+		}
+		g.log.Logf("%s -> %s\n", l.path, targetRelToRoot)
+	}
+	return err
+}
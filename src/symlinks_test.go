@@ -0,0 +1,66 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLinkMode(t *testing.T) {
+	modes := map[string]LinkMode{
+		"":         LinksFollow,
+		"follow":   LinksFollow,
+		" Skip ":   LinksSkip,
+		"shortcut": LinksShortcut,
+	}
+	for spec, want := range modes {
+		if got, err := ParseLinkMode(spec); err != nil || got != want {
+			t.Errorf("%q: got (%v, %v) want %v", spec, got, err, want)
+		}
+	}
+	if _, err := ParseLinkMode("dereference"); err == nil {
+		t.Errorf("expected an unknown mode to be rejected")
+	}
+}
+
+func TestNewLocalLink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	linkPath := filepath.Join(dir, "latest")
+	if err := os.Symlink("reports", linkPath); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	info, err := os.Lstat(linkPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := newLocalLink(linkPath, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.isLink() || f.linkTarget != "reports" || f.Name != "latest" {
+		t.Errorf("unexpected link %+v", f)
+	}
+	if (&File{}).isLink() {
+		t.Errorf("expected a plain file not to be a link")
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+)
+
+// syncAction is what `drive sync` does about a path given
+// how both sides changed since it last synced them.
+type syncAction uint8
+
+const (
+	syncInSync syncAction = iota
+	syncPush
+	syncPull
+	syncLeave
+	syncConflict
+)
+
+// localChangedSince tells whether l is no longer what st recorded, only
+// checksumming it if its size and modTime can't tell.
+func localChangedSince(l *File, st *config.SyncState) bool {
+	if l.Size != st.Size {
+		return true
+	}
+	return l.ModTime.Unix() != st.ModTime && md5Checksum(l) != st.Md5
+}
+
+func remoteChangedSince(r *File, st *config.SyncState) bool {
+	return r.Md5Checksum != st.Md5 || r.Size != st.Size
+}
+
+// sameSyncContent tells whether l and r have the same content, trusting
+// st rather than checksumming l if neither changed since it was recorded.
+func sameSyncContent(l, r *File, st *config.SyncState) bool {
+	if l.Size != r.Size {
+		return false
+	}
+	if st != nil && !localChangedSince(l, st) && !remoteChangedSince(r, st) {
+		return true
+	}
+	return md5Checksum(l) == r.Md5Checksum
+}
+
+// classifySync decides what to do about the local file l and the remote
+// file r, either of which may be missing, given st their state as of the
+// last sync if any. A side only changed since then is propagated to the
+// other, including its deletion, while changes on both sides are conflicts.
+// Folders are created on the side missing them but never deleted, since
+// files in them may not have been synced yet.
+func classifySync(l, r *File, st *config.SyncState) syncAction {
+	if r != nil && !r.IsDir && r.Md5Checksum == "" {
+		// Google Docs and the like have no content to compare.
+		return syncLeave
+	}
+
+	if (l != nil && l.IsDir) || (r != nil && r.IsDir) {
+		switch {
+		case l != nil && r != nil:
+			if l.IsDir != r.IsDir {
+				return syncConflict
+			}
+			return syncInSync
+		case st != nil:
+			return syncLeave
+		case l != nil:
+			return syncPush
+		default:
+			return syncPull
+		}
+	}
+
+	switch {
+	case l != nil && r != nil:
+		if sameSyncContent(l, r, st) {
+			return syncInSync
+		}
+		if st == nil {
+			return syncConflict
+		}
+		localChanged, remoteChanged := localChangedSince(l, st), remoteChangedSince(r, st)
+		if localChanged && !remoteChanged {
+			return syncPush
+		}
+		if remoteChanged && !localChanged {
+			return syncPull
+		}
+		return syncConflict
+	case l != nil:
+		if st == nil {
+			return syncPush
+		}
+		if localChangedSince(l, st) {
+			return syncConflict
+		}
+		// Deleted remotely since the last sync.
+		return syncPull
+	case r != nil:
+		if st == nil {
+			return syncPull
+		}
+		if remoteChangedSince(r, st) {
+			return syncConflict
+		}
+		// Deleted locally since the last sync.
+		return syncPush
+	}
+	return syncLeave
+}
+
+// syncPlan is what a sync will do, in each direction.
+type syncPlan struct {
+	pushes    []*Change
+	pulls     []*Change
+	conflicts []*Change
+	// inSync are the states of the paths already the same on both sides.
+	inSync []*config.SyncState
+	// forgotten are the paths with a state but missing on both sides.
+	forgotten []string
+}
+
+// planSync classifies pairs, changes from resolving a push with unchanged
+// files kept, against states.
+func (g *Commands) planSync(pairs []*Change, states map[string]*config.SyncState) *syncPlan {
+	plan := &syncPlan{}
+	seen := make(map[string]bool)
+
+	for _, c := range pairs {
+		if c == nil {
+			continue
+		}
+		seen[c.Path] = true
+
+		l, r := c.Src, c.Dest
+		change := &Change{Path: c.Path, Parent: c.Parent, g: g, policy: c.policy, IgnoreConflict: true}
+
+		switch classifySync(l, r, states[c.Path]) {
+		case syncInSync:
+			plan.inSync = append(plan.inSync, syncStateOf(c.Path, l, r, l.ModTime))
+		case syncPush:
+			change.Src, change.Dest = l, r
+			plan.pushes = append(plan.pushes, change)
+		case syncPull:
+			change.Src, change.Dest = r, l
+			plan.pulls = append(plan.pulls, change)
+		case syncConflict:
+			plan.conflicts = append(plan.conflicts, c)
+		}
+	}
+
+	for p := range states {
+		if !seen[p] {
+			plan.forgotten = append(plan.forgotten, p)
+		}
+	}
+	return plan
+}
+
+func syncStateOf(p string, l, r *File, modTime time.Time) *config.SyncState {
+	st := &config.SyncState{Path: p, ModTime: modTime.Unix(), Time: time.Now().UTC()}
+	if r != nil {
+		st.Id = r.Id
+	}
+	if r != nil && r.Md5Checksum != "" {
+		st.Md5, st.Size = r.Md5Checksum, r.Size
+	} else if l != nil {
+		st.Md5, st.Size = md5Checksum(l), l.Size
+	}
+	if (l != nil && l.IsDir) || (r != nil && r.IsDir) {
+		st.Md5, st.Size = "", 0
+	}
+	return st
+}
+
+// syncedStates returns the states of the paths that cl, played in the
+// direction of push, left the same on both sides and those that it deleted.
+func syncedStates(cl []*Change, push bool) (saved []*config.SyncState, forgotten []string) {
+	for _, c := range cl {
+		if c.Src == nil {
+			forgotten = append(forgotten, c.Path)
+			continue
+		}
+		if push {
+			// The upload has the local content and modTime.
+			saved = append(saved, syncStateOf(c.Path, c.Src, nil, c.Src.ModTime))
+		} else {
+			// Pulls set the local modTime to the remote one.
+			saved = append(saved, syncStateOf(c.Path, nil, c.Src, c.Src.ModTime))
+		}
+	}
+	return saved, forgotten
+}
+
+// Sync propagates the changes made on either side since the last sync of
+// the sources to the other, leaving those made on both sides as conflicts.
+func (g *Commands) Sync() error {
+	defer g.handleInterrupts(nil)()
+	defer g.flushChecksums()
+
+	g.keepUnchanged = true
+	defer func() {
+		g.keepUnchanged = false
+	}()
+
+	var pairs, clashes []*Change
+	for _, relToRootPath := range g.opts.Sources {
+		fsAbsPath := g.context.AbsPathOf(relToRootPath)
+		ccl, cclashes, cErr := g.changeListResolve(relToRootPath, fsAbsPath, true)
+		clashes = append(clashes, cclashes...)
+		if cErr != nil && cErr != ErrClashesDetected {
+			return cErr
+		}
+		pairs = append(pairs, ccl...)
+	}
+
+	if len(clashes) >= 1 {
+		if !g.opts.FixClashes {
+			warnClashesPersist(g.log, clashes)
+			return ErrClashesDetected
+		}
+		fn := g.opts.clashesHandler()
+		if err := fn(g, clashes); err != nil {
+			return err
+		}
+		return clashesFixedErr(errors.New(MsgClashesFixedNowRetry))
+	}
+
+	states, err := g.context.SyncStates(g.opts.Sources...)
+	if err != nil {
+		return err
+	}
+
+	plan := g.planSync(pairs, states)
+
+	var conflictsErr error
+	if len(plan.conflicts) >= 1 {
+		_warnChangeStopper(g.log, plan.conflicts, "\033[31mX\033[00m", "These %d file(s) changed on both sides since they were last synced and were left as they are\n", len(plan.conflicts))
+		conflictsErr = unresolvedConflictsErr(fmt.Errorf("sync: %d conflict(s) left unresolved", len(plan.conflicts)))
+	}
+
+	if g.opts.DryRun {
+		g.previewSync(plan)
+		return conflictsErr
+	}
+
+	if len(plan.pushes) < 1 && len(plan.pulls) < 1 {
+		g.log.Logln("Everything is up-to-date.")
+		return combineErrors(g.context.UpdateSyncStates(plan.inSync, plan.forgotten), conflictsErr)
+	}
+
+	if g.opts.canPreview() {
+		g.previewSync(plan)
+	}
+	if g.opts.canPrompt() {
+		if status := promptForChanges(); !accepted(status) {
+			return status.Error()
+		}
+	}
+
+	saved, forgotten := plan.inSync, plan.forgotten
+
+	var pushErr, pullErr error
+	if len(plan.pushes) >= 1 {
+		if pushErr = g.playPushChanges(plan.pushes, nil); pushErr == nil {
+			pSaved, pForgotten := syncedStates(plan.pushes, true)
+			saved, forgotten = append(saved, pSaved...), append(forgotten, pForgotten...)
+		}
+		// Each play closes the progress channel once done.
+		g.rem.progressChan = make(chan int)
+	}
+	if len(plan.pulls) >= 1 && !g.interrupted() {
+		if pullErr = g.playPullChanges(plan.pulls, nil, nil); pullErr == nil {
+			pSaved, pForgotten := syncedStates(plan.pulls, false)
+			saved, forgotten = append(saved, pSaved...), append(forgotten, pForgotten...)
+		}
+	}
+
+	// States are only recorded for the directions that were fully played,
+	// the rest are settled by comparing content on the next sync.
+	sErr := g.context.UpdateSyncStates(saved, forgotten)
+	return combineErrors(combineErrors(pushErr, pullErr), combineErrors(sErr, conflictsErr))
+}
+
+func (g *Commands) previewSync(plan *syncPlan) {
+	if len(plan.pushes) < 1 && len(plan.pulls) < 1 {
+		g.log.Logln("Everything is up-to-date.")
+		return
+	}
+	for _, direction := range []struct {
+		verb string
+		cl   []*Change
+	}{{verb: "push", cl: plan.pushes}, {verb: "pull", cl: plan.pulls}} {
+		if len(direction.cl) < 1 {
+			continue
+		}
+		g.log.Logf("To %s:\n", direction.verb)
+		opMap := opChangeCount(direction.cl)
+		previewChanges(&changeListArg{logy: g.log, changes: direction.cl}, true, opMap)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+)
+
+func TestClassifySync(t *testing.T) {
+	then := time.Unix(1450000000, 0)
+	later := then.Add(time.Hour)
+	file := func(md5 string, size int64, modTime time.Time) *File {
+		return &File{Name: "a", Md5Checksum: md5, Size: size, ModTime: modTime}
+	}
+	st := &config.SyncState{Path: "/a", Md5: "old", Size: 3, ModTime: then.Unix()}
+
+	tests := []struct {
+		desc string
+		l, r *File
+		st   *config.SyncState
+		want syncAction
+	}{
+		{"unchanged", file("old", 3, then), file("old", 3, later), st, syncInSync},
+		{"same content never synced", file("new", 4, later), file("new", 4, then), nil, syncInSync},
+		{"changed locally", file("new", 4, later), file("old", 3, then), st, syncPush},
+		{"changed remotely", file("old", 3, then), file("new", 4, later), st, syncPull},
+		{"changed on both sides", file("mine", 4, later), file("theirs", 4, later), st, syncConflict},
+		{"differ never synced", file("mine", 4, later), file("theirs", 4, later), nil, syncConflict},
+		{"added locally", file("new", 4, later), nil, nil, syncPush},
+		{"added remotely", nil, file("new", 4, later), nil, syncPull},
+		{"deleted remotely", file("old", 3, then), nil, st, syncPull},
+		{"deleted remotely, changed locally", file("new", 4, later), nil, st, syncConflict},
+		{"deleted locally", nil, file("old", 3, then), st, syncPush},
+		{"deleted locally, changed remotely", nil, file("new", 4, later), st, syncConflict},
+		{"doc", file("old", 3, then), file("", 0, then), st, syncLeave},
+		{"folder added locally", &File{IsDir: true}, nil, nil, syncPush},
+		{"folder deleted locally", nil, &File{IsDir: true}, &config.SyncState{Path: "/a"}, syncLeave},
+	}
+	for _, tt := range tests {
+		if got := classifySync(tt.l, tt.r, tt.st); got != tt.want {
+			t.Errorf("%s: got %v want %v", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -19,6 +19,30 @@ import (
 	// "path/filepath"
 )
 
+const (
+	DeletionTrash     = "trash"
+	DeletionPermanent = "permanent"
+)
+
+// permanentDeletion reports whether a destructive operation should be
+// permanent. That always requires Permanent, the deletion mode only
+// decides what happens without it: trashing instead or refusing.
+func (g *Commands) permanentDeletion(command string) (bool, error) {
+	if g.opts.Permanent {
+		return true, nil
+	}
+
+	switch mode := g.opts.DeletionMode; mode {
+	case "", DeletionTrash:
+		return false, nil
+	case DeletionPermanent:
+		return false, permanentDeletionRefusedErr(fmt.Errorf("%s: deletions in this context are permanent, use `-%s` to confirm or `-%s %s` to trash",
+			command, CLIOptionPermanent, DeletionModeKey, DeletionTrash))
+	default:
+		return false, invalidArgumentsErr(fmt.Errorf("%s: unknown %s %q, expected %s or %s", command, DeletionModeKey, mode, DeletionTrash, DeletionPermanent))
+	}
+}
+
 type trashOpt struct {
 	permanent bool
 	toTrash   bool
@@ -35,14 +59,27 @@ func (g *Commands) Trash(byId bool) (err error) {
 }
 
 func (g *Commands) Delete(byId bool) (err error) {
+	permanent, err := g.deletePermanently()
+	if err != nil {
+		return err
+	}
+
 	opt := trashOpt{
 		toTrash:   true,
-		permanent: true,
+		permanent: permanent,
 		byId:      byId,
 	}
 	return g.reduceForTrash(g.opts.Sources, &opt)
 }
 
+func (g *Commands) deletePermanently() (bool, error) {
+	permanent, err := g.permanentDeletion(DeleteKey)
+	if err == nil && !permanent {
+		g.log.LogErrf("delete: moving to trash instead, use `-%s` to delete permanently\n", CLIOptionPermanent)
+	}
+	return permanent, err
+}
+
 func (g *Commands) Untrash(byId bool) (err error) {
 	opt := trashOpt{
 		toTrash:   false,
@@ -53,6 +90,10 @@ func (g *Commands) Untrash(byId bool) (err error) {
 }
 
 func (g *Commands) EmptyTrash() error {
+	if !g.opts.Permanent {
+		return permanentDeletionRefusedErr(fmt.Errorf("%s: emptying the trash is irreversible, use `-%s` to confirm", EmptyTrashKey, CLIOptionPermanent))
+	}
+
 	rootFile, err := g.rem.FindByPath("/")
 	if err != nil {
 		return err
@@ -205,10 +246,21 @@ func (g *Commands) UntrashByMatch() error {
 }
 
 func (g *Commands) DeleteByMatch() error {
-	return g.trashByMatch(false, true)
+	permanent, err := g.deletePermanently()
+	if err != nil {
+		return err
+	}
+	return g.trashByMatch(false, permanent)
 }
 
 func (g *Commands) reduceForTrash(args []string, opt *trashOpt) error {
+	if opt.toTrash && !opt.byId {
+		var err error
+		if args, err = g.expandRemoteGlobs(args); err != nil {
+			return err
+		}
+	}
+
 	var cl []*Change
 	for i, relToRoot := range args {
 		c, cErr := g.trasher(relToRoot, opt)
@@ -242,6 +294,30 @@ func (g *Commands) reduceForTrash(args []string, opt *trashOpt) error {
 	return g.playTrashChangeList(cl, opt)
 }
 
+// untrashDestination is the folder that untrashed items are restored into
+// instead of their original parents, created if need be, or nil if none
+// was set with `-to`.
+func (g *Commands) untrashDestination() (*File, error) {
+	var to string
+	if g.opts.Meta != nil {
+		if tos := (*g.opts.Meta)[CLIOptionUntrashTo]; len(tos) >= 1 {
+			to = tos[0]
+		}
+	}
+	if to == "" {
+		return nil, nil
+	}
+
+	dest, err := g.remoteMkdirAll(to)
+	if err != nil {
+		return nil, err
+	}
+	if !dest.IsDir {
+		return nil, invalidArgumentsErr(fmt.Errorf("-%s %s: not a folder", CLIOptionUntrashTo, to))
+	}
+	return dest, nil
+}
+
 func (g *Commands) playTrashChangeList(cl []*Change, opt *trashOpt) (err error) {
 	trashSize, unTrashSize := reduceToSize(cl, SelectDest|SelectSrc)
 	g.taskStart(trashSize + unTrashSize)
@@ -250,10 +326,15 @@ func (g *Commands) playTrashChangeList(cl []*Change, opt *trashOpt) (err error)
 	if opt.permanent {
 		fn = g.remoteDelete
 		g.DebugPrintf("[playTrashChangeList] isPermanentOp. Selecting remoteDelete\n")
+	} else if opt.toTrash {
+		fn = g.remoteTrash
 	} else {
-		fn = g.remoteUntrash
-		if opt.toTrash {
-			fn = g.remoteTrash
+		to, toErr := g.untrashDestination()
+		if toErr != nil {
+			return toErr
+		}
+		fn = func(c *Change) error {
+			return g.remoteUntrashTo(c, to)
 		}
 		g.DebugPrintf("[playTrashChangeList/nonPermanentOp]: toTrash: %v\n", opt.toTrash)
 	}
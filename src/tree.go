@@ -0,0 +1,121 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/odeke-em/log"
+)
+
+// treeNode collects a traversal so that it can be printed like
+// the `tree` command once the sizes of all descendants are known.
+type treeNode struct {
+	file     *File
+	children []*treeNode
+
+	dirs  int
+	files int
+	size  int64
+}
+
+func (tn *treeNode) add(f *File) *treeNode {
+	child := &treeNode{file: f}
+	tn.children = append(tn.children, child)
+	return child
+}
+
+func (tn *treeNode) find(f *File) *treeNode {
+	if tn == nil {
+		return nil
+	}
+	for _, child := range tn.children {
+		if child.file == f {
+			return child
+		}
+	}
+	return nil
+}
+
+// tally sums up the folders, files and sizes below each node.
+// Folders beyond the traversal depth only count for themselves.
+func (tn *treeNode) tally() {
+	if !tn.file.IsDir {
+		tn.files, tn.size = 1, tn.file.Size
+		return
+	}
+
+	for _, child := range tn.children {
+		child.tally()
+		tn.dirs += child.dirs
+		tn.files += child.files
+		tn.size += child.size
+		if child.file.IsDir {
+			tn.dirs += 1
+		}
+	}
+}
+
+func (tn *treeNode) label(name string) string {
+	if !tn.file.IsDir {
+		return fmt.Sprintf("%s (%s)", name, prettyBytes(tn.size))
+	}
+	if !strings.HasSuffix(name, "/") {
+		name += "/"
+	}
+	return fmt.Sprintf("%s (%d files, %s)", name, tn.files, prettyBytes(tn.size))
+}
+
+func (tn *treeNode) render(logy *log.Logger, prefix string) {
+	for i, child := range tn.children {
+		branch, indent := "├── ", "│   "
+		if i == len(tn.children)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		logy.Logf("%s%s%s\n", prefix, branch, child.label(child.file.Name))
+		child.render(logy, prefix+indent)
+	}
+}
+
+// treeFirst traverses travSt collecting the tree then prints it out,
+// with the number of files and cumulative size at each folder.
+func (g *Commands) treeFirst(travSt traversalSt, spin *playable) bool {
+	root := &treeNode{file: travSt.file}
+	travSt.node = root
+
+	completed := g.breadthFirst(travSt, spin)
+
+	parent := ""
+	if travSt.headPath != "/" {
+		parent = travSt.headPath
+	}
+	name := sepJoin("/", parent, travSt.file.Name)
+	if rootLike(name) {
+		name = "/"
+	}
+
+	spin.pause()
+	root.tally()
+	g.log.Logln(root.label(name))
+	root.render(g.log, "")
+	if root.file.IsDir {
+		g.log.Logf("\n%d directories, %d files\n", root.dirs, root.files)
+	}
+	spin.play()
+
+	return completed
+}
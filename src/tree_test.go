@@ -0,0 +1,37 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"testing"
+)
+
+func TestTreeNodeTally(t *testing.T) {
+	root := &treeNode{file: &File{Name: "photos", IsDir: true}}
+	root.add(&File{Name: "a.jpg", Size: 10})
+	album := root.add(&File{Name: "2015", IsDir: true})
+	album.add(&File{Name: "b.jpg", Size: 20})
+	album.add(&File{Name: "c.jpg", Size: 30})
+	root.add(&File{Name: "empty", IsDir: true})
+
+	root.tally()
+
+	if root.dirs != 2 || root.files != 3 || root.size != 60 {
+		t.Errorf("root: got %d dirs %d files %d bytes, want 2 dirs 3 files 60 bytes", root.dirs, root.files, root.size)
+	}
+	if album.dirs != 0 || album.files != 2 || album.size != 50 {
+		t.Errorf("album: got %d dirs %d files %d bytes, want 0 dirs 2 files 50 bytes", album.dirs, album.files, album.size)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -62,7 +63,8 @@ const (
 )
 
 const (
-	DriveFolderMimeType = "application/vnd.google-apps.folder"
+	DriveFolderMimeType   = "application/vnd.google-apps.folder"
+	DriveShortcutMimeType = "application/vnd.google-apps.shortcut"
 )
 
 // Arbitrary value. TODO: Get better definition of BigFileSize.
@@ -108,6 +110,16 @@ type File struct {
 	Version int64
 	// The onwers of this file.
 	OwnerNames []string
+	Owners     []*drive.User
+	// SharingUser is the user who shared this file with the current user, if any.
+	SharingUser      *drive.User
+	SharedWithMeTime time.Time
+	// Capabilities describes what the current user can do to this file.
+	Capabilities       *drive.FileCapabilities
+	ImageMediaMetadata *drive.FileImageMediaMetadata
+	VideoMediaMetadata *drive.FileVideoMediaMetadata
+	// ShortcutDetails is set for shortcuts, naming what they point to.
+	ShortcutDetails *drive.FileShortcutDetails
 	// Permissions contains the overall permissions for this file
 	Permissions           []*drive.Permission
 	LastModifyingUsername string
@@ -116,6 +128,111 @@ type File struct {
 	Description           string
 	Parents               []*ParentFile
 	QuotaBytesUsed        int64
+	// raw is the API representation that this file was created from
+	raw *drive.File
+	// relPath if known is the remote path of the file, for sorting by depth.
+	relPath string
+	// shortcutTarget is the resolved path, or id, that a shortcut points to.
+	shortcutTarget string
+	// linkTarget is what a local symlink points to, when it isn't followed.
+	linkTarget string
+	// compressed is set for remote files pushed gzipped, whose Size and
+	// Md5Checksum are then those of their uncompressed content.
+	compressed bool
+	// checksums records the checksum of a local file once computed.
+	checksums *checksumIndex
+	// pageToken and pageIndex are where the file was listed, for
+	// listings stopped at it to be resumed from it.
+	pageToken string
+	pageIndex int
+	// labels if fetched are the Drive Labels applied to the file.
+	labels []*fileLabel
+}
+
+// userDescription returns "Name <email>" to tell apart users with the same display name.
+func userDescription(u *drive.User) string {
+	if u == nil {
+		return ""
+	}
+	if u.EmailAddress == "" {
+		return u.DisplayName
+	}
+	if u.DisplayName == "" {
+		return u.EmailAddress
+	}
+	return fmt.Sprintf("%s <%s>", u.DisplayName, u.EmailAddress)
+}
+
+func (f *File) ownerDescriptions() []string {
+	if len(f.Owners) < 1 {
+		return f.OwnerNames
+	}
+
+	var descriptions []string
+	for _, owner := range f.Owners {
+		descriptions = append(descriptions, userDescription(owner))
+	}
+	return descriptions
+}
+
+// capabilitiesColumn summarizes the capabilities like ls does permissions
+// e.g "es-m" for edit, share, delete and move out of drive.
+func (f *File) capabilitiesColumn() string {
+	caps := f.Capabilities
+	if caps == nil {
+		return "????"
+	}
+
+	column := []byte("----")
+	for i, capability := range []struct {
+		can  bool
+		code byte
+	}{
+		{can: caps.CanEdit, code: 'e'},
+		{can: caps.CanShare, code: 's'},
+		{can: caps.CanDelete, code: 'd'},
+		{can: caps.CanMoveItemOutOfDrive, code: 'm'},
+	} {
+		if capability.can {
+			column[i] = capability.code
+		}
+	}
+	return string(column)
+}
+
+// permissionsColumn lists who has access to f and as what e.g
+// "alice@example.com:owner bob@example.com:commenter anyone(link):reader".
+func (f *File) permissionsColumn() string {
+	if f.Permissions == nil {
+		return "?"
+	}
+
+	var entries []string
+	for _, perm := range f.Permissions {
+		if perm == nil {
+			continue
+		}
+
+		addressee := permissionValue(perm)
+		if perm.Type == "anyone" {
+			addressee = "anyone"
+		}
+		if addressee == "" {
+			addressee = perm.Type
+		}
+		if perm.WithLink {
+			addressee += "(link)"
+		}
+
+		role := perm.Role
+		for _, additional := range perm.AdditionalRoles {
+			if additional == "commenter" {
+				role = additional
+			}
+		}
+		entries = append(entries, fmt.Sprintf("%s:%s", addressee, role))
+	}
+	return strings.Join(entries, " ")
 }
 
 func newParentFile(p *drive.ParentReference) *ParentFile {
@@ -142,7 +259,7 @@ func NewRemoteFile(f *drive.File) *File {
 		return pfl
 	}(f.Parents)
 
-	return &File{
+	rf := &File{
 		AlternateLink:      f.AlternateLink,
 		BlobAt:             f.DownloadUrl,
 		Copyable:           f.Copyable,
@@ -161,6 +278,13 @@ func NewRemoteFile(f *drive.File) *File {
 		UserPermission:        f.UserPermission,
 		Version:               f.Version,
 		OwnerNames:            f.OwnerNames,
+		Owners:                f.Owners,
+		SharingUser:           f.SharingUser,
+		SharedWithMeTime:      parseTimeAndRound(f.SharedWithMeDate),
+		Capabilities:          f.Capabilities,
+		ImageMediaMetadata:    f.ImageMediaMetadata,
+		VideoMediaMetadata:    f.VideoMediaMetadata,
+		ShortcutDetails:       f.ShortcutDetails,
 		Permissions:           f.Permissions,
 		LastModifyingUsername: f.LastModifyingUserName,
 		OriginalFilename:      f.OriginalFilename,
@@ -168,7 +292,10 @@ func NewRemoteFile(f *drive.File) *File {
 		Description:           f.Description,
 		Parents:               parents,
 		QuotaBytesUsed:        f.QuotaBytesUsed,
+		raw:                   f,
 	}
+	rf.applyCompression(f.Properties)
+	return rf
 }
 
 func DupFile(f *File) *File {
@@ -193,6 +320,13 @@ func DupFile(f *File) *File {
 		UserPermission:     f.UserPermission,
 		Version:            f.Version,
 		OwnerNames:         f.OwnerNames,
+		Owners:             f.Owners,
+		SharingUser:        f.SharingUser,
+		SharedWithMeTime:   f.SharedWithMeTime,
+		Capabilities:       f.Capabilities,
+		ImageMediaMetadata: f.ImageMediaMetadata,
+		VideoMediaMetadata: f.VideoMediaMetadata,
+		ShortcutDetails:    f.ShortcutDetails,
 		Permissions:        f.Permissions,
 		LastViewedByMeTime: f.LastViewedByMeTime,
 		Labels:             f.Labels,
@@ -201,6 +335,8 @@ func DupFile(f *File) *File {
 		Description:        f.Description,
 		Parents:            f.Parents,
 		QuotaBytesUsed:     f.QuotaBytesUsed,
+		raw:                f.raw,
+		compressed:         f.compressed,
 	}
 }
 
@@ -282,6 +418,7 @@ type Change struct {
 	IgnoreConflict bool
 	IgnoreChecksum bool
 	g              *Commands
+	policy         *subtreePolicy
 }
 
 type ByPrecedence []*Change
@@ -366,6 +503,7 @@ func md5Checksum(f *File) string {
 	if f.CacheChecksum {
 		f.Md5Checksum = checksum
 	}
+	f.checksums.remember(f.BlobAt, f.Size, f.ModTime, checksum, "")
 	return checksum
 }
 
@@ -519,6 +657,7 @@ func (c *Change) checkIndexExistance() (bool, error) {
 	}
 
 	index, err := c.g.context.DeserializeIndex(f.Id)
+	c.g.countCacheLookup(err == nil)
 	if err != nil {
 		return false, err
 	}
@@ -556,6 +695,8 @@ func (f *File) ToIndex() *config.Index {
 		MimeType:    f.MimeType,
 		ModTime:     f.ModTime.Unix(),
 		Version:     f.Version,
+		IndexTime:   time.Now().Unix(),
+		Metadata:    f.metadata(),
 	}
 }
 
@@ -565,6 +706,8 @@ type fuzzyStringsValuePair struct {
 	starred    bool
 	joiner     joiner
 	values     []string
+	// patterns are the compiled values of a Regex search.
+	patterns []*regexp.Regexp
 }
 
 type matchQuery struct {
@@ -575,6 +718,19 @@ type matchQuery struct {
 	mimeQuerySearches []fuzzyStringsValuePair
 	titleSearches     []fuzzyStringsValuePair
 	ownerSearches     []fuzzyStringsValuePair
+	// modifiedAfter and modifiedBefore if set bound the modification
+	// times of the files matched, though not of folders.
+	modifiedAfter  time.Time
+	modifiedBefore time.Time
+
+	// mimeCategories are the friendly types matched e.g docs or images.
+	mimeCategories []string
+	// traversesFolders lets folders through mimeCategories
+	// so that their contents can still be listed.
+	traversesFolders bool
+
+	// labelIds are the Drive Labels that files must all have applied.
+	labelIds []string
 }
 
 type fuzziness int
@@ -584,6 +740,10 @@ const (
 	Like
 	NotIn
 	Is
+	// Regex matches titles by regular expression. Drive cannot search
+	// that way so queries are only narrowed down by the literal prefix
+	// of anchored patterns and results are filtered by matchesTitle.
+	Regex
 )
 
 func (fz *fuzziness) Stringer() string {
@@ -596,11 +756,54 @@ func (fz *fuzziness) Stringer() string {
 		return "contains"
 	case Is:
 		return "="
+	case Regex:
+		return "contains"
 	}
 
 	return "="
 }
 
+// regexTitleSearch compiles patterns into a search for titles matching any of them.
+func regexTitleSearch(patterns []string, inTrash bool) (*fuzzyStringsValuePair, error) {
+	fz := &fuzzyStringsValuePair{fuzzyLevel: Regex, inTrash: inTrash, joiner: Or, values: patterns}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		fz.patterns = append(fz.patterns, re)
+	}
+	return fz, nil
+}
+
+// matchesTitle reports whether title is matched by one of the patterns of a
+// Regex search. Searches of any other fuzziness are left to the server.
+func (fz *fuzzyStringsValuePair) matchesTitle(title string) bool {
+	if fz.fuzzyLevel != Regex || len(fz.patterns) < 1 {
+		return true
+	}
+	for _, re := range fz.patterns {
+		if re.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// regexTitlePrefix returns the literal prefix that titles matched by an
+// anchored pattern must start with e.g "report_" for `^report_\d{4}`.
+func regexTitlePrefix(pattern string) string {
+	if !strings.HasPrefix(pattern, "^") {
+		return ""
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ""
+	}
+	prefix, _ := re.LiteralPrefix()
+	return prefix
+}
+
 type joiner int
 
 const (
@@ -665,6 +868,13 @@ func titleQueryStringify(fz *fuzzyStringsValuePair) string {
 	quote := strconv.Quote
 
 	for _, title := range fz.values {
+		if fz.fuzzyLevel == Regex {
+			title = regexTitlePrefix(title)
+			if title == "" {
+				keySearches = append(keySearches, fmt.Sprintf("(trashed=%v)", fz.inTrash))
+				continue
+			}
+		}
 		if !fz.starred {
 			keySearches = append(keySearches, fmt.Sprintf("(title %s %s and trashed=%v)", fuzzyDesc, quote(title), fz.inTrash))
 		} else {
@@ -688,6 +898,77 @@ func joinLists(exprJoiner string, expressions []string) string {
 	return reduced
 }
 
+// mimeCategories maps the friendly types of --type, singular,
+// to the mimeType clauses that they expand to.
+var mimeCategories = []struct {
+	name   string
+	clause string
+}{
+	{"doc", fmt.Sprintf("mimeType = '%s'", "application/vnd.google-apps.document")},
+	{"sheet", fmt.Sprintf("mimeType = '%s'", "application/vnd.google-apps.spreadsheet")},
+	{"slide", fmt.Sprintf("mimeType = '%s'", "application/vnd.google-apps.presentation")},
+	{"form", fmt.Sprintf("mimeType = '%s'", "application/vnd.google-apps.form")},
+	{"folder", fmt.Sprintf("mimeType = '%s'", DriveFolderMimeType)},
+	{"pdf", fmt.Sprintf("mimeType = '%s'", "application/pdf")},
+	{"image", "mimeType contains 'image/'"},
+	{"video", "mimeType contains 'video/'"},
+	{"audio", "mimeType contains 'audio/'"},
+}
+
+// mimeCategoryClauses expands types such as "docs" or "images" into
+// their mimeType clauses, failing on any type that it doesn't know.
+func mimeCategoryClauses(categories []string) ([]string, error) {
+	var clauses []string
+	for _, category := range categories {
+		singular := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(category)), "s")
+		clause := ""
+		for _, mc := range mimeCategories {
+			if mc.name == singular {
+				clause = mc.clause
+				break
+			}
+		}
+		if clause == "" {
+			var known []string
+			for _, mc := range mimeCategories {
+				known = append(known, mc.name+"s")
+			}
+			return nil, fmt.Errorf("unknown type %q, expecting one of %s", category, strings.Join(known, ", "))
+		}
+		clauses = append(clauses, clause)
+	}
+	return clauses, nil
+}
+
+// mimeCategoryStringify matches any of the types, letting
+// folders through too if they are to be traversed.
+func mimeCategoryStringify(categories []string, traversesFolders bool) []string {
+	clauses, _ := mimeCategoryClauses(categories)
+	if len(clauses) < 1 {
+		return nil
+	}
+	if traversesFolders {
+		clauses = append(clauses, fmt.Sprintf("mimeType = '%s'", DriveFolderMimeType))
+	}
+	return []string{fmt.Sprintf("(%s)", strings.Join(clauses, " or "))}
+}
+
+// modifiedDateStringify bounds the modifiedDate of files. Folders are let
+// through regardless so that their contents can still be traversed.
+func modifiedDateStringify(after, before time.Time) []string {
+	var bounds []string
+	if !after.IsZero() {
+		bounds = append(bounds, fmt.Sprintf("modifiedDate > '%s'", after.UTC().Format(time.RFC3339)))
+	}
+	if !before.IsZero() {
+		bounds = append(bounds, fmt.Sprintf("modifiedDate < '%s'", before.UTC().Format(time.RFC3339)))
+	}
+	if len(bounds) < 1 {
+		return nil
+	}
+	return []string{fmt.Sprintf("(mimeType = '%s' or (%s))", DriveFolderMimeType, strings.Join(bounds, " and "))}
+}
+
 func (mq *matchQuery) Stringer() string {
 	overallSearchList := []string{}
 
@@ -700,6 +981,7 @@ func (mq *matchQuery) Stringer() string {
 
 		mimeTranslations = append(mimeTranslations, query)
 	}
+	mimeTranslations = append(mimeTranslations, mimeCategoryStringify(mq.mimeCategories, mq.traversesFolders)...)
 
 	titleTranslations := []string{}
 	for _, titleFzPair := range mq.titleSearches {
@@ -726,6 +1008,9 @@ func (mq *matchQuery) Stringer() string {
 		starredTranslations = []string{"(starred=true)"}
 	}
 
+	modTimeTranslations := modifiedDateStringify(mq.modifiedAfter, mq.modifiedBefore)
+	labelTranslations := labelStringify(mq.labelIds, mq.traversesFolders)
+
 	exprPairs := []struct {
 		joiner   string
 		elements []string
@@ -734,6 +1019,8 @@ func (mq *matchQuery) Stringer() string {
 		{" and ", titleTranslations},
 		{" and ", ownerTranslations},
 		{" and ", starredTranslations},
+		{" and ", modTimeTranslations},
+		{" and ", labelTranslations},
 	}
 
 	for _, exprPair := range exprPairs {
@@ -0,0 +1,114 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModifiedDateStringify(t *testing.T) {
+	after := time.Date(2016, 5, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2016, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	samples := []struct {
+		after, before time.Time
+		want          []string
+	}{
+		{want: nil},
+		{after: after, want: []string{"(mimeType = 'application/vnd.google-apps.folder' or (modifiedDate > '2016-05-01T00:00:00Z'))"}},
+		{
+			after: after, before: before,
+			want: []string{"(mimeType = 'application/vnd.google-apps.folder' or (modifiedDate > '2016-05-01T00:00:00Z' and modifiedDate < '2016-06-01T12:30:00Z'))"},
+		},
+	}
+
+	for i, sample := range samples {
+		got := modifiedDateStringify(sample.after, sample.before)
+		if !reflect.DeepEqual(got, sample.want) {
+			t.Errorf("#%d: got %q want %q", i, got, sample.want)
+		}
+	}
+
+	if !modifiedWithin(after.Add(time.Hour), after, before) {
+		t.Errorf("expected a time within the range to be accepted")
+	}
+	if modifiedWithin(before, after, before) || modifiedWithin(after, after, time.Time{}) {
+		t.Errorf("expected the bounds themselves to be excluded")
+	}
+}
+
+func TestRegexTitleSearch(t *testing.T) {
+	fz, err := regexTitleSearch([]string{`^report_\d{4}\.pdf$`}, false)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if got, want := titleQueryStringify(fz), `(title contains "report_" and trashed=false)`; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	for title, want := range map[string]bool{
+		"report_2016.pdf":     true,
+		"report_16.pdf":       false,
+		"old_report_2016.pdf": false,
+	} {
+		if got := fz.matchesTitle(title); got != want {
+			t.Errorf("%q: got %v want %v", title, got, want)
+		}
+	}
+
+	unanchored, err := regexTitleSearch([]string{`\.pdf$`}, true)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if got, want := titleQueryStringify(unanchored), "(trashed=true)"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+
+	if _, err := regexTitleSearch([]string{"report_("}, false); err == nil {
+		t.Errorf("expected an invalid pattern to be rejected")
+	}
+}
+
+func TestMimeCategoryClauses(t *testing.T) {
+	clauses, err := mimeCategoryClauses([]string{"docs", "Image", " pdf "})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := []string{
+		"mimeType = 'application/vnd.google-apps.document'",
+		"mimeType contains 'image/'",
+		"mimeType = 'application/pdf'",
+	}
+	if strings.Join(clauses, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q want %q", clauses, want)
+	}
+
+	if _, err := mimeCategoryClauses([]string{"spreadsheets"}); err == nil {
+		t.Errorf("expected unknown types to be rejected")
+	}
+
+	got := mimeCategoryStringify([]string{"videos"}, true)
+	wantExpr := fmt.Sprintf("(mimeType contains 'video/' or mimeType = '%s')", DriveFolderMimeType)
+	if len(got) != 1 || got[0] != wantExpr {
+		t.Errorf("got %q want %q", got, wantExpr)
+	}
+	if got := mimeCategoryStringify(nil, true); len(got) != 0 {
+		t.Errorf("expected no types to match anything, got %q", got)
+	}
+}
@@ -0,0 +1,461 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+const (
+	WatchTrashedEvent     = "trashed"
+	WatchPermissionsEvent = "permissions"
+	// WatchModifiedEvent is any other change, only reported in digests.
+	WatchModifiedEvent = "modified"
+
+	DefaultWatchInterval = "1m"
+	DefaultWatchEvents   = WatchTrashedEvent + "," + WatchPermissionsEvent
+)
+
+type watchEvent int
+
+const (
+	watchTrashed watchEvent = 1 << iota
+	watchPermissions
+)
+
+var watchEventNames = map[string]watchEvent{
+	WatchTrashedEvent:     watchTrashed,
+	WatchPermissionsEvent: watchPermissions,
+}
+
+// watchRule alerts on events for anything within folder.
+type watchRule struct {
+	folder  string
+	events  watchEvent
+	webhook string
+}
+
+func (wr *watchRule) covers(p string) bool {
+	return wr.folder == "/" || p == wr.folder || strings.HasPrefix(p, wr.folder+"/")
+}
+
+func parseWatchEvents(csv string) (events watchEvent, err error) {
+	for _, name := range NonEmptyTrimmedStrings(strings.Split(csv, ",")...) {
+		event, ok := watchEventNames[strings.ToLower(name)]
+		if !ok {
+			return 0, fmt.Errorf("unknown event %q, expected %s or %s", name, WatchTrashedEvent, WatchPermissionsEvent)
+		}
+		events |= event
+	}
+	if events == 0 {
+		return 0, fmt.Errorf("no events in %q", csv)
+	}
+	return events, nil
+}
+
+// parseWatchRule parses a rule of the form `<folder> [events] [webhook]`
+// e.g `/Shared/Contracts trashed,permissions https://hooks.example.com/drive`.
+// Omitted events and webhook fall back to those of defaults.
+func parseWatchRule(line string, defaults *watchRule) (*watchRule, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 1 || len(fields) > 3 {
+		return nil, fmt.Errorf("expected `<folder> [events] [webhook]`, got %q", line)
+	}
+
+	rule := &watchRule{
+		folder:  path.Clean("/" + fields[0]),
+		events:  defaults.events,
+		webhook: defaults.webhook,
+	}
+
+	rest := fields[1:]
+	if len(rest) >= 1 && !strings.Contains(rest[0], "://") {
+		events, err := parseWatchEvents(rest[0])
+		if err != nil {
+			return nil, err
+		}
+		rule.events = events
+		rest = rest[1:]
+	}
+	if len(rest) == 1 {
+		rule.webhook = rest[0]
+	} else if len(rest) > 1 {
+		return nil, fmt.Errorf("expected `<folder> [events] [webhook]`, got %q", line)
+	}
+
+	return rule, nil
+}
+
+func (g *Commands) watchRules() (rules []*watchRule, err error) {
+	meta := map[string][]string{}
+	if g.opts.Meta != nil {
+		meta = *g.opts.Meta
+	}
+
+	defaults := &watchRule{events: watchTrashed | watchPermissions}
+	if on := meta[WatchOnKey]; len(on) >= 1 && on[0] != "" {
+		if defaults.events, err = parseWatchEvents(on[0]); err != nil {
+			return nil, invalidArgumentsErr(fmt.Errorf("--%s: %v", WatchOnKey, err))
+		}
+	}
+	if webhook := meta[WatchWebhookKey]; len(webhook) >= 1 {
+		defaults.webhook = webhook[0]
+	}
+
+	lines := append([]string{}, g.opts.Sources...)
+	if rulesPath := meta[WatchRulesKey]; len(rulesPath) >= 1 && rulesPath[0] != "" {
+		clauses, rErr := readCommentedFile(rulesPath[0], "#")
+		if rErr != nil {
+			return nil, rErr
+		}
+		lines = append(lines, clauses...)
+	}
+
+	for _, line := range lines {
+		rule, pErr := parseWatchRule(line, defaults)
+		if pErr != nil {
+			return nil, invalidArgumentsErr(pErr)
+		}
+		rules = append(rules, rule)
+	}
+
+	if len(rules) < 1 {
+		return nil, invalidArgumentsErr(fmt.Errorf("watch: expecting at least one folder to watch"))
+	}
+	return rules, nil
+}
+
+// WatchAlert is what is logged and posted to webhooks.
+type WatchAlert struct {
+	Event string    `json:"event"`
+	Path  string    `json:"path"`
+	Id    string    `json:"id"`
+	User  string    `json:"user,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+type watchedFile struct {
+	path    string
+	trashed bool
+	shared  bool
+	// perms is a fingerprint of the permissions when last seen,
+	// empty if they were never fetched.
+	perms string
+}
+
+type watcher struct {
+	g      *Commands
+	rules  []*watchRule
+	files  map[string]*watchedFile
+	client *http.Client
+	// digest if set sums up all changes periodically.
+	digest *watchDigest
+	// self is the email of the user watching, whose own
+	// changes aren't alerted on.
+	self string
+}
+
+func permissionsFingerprint(perms []*drive.Permission) string {
+	var entries []string
+	for _, perm := range perms {
+		if perm == nil {
+			continue
+		}
+		entries = append(entries, sepJoin(":", perm.Id, perm.Type, perm.Role, sepJoin(",", perm.AdditionalRoles...)))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ";")
+}
+
+func (w *watcher) rulesFor(p string) (events watchEvent, matched []*watchRule) {
+	for _, rule := range w.rules {
+		if rule.covers(p) {
+			events |= rule.events
+			matched = append(matched, rule)
+		}
+	}
+	return events, matched
+}
+
+func (w *watcher) fingerprint(id string) (string, error) {
+	perms, err := w.g.rem.listPermissions(id)
+	if err != nil {
+		return "", err
+	}
+	return permissionsFingerprint(perms), nil
+}
+
+// seed records the paths of everything within the watched folders and
+// whether it is shared, permissions only being fingerprinted for the
+// files that the changes feed reports.
+func (w *watcher) seed() error {
+	for _, rule := range w.rules {
+		root, err := w.g.rem.FindByPath(rule.folder)
+		if err != nil {
+			return fmt.Errorf("%s: %v", rule.folder, err)
+		}
+
+		frontier := []*globMatch{{path: rule.folder, file: root}}
+		for len(frontier) >= 1 {
+			match := frontier[0]
+			frontier = frontier[1:]
+
+			if _, seen := w.files[match.file.Id]; !seen {
+				w.files[match.file.Id] = &watchedFile{path: match.path, shared: match.file.Shared}
+			}
+
+			if !match.file.IsDir {
+				continue
+			}
+
+			children, err := w.g.globChildren(match, "*")
+			if err != nil {
+				return err
+			}
+			frontier = append(frontier, children...)
+		}
+	}
+	return nil
+}
+
+func (w *watcher) pathOf(ch *drive.Change) string {
+	if wf, ok := w.files[ch.FileId]; ok {
+		return wf.path
+	}
+	if ch.File == nil {
+		return ""
+	}
+
+	backPaths, err := w.g.rem.FindBackPaths(ch.FileId)
+	if err != nil {
+		return ""
+	}
+	for _, p := range backPaths {
+		if events, _ := w.rulesFor(p); events != 0 {
+			return p
+		}
+	}
+	return ""
+}
+
+// bySelf tells whether ch was last made by the user watching.
+func (w *watcher) bySelf(ch *drive.Change) bool {
+	if w.self == "" || ch.File == nil || ch.File.LastModifyingUser == nil {
+		return false
+	}
+	return strings.EqualFold(ch.File.LastModifyingUser.EmailAddress, w.self)
+}
+
+func (w *watcher) process(ch *drive.Change) {
+	p := w.pathOf(ch)
+	if p == "" {
+		return
+	}
+	events, rules := w.rulesFor(p)
+	if events == 0 {
+		return
+	}
+
+	wf, known := w.files[ch.FileId]
+	if !known {
+		wf = &watchedFile{path: p}
+		w.files[ch.FileId] = wf
+	}
+
+	user := ""
+	if ch.File != nil && ch.File.LastModifyingUser != nil {
+		user = userDescription(ch.File.LastModifyingUser)
+	}
+	bySelf := w.bySelf(ch)
+
+	kind := WatchModifiedEvent
+	defer func() {
+		w.digest.record(&WatchAlert{Event: kind, Path: p, Id: ch.FileId, User: user})
+	}()
+
+	trashed := ch.Deleted || (ch.File != nil && ch.File.Labels != nil && ch.File.Labels.Trashed)
+	if trashed && !wf.trashed {
+		kind = WatchTrashedEvent
+		if (events&watchTrashed) != 0 && !bySelf {
+			w.alert(rules, watchTrashed, &WatchAlert{Event: WatchTrashedEvent, Path: p, Id: ch.FileId, User: user})
+		}
+	}
+	wf.trashed = trashed
+
+	if trashed || (events&watchPermissions) == 0 {
+		return
+	}
+
+	fingerprint, err := w.fingerprint(ch.FileId)
+	if err != nil {
+		w.g.log.LogErrf("watch: %s: %v\n", p, err)
+		return
+	}
+	changed := wf.perms != "" && fingerprint != wf.perms
+	if wf.perms == "" {
+		// Fingerprinted for the first time, only sharing a file that
+		// wasn't shared when it was seeded can be told apart.
+		changed = known && !wf.shared && ch.File != nil && ch.File.Shared
+	}
+	if changed {
+		kind = WatchPermissionsEvent
+		if !bySelf {
+			w.alert(rules, watchPermissions, &WatchAlert{Event: WatchPermissionsEvent, Path: p, Id: ch.FileId, User: user})
+		}
+	}
+	wf.perms = fingerprint
+	if ch.File != nil {
+		wf.shared = ch.File.Shared
+	}
+}
+
+func (w *watcher) alert(rules []*watchRule, event watchEvent, alert *WatchAlert) {
+	alert.Time = time.Now().UTC()
+
+	by := ""
+	if alert.User != "" {
+		by = fmt.Sprintf(" last modified by %s", alert.User)
+	}
+	w.g.log.Logf("%s %s %s (%s)%s\n", alert.Time.Format(time.RFC3339), alert.Event, alert.Path, alert.Id, by)
+
+	posted := map[string]bool{}
+	for _, rule := range rules {
+		if rule.webhook == "" || (rule.events&event) == 0 || posted[rule.webhook] {
+			continue
+		}
+		posted[rule.webhook] = true
+		if err := w.post(rule.webhook, alert); err != nil {
+			w.g.log.LogErrf("watch: webhook %s: %v\n", rule.webhook, err)
+		}
+	}
+}
+
+// post sends an alert or a digest to a webhook as JSON.
+func (w *watcher) post(webhook string, payload interface{}) error {
+	blob, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	res, err := w.client.Post(webhook, "application/json", bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if !httpOk(res.StatusCode) {
+		return fmt.Errorf("%s", res.Status)
+	}
+	return nil
+}
+
+func (g *Commands) watchInterval() (time.Duration, error) {
+	interval := DefaultWatchInterval
+	if g.opts.Meta != nil {
+		if intervalL := (*g.opts.Meta)[WatchIntervalKey]; len(intervalL) >= 1 && intervalL[0] != "" {
+			interval = intervalL[0]
+		}
+	}
+
+	d, err := parseAgeDuration(interval)
+	if err == nil && d < time.Second {
+		err = fmt.Errorf("%q is less than a second", interval)
+	}
+	if err != nil {
+		return 0, invalidArgumentsErr(fmt.Errorf("--%s: %v", WatchIntervalKey, err))
+	}
+	return d, nil
+}
+
+// Watch polls the changes feed and alerts whenever files within the
+// watched folders are trashed or have their permissions changed.
+// It runs until interrupted.
+func (g *Commands) Watch() error {
+	rules, err := g.watchRules()
+	if err != nil {
+		return err
+	}
+
+	interval, err := g.watchInterval()
+	if err != nil {
+		return err
+	}
+
+	digest, err := g.watchDigest()
+	if err != nil {
+		return err
+	}
+
+	about, err := g.rem.About()
+	if err != nil {
+		return err
+	}
+	nextChangeId := about.LargestChangeId + 1
+
+	w := &watcher{
+		g:      g,
+		rules:  rules,
+		files:  map[string]*watchedFile{},
+		client: &http.Client{Timeout: 30 * time.Second},
+		digest: digest,
+	}
+	if about.User != nil {
+		w.self = about.User.EmailAddress
+	}
+
+	if err := w.seed(); err != nil {
+		return err
+	}
+
+	if g.opts.Verbose {
+		g.log.Logf("watch: watching %d files in %d folders every %v\n", len(w.files), len(rules), interval)
+	}
+
+	for {
+		time.Sleep(interval)
+
+		changes, largestChangeId, err := g.rem.changesSince(nextChangeId)
+		if err != nil {
+			g.log.LogErrf("watch: %v\n", err)
+			continue
+		}
+
+		for _, ch := range changes {
+			if ch == nil {
+				continue
+			}
+			w.process(ch)
+			if ch.Id >= nextChangeId {
+				nextChangeId = ch.Id + 1
+			}
+		}
+		if largestChangeId >= nextChangeId {
+			nextChangeId = largestChangeId + 1
+		}
+
+		if now := time.Now(); digest.due(now) {
+			w.flushDigest(now)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/odeke-em/log"
+	drive "google.golang.org/api/drive/v2"
+)
+
+func TestParseWatchRule(t *testing.T) {
+	defaults := &watchRule{events: watchTrashed | watchPermissions, webhook: "https://default.example.com"}
+
+	samples := []struct {
+		line    string
+		want    *watchRule
+		wantErr bool
+	}{
+		{line: "Shared", want: &watchRule{folder: "/Shared", events: watchTrashed | watchPermissions, webhook: "https://default.example.com"}},
+		{line: "/Shared/Contracts/ trashed", want: &watchRule{folder: "/Shared/Contracts", events: watchTrashed, webhook: "https://default.example.com"}},
+		{line: "/ https://hooks.example.com/drive", want: &watchRule{folder: "/", events: watchTrashed | watchPermissions, webhook: "https://hooks.example.com/drive"}},
+		{line: "Docs permissions https://hooks.example.com/drive", want: &watchRule{folder: "/Docs", events: watchPermissions, webhook: "https://hooks.example.com/drive"}},
+		{line: "Docs renamed", wantErr: true},
+		{line: "Docs trashed https://a.example.com https://b.example.com", wantErr: true},
+		{line: "", wantErr: true},
+	}
+
+	for i, sample := range samples {
+		got, err := parseWatchRule(sample.line, defaults)
+		if sample.wantErr {
+			if err == nil {
+				t.Errorf("#%d: %q expected an error, got %#v", i, sample.line, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %q unexpected err %v", i, sample.line, err)
+			continue
+		}
+		if *got != *sample.want {
+			t.Errorf("#%d: %q got %#v want %#v", i, sample.line, got, sample.want)
+		}
+	}
+}
+
+func TestWatcherSkipsOwnChanges(t *testing.T) {
+	var alerts []*WatchAlert
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		alert := &WatchAlert{}
+		if err := json.NewDecoder(req.Body).Decode(alert); err != nil {
+			t.Errorf("undecodable alert: %v", err)
+		}
+		alerts = append(alerts, alert)
+	}))
+	defer server.Close()
+
+	w := &watcher{
+		g:      &Commands{log: log.New(os.Stdin, os.Stdout, os.Stderr)},
+		rules:  []*watchRule{{folder: "/Shared", events: watchTrashed, webhook: server.URL}},
+		files:  map[string]*watchedFile{"mine": {path: "/Shared/mine.txt"}, "theirs": {path: "/Shared/theirs.txt"}},
+		client: &http.Client{},
+		self:   "me@example.com",
+	}
+
+	trashedBy := func(id, email string) *drive.Change {
+		return &drive.Change{FileId: id, File: &drive.File{
+			Labels:            &drive.FileLabels{Trashed: true},
+			LastModifyingUser: &drive.User{EmailAddress: email},
+		}}
+	}
+
+	w.process(trashedBy("mine", "Me@Example.com"))
+	w.process(trashedBy("theirs", "them@example.com"))
+
+	if len(alerts) != 1 || alerts[0].Id != "theirs" {
+		t.Fatalf("expected a single alert for the file trashed by someone else, got %v", alerts)
+	}
+	if !w.files["mine"].trashed {
+		t.Errorf("expected own changes to still be tracked")
+	}
+}